@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"usdt-balance-checker/core"
+	"usdt-balance-checker/view"
+)
+
+// commandUsage 子命令列表，未知子命令或 help 时输出
+const commandUsage = `用法: usdt-balance-checker <子命令> [参数]
+
+子命令:
+  query     查询地址余额（参数与 -cli 模式相同）
+  convert   在 Base58 和 hex 格式之间转换地址
+  merge     合并多个结果文件，按地址去重后导出
+  validate  校验输入文件中的地址，列出无效的单元格
+  split     将地址文件拆分为多个较小的 TXT 文件
+
+不带子命令时启动图形界面；-cli 等参数仍可使用（等同于 query）
+使用 "usdt-balance-checker <子命令> -h" 查看子命令的参数
+`
+
+// runCommand 按子命令名解析参数并执行，每个子命令使用独立的 flag.FlagSet
+func runCommand(name string, args []string) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	switch name {
+	case "query":
+		options := defineQueryFlags(fs)
+		fs.Parse(args)
+		view.RunCLI(options())
+
+	case "convert":
+		inputFile := fs.String("input", "", "地址文件 (每行一个)，也可以直接在参数中写地址")
+		to := fs.String("to", "", "目标格式: hex 或 base58 (默认按输入自动选择另一种)")
+		fs.Parse(args)
+		view.RunConvert(*inputFile, fs.Args(), *to)
+
+	case "merge":
+		outputFile := fs.String("output", "results.csv", "输出文件路径 (CSV/Excel)")
+		lang := fs.String("lang", "zh", "导出表头和状态文案的语言: zh 中文, en 英文")
+		splitFiles := fs.Bool("split-files", false, "Excel 超过 1,048,576 行上限时拆分为多个文件（默认拆分为多个工作表）")
+		statusLabels := fs.String("status-labels", "", "自定义导出的状态文案，逗号分隔的 状态=文案")
+		fs.Usage = func() {
+			fmt.Fprintln(fs.Output(), "用法: usdt-balance-checker merge [参数] 结果文件1 结果文件2 ...")
+			fs.PrintDefaults()
+		}
+		fs.Parse(args)
+		if fs.NArg() == 0 {
+			fs.Usage()
+			os.Exit(2)
+		}
+		view.RunCLI(view.CLIOptions{
+			MergeFiles:   strings.Join(fs.Args(), ","),
+			OutputFile:   *outputFile,
+			Language:     *lang,
+			SplitFiles:   *splitFiles,
+			StatusLabels: *statusLabels,
+		})
+
+	case "validate":
+		inputFile := fs.String("input", "", "输入文件路径 (TXT/CSV/XLSX/JSON)")
+		sheet := fs.String("sheet", "", "Excel 输入的工作表名称 (默认第一个工作表)")
+		column := fs.String("column", "", "只读取 CSV/Excel 输入中的该列：表头名或列字母；JSON 输入为地址字段名")
+		fs.Parse(args)
+		view.RunValidate(*inputFile, core.LoadOptions{Sheet: *sheet, Column: *column})
+
+	case "split":
+		inputFile := fs.String("input", "", "输入文件路径 (TXT/CSV/XLSX/JSON)")
+		size := fs.Int("size", 10000, "每个文件的地址数")
+		prefix := fs.String("prefix", "", "输出文件名前缀，如 out/part 输出 out/part_001.txt (默认为输入文件去掉扩展名)")
+		sheet := fs.String("sheet", "", "Excel 输入的工作表名称 (默认第一个工作表)")
+		column := fs.String("column", "", "只读取 CSV/Excel 输入中的该列：表头名或列字母；JSON 输入为地址字段名")
+		keepDuplicates := fs.Bool("keep-duplicates", false, "保留输入中的重复地址")
+		fs.Parse(args)
+		view.RunSplit(*inputFile, core.LoadOptions{Sheet: *sheet, Column: *column, KeepDuplicates: *keepDuplicates}, *size, *prefix)
+
+	case "help":
+		fmt.Print(commandUsage)
+
+	default:
+		fmt.Fprintf(os.Stderr, "未知的子命令: %s\n\n", name)
+		fmt.Fprint(os.Stderr, commandUsage)
+		os.Exit(2)
+	}
+}
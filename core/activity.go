@@ -0,0 +1,102 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"usdt-balance-checker/tron"
+)
+
+// HeartbeatInterval 无新结果超过该时长时输出心跳提示
+const HeartbeatInterval = 5 * time.Second
+
+// Activity 查询运行状态快照（用于长时间无进展时提示仍在运行）
+type Activity struct {
+	Total     int           // 地址总数
+	Completed int           // 已完成数量
+	InFlight  int           // 正在请求中的数量（含限流和重试退避等待）
+	IdleFor   time.Duration // 距最近一次完成的时长
+
+	// Retrying 正在重试（含退避等待）的请求数，按触发重试的错误类别统计
+	Retrying map[tron.ErrorKind]int
+
+	AutoRetryPass    int // 正在进行的自动重试轮次（从 1 开始），0 表示不在自动重试中
+	AutoRetryPending int // 本轮自动重试的地址数
+}
+
+// GetActivity 获取当前运行状态
+func (qm *QueryManager) GetActivity() Activity {
+	qm.mu.RLock()
+	defer qm.mu.RUnlock()
+
+	retrying := make(map[tron.ErrorKind]int, len(qm.retrying))
+	for kind, count := range qm.retrying {
+		retrying[kind] = count
+	}
+	return Activity{
+		Total:     len(qm.results),
+		Completed: qm.completed,
+		InFlight:  qm.inFlight,
+		IdleFor:   time.Since(qm.lastCompletion),
+		Retrying:  retrying,
+
+		AutoRetryPass:    qm.autoRetryPass,
+		AutoRetryPending: qm.autoRetryPending,
+	}
+}
+
+// beginRetry 记录一个请求开始因 kind 类别的错误重试（见 Activity.Retrying）
+func (qm *QueryManager) beginRetry(kind tron.ErrorKind) {
+	qm.mu.Lock()
+	if qm.retrying == nil {
+		qm.retrying = make(map[tron.ErrorKind]int)
+	}
+	qm.retrying[kind]++
+	qm.mu.Unlock()
+}
+
+// endRetry 记录一个请求的重试结束（成功、失败或换了另一类错误继续重试）
+func (qm *QueryManager) endRetry(kind tron.ErrorKind) {
+	qm.mu.Lock()
+	if qm.retrying[kind]--; qm.retrying[kind] <= 0 {
+		delete(qm.retrying, kind)
+	}
+	qm.mu.Unlock()
+}
+
+// RetryText 重试状态文案，例如 "正在重试 5 个请求（429 限流退避 4、网络超时退避 1）"；没有重试时返回空字符串
+func (a Activity) RetryText() string {
+	total := 0
+	kinds := make([]tron.ErrorKind, 0, len(a.Retrying))
+	for kind, count := range a.Retrying {
+		total += count
+		kinds = append(kinds, kind)
+	}
+	if total == 0 {
+		return ""
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		if a.Retrying[kinds[i]] != a.Retrying[kinds[j]] {
+			return a.Retrying[kinds[i]] > a.Retrying[kinds[j]]
+		}
+		return kinds[i] < kinds[j]
+	})
+	parts := make([]string, len(kinds))
+	for i, kind := range kinds {
+		parts[i] = fmt.Sprintf("%s退避 %d", kind, a.Retrying[kind])
+	}
+	return fmt.Sprintf("正在重试 %d 个请求（%s）", total, strings.Join(parts, "、"))
+}
+
+// Stalled 是否已超过 HeartbeatInterval 没有新结果（且尚未完成）
+func (a Activity) Stalled() bool {
+	return a.Completed < a.Total && a.IdleFor >= HeartbeatInterval
+}
+
+// HeartbeatText 心跳提示文案
+func (a Activity) HeartbeatText() string {
+	return fmt.Sprintf("限流等待中，已完成 %d/%d，%d 个请求进行中，已 %d 秒无新结果，等待恢复…",
+		a.Completed, a.Total, a.InFlight, int(a.IdleFor.Seconds()))
+}
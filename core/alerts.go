@@ -0,0 +1,173 @@
+package core
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"usdt-balance-checker/tron"
+)
+
+// AlertDirection 余额相对告警阈值的方向
+type AlertDirection string
+
+const (
+	AlertAbove AlertDirection = "above" // 余额达到或超过阈值
+	AlertBelow AlertDirection = "below" // 余额低于阈值
+)
+
+// String 返回方向的中文显示文案（日志和导出使用）
+func (d AlertDirection) String() string {
+	switch d {
+	case AlertAbove:
+		return "达到或超过"
+	case AlertBelow:
+		return "低于"
+	}
+	return string(d)
+}
+
+// Alert 余额跨越告警阈值的地址
+type Alert struct {
+	Address   string
+	Balance   string  // 本次查询的余额
+	Threshold float64 // 告警阈值（绝对值）
+	Direction AlertDirection
+}
+
+// LoadAlertRules 从文本或 CSV 文件加载告警规则，返回 地址 -> 阈值（见 CheckAlerts）
+//
+// 每行一个地址和阈值（用逗号、制表符或空格分隔），如 "TXxx...,1000"：余额达到或超过 1000 时告警；
+// 阈值前加 "<" 时余额低于该值告警（如 "TXxx...,<500"），加 ">" 或 ">=" 与不加相同。
+// 空行、# 开头的注释行和无效地址（如表头）被忽略，阈值无效时报错，同一地址出现多次时使用最后的规则
+func LoadAlertRules(path string) (map[string]float64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开告警规则失败: %v", err)
+	}
+	defer file.Close()
+
+	rules := make(map[string]float64)
+	scanner := bufio.NewScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		address, value := line, ""
+		if i := strings.IndexAny(line, ",\t "); i >= 0 {
+			address, value = line[:i], strings.Trim(strings.TrimSpace(line[i+1:]), `"`)
+		}
+		address = strings.Trim(address, `"`)
+		if !tron.ValidateAddress(address) {
+			continue
+		}
+		threshold, err := parseAlertThreshold(value)
+		if err != nil {
+			return nil, fmt.Errorf("告警规则第 %d 行: %v", lineNum, err)
+		}
+		rules[address] = threshold
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取告警规则失败: %v", err)
+	}
+	if len(rules) == 0 {
+		return nil, errors.New("告警规则中没有找到有效的 TRON 地址")
+	}
+	return rules, nil
+}
+
+// parseAlertThreshold 解析规则中的阈值，"<" 开头时返回负数（低于其绝对值时告警）
+func parseAlertThreshold(s string) (float64, error) {
+	below := false
+	switch {
+	case strings.HasPrefix(s, "<"):
+		below, s = true, strings.TrimSpace(strings.TrimPrefix(s, "<"))
+	case strings.HasPrefix(s, ">"):
+		s = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(s, ">="), ">"))
+	}
+	if s == "" {
+		return 0, errors.New("缺少阈值")
+	}
+	threshold, err := ParseBalance(s)
+	if err != nil || threshold <= 0 || math.IsInf(threshold, 0) || math.IsNaN(threshold) {
+		return 0, fmt.Errorf("阈值无效: %q（应为大于 0 的数字，如 1000 或 <500）", s)
+	}
+	if below {
+		return -threshold, nil
+	}
+	return threshold, nil
+}
+
+// CheckAlerts 按每个地址的阈值检查查询结果，返回余额跨越阈值的地址（按结果的顺序）
+//
+// rules 为 地址 -> 阈值：正数时余额达到或超过阈值告警，负数时余额低于其绝对值告警（见 LoadAlertRules）。
+// 只检查查询成功的地址，无法解析的余额不告警；同一地址只检查一次（重复行只看第一次出现）
+func CheckAlerts(results []QueryResult, rules map[string]float64) []Alert {
+	seen := make(map[string]bool, len(rules))
+	var alerts []Alert
+	for _, result := range results {
+		threshold, ok := rules[result.Address]
+		if !ok || result.Status != StatusSuccess || seen[result.Address] {
+			continue
+		}
+		seen[result.Address] = true
+
+		balance, err := ParseBalance(result.Balance)
+		if err != nil {
+			continue
+		}
+		alert := Alert{Address: result.Address, Balance: result.Balance, Threshold: math.Abs(threshold)}
+		switch {
+		case threshold > 0 && balance >= threshold:
+			alert.Direction = AlertAbove
+		case threshold < 0 && balance < -threshold:
+			alert.Direction = AlertBelow
+		default:
+			continue
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts
+}
+
+// Text 返回告警的一行说明，如 "TXxx...: 余额 1,500 达到或超过阈值 1,000"
+func (a Alert) Text() string {
+	return fmt.Sprintf("%s: 余额 %s %s阈值 %s", a.Address, a.Balance, a.Direction, formatThreshold(a.Threshold))
+}
+
+// formatThreshold 按余额合计的格式显示阈值（千分位，不带多余的 0）
+func formatThreshold(threshold float64) string {
+	return FormatBalanceTotal(strconv.FormatFloat(threshold, 'f', -1, 64))
+}
+
+// ExportAlerts 导出告警列表：地址、当前余额、阈值、方向
+// 按扩展名选择格式：.xlsx 导出 Excel，其他导出 CSV
+func ExportAlerts(alerts []Alert, filepath string) error {
+	rows := make([][]string, 0, len(alerts)+1)
+	rows = append(rows, []string{"地址", "当前余额", "阈值", "方向"})
+	for _, alert := range alerts {
+		rows = append(rows, neutralizeFormulas([]string{alert.Address, alert.Balance, formatThreshold(alert.Threshold), alert.Direction.String()}))
+	}
+
+	if strings.HasSuffix(strings.ToLower(filepath), ".xlsx") {
+		return writeRowsExcel(rows, filepath, "告警的地址")
+	}
+
+	file, err := os.Create(filepath)
+	if err != nil {
+		return fmt.Errorf("创建文件失败: %v", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("写入数据失败: %v", err)
+	}
+	return nil
+}
@@ -2,13 +2,16 @@ package core
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 )
 
 const (
@@ -20,7 +23,8 @@ const (
 
 // KeyStatsFile 用于持久化的 Key 统计文件结构
 type KeyStatsFile struct {
-	Keys map[string]int `json:"keys"` // Key -> 已使用次数
+	Keys  map[string]int    `json:"keys"`            // Key -> 已使用次数
+	Names map[string]string `json:"names,omitempty"` // Key -> 备注名
 }
 
 // APIKeyManager API Key 管理器
@@ -29,25 +33,49 @@ type APIKeyManager struct {
 	current   int
 	mu        sync.RWMutex
 	totalUsed int // 总使用次数
+
+	statsDisabled bool // 不读写统计文件，使用次数只保存在内存中
+
+	pacing   bool                 // 平滑使用额度，见 SetPacing
+	paceNext map[string]time.Time // 平滑模式下每个 Key 下一个可用的请求时间
+
+	scheduler keyScheduler // 所有查询管理器共享的按 Key 请求调度，见 SetKeyRateLimit
+
+	// 统计文件锁（多个程序实例同时运行时，见 statslock.go）
+	statsMu        sync.Mutex     // 保护以下锁状态，并串行化统计文件的写入
+	statsOwner     bool           // 是否持有统计文件锁
+	statsLockOwner int            // 只读模式下持有锁的实例 PID（未知为 -1），0 表示不是只读模式
+	statsLockTried time.Time      // 上次尝试获取锁的时间
+	statsBase      map[string]int // 只读模式下各 Key 的使用次数基准（受 mu 保护），增量 = Used - 基准
 }
 
 // APIKeyInfo API Key 信息
 type APIKeyInfo struct {
 	Key      string
-	Used     int  // 已使用次数
-	MaxLimit int  // 最大限额
-	Enabled  bool // 是否启用
+	Name     string // 备注名（可选，如"付费 Key A"）
+	Used     int    // 已使用次数
+	MaxLimit int    // 最大限额
+	Enabled  bool   // 是否启用
+}
+
+// DisplayName 显示名称：有备注名时用备注名，否则为 "Key 序号"（index 从 0 开始）
+func (k APIKeyInfo) DisplayName(index int) string {
+	if k.Name != "" {
+		return k.Name
+	}
+	return fmt.Sprintf("Key %d", index+1)
 }
 
 // NewAPIKeyManager 创建 API Key 管理器
 func NewAPIKeyManager() *APIKeyManager {
 	return &APIKeyManager{
-		keys:    make([]APIKeyInfo, 0),
-		current: 0,
+		keys:      make([]APIKeyInfo, 0),
+		current:   0,
+		scheduler: keyScheduler{rate: DefaultKeyRateLimit},
 	}
 }
 
-// LoadKeysFromFile 从文件加载 API Keys（每行一个）
+// LoadKeysFromFile 从文件加载 API Keys（每行一个，可写成 "key,备注名"）
 func (m *APIKeyManager) LoadKeysFromFile(filepath string) error {
 	file, err := os.Open(filepath)
 	if err != nil {
@@ -55,24 +83,90 @@ func (m *APIKeyManager) LoadKeysFromFile(filepath string) error {
 	}
 	defer file.Close()
 
+	return m.loadKeys(file)
+}
+
+// LoadKeysFromEncryptedFile 从加密文件加载 API Keys（解密后格式与明文文件相同）
+func (m *APIKeyManager) LoadKeysFromEncryptedFile(filepath, password string) error {
+	data, err := os.ReadFile(filepath)
+	if err != nil {
+		return errors.New("打开文件失败")
+	}
+
+	plaintext, err := decryptWithPassword(data, password)
+	if err != nil {
+		return err
+	}
+
+	return m.loadKeys(bytes.NewReader(plaintext))
+}
+
+// LoadKeysFromLines 从文本行加载 API Keys（格式与 LoadKeysFromFile 相同），用于混合文件导入，见 ParseMixedFile
+func (m *APIKeyManager) LoadKeysFromLines(lines []string) error {
+	return m.loadKeys(strings.NewReader(strings.Join(lines, "\n")))
+}
+
+// SaveKeysEncrypted 将当前所有 Key 加密保存到文件（每行一个，有备注名时保存为 "key,备注名"）
+func (m *APIKeyManager) SaveKeysEncrypted(filepath, password string) error {
+	if password == "" {
+		return errors.New("密码不能为空")
+	}
+
+	m.mu.RLock()
+	var buf bytes.Buffer
+	for _, keyInfo := range m.keys {
+		buf.WriteString(keyInfo.Key)
+		if keyInfo.Name != "" {
+			buf.WriteString(",")
+			buf.WriteString(keyInfo.Name)
+		}
+		buf.WriteString("\n")
+	}
+	keyCount := len(m.keys)
+	m.mu.RUnlock()
+
+	if keyCount == 0 {
+		return errors.New("没有可保存的 API Key")
+	}
+
+	encrypted, err := encryptWithPassword(buf.Bytes(), password)
+	if err != nil {
+		return fmt.Errorf("加密失败: %v", err)
+	}
+
+	if err := os.WriteFile(filepath, encrypted, 0600); err != nil {
+		return errors.New("保存加密文件失败")
+	}
+	return nil
+}
+
+// loadKeys 从 reader 解析 API Keys（每行一个，去重）并替换当前 Key 列表
+// 每行可以是 "key" 或 "key,备注名"；没有写备注名时沿用统计文件中保存的备注名
+func (m *APIKeyManager) loadKeys(r io.Reader) error {
 	keys := make([]APIKeyInfo, 0)
 	seen := make(map[string]bool)
 
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" {
 			continue
 		}
+		key, name, _ := strings.Cut(line, ",")
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
 
 		// 去重
-		if seen[line] {
+		if seen[key] {
 			continue
 		}
-		seen[line] = true
+		seen[key] = true
 
 		keys = append(keys, APIKeyInfo{
-			Key:      line,
+			Key:      key,
+			Name:     strings.TrimSpace(name),
 			Used:     0,
 			MaxLimit: MaxQueriesPerKey,
 			Enabled:  true,
@@ -96,11 +190,20 @@ func (m *APIKeyManager) LoadKeysFromFile(filepath string) error {
 			if used, exists := stats.Keys[keys[i].Key]; exists {
 				keys[i].Used = used
 			}
+			if keys[i].Name == "" {
+				keys[i].Name = stats.Names[keys[i].Key]
+			}
 		}
 	}
 
 	m.keys = keys
 	m.current = 0
+	if m.statsBase != nil {
+		// 只读模式：新加载的 Key 以读取到的使用次数为基准
+		for _, keyInfo := range keys {
+			m.statsBase[keyInfo.Key] = keyInfo.Used
+		}
+	}
 	m.mu.Unlock()
 
 	// 保存更新后的记录
@@ -172,8 +275,12 @@ func (m *APIKeyManager) RemoveKeysByUsageThreshold(threshold int) (int, error) {
 	return removedCount, nil
 }
 
+// ErrKeyExhausted 所有 API Key（只有一个时即该 Key）都已达到使用上限或被禁用，见 GetNextKey
+var ErrKeyExhausted = errors.New("所有 API Key 都已达到使用上限")
+
 // GetNextKey 获取下一个可用的 API Key（循环切换）
 // 如果只有一个Key，则一直用这个Key；如果有多个Key，则轮询使用
+// 没有可用额度时返回 ErrKeyExhausted（单个和多个 Key 相同）
 func (m *APIKeyManager) GetNextKey() (string, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -200,7 +307,7 @@ func (m *APIKeyManager) GetNextKey() (string, error) {
 
 			return key, nil
 		}
-		return "", errors.New("API Key 已达到使用上限")
+		return "", ErrKeyExhausted
 	}
 
 	// 多个Key时，轮询使用
@@ -238,7 +345,7 @@ func (m *APIKeyManager) GetNextKey() (string, error) {
 		}
 	}
 
-	return "", errors.New("所有 API Key 都已达到使用上限")
+	return "", ErrKeyExhausted
 }
 
 // GetKeyStatus 获取所有 Key 的状态信息
@@ -246,6 +353,7 @@ func (m *APIKeyManager) GetKeyStatus() []APIKeyStatus {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
+	now := time.Now()
 	status := make([]APIKeyStatus, len(m.keys))
 	for i, keyInfo := range m.keys {
 		status[i] = APIKeyStatus{
@@ -254,7 +362,11 @@ func (m *APIKeyManager) GetKeyStatus() []APIKeyStatus {
 			Remaining:   keyInfo.MaxLimit - keyInfo.Used,
 			MaxLimit:    keyInfo.MaxLimit,
 			Enabled:     keyInfo.Enabled,
-			DisplayName: fmt.Sprintf("Key %d", i+1),
+			Name:        keyInfo.Name,
+			DisplayName: keyInfo.DisplayName(i),
+		}
+		if m.pacing {
+			status[i].Pace = keyPace(status[i].Remaining, now)
 		}
 	}
 	return status
@@ -267,7 +379,33 @@ type APIKeyStatus struct {
 	Remaining   int
 	MaxLimit    int
 	Enabled     bool
-	DisplayName string // 显示名称（如 "Key 1", "Key 2"）
+	Name        string  // 备注名，未设置时为空
+	DisplayName string  // 显示名称（备注名，未设置时为 "Key 1", "Key 2"）
+	Pace        float64 // 平滑使用额度时的当前速率（次/秒），未开启时为 0
+}
+
+// SetKeyName 设置 Key 的备注名（空字符串清除备注），并保存到统计文件
+func (m *APIKeyManager) SetKeyName(key, name string) error {
+	name = strings.TrimSpace(name)
+	if strings.ContainsAny(name, ",\r\n") {
+		return errors.New("备注名不能包含逗号或换行")
+	}
+
+	m.mu.Lock()
+	found := false
+	for i := range m.keys {
+		if m.keys[i].Key == key {
+			m.keys[i].Name = name
+			found = true
+			break
+		}
+	}
+	m.mu.Unlock()
+
+	if !found {
+		return errors.New("未找到指定的 Key")
+	}
+	return m.saveStats()
 }
 
 // GetTotalUsed 获取总使用次数
@@ -284,6 +422,21 @@ func (m *APIKeyManager) GetKeyCount() int {
 	return len(m.keys)
 }
 
+// SetStatsPersistence 设置是否读写统计文件（默认开启）
+// 关闭后不再读取或写入 apikey_stats.json，使用次数只在本次运行中有效（只读环境或不希望留下记录时使用）
+func (m *APIKeyManager) SetStatsPersistence(enabled bool) {
+	m.mu.Lock()
+	m.statsDisabled = !enabled
+	m.mu.Unlock()
+}
+
+// StatsPersistenceEnabled 是否读写统计文件
+func (m *APIKeyManager) StatsPersistenceEnabled() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return !m.statsDisabled
+}
+
 // GetStatsFilePath 获取统计文件路径（用于调试）
 func (m *APIKeyManager) GetStatsFilePath() string {
 	statsPath, err := getStatsPath()
@@ -295,6 +448,11 @@ func (m *APIKeyManager) GetStatsFilePath() string {
 
 // getStatsPath 获取统计文件的实际保存路径
 func getStatsPath() (string, error) {
+	return appFilePath(StatsFileName)
+}
+
+// appFilePath 获取程序数据文件（统计、配置方案）的保存路径：可执行文件所在目录，go run 时为当前工作目录
+func appFilePath(name string) (string, error) {
 	exePath, err := os.Executable()
 	if err != nil {
 		return "", err
@@ -307,17 +465,27 @@ func getStatsPath() (string, error) {
 		// 使用当前工作目录
 		workDir, err := os.Getwd()
 		if err != nil {
-			return filepath.Join(exeDir, StatsFileName), nil
+			return filepath.Join(exeDir, name), nil
 		}
-		return filepath.Join(workDir, StatsFileName), nil
+		return filepath.Join(workDir, name), nil
 	}
 
 	// 否则使用可执行文件所在目录
-	return filepath.Join(exeDir, StatsFileName), nil
+	return filepath.Join(exeDir, name), nil
 }
 
 // LoadStatsIfExists 如果存在统计文件，加载之前的使用记录（用于程序启动时）
+// 同时尝试获取统计文件锁，被其他实例占用时进入只读模式，见 StatsLockOwner
 func (m *APIKeyManager) LoadStatsIfExists() error {
+	if !m.StatsPersistenceEnabled() {
+		return nil
+	}
+	if statsPath, err := getStatsPath(); err == nil {
+		m.statsMu.Lock()
+		m.ownStatsFile(statsPath)
+		m.statsMu.Unlock()
+	}
+
 	stats, err := m.loadStats()
 	if err != nil {
 		return err
@@ -329,6 +497,12 @@ func (m *APIKeyManager) LoadStatsIfExists() error {
 		if used, exists := stats.Keys[m.keys[i].Key]; exists {
 			m.keys[i].Used = used
 		}
+		if m.keys[i].Name == "" {
+			m.keys[i].Name = stats.Names[m.keys[i].Key]
+		}
+		if m.statsBase != nil {
+			m.statsBase[m.keys[i].Key] = m.keys[i].Used
+		}
 	}
 	m.mu.Unlock()
 
@@ -336,47 +510,62 @@ func (m *APIKeyManager) LoadStatsIfExists() error {
 }
 
 // loadStats 从文件加载 Key 使用统计
+// loadKeys 调用时已持有写锁，因此这里直接读取 statsDisabled
 func (m *APIKeyManager) loadStats() (*KeyStatsFile, error) {
+	if m.statsDisabled {
+		return &KeyStatsFile{Keys: make(map[string]int)}, nil
+	}
+
 	// 获取统计文件路径
 	statsPath, err := getStatsPath()
 	if err != nil {
 		return nil, err
 	}
+	return readStatsFile(statsPath), nil // 文件不存在或解析失败时返回空记录
+}
 
-	file, err := os.Open(statsPath)
-	if err != nil {
-		return &KeyStatsFile{Keys: make(map[string]int)}, nil // 文件不存在时返回空记录
+// saveStats 保存 Key 使用统计到文件
+// 多个程序实例同时运行时只有持有统计文件锁的实例写入，见 statslock.go
+func (m *APIKeyManager) saveStats() error {
+	if !m.StatsPersistenceEnabled() {
+		return nil
 	}
-	defer file.Close()
 
-	var stats KeyStatsFile
-	decoder := json.NewDecoder(file)
-	if err := decoder.Decode(&stats); err != nil {
-		return &KeyStatsFile{Keys: make(map[string]int)}, nil // 解析失败时返回空记录
+	// 获取统计文件路径
+	statsPath, err := getStatsPath()
+	if err != nil {
+		return err
 	}
 
-	if stats.Keys == nil {
-		stats.Keys = make(map[string]int)
+	m.statsMu.Lock()
+	defer m.statsMu.Unlock()
+	if !m.ownStatsFile(statsPath) {
+		return nil // 只读模式：使用次数记为增量，锁释放或退出时合并
 	}
-
-	return &stats, nil
+	return m.writeStats(statsPath)
 }
 
-// saveStats 保存 Key 使用统计到文件
-func (m *APIKeyManager) saveStats() error {
-	m.mu.RLock()
-	stats := KeyStatsFile{
-		Keys: make(map[string]int),
+// writeStats 合并待合并的使用增量后写入统计文件，文件中不属于当前 Key 的记录（使用次数和备注）保留
+// 调用方需持有 statsMu 且持有统计文件锁
+func (m *APIKeyManager) writeStats(statsPath string) error {
+	others := m.mergePendingStats(statsPath)
+
+	stats := readStatsFile(statsPath)
+	for key, delta := range others {
+		stats.Keys[key] += delta
 	}
+	m.mu.RLock()
 	for _, keyInfo := range m.keys {
 		stats.Keys[keyInfo.Key] = keyInfo.Used
+		if keyInfo.Name != "" {
+			stats.Names[keyInfo.Key] = keyInfo.Name
+		} else {
+			delete(stats.Names, keyInfo.Key) // 备注已清除，不从文件中恢复
+		}
 	}
 	m.mu.RUnlock()
-
-	// 获取统计文件路径
-	statsPath, err := getStatsPath()
-	if err != nil {
-		return err
+	if len(stats.Names) == 0 {
+		stats.Names = nil
 	}
 
 	// 创建或覆盖文件
@@ -0,0 +1,166 @@
+package core
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// setKeyLimits 设置每个 Key 的上限和已用次数，enabled 为 false 的 Key 被禁用
+func setKeyLimits(km *APIKeyManager, limits, used []int, enabled []bool) {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+	for i := range km.keys {
+		km.keys[i].MaxLimit, km.keys[i].Used, km.keys[i].Enabled = limits[i], used[i], enabled[i]
+	}
+}
+
+// 只有一个 Key 时：额度用完或被禁用都返回 ErrKeyExhausted
+func TestGetNextKeySingleKeyExhausted(t *testing.T) {
+	cases := []struct {
+		name    string
+		limit   int
+		used    int
+		enabled bool
+		ok      int // 返回 ErrKeyExhausted 之前能取到 Key 的次数
+	}{
+		{"还有 2 次额度", 5, 3, true, 2},
+		{"额度已用完", 5, 5, true, 0},
+		{"已禁用", 5, 0, false, 0},
+	}
+	for _, c := range cases {
+		km := newTestKeyManager(t, 1)
+		setKeyLimits(km, []int{c.limit}, []int{c.used}, []bool{c.enabled})
+		for i := 0; i < c.ok; i++ {
+			if key, err := km.GetNextKey(); err != nil || key != "test-key-0" {
+				t.Fatalf("%s: 第 %d 次 GetNextKey = %q, %v", c.name, i+1, key, err)
+			}
+		}
+		if _, err := km.GetNextKey(); !errors.Is(err, ErrKeyExhausted) {
+			t.Fatalf("%s: 额度用完后 err = %v, want ErrKeyExhausted", c.name, err)
+		}
+	}
+}
+
+// 有多个 Key 时：轮询跳过用完或被禁用的 Key，全部用完后返回 ErrKeyExhausted
+func TestGetNextKeyMultiKeyExhausted(t *testing.T) {
+	cases := []struct {
+		name    string
+		limits  []int
+		used    []int
+		enabled []bool
+		want    []string // 返回 ErrKeyExhausted 之前依次取到的 Key
+	}{
+		{"轮询直到全部用完", []int{2, 1, 2}, []int{1, 0, 1}, []bool{true, true, true}, []string{"test-key-0", "test-key-1", "test-key-2"}},
+		{"跳过用完和禁用的 Key", []int{2, 1, 2}, []int{0, 1, 0}, []bool{true, true, false}, []string{"test-key-0", "test-key-0"}},
+		{"全部已用完", []int{1, 1, 1}, []int{1, 1, 1}, []bool{true, true, true}, nil},
+		{"全部已禁用", []int{5, 5, 5}, []int{0, 0, 0}, []bool{false, false, false}, nil},
+	}
+	for _, c := range cases {
+		km := newTestKeyManager(t, len(c.limits))
+		setKeyLimits(km, c.limits, c.used, c.enabled)
+		for i, want := range c.want {
+			if key, err := km.GetNextKey(); err != nil || key != want {
+				t.Fatalf("%s: 第 %d 次 GetNextKey = %q, %v, want %q", c.name, i+1, key, err, want)
+			}
+		}
+		if _, err := km.GetNextKey(); !errors.Is(err, ErrKeyExhausted) {
+			t.Fatalf("%s: 全部用完后 err = %v, want ErrKeyExhausted", c.name, err)
+		}
+	}
+}
+
+// 查询中 Key 额度用完时自动暂停：单个 Key 和多个 Key 相同，已查询的结果保留，剩余地址未完成（已取消或待查询，可以继续）
+func TestQueryKeysExhausted(t *testing.T) {
+	cases := []struct {
+		name   string
+		limits []int
+	}{
+		{"单个 Key", []int{4}},
+		{"多个 Key", []int{2, 2}},
+	}
+	for _, c := range cases {
+		srv := newTestNode(t, nil)
+		km := newTestKeyManager(t, len(c.limits))
+		setKeyLimits(km, c.limits, make([]int, len(c.limits)), []bool{true, true})
+		qm := newTestManager(km, srv)
+		qm.SetMaxConcurrent(1)
+		if err := qm.QueryAddresses(testAddresses(6), nil); err != nil {
+			t.Fatal(err)
+		}
+
+		// 共 4 次额度：1 次查询块高，3 次查询余额
+		var success, remaining int
+		for _, result := range qm.GetResults() {
+			switch result.Status {
+			case StatusSuccess:
+				success++
+			case StatusCancelled, StatusPending:
+				remaining++
+			default:
+				t.Errorf("%s: 地址 %s 状态 %v（%s），应为成功或未完成", c.name, result.Address, result.Status, result.Error)
+			}
+		}
+		if success != 3 || remaining != 3 {
+			t.Errorf("%s: 成功 %d 个、未完成 %d 个, want 3、3", c.name, success, remaining)
+		}
+		if !qm.KeysExhausted() || qm.FinishReason() != FinishKeysExhausted {
+			t.Errorf("%s: KeysExhausted %v，结束原因 %s", c.name, qm.KeysExhausted(), qm.FinishReason())
+		}
+	}
+}
+
+// 备注名保存到统计文件，重新加载后恢复；清除（空字符串）后重新加载仍为空，其他实例未加载的 Key 的备注保留
+func TestSetKeyNamePersisted(t *testing.T) {
+	t.Chdir(t.TempDir()) // 测试中统计文件保存在当前工作目录
+
+	load := func(keys ...string) *APIKeyManager {
+		t.Helper()
+		km := NewAPIKeyManager()
+		if err := km.LoadKeysFromLines(keys); err != nil {
+			t.Fatal(err)
+		}
+		return km
+	}
+	names := func(km *APIKeyManager) []string {
+		var names []string
+		for _, status := range km.GetKeyStatus() {
+			names = append(names, status.Name)
+		}
+		return names
+	}
+	closeStats := func(km *APIKeyManager) {
+		t.Helper()
+		if err := km.CloseStats(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	km := load("key-a", "key-b")
+	if err := km.SetKeyName("key-a", "主账号"); err != nil {
+		t.Fatal(err)
+	}
+	if err := km.SetKeyName("key-b", "备用"); err != nil {
+		t.Fatal(err)
+	}
+	closeStats(km)
+
+	km = load("key-a", "key-b")
+	if got := names(km); !slices.Equal(got, []string{"主账号", "备用"}) {
+		t.Fatalf("重新加载后备注 = %q", got)
+	}
+	closeStats(km)
+
+	// 只加载 key-a 的实例清除它的备注，key-b 的备注保留
+	km = load("key-a")
+	if err := km.SetKeyName("key-a", ""); err != nil {
+		t.Fatal(err)
+	}
+	closeStats(km)
+
+	km = load("key-a", "key-b")
+	if got := names(km); !slices.Equal(got, []string{"", "备用"}) {
+		t.Fatalf("清除备注后重新加载，备注 = %q", got)
+	}
+	closeStats(km)
+}
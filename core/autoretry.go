@@ -0,0 +1,107 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"usdt-balance-checker/tron"
+)
+
+// MaxAutoRetryPasses 自动重试的最多轮数
+const MaxAutoRetryPasses = 10
+
+// AutoRetryBackoff 自动重试每轮之前的等待时间基数：第 n 轮之前等待 n 倍
+// 失败多为临时的限流或网络问题，等待一段时间后重新查询恢复的可能性更大
+const AutoRetryBackoff = 5 * time.Second
+
+// AutoRetryPass 一轮自动重试的结果
+type AutoRetryPass struct {
+	Pass      int // 第几轮（从 1 开始）
+	Failed    int // 本轮重新查询的失败地址数
+	Recovered int // 本轮查询成功的地址数
+}
+
+// SetAutoRetry 设置主查询结束后自动重新查询失败地址的最多轮数（0 关闭，最多 MaxAutoRetryPasses）
+// 每轮之前按 AutoRetryBackoff 等待，只重新查询仍然失败（StatusError）的地址，没有失败的地址时提前结束
+func (qm *QueryManager) SetAutoRetry(passes int) {
+	passes = max(0, min(passes, MaxAutoRetryPasses))
+	qm.mu.Lock()
+	qm.autoRetry = passes
+	qm.mu.Unlock()
+}
+
+// autoRetryFailed 按设置的轮数重新查询 order 中失败的地址，每轮的结果记录到汇总（见 RunSummary.AutoRetries）
+// 被取消或等待中取消时结束
+func (qm *QueryManager) autoRetryFailed(order []int, runPass func(order []int, retry bool)) {
+	qm.mu.RLock()
+	passes := qm.autoRetry
+	qm.mu.RUnlock()
+
+	defer func() {
+		qm.mu.Lock()
+		qm.autoRetryPass, qm.autoRetryPending = 0, 0
+		qm.mu.Unlock()
+	}()
+
+	for pass := 1; pass <= passes; pass++ {
+		failed := qm.failedIndices(order)
+		if len(failed) == 0 || qm.ctx.Err() != nil {
+			return
+		}
+
+		qm.mu.Lock()
+		qm.autoRetryPass, qm.autoRetryPending = pass, len(failed)
+		qm.mu.Unlock()
+		log.Info("自动重试第 %d/%d 轮：%d 个失败地址，%d 秒后重新查询\n", pass, passes, len(failed), int((time.Duration(pass) * AutoRetryBackoff).Seconds()))
+		if !tron.SleepWithContext(qm.ctx, time.Duration(pass)*AutoRetryBackoff) {
+			return
+		}
+
+		runPass(failed, true)
+		if qm.ctx.Err() != nil {
+			return // 取消时本轮不完整，不记录
+		}
+		recovered := len(failed) - len(qm.failedIndices(failed))
+		log.Info("自动重试第 %d/%d 轮：恢复 %d/%d 个\n", pass, passes, recovered, len(failed))
+
+		qm.mu.Lock()
+		qm.summary.AutoRetries = append(qm.summary.AutoRetries, AutoRetryPass{Pass: pass, Failed: len(failed), Recovered: recovered})
+		qm.mu.Unlock()
+	}
+}
+
+// failedIndices 返回 indices 中结果仍为失败的索引
+func (qm *QueryManager) failedIndices(indices []int) []int {
+	qm.mu.RLock()
+	defer qm.mu.RUnlock()
+	var failed []int
+	for _, i := range indices {
+		if qm.results[i].Status == StatusError {
+			failed = append(failed, i)
+		}
+	}
+	return failed
+}
+
+// AutoRetryText 返回每轮自动重试恢复的数量，如 "自动重试：第 1 轮恢复 280/300，第 2 轮恢复 15/20"；未自动重试时返回空字符串
+func (s RunSummary) AutoRetryText() string {
+	if len(s.AutoRetries) == 0 {
+		return ""
+	}
+	parts := make([]string, len(s.AutoRetries))
+	for i, pass := range s.AutoRetries {
+		parts[i] = fmt.Sprintf("第 %d 轮恢复 %d/%d", pass.Pass, pass.Recovered, pass.Failed)
+	}
+	return "自动重试：" + strings.Join(parts, "，")
+}
+
+// AutoRetryText 自动重试状态文案，如 "自动重试第 1 轮：重新查询 300 个失败地址"；不在自动重试中时返回空字符串
+func (a Activity) AutoRetryText() string {
+	if a.AutoRetryPass == 0 {
+		return ""
+	}
+	return fmt.Sprintf("自动重试第 %d 轮：重新查询 %d 个失败地址", a.AutoRetryPass, a.AutoRetryPending)
+}
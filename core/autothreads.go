@@ -0,0 +1,199 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"usdt-balance-checker/tron"
+)
+
+// AutoThreadsInterval 自动线程数每次调整的间隔（统计该时间窗口内的请求结果）
+const AutoThreadsInterval = 5 * time.Second
+
+// DefaultAutoThreadsMax 没有指定线程数时自动线程数的上限
+const DefaultAutoThreadsMax = 20
+
+const (
+	autoThreadsStart         = 2    // 自动线程数的初始值
+	autoThreadsMinRequests   = 10   // 窗口内请求数少于该值时样本太少，不调整
+	autoThreadsSuccessRatio  = 0.95 // 窗口内成功率达到该比例（且没有 429）时才增加线程
+	autoThreadsGain          = 1.1  // 增加线程后吞吐量至少提高该倍数，否则退回
+	autoThreadsStableWindows = 3    // 连续该数量的窗口没有调整时视为已收敛
+)
+
+// autoThreadsState 自动线程数的调整状态，见 SetAutoThreads
+type autoThreadsState struct {
+	enabled bool
+	threads int // 当前线程数
+	ceiling int // 不再增加到的线程数（出现过 429 或增加后吞吐量没有提高），0 表示还没有找到
+	stable  int // 连续没有调整的窗口数
+
+	previous    int // 上一个窗口的线程数
+	lastSuccess int // 上一个窗口的成功请求数
+
+	success, failed, rateLimited int // 当前窗口内的请求结果
+}
+
+// SetAutoThreads 设置是否自动调整线程数（默认关闭）
+//
+// 开启后查询从 2 个线程开始，每隔 AutoThreadsInterval 按窗口内的请求结果调整：
+// 成功率高且没有 429 时增加线程（找到上限前每次翻倍，之后每次加 1），出现 429 时减少约四分之一，
+// 增加线程后吞吐量没有明显提高（瓶颈在限流或节点）时退回。SetMaxConcurrent 设置的值为上限。
+// 收敛后的线程数见 AutoThreads 和 RunSummary.AutoThreads，可以用 SetMaxConcurrent 固定下来
+func (qm *QueryManager) SetAutoThreads(enabled bool) {
+	qm.mu.Lock()
+	qm.autoThreads.enabled = enabled
+	qm.mu.Unlock()
+}
+
+// AutoThreads 返回自动调整的当前线程数，以及是否已收敛（连续 3 个窗口没有调整）；未开启时返回 0, false
+func (qm *QueryManager) AutoThreads() (int, bool) {
+	qm.mu.RLock()
+	defer qm.mu.RUnlock()
+	if !qm.autoThreads.enabled {
+		return 0, false
+	}
+	return qm.autoThreads.threads, qm.autoThreads.stable >= autoThreadsStableWindows
+}
+
+// activeThreadsLocked 返回当前允许同时工作的线程数：自动调整时为调整后的值，否则为最大并发数
+func (qm *QueryManager) activeThreadsLocked() int {
+	if qm.autoThreads.enabled && qm.autoThreads.threads > 0 {
+		return qm.autoThreads.threads
+	}
+	return qm.maxConcurrent
+}
+
+// resetAutoThreadsLocked 查询开始时重置调整状态（每次查询重新收敛）
+func (qm *QueryManager) resetAutoThreadsLocked() {
+	enabled := qm.autoThreads.enabled
+	qm.autoThreads = autoThreadsState{enabled: enabled}
+	if enabled {
+		qm.autoThreads.threads = min(autoThreadsStart, qm.maxConcurrent)
+	}
+}
+
+// waitThreadSlot 编号为 worker 的线程在取下一个任务前等待，直到自动调整的线程数大于其编号；被取消时立即返回
+func (qm *QueryManager) waitThreadSlot(worker int) {
+	for {
+		qm.mu.RLock()
+		active := qm.activeThreadsLocked()
+		qm.mu.RUnlock()
+		if worker < active || !tron.SleepWithContext(qm.ctx, 200*time.Millisecond) {
+			return
+		}
+	}
+}
+
+// recordThroughput 记录一次余额请求的结果（err 为 nil 表示成功），用于自动调整线程数；取消的请求不计入
+func (qm *QueryManager) recordThroughput(err error) {
+	if err != nil && tron.KindOf(err) == tron.ErrorKindCancelled {
+		return
+	}
+	qm.mu.Lock()
+	defer qm.mu.Unlock()
+	state := &qm.autoThreads
+	if !state.enabled {
+		return
+	}
+	switch {
+	case err == nil:
+		state.success++
+	case tron.KindOf(err) == tron.ErrorKindRateLimited:
+		state.failed++
+		state.rateLimited++
+	default:
+		state.failed++
+	}
+}
+
+// startAutoThreads 开启自动线程数时在后台定期调整，返回停止函数
+func (qm *QueryManager) startAutoThreads() func() {
+	qm.mu.RLock()
+	enabled := qm.autoThreads.enabled
+	qm.mu.RUnlock()
+	if !enabled {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(AutoThreadsInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-qm.ctx.Done():
+				return
+			case <-ticker.C:
+				qm.adjustThreads()
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// adjustThreads 按上一个窗口的请求结果调整一次线程数
+func (qm *QueryManager) adjustThreads() {
+	qm.mu.Lock()
+	state := &qm.autoThreads
+	before := state.threads
+	state.adjust(qm.maxConcurrent)
+	after := state.threads
+	qm.mu.Unlock()
+
+	if after != before {
+		log.Info("自动线程数: %d -> %d\n", before, after)
+	}
+}
+
+// adjust 按当前窗口的请求结果调整线程数（不超过 upper），并开始新的窗口
+func (s *autoThreadsState) adjust(upper int) {
+	requests := s.success + s.failed
+	success, rateLimited := s.success, s.rateLimited
+	previous, lastSuccess := s.previous, s.lastSuccess
+	s.success, s.failed, s.rateLimited = 0, 0, 0
+	s.previous, s.lastSuccess = s.threads, success
+
+	switch {
+	case rateLimited > 0:
+		// 出现 429：记住该线程数，之后不再增加到这里
+		if s.ceiling == 0 || s.threads < s.ceiling {
+			s.ceiling = s.threads
+		}
+		s.threads = max(1, s.threads-max(1, s.threads/4))
+	case requests < autoThreadsMinRequests:
+		// 样本太少（如都在退避等待或查询即将结束），保持不变
+		return
+	case previous > 0 && previous < s.threads && float64(success) < float64(lastSuccess)*autoThreadsGain:
+		// 增加线程后吞吐量没有明显提高：瓶颈不在线程数，退回
+		s.ceiling = s.threads
+		s.threads = previous
+	case float64(success) >= float64(requests)*autoThreadsSuccessRatio && s.threads < upper && (s.ceiling == 0 || s.threads+1 < s.ceiling):
+		next := s.threads * 2
+		if s.ceiling > 0 {
+			next = min(s.threads+1, s.ceiling-1)
+		}
+		s.threads = min(next, upper)
+	default:
+		s.stable++
+		return
+	}
+	if s.threads != s.previous {
+		s.stable = 0
+	}
+}
+
+// AutoThreadsText 返回自动线程数的结果，如 "自动线程数: 收敛为 6"；未开启自动线程数时返回空字符串
+func (s RunSummary) AutoThreadsText() string {
+	if s.AutoThreads == 0 {
+		return ""
+	}
+	if !s.AutoThreadsConverged {
+		return fmt.Sprintf("自动线程数: 结束时为 %d（尚未收敛）", s.AutoThreads)
+	}
+	return fmt.Sprintf("自动线程数: 收敛为 %d", s.AutoThreads)
+}
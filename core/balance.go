@@ -0,0 +1,178 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ParseBalance 解析余额字符串（界面筛选、统计和重新导入结果时统一使用）
+//
+// 解析规则：
+//   - 空字符串视为 0；空格、下划线、撇号和不换行空格视为分组符，直接去掉
+//   - 同时出现 "," 和 "." 时，最后出现的那个是小数点，另一个是分组符（1,234.5 / 1.234,5）
+//   - 只出现一种符号且出现多次时，视为分组符（1,234,567 / 1.234.567）
+//   - 只出现一次 "."：小数点
+//   - 只出现一次 ","：后面恰好 3 位数字时视为分组符（1,234 = 1234，与本程序导出格式一致），否则为小数点（12,5）
+//   - 分组符必须每 3 位一组，否则返回错误，避免把格式错误的值悄悄解析成别的数
+func ParseBalance(s string) (float64, error) {
+	normalized, err := normalizeBalance(s)
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := strconv.ParseFloat(normalized, 64)
+	if err != nil {
+		return 0, fmt.Errorf("余额格式错误: %q", s)
+	}
+	return value, nil
+}
+
+// normalizeBalance 按 ParseBalance 的规则把余额字符串转换为不带分组符、以 "." 为小数点的十进制字符串
+func normalizeBalance(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "0", nil
+	}
+
+	s = strings.NewReplacer(" ", "", "_", "", "'", "", "\u00a0", "", "\u202f", "").Replace(s)
+
+	sign := ""
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		sign, s = s[:1], s[1:]
+	}
+
+	lastComma := strings.LastIndex(s, ",")
+	lastDot := strings.LastIndex(s, ".")
+	commas := strings.Count(s, ",")
+	dots := strings.Count(s, ".")
+
+	var group, decimal string
+	switch {
+	case commas > 0 && dots > 0:
+		if lastDot > lastComma {
+			group, decimal = ",", "."
+		} else {
+			group, decimal = ".", ","
+		}
+	case commas > 1:
+		group = ","
+	case dots > 1:
+		group = "."
+	case dots == 1:
+		decimal = "."
+	case commas == 1:
+		if len(s)-lastComma-1 == 3 {
+			group = ","
+		} else {
+			decimal = ","
+		}
+	}
+
+	intPart, fracPart := s, ""
+	if decimal != "" {
+		idx := strings.LastIndex(s, decimal)
+		intPart, fracPart = s[:idx], s[idx+1:]
+		if strings.Contains(fracPart, ",") || strings.Contains(fracPart, ".") {
+			return "", fmt.Errorf("余额格式错误: %q", s)
+		}
+	}
+
+	if group != "" {
+		groups := strings.Split(intPart, group)
+		for i, g := range groups {
+			if (i == 0 && (len(g) == 0 || len(g) > 3)) || (i > 0 && len(g) != 3) {
+				return "", fmt.Errorf("余额分组格式错误: %q", s)
+			}
+		}
+		intPart = strings.Join(groups, "")
+	}
+
+	normalized := sign + intPart
+	if fracPart != "" {
+		normalized += "." + fracPart
+	}
+	if intPart == "" && fracPart == "" {
+		return "", errors.New("余额为空")
+	}
+	return normalized, nil
+}
+
+// DisplayBalance 返回界面和导出中显示的余额，由状态决定而不是看余额字符串是否为空：
+//   - 查询成功：始终是数字，余额为 0 时显示 "0"
+//   - 其他状态（失败、待查询、已取消、已跳过、无效地址）：留空，避免与真实的 0 余额混淆
+func (r QueryResult) DisplayBalance() string {
+	return displayBalance(r.Status, r.Balance)
+}
+
+// DisplayTokenBalance 返回其他代币的显示余额，规则与 DisplayBalance 相同
+func (r QueryResult) DisplayTokenBalance(symbol string) string {
+	return displayBalance(r.Status, r.TokenBalances[symbol])
+}
+
+// displayBalance 按状态决定余额的显示值，见 DisplayBalance
+func displayBalance(status ResultStatus, balance string) string {
+	if status != StatusSuccess {
+		return ""
+	}
+	if strings.TrimSpace(balance) == "" {
+		return "0"
+	}
+	return balance
+}
+
+// SumBalances 精确计算结果的余额合计（大数运算，不受浮点误差影响），保留 6 位小数
+// 只统计查询成功的行；重复行（Duplicate）不重复计入；无法解析的余额忽略
+func SumBalances(results []QueryResult) string {
+	return sumBalances(results, func(result QueryResult) string { return result.Balance })
+}
+
+// SumTokenBalances 与 SumBalances 相同，但合计的是其他代币的余额（QueryResult.TokenBalances[symbol]）
+func SumTokenBalances(results []QueryResult, symbol string) string {
+	return sumBalances(results, func(result QueryResult) string { return result.TokenBalances[symbol] })
+}
+
+// sumBalances 精确合计成功且非重复行的余额，无法解析的余额跳过
+func sumBalances(results []QueryResult, balanceOf func(QueryResult) string) string {
+	sum := new(big.Rat)
+	for _, result := range results {
+		if result.Status != StatusSuccess || result.Duplicate {
+			continue
+		}
+		normalized, err := normalizeBalance(balanceOf(result))
+		if err != nil {
+			continue
+		}
+		value, ok := new(big.Rat).SetString(normalized)
+		if !ok {
+			continue
+		}
+		sum.Add(sum, value)
+	}
+	return sum.FloatString(6)
+}
+
+// FormatBalanceTotal 将 SumBalances 的合计格式化为显示文案：整数部分加千分位，去掉小数末尾的 0
+// （如 1234567.500000 -> 1,234,567.5），按 ParseBalance 的规则可以解析回原值
+func FormatBalanceTotal(sum string) string {
+	sign, digits := "", sum
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	intPart, fracPart, _ := strings.Cut(digits, ".")
+	fracPart = strings.TrimRight(fracPart, "0")
+
+	var grouped strings.Builder
+	for i, digit := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+		grouped.WriteRune(digit)
+	}
+	if fracPart == "" {
+		return sign + grouped.String()
+	}
+	return sign + grouped.String() + "." + fracPart
+}
@@ -0,0 +1,104 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+)
+
+// BookmarksFileName 书签文件名（与统计文件保存在同一目录）
+const BookmarksFileName = "bookmarks.json"
+
+// Bookmarks 用户标记的地址（书签），按地址记录，重新查询或切换批次后仍然保留
+// 每次修改后立即保存到书签文件，下次启动时恢复；可在多个批次间共享
+type Bookmarks struct {
+	mu        sync.RWMutex
+	addresses map[string]bool
+}
+
+// bookmarksFile 书签文件结构
+type bookmarksFile struct {
+	Addresses []string `json:"addresses"`
+}
+
+// GetBookmarksFilePath 获取书签文件路径
+func GetBookmarksFilePath() (string, error) {
+	return appFilePath(BookmarksFileName)
+}
+
+// LoadBookmarks 读取保存的书签，文件不存在时返回空书签
+func LoadBookmarks() (*Bookmarks, error) {
+	b := &Bookmarks{addresses: make(map[string]bool)}
+
+	path, err := GetBookmarksFilePath()
+	if err != nil {
+		return b, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return b, nil
+	}
+	if err != nil {
+		return b, fmt.Errorf("读取书签失败: %v", err)
+	}
+
+	var file bookmarksFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return b, fmt.Errorf("解析书签失败: %v", err)
+	}
+	for _, addr := range file.Addresses {
+		b.addresses[addr] = true
+	}
+	return b, nil
+}
+
+// Has 地址是否已加入书签
+func (b *Bookmarks) Has(address string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.addresses[address]
+}
+
+// Count 返回书签数量
+func (b *Bookmarks) Count() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.addresses)
+}
+
+// Toggle 加入或取消地址的书签并保存，返回修改后是否在书签中
+func (b *Bookmarks) Toggle(address string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock() // 保存时仍持有锁，避免并发修改时旧内容覆盖新内容
+	marked := !b.addresses[address]
+	if marked {
+		b.addresses[address] = true
+	} else {
+		delete(b.addresses, address)
+	}
+	addresses := make([]string, 0, len(b.addresses))
+	for addr := range b.addresses {
+		addresses = append(addresses, addr)
+	}
+	sort.Strings(addresses)
+	return marked, saveBookmarks(addresses)
+}
+
+// saveBookmarks 将书签写入书签文件
+func saveBookmarks(addresses []string) error {
+	data, err := json.MarshalIndent(bookmarksFile{Addresses: addresses}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("保存书签失败: %v", err)
+	}
+	path, err := GetBookmarksFilePath()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("保存书签失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package core
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"os"
+)
+
+// encryptedFileMagic 加密文件头，用于识别加密的 Key 文件，文件头后为 salt、nonce 和密文
+var encryptedFileMagic = []byte("USDTENC2")
+
+const (
+	// keySaltSize 每个加密文件随机生成的 salt 长度
+	keySaltSize = 16
+	// keyIterations PBKDF2 迭代次数（OWASP 对 PBKDF2-HMAC-SHA256 的建议值）
+	keyIterations = 600000
+)
+
+// deriveKey 使用 PBKDF2-HMAC-SHA256 根据密码和 salt 生成 32 字节 AES-256 密钥
+func deriveKey(password string, salt []byte) ([]byte, error) {
+	return pbkdf2.Key(sha256.New, password, salt, keyIterations, 32)
+}
+
+// encryptWithPassword 使用密码加密数据，输出格式：文件头 + salt + nonce + 密文
+func encryptWithPassword(plaintext []byte, password string) ([]byte, error) {
+	salt := make([]byte, keySaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	key, err := deriveKey(password, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, len(encryptedFileMagic)+len(salt))
+	header = append(header, encryptedFileMagic...)
+	header = append(header, salt...)
+	return encryptAES(header, plaintext, key)
+}
+
+// decryptWithPassword 解密 encryptWithPassword 生成的数据
+func decryptWithPassword(data []byte, password string) ([]byte, error) {
+	if !bytes.HasPrefix(data, encryptedFileMagic) {
+		return nil, errors.New("不是有效的加密文件")
+	}
+	data = data[len(encryptedFileMagic):]
+	if len(data) < keySaltSize {
+		return nil, errors.New("加密文件已损坏")
+	}
+	key, err := deriveKey(password, data[:keySaltSize])
+	if err != nil {
+		return nil, err
+	}
+	return decryptAES(data[keySaltSize:], key)
+}
+
+// encryptAES 使用 AES-GCM 加密数据，输出格式：header + nonce + 密文
+func encryptAES(header, plaintext, key []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(header)+len(nonce)+len(plaintext)+gcm.Overhead())
+	out = append(out, header...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, plaintext, nil), nil
+}
+
+// decryptAES 解密文件头之后的 nonce + 密文
+func decryptAES(data []byte, key []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("加密文件已损坏")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("密码错误或文件已损坏")
+	}
+	return plaintext, nil
+}
+
+// IsEncryptedFile 判断文件是否为加密文件（检查文件头）
+func IsEncryptedFile(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	header := make([]byte, len(encryptedFileMagic))
+	if _, err := io.ReadFull(file, header); err != nil {
+		return false
+	}
+	return bytes.Equal(header, encryptedFileMagic)
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncryptWithPassword(t *testing.T) {
+	plaintext := []byte("key-1,主账号\nkey-2\n")
+	first, err := encryptWithPassword(plaintext, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := encryptWithPassword(plaintext, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.HasPrefix(first, encryptedFileMagic) {
+		t.Fatalf("文件头 = %q, want %q", first[:len(encryptedFileMagic)], encryptedFileMagic)
+	}
+	// 相同的密码每次使用不同的 salt，派生的密钥不同
+	saltEnd := len(encryptedFileMagic) + keySaltSize
+	if bytes.Equal(first[len(encryptedFileMagic):saltEnd], second[len(encryptedFileMagic):saltEnd]) {
+		t.Fatal("两次加密使用了相同的 salt")
+	}
+
+	got, err := decryptWithPassword(first, "secret")
+	if err != nil || !bytes.Equal(got, plaintext) {
+		t.Fatalf("解密 = %q, %v", got, err)
+	}
+	if _, err := decryptWithPassword(first, "wrong"); err == nil {
+		t.Fatal("密码错误时应解密失败")
+	}
+	if _, err := decryptWithPassword(first[:saltEnd-1], "secret"); err == nil {
+		t.Fatal("文件截断时应解密失败")
+	}
+}
+
+func TestSaveKeysEncrypted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.enc")
+	km := newTestKeyManager(t, 3)
+	if err := km.SaveKeysEncrypted(path, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncryptedFile(path) {
+		t.Fatal("IsEncryptedFile = false")
+	}
+
+	loaded := NewAPIKeyManager()
+	loaded.SetStatsPersistence(false)
+	if err := loaded.LoadKeysFromEncryptedFile(path, "wrong"); err == nil {
+		t.Fatal("密码错误时应加载失败")
+	}
+	if err := loaded.LoadKeysFromEncryptedFile(path, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.GetKeyCount(); got != 3 {
+		t.Fatalf("加载了 %d 个 Key, want 3", got)
+	}
+}
@@ -0,0 +1,378 @@
+package core
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"usdt-balance-checker/tron"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/xuri/excelize/v2"
+)
+
+// BalanceChange 两次查询之间余额发生变化的地址
+type BalanceChange struct {
+	Address    string
+	OldBalance string // 上次的余额；上次没有该地址或上次查询未成功时为空
+	NewBalance string
+}
+
+// IsNew 是否为上次没有成功结果的地址
+func (c BalanceChange) IsNew() bool {
+	return c.OldBalance == ""
+}
+
+// DiffResults 对比两次查询结果，返回余额发生变化的地址（按新结果的顺序）
+//
+// 对比规则：
+//   - 只对比新结果中查询成功的地址，失败、跳过、无效的地址无法判断是否变化，不输出
+//   - 余额按数值比较（见 ParseBalance），1,234.500000 与 1234.5 视为相同
+//   - 上次没有该地址或上次查询未成功时视为变化，OldBalance 为空
+//   - 同一地址只输出一次（重复行只看第一次出现）
+func DiffResults(oldResults, newResults []QueryResult) []BalanceChange {
+	previous := make(map[string]QueryResult, len(oldResults))
+	for _, result := range oldResults {
+		if _, ok := previous[result.Address]; !ok {
+			previous[result.Address] = result
+		}
+	}
+
+	seen := make(map[string]bool, len(newResults))
+	var changes []BalanceChange
+	for _, result := range newResults {
+		if result.Status != StatusSuccess || seen[result.Address] {
+			continue
+		}
+		seen[result.Address] = true
+
+		prev, ok := previous[result.Address]
+		if !ok || prev.Status != StatusSuccess {
+			changes = append(changes, BalanceChange{Address: result.Address, NewBalance: result.Balance})
+			continue
+		}
+
+		oldValue, errOld := ParseBalance(prev.Balance)
+		newValue, errNew := ParseBalance(result.Balance)
+		if errOld == nil && errNew == nil && oldValue == newValue {
+			continue
+		}
+		if errOld != nil || errNew != nil {
+			// 无法解析时退回文本比较
+			if strings.TrimSpace(prev.Balance) == strings.TrimSpace(result.Balance) {
+				continue
+			}
+		}
+		changes = append(changes, BalanceChange{
+			Address:    result.Address,
+			OldBalance: prev.Balance,
+			NewBalance: result.Balance,
+		})
+	}
+	return changes
+}
+
+// PreviouslyNonzero 从地址列表中只保留上次结果中有余额（>0）的地址，用于低成本地刷新持币地址列表
+// 上次结果中没有的地址（新增地址）和上次未查询成功的地址余额未知，同样保留；顺序和重复行不变
+func PreviouslyNonzero(addresses []string, previousResults []QueryResult) []string {
+	zero := make(map[string]bool, len(previousResults))
+	for _, result := range previousResults {
+		if result.Status != StatusSuccess {
+			continue
+		}
+		balance, err := ParseBalance(result.Balance)
+		zero[result.Address] = err == nil && balance == 0
+	}
+
+	kept := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		if !zero[address] {
+			kept = append(kept, address)
+		}
+	}
+	return kept
+}
+
+// ResumeIndex 返回从 start 开始第一个在上次结果中没有最终结果（见 ResultStatus.IsFinal）的地址位置，用于从上次停止的地方继续查询；
+// 上次结果中待查询、已取消或没有出现的地址视为未完成，之后的地址即使已完成也会重新查询。全部完成时返回 len(addresses)
+func ResumeIndex(addresses []string, previousResults []QueryResult, start int) int {
+	done := make(map[string]bool, len(previousResults))
+	for _, result := range previousResults {
+		if result.Status.IsFinal() {
+			done[result.Address] = true
+		}
+	}
+	for i := start; i < len(addresses); i++ {
+		if !done[addresses[i]] {
+			return i
+		}
+	}
+	return max(start, len(addresses))
+}
+
+// ExportChanges 对比两次查询结果，只导出余额发生变化的地址及新旧余额
+// 按扩展名选择格式：.xlsx 导出 Excel，其他导出 CSV
+func ExportChanges(oldResults, newResults []QueryResult, filepath string) error {
+	changes := DiffResults(oldResults, newResults)
+
+	rows := make([][]string, 0, len(changes)+1)
+	rows = append(rows, []string{"地址", "旧余额", "新余额", "变化"})
+	for _, change := range changes {
+		// 旧余额来自上次的结果文件，同样防止 CSV 注入（见 neutralizeFormula）
+		rows = append(rows, neutralizeFormulas(changeRecord(change)))
+	}
+
+	if strings.HasSuffix(strings.ToLower(filepath), ".xlsx") {
+		return writeRowsExcel(rows, filepath, "变化的地址")
+	}
+
+	file, err := os.Create(filepath)
+	if err != nil {
+		return fmt.Errorf("创建文件失败: %v", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("写入数据失败: %v", err)
+	}
+	return nil
+}
+
+// changeRecord 返回单条变化的导出行，变化列为新余额减旧余额（新地址为"新增"）
+func changeRecord(change BalanceChange) []string {
+	delta := "新增"
+	if !change.IsNew() {
+		delta = ""
+		oldValue, errOld := ParseBalance(change.OldBalance)
+		newValue, errNew := ParseBalance(change.NewBalance)
+		if errOld == nil && errNew == nil {
+			delta = fmt.Sprintf("%+.6f", newValue-oldValue)
+		}
+	}
+	return []string{change.Address, change.OldBalance, change.NewBalance, delta}
+}
+
+// writeRowsExcel 将导出行（第一行为表头）写入 Excel 的 Sheet1，subject 为超过行数上限时提示中的名称
+func writeRowsExcel(rows [][]string, filepath string, subject string) error {
+	if len(rows) > ExcelMaxRows {
+		return fmt.Errorf("%s共 %d 个，超过 Excel 单表上限，请导出为 CSV", subject, len(rows)-1)
+	}
+
+	f := excelize.NewFile()
+	defer func() {
+		if err := f.Close(); err != nil {
+			log.Error("关闭文件失败: %v\n", err)
+		}
+	}()
+
+	// 流式写入（行数较多时内存占用较小），列宽需在写入行之前设置
+	sw, err := f.NewStreamWriter("Sheet1")
+	if err != nil {
+		return fmt.Errorf("创建工作表失败: %v", err)
+	}
+	sw.SetColWidth(1, 1, 45)
+	sw.SetColWidth(2, 4, 20)
+	for i, row := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := sw.SetRow(cell, styledRow(row, 0)); err != nil {
+			return fmt.Errorf("写入数据失败: %v", err)
+		}
+	}
+	if err := sw.Flush(); err != nil {
+		return fmt.Errorf("写入工作表失败: %v", err)
+	}
+
+	if err := f.SaveAs(filepath); err != nil {
+		return fmt.Errorf("保存文件失败: %v", err)
+	}
+	return nil
+}
+
+// LoadResultsFromFile 读取本程序导出的结果文件（CSV 或 Excel），用于和新结果对比
+//
+// 按表头识别列：中英文表头均可，不区分大小写，状态文案也同时识别中英文（见 resultStatusLookup）；
+// 表头无法识别（如在 Excel 中改过表头）时按导出的列顺序（地址、余额、状态、错误信息）读取并警告。
+// CSV 开头的 # 注释行会被跳过，兼容 Excel 另存时加上的 BOM 和分号分隔符；
+// Excel 读取所有结果工作表（Sheet1、Sheet2 …），忽略汇总工作表（"汇总" 或 "Summary"）
+func LoadResultsFromFile(path string) ([]QueryResult, error) {
+	var rows [][]string
+	if strings.HasSuffix(strings.ToLower(path), ".xlsx") {
+		f, err := excelize.OpenFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("打开结果文件失败: %v", err)
+		}
+		defer f.Close()
+
+		for _, sheet := range f.GetSheetList() {
+			if isSummarySheet(sheet) {
+				continue
+			}
+			sheetRows, err := f.GetRows(sheet)
+			if err != nil {
+				return nil, fmt.Errorf("读取工作表 %s 失败: %v", sheet, err)
+			}
+			if len(rows) > 0 && len(sheetRows) > 0 {
+				sheetRows = sheetRows[1:] // 拆分的工作表每个都有表头，只保留第一个
+			}
+			rows = append(rows, sheetRows...)
+		}
+	} else {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("打开结果文件失败: %v", err)
+		}
+		defer file.Close()
+
+		reader := newResultCSVReader(file)
+		for {
+			record, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("解析结果文件失败: %v", err)
+			}
+			if len(rows) == 0 && isCommentRecord(record) {
+				continue // 表头前被加上引号的注释行（Excel 另存时可能出现）
+			}
+			rows = append(rows, record)
+		}
+	}
+
+	if len(rows) == 0 {
+		return nil, errors.New("结果文件为空")
+	}
+
+	columns := resultColumns(rows[0])
+	data := rows[1:]
+	if _, ok := columns["address"]; !ok {
+		// 表头无法识别：按导出的列顺序读取，第一行不是有效地址时视为表头跳过
+		log.Warn("结果文件 %s 的表头无法识别，按列顺序读取（地址、余额、状态、错误信息）\n", path)
+		columns = map[string]int{"address": 0, "balance": 1, "status": 2, "error": 3}
+		if len(rows[0]) > 0 {
+			if first := strings.TrimSpace(strings.TrimPrefix(rows[0][0], "\ufeff")); tron.ValidateAddress(first) {
+				rows[0][0] = first
+				data = rows
+			}
+		}
+	}
+	addrCol := columns["address"]
+	balanceCol, ok := columns["balance"]
+	if !ok {
+		return nil, errors.New("结果文件缺少余额列")
+	}
+	statusCol, hasStatus := columns["status"]
+	errorCol, hasError := columns["error"]
+
+	statuses := resultStatusLookup()
+	results := make([]QueryResult, 0, len(data))
+	for _, row := range data {
+		// 导出时为防止 CSV 注入加上的单引号在这里去掉（见 restoreFormula）
+		cell := func(i int) string {
+			if i < len(row) {
+				return restoreFormula(strings.TrimSpace(row[i]))
+			}
+			return ""
+		}
+
+		address := cell(addrCol)
+		if address == "" {
+			continue
+		}
+		result := QueryResult{Address: address, Balance: cell(balanceCol), Status: StatusSuccess}
+		if hasStatus {
+			if cell(statusCol) == "" {
+				continue // 合计行等非结果行没有状态
+			}
+			status, ok := statuses[strings.ToLower(cell(statusCol))]
+			if !ok {
+				status = StatusError
+			}
+			result.Status = status
+		}
+		if hasError {
+			result.Error = cell(errorCol)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// newResultCSVReader 返回读取结果 CSV 的 reader：跳过 BOM 和 # 注释行，
+// 第一个非注释行（表头）中只有分号没有逗号时按分号分隔（部分地区的 Excel 另存 CSV 使用分号）
+func newResultCSVReader(r io.Reader) *csv.Reader {
+	buffered := bufio.NewReader(r)
+	if bom, err := buffered.Peek(3); err == nil && string(bom) == "\ufeff" {
+		buffered.Discard(3)
+	}
+
+	reader := csv.NewReader(buffered)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	head, _ := buffered.Peek(buffered.Size())
+	for _, line := range strings.Split(string(head), "\n") {
+		if line = strings.TrimSpace(line); line == "" || strings.HasPrefix(strings.TrimLeft(line, `"`), "#") {
+			continue
+		}
+		if strings.Contains(line, ";") && !strings.Contains(line, ",") {
+			reader.Comma = ';'
+		}
+		break
+	}
+	return reader
+}
+
+// isCommentRecord 是否为第一个单元格以 # 开头的注释行
+func isCommentRecord(record []string) bool {
+	return len(record) > 0 && strings.HasPrefix(strings.TrimSpace(record[0]), "#")
+}
+
+// resultColumns 根据表头返回各列的位置（同时识别中英文表头，不区分大小写）
+// 多代币导出时余额列为"余额_USDT"等，取第一个作为余额列
+func resultColumns(header []string) map[string]int {
+	names := make(map[string]string)
+	var tokenPrefixes []string
+	for _, lang := range []ExportLanguage{LangChinese, LangEnglish} {
+		l := labelsFor(lang)
+		names[strings.ToLower(l.address)] = "address"
+		names[strings.ToLower(l.balance)] = "balance"
+		names[strings.ToLower(l.status)] = "status"
+		names[strings.ToLower(l.errorMsg)] = "error"
+		tokenPrefixes = append(tokenPrefixes, strings.ToLower(tokenBalanceHeader(l, "")))
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		for _, prefix := range tokenPrefixes {
+			if strings.HasPrefix(name, prefix) {
+				name = strings.TrimSuffix(prefix, "_")
+			}
+		}
+		if key, ok := names[name]; ok {
+			if _, exists := columns[key]; !exists {
+				columns[key] = i
+			}
+		}
+	}
+	return columns
+}
+
+// resultStatusLookup 返回导出状态文案（小写）到状态的映射（中英文文案和内部值都能识别）
+func resultStatusLookup() map[string]ResultStatus {
+	lookup := make(map[string]ResultStatus)
+	for _, status := range []ResultStatus{StatusPending, StatusSuccess, StatusError, StatusCancelled, StatusSkipped, StatusInvalid} {
+		lookup[string(status)] = status
+		for _, lang := range []ExportLanguage{LangChinese, LangEnglish} {
+			lookup[strings.ToLower(labelsFor(lang).statusText(status))] = status
+		}
+	}
+	return lookup
+}
@@ -0,0 +1,66 @@
+package core
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestResumeIndex(t *testing.T) {
+	a := testAddresses(5)
+	previous := []QueryResult{
+		{Address: a[0], Status: StatusSuccess, Balance: "1"},
+		{Address: a[1], Status: StatusError},
+		{Address: a[2], Status: StatusCancelled},
+		{Address: a[3], Status: StatusSuccess, Balance: "0"},
+		{Address: a[4], Status: StatusPending},
+	}
+	cases := []struct {
+		name     string
+		previous []QueryResult
+		start    int
+		want     int
+	}{
+		{"从第一个已取消的地址继续", previous, 0, 2},
+		{"起始位置之后的第一个未完成地址", previous, 3, 4},
+		{"起始位置本身未完成", previous, 2, 2},
+		{"上次结果为空", nil, 1, 1},
+		{"全部完成", []QueryResult{previous[0], previous[1], {Address: a[2], Status: StatusSkipped}, previous[3], {Address: a[4], Status: StatusInvalid}}, 0, 5},
+	}
+	for _, c := range cases {
+		if got := ResumeIndex(a, c.previous, c.start); got != c.want {
+			t.Errorf("%s: ResumeIndex = %d, want %d", c.name, got, c.want)
+		}
+	}
+}
+
+// 超时结束后导出的结果文件（含待查询、已取消的行）读回后能找到停止的位置
+func TestResumeIndexFromExport(t *testing.T) {
+	a := testAddresses(4)
+	results := []QueryResult{
+		{Address: a[0], Status: StatusSuccess, Balance: "1.5"},
+		{Address: a[1], Status: StatusSuccess, Balance: "0"},
+		{Address: a[2], Status: StatusCancelled, Error: "请求已取消"},
+		{Address: a[3], Status: StatusPending},
+	}
+	for _, lang := range []ExportLanguage{LangChinese, LangEnglish} {
+		for _, ext := range []string{".csv", ".xlsx"} {
+			path := filepath.Join(t.TempDir(), "results"+ext)
+			var err error
+			if ext == ".xlsx" {
+				err = ExportToExcelWithOptions(results, path, ExportOptions{Language: lang})
+			} else {
+				err = ExportToCSVWithOptions(results, path, ExportOptions{Language: lang})
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			previous, err := LoadResultsFromFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ResumeIndex(a, previous, 0); got != 2 {
+				t.Errorf("%s/%s: ResumeIndex = %d, want 2", ext, lang, got)
+			}
+		}
+	}
+}
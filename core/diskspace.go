@@ -0,0 +1,40 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// MinFreeDiskSpace 导出和自动保存所需的最小剩余磁盘空间（100MB）
+const MinFreeDiskSpace = 100 * 1024 * 1024
+
+// LowDiskSpaceError 磁盘剩余空间不足
+type LowDiskSpaceError struct {
+	Dir  string // 检查的目录
+	Free uint64 // 剩余空间（字节）
+}
+
+func (e *LowDiskSpaceError) Error() string {
+	return fmt.Sprintf("磁盘空间不足: %s 剩余 %.1f MB（至少需要 %d MB）",
+		e.Dir, float64(e.Free)/1024/1024, MinFreeDiskSpace/1024/1024)
+}
+
+// CheckDiskSpace 检查 path 所在磁盘的剩余空间，低于 MinFreeDiskSpace 时返回 *LowDiskSpaceError
+// path 可以是文件或目录；文件不存在时检查其所在目录
+func CheckDiskSpace(path string) error {
+	dir := path
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		dir = filepath.Dir(path)
+	}
+
+	free, err := freeDiskSpace(dir)
+	if err != nil {
+		// 无法获取时不阻止操作
+		return nil
+	}
+	if free < MinFreeDiskSpace {
+		return &LowDiskSpaceError{Dir: dir, Free: free}
+	}
+	return nil
+}
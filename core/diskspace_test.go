@@ -0,0 +1,45 @@
+package core
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckDiskSpace(t *testing.T) {
+	dir := t.TempDir()
+	free, err := freeDiskSpace(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if free < MinFreeDiskSpace {
+		t.Skipf("测试目录剩余空间不足 %d MB", MinFreeDiskSpace/1024/1024)
+	}
+
+	file := filepath.Join(dir, "results.csv")
+	if err := os.WriteFile(file, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{dir, file, filepath.Join(dir, "not-yet-exported.xlsx")} {
+		if err := CheckDiskSpace(path); err != nil {
+			t.Errorf("CheckDiskSpace(%s) = %v", path, err)
+		}
+	}
+	// 无法获取剩余空间（目录不存在）时不阻止操作
+	if err := CheckDiskSpace(filepath.Join(dir, "missing", "results.csv")); err != nil {
+		t.Errorf("目录不存在时 CheckDiskSpace = %v, want nil", err)
+	}
+}
+
+func TestLowDiskSpaceError(t *testing.T) {
+	var err error = &LowDiskSpaceError{Dir: "/data", Free: 10 * 1024 * 1024}
+	var lowErr *LowDiskSpaceError
+	if !errors.As(err, &lowErr) {
+		t.Fatal("errors.As 失败")
+	}
+	if text := err.Error(); !strings.Contains(text, "/data") || !strings.Contains(text, "10.0 MB") || !strings.Contains(text, "100 MB") {
+		t.Fatalf("Error() = %q", text)
+	}
+}
@@ -0,0 +1,29 @@
+//go:build !windows
+
+package core
+
+import (
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestFreeDiskSpaceUnix(t *testing.T) {
+	dir := t.TempDir()
+	free, err := freeDiskSpace(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 可用空间（Bavail，非 root 用户可用）不超过总空间
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		t.Fatal(err)
+	}
+	if total := uint64(stat.Blocks) * uint64(stat.Bsize); free == 0 || free > total {
+		t.Fatalf("剩余空间 %d，总空间 %d", free, total)
+	}
+
+	if _, err := freeDiskSpace(filepath.Join(dir, "missing")); err == nil {
+		t.Fatal("目录不存在时应返回错误")
+	}
+}
@@ -0,0 +1,34 @@
+//go:build windows
+
+package core
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFreeDiskSpaceWindows(t *testing.T) {
+	dir := t.TempDir()
+	free, err := freeDiskSpace(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if free == 0 {
+		t.Fatal("剩余空间为 0")
+	}
+	// 同一个卷的根目录和子目录剩余空间相同（允许测试期间的少量变化）
+	root, err := freeDiskSpace(filepath.VolumeName(dir) + `\`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := int64(root) - int64(free); diff > 64<<20 || diff < -64<<20 {
+		t.Fatalf("根目录剩余 %d，临时目录剩余 %d", root, free)
+	}
+
+	if _, err := freeDiskSpace(filepath.Join(dir, "missing")); err == nil {
+		t.Fatal("目录不存在时应返回错误")
+	}
+	if _, err := freeDiskSpace("bad\x00path"); err == nil {
+		t.Fatal("路径包含 NUL 时应返回错误")
+	}
+}
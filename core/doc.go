@@ -0,0 +1,32 @@
+// Package core 提供批量查询 USDT 余额的核心逻辑，可脱离 GUI 嵌入其他程序使用。
+//
+// 主要类型：
+//   - APIKeyManager：管理多个 TronGrid API Key，轮询分配并持久化使用次数
+//   - QueryManager：并发批量查询，结果与输入地址一一对应
+//   - QueryResult / ResultStatus：单个地址的查询结果及状态
+//   - Estimate：查询开始前预估请求数和 Key 剩余额度是否足够，见 EstimateRun
+//   - SaveState / LoadState / Resume：保存查询状态（不含 Key），在另一个进程中继续未完成的地址
+//
+// 典型用法：
+//
+//	keys := core.NewAPIKeyManager()
+//	if err := keys.LoadKeysFromFile("keys.txt"); err != nil {
+//		return err
+//	}
+//	qm := core.NewQueryManagerWithOptions(keys, core.QueryOptions{MaxConcurrent: 4})
+//	if err := qm.QueryAddresses(addresses, nil); err != nil {
+//		return err
+//	}
+//	for _, r := range qm.GetResults() {
+//		if r.Status == core.StatusSuccess {
+//			fmt.Println(r.Address, r.Balance)
+//		}
+//	}
+//
+// 行为约定：
+//   - GetResults 返回的切片长度等于输入地址数，顺序与输入一致（打乱查询顺序时也一样）
+//   - Balance 为十进制字符串（6 位精度，已去掉末尾的 0），仅在 StatusSuccess 时有意义
+//   - ResultStatus 的 String() 返回中文显示文案，其他语言使用 Label(lang)；比较状态请使用 Status* 常量
+//   - 每个 QueryManager 的 context 在 Cancel 后不可恢复，继续查询需创建新的 QueryManager
+//   - 同一个 QueryManager 不能并发查询，重复开始返回 ErrAlreadyRunning，运行状态见 State
+package core
@@ -0,0 +1,108 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultMaxErrorLength 结果中保存的错误信息的默认最大长度（字符数）
+const DefaultMaxErrorLength = 300
+
+// maxInternedErrors 去重表最多保存的不同错误信息数，超过后新的错误信息不再去重（避免每条都不同时无限增长）
+const maxInternedErrors = 10000
+
+// errorLog 错误日志：失败地址的完整错误信息，见 SetErrorLog
+type errorLog struct {
+	mu     sync.Mutex
+	writer io.Writer
+	failed bool // 写入失败后不再写入（只提示一次）
+}
+
+// SetMaxErrorLength 设置结果中保存的错误信息的最大长度（字符数），超过时截断并以 "…" 结尾
+// 0 使用默认的 DefaultMaxErrorLength，<0 不截断。部分错误包含节点返回的完整响应体，
+// 大量失败时会占用很多内存并使导出文件变大；完整的错误信息可以写入错误日志，见 SetErrorLog。
+// 相同的错误信息只保存一份（失败通常只有少数几种错误信息）
+func (qm *QueryManager) SetMaxErrorLength(length int) {
+	if length == 0 {
+		length = DefaultMaxErrorLength
+	}
+	qm.mu.Lock()
+	qm.maxErrorLength = length
+	qm.mu.Unlock()
+}
+
+// SetErrorLog 设置错误日志：每个失败的地址完成时，完整的错误信息（不截断）以一行追加写入 w，
+// 格式为 时间<TAB>地址<TAB>错误类别<TAB>错误信息（换行替换为空格）。写入失败时提示一次警告并停止写入，nil 关闭
+func (qm *QueryManager) SetErrorLog(w io.Writer) {
+	var errLog *errorLog
+	if w != nil {
+		errLog = &errorLog{writer: w}
+	}
+	qm.mu.Lock()
+	qm.errorLog = errLog
+	qm.mu.Unlock()
+}
+
+// writeErrorLog 将失败结果的完整错误信息写入错误日志（未设置或不是失败的结果时不做任何事）
+func (qm *QueryManager) writeErrorLog(result QueryResult) {
+	qm.mu.RLock()
+	errLog := qm.errorLog
+	qm.mu.RUnlock()
+	if errLog == nil || result.Status != StatusError {
+		return
+	}
+
+	text := strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(result.Error)
+	line := fmt.Sprintf("%s\t%s\t%s\t%s\n", time.Now().UTC().Format(time.RFC3339), result.Address, result.ErrorKind, text)
+
+	errLog.mu.Lock()
+	if errLog.failed {
+		errLog.mu.Unlock()
+		return
+	}
+	_, err := io.WriteString(errLog.writer, line)
+	errLog.failed = err != nil
+	errLog.mu.Unlock()
+
+	if err != nil {
+		qm.warn(fmt.Sprintf("写入错误日志失败，之后的错误不再写入: %v", err))
+	}
+}
+
+// compactErrorLocked 按最大长度截断错误信息，并返回去重后的字符串（相同内容共用一份内存），调用方需持有 qm.mu
+func (qm *QueryManager) compactErrorLocked(text string) string {
+	if text == "" {
+		return text
+	}
+	truncated := truncateError(text, qm.maxErrorLength)
+	if interned, ok := qm.errorTexts[truncated]; ok {
+		return interned
+	}
+	if qm.errorTexts == nil {
+		qm.errorTexts = make(map[string]string)
+	}
+	if len(qm.errorTexts) < maxInternedErrors {
+		qm.errorTexts[truncated] = truncated
+	}
+	return truncated
+}
+
+// truncateError 将错误信息截断到最多 limit 个字符（超过时最后一个字符为 "…"），limit <= 0 时不截断
+// 截断后的字符串是新分配的，不再引用完整的错误信息
+func truncateError(text string, limit int) string {
+	if limit <= 0 || len(text) <= limit || utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	count := 0
+	for i := range text {
+		if count == limit-1 {
+			return text[:i] + "…"
+		}
+		count++
+	}
+	return text
+}
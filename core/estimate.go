@@ -0,0 +1,143 @@
+package core
+
+import (
+	"fmt"
+
+	"usdt-balance-checker/tron"
+)
+
+// EstimateVerdict 剩余额度是否足够完成一次查询
+type EstimateVerdict int
+
+const (
+	// EstimateSufficient 剩余额度足够（即使重试预算全部用完）
+	EstimateSufficient EstimateVerdict = iota
+	// EstimateMarginal 全部一次成功时足够，重试较多时可能不够
+	EstimateMarginal
+	// EstimateInsufficient 即使全部一次成功也不够
+	EstimateInsufficient
+)
+
+// String 返回结论的中文显示文案
+func (v EstimateVerdict) String() string {
+	switch v {
+	case EstimateSufficient:
+		return "额度充足"
+	case EstimateMarginal:
+		return "额度可能不足"
+	case EstimateInsufficient:
+		return "额度不足"
+	}
+	return fmt.Sprintf("EstimateVerdict(%d)", int(v))
+}
+
+// Estimate 查询开始前的请求数预估
+type Estimate struct {
+	Addresses   int             // 要查询的地址数（去重后）
+	MinRequests int             // 全部一次成功时的请求数
+	MaxRequests int             // 重试预算全部用完（不限制时每个请求都重试到上限）、批量调用全部回退时的请求数
+	Remaining   int             // 所有启用 Key 的剩余额度合计
+	Keys        int             // 有剩余额度的启用 Key 数
+	Verdict     EstimateVerdict // 剩余额度是否足够
+}
+
+// Text 返回预估的说明文字（界面确认框和 CLI 警告使用）
+func (e Estimate) Text() string {
+	requests := formatThousands(int64(e.MinRequests))
+	if e.MaxRequests > e.MinRequests {
+		requests += " ~ " + formatThousands(int64(e.MaxRequests))
+	}
+	return fmt.Sprintf("%s：%s 个地址预计需要 %s 次请求，%d 个可用 Key 剩余额度 %s",
+		e.Verdict, formatThousands(int64(e.Addresses)), requests, e.Keys, formatThousands(int64(e.Remaining)))
+}
+
+// EstimateRun 按查询选项预估 addresses 个（去重后的）地址需要的请求数，并判断 keys 的剩余额度是否足够
+//
+// 计算规则与 QueryManager 一致：开始时查询一次块信息；每个地址每种代币一次请求，
+// 开启合约检查时每个地址多一次请求（排除合约时按全部地址都查询余额计算）；
+// 开启 JSON-RPC 批量调用（且未开启合约检查）时每批每种代币一次请求。
+// 额度按请求数计算，keys 为 nil 时视为没有 Key
+func EstimateRun(addresses int, opts QueryOptions, keys *APIKeyManager) Estimate {
+	if addresses < 0 {
+		addresses = 0
+	}
+	tokens := len(opts.Tokens)
+	if tokens == 0 {
+		tokens = 1
+	}
+
+	// 逐个查询时每个地址的请求数
+	perAddress := tokens
+	if opts.ContractFilter != ContractFilterOff {
+		perAddress++
+	}
+	single := addresses * perAddress
+
+	estimate := Estimate{Addresses: addresses}
+	if addresses > 0 {
+		estimate.MinRequests = 1 + single // 块信息 + 余额查询
+	}
+
+	// 批量调用：每批每种代币一次请求，失败时整批回退为逐个查询
+	batchSize := opts.JSONRPCBatch
+	if batchSize > tron.MaxJSONRPCBatch {
+		batchSize = tron.MaxJSONRPCBatch
+	}
+	fallback := 0
+	if batchSize > 1 && opts.ContractFilter == ContractFilterOff && addresses > 0 {
+		batches := (addresses + batchSize - 1) / batchSize
+		estimate.MinRequests = 1 + batches*tokens
+		fallback = batches * tokens
+	}
+
+	// 重试：预算按地址数计算，不限制时每个余额请求按重试策略中最多的次数重试
+	ratio := opts.RetryBudget
+	if ratio == 0 {
+		ratio = DefaultRetryBudgetRatio
+	}
+	policy := DefaultRetryPolicy
+	if opts.RetryPolicy != nil {
+		policy = *opts.RetryPolicy
+	}
+	retries := 0
+	if addresses > 0 {
+		if ratio < 0 {
+			retries = addresses * tokens * policy.maxRetries()
+		} else {
+			retries = newRetryBudget(addresses, ratio).limit
+		}
+	}
+	if addresses > 0 {
+		estimate.MaxRequests = 1 + fallback + single + retries
+	}
+
+	if keys != nil {
+		for _, status := range keys.GetKeyStatus() {
+			if status.Enabled && status.Remaining > 0 {
+				estimate.Remaining += status.Remaining
+				estimate.Keys++
+			}
+		}
+	}
+
+	switch {
+	case estimate.Remaining >= estimate.MaxRequests:
+		estimate.Verdict = EstimateSufficient
+	case estimate.Remaining >= estimate.MinRequests:
+		estimate.Verdict = EstimateMarginal
+	default:
+		estimate.Verdict = EstimateInsufficient
+	}
+	return estimate
+}
+
+// QueryableAddresses 返回 addresses 中实际会发送请求的地址数（有效且去重后），用于 EstimateRun
+func QueryableAddresses(addresses []string) int {
+	seen := make(map[string]bool, len(addresses))
+	for _, addr := range addresses {
+		if !seen[addr] && tron.ValidateAddress(addr) {
+			seen[addr] = true
+		}
+	}
+	return len(seen)
+}
@@ -0,0 +1,37 @@
+package core_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"usdt-balance-checker/core"
+)
+
+func ExampleQueryManager_QueryAddresses() {
+	// 模拟节点：每个地址余额 1.5 USDT
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"result":{"result":true},"constant_result":["%064x"]}`, 1500000)
+	}))
+	defer node.Close()
+
+	km := core.NewAPIKeyManager()
+	km.SetStatsPersistence(false)
+	if err := km.LoadKeysFromLines([]string{"your-api-key"}); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	qm := core.NewQueryManager(km, node.URL)
+	addresses := []string{"TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", "not-an-address"}
+	if err := qm.QueryAddresses(addresses, nil); err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, r := range qm.GetResults() {
+		fmt.Println(r.Address, r.Status.Label(core.LangEnglish), r.DisplayBalance())
+	}
+	// Output:
+	// TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t Success 1.5
+	// not-an-address Invalid
+}
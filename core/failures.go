@@ -0,0 +1,88 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"usdt-balance-checker/tron"
+)
+
+// FailureCount 某一错误类别的失败数量
+type FailureCount struct {
+	Kind  tron.ErrorKind
+	Count int
+}
+
+// FailureBreakdown 按错误类别统计失败（StatusError）的结果，按数量从多到少排列
+// 遍历 results（O(n)），查询中的统计见 QueryManager.GetSummary
+func FailureBreakdown(results []QueryResult) []FailureCount {
+	return countFailures(results).breakdown()
+}
+
+// CombineFailures 合并两份失败分类（如继续查询时之前的结果加上本次查询的结果），按数量从多到少排列
+func CombineFailures(a, b []FailureCount) []FailureCount {
+	counts := make(failureCounts)
+	for _, failure := range append(slices.Clip(a), b...) {
+		counts[failure.Kind] += failure.Count
+	}
+	return counts.breakdown()
+}
+
+// failureCounts 按错误类别的失败数量，与 ResultStats 一样在每次更新结果时增量维护
+type failureCounts map[tron.ErrorKind]int
+
+// countFailures 遍历 results 统计失败数量（读取保存的状态等需要重新统计时使用）
+func countFailures(results []QueryResult) failureCounts {
+	counts := make(failureCounts)
+	for _, result := range results {
+		counts.count(result, 1)
+	}
+	return counts
+}
+
+// replace 同一位置的结果由 old 变为 new 时更新失败数量
+func (c failureCounts) replace(old, new QueryResult) {
+	c.count(old, -1)
+	c.count(new, 1)
+}
+
+// count 结果为失败时将其类别的数量加上 delta，数量为 0 的类别删除
+func (c failureCounts) count(result QueryResult, delta int) {
+	if result.Status != StatusError {
+		return
+	}
+	if c[result.ErrorKind] += delta; c[result.ErrorKind] == 0 {
+		delete(c, result.ErrorKind)
+	}
+}
+
+// breakdown 返回按数量从多到少排列的失败数量
+func (c failureCounts) breakdown() []FailureCount {
+	breakdown := make([]FailureCount, 0, len(c))
+	for kind, count := range c {
+		breakdown = append(breakdown, FailureCount{Kind: kind, Count: count})
+	}
+	sort.Slice(breakdown, func(i, j int) bool {
+		if breakdown[i].Count != breakdown[j].Count {
+			return breakdown[i].Count > breakdown[j].Count
+		}
+		return breakdown[i].Kind < breakdown[j].Kind
+	})
+	return breakdown
+}
+
+// FailureText 返回失败数量及分类，例如 "失败 4,312：其中 429 限流 3,900、网络超时 300、无效响应 112"
+// 没有分类时只返回失败数量
+func (s RunSummary) FailureText() string {
+	text := "失败 " + formatThousands(int64(s.Failed))
+	if len(s.FailedByKind) == 0 {
+		return text
+	}
+	parts := make([]string, len(s.FailedByKind))
+	for i, failure := range s.FailedByKind {
+		parts[i] = fmt.Sprintf("%s %s", failure.Kind, formatThousands(int64(failure.Count)))
+	}
+	return text + "：其中 " + strings.Join(parts, "、")
+}
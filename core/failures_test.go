@@ -0,0 +1,114 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"usdt-balance-checker/tron"
+)
+
+// 查询中和读取保存的状态后，GetSummary 增量维护的失败分类与遍历结果统计（FailureBreakdown）一致
+func TestSummaryFailedByKind(t *testing.T) {
+	addrs := testAddresses(10)
+	srv := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Parameter string `json:"parameter"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		index := -1
+		for i, addr := range addrs {
+			if body.Parameter != "" && containsAddressParam(body.Parameter, addr) {
+				index = i
+			}
+		}
+		switch {
+		case index < 0:
+			fmt.Fprint(w, `{}`) // 区块高度等其他请求
+		case index < 4:
+			http.Error(w, "not found", http.StatusNotFound)
+		case index < 6:
+			fmt.Fprint(w, `not json`)
+		default:
+			fmt.Fprint(w, balanceResponse)
+		}
+	})
+
+	qm := newTestManager(newTestKeyManager(t, 1), srv)
+	if err := qm.QueryAddresses(addrs, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := []FailureCount{{Kind: tron.ErrorKindHTTP, Count: 4}, {Kind: tron.ErrorKindResponse, Count: 2}}
+	if got := qm.GetSummary().FailedByKind; !reflect.DeepEqual(got, want) {
+		t.Fatalf("FailedByKind = %v, want %v", got, want)
+	}
+	if got := FailureBreakdown(qm.GetResults()); !reflect.DeepEqual(got, want) {
+		t.Fatalf("FailureBreakdown = %v, want %v", got, want)
+	}
+
+	var state bytes.Buffer
+	if err := qm.SaveState(&state); err != nil {
+		t.Fatal(err)
+	}
+	loaded := newTestManager(newTestKeyManager(t, 1), srv)
+	if err := loaded.LoadState(&state); err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.GetSummary().FailedByKind; !reflect.DeepEqual(got, want) {
+		t.Fatalf("读取保存的状态后 FailedByKind = %v, want %v", got, want)
+	}
+
+	// 同一管理器再次查询时重新统计，不累加上一次的失败
+	if err := qm.QueryAddresses(addrs[3:], nil); err != nil {
+		t.Fatal(err)
+	}
+	want = []FailureCount{{Kind: tron.ErrorKindResponse, Count: 2}, {Kind: tron.ErrorKindHTTP, Count: 1}}
+	if got := qm.GetSummary().FailedByKind; !reflect.DeepEqual(got, want) {
+		t.Fatalf("再次查询后 FailedByKind = %v, want %v", got, want)
+	}
+}
+
+// 结果由失败变为其他状态时对应类别的数量减少，减到 0 的类别不再出现
+func TestFailureCountsReplace(t *testing.T) {
+	failed := func(kind tron.ErrorKind) QueryResult {
+		return QueryResult{Status: StatusError, ErrorKind: kind}
+	}
+	counts := countFailures([]QueryResult{
+		failed(tron.ErrorKindNetwork), failed(tron.ErrorKindNetwork), failed(tron.ErrorKindRateLimited),
+		{Status: StatusSuccess, Balance: "1"}, {Status: StatusPending},
+	})
+	want := []FailureCount{{Kind: tron.ErrorKindNetwork, Count: 2}, {Kind: tron.ErrorKindRateLimited, Count: 1}}
+	if got := counts.breakdown(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("breakdown = %v, want %v", got, want)
+	}
+
+	counts.replace(failed(tron.ErrorKindRateLimited), QueryResult{Status: StatusSuccess, Balance: "1"})
+	counts.replace(failed(tron.ErrorKindNetwork), failed(tron.ErrorKindTimeout))
+	counts.replace(QueryResult{Status: StatusPending}, QueryResult{Status: StatusCancelled})
+	want = []FailureCount{{Kind: tron.ErrorKindNetwork, Count: 1}, {Kind: tron.ErrorKindTimeout, Count: 1}}
+	if got := counts.breakdown(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("更新后 breakdown = %v, want %v", got, want)
+	}
+	if _, ok := counts[tron.ErrorKindRateLimited]; ok {
+		t.Errorf("数量为 0 的类别应删除")
+	}
+}
+
+// 继续查询时之前的失败分类与本次的合并，同一类别数量相加
+func TestCombineFailures(t *testing.T) {
+	base := []FailureCount{{Kind: tron.ErrorKindNetwork, Count: 2}, {Kind: tron.ErrorKindHTTP, Count: 1}}
+	run := []FailureCount{{Kind: tron.ErrorKindHTTP, Count: 3}, {Kind: tron.ErrorKindTimeout, Count: 1}}
+	want := []FailureCount{{Kind: tron.ErrorKindHTTP, Count: 4}, {Kind: tron.ErrorKindNetwork, Count: 2}, {Kind: tron.ErrorKindTimeout, Count: 1}}
+	if got := CombineFailures(base, run); !reflect.DeepEqual(got, want) {
+		t.Fatalf("CombineFailures = %v, want %v", got, want)
+	}
+	if got := CombineFailures(nil, run[:1]); !reflect.DeepEqual(got, run[:1]) {
+		t.Fatalf("没有之前的失败时 CombineFailures = %v, want %v", got, run[:1])
+	}
+	if len(base) != 2 || base[1].Count != 1 {
+		t.Fatalf("CombineFailures 不应修改参数: %v", base)
+	}
+}
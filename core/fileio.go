@@ -1,12 +1,13 @@
 package core
 
 import (
-	"bufio"
 	"encoding/csv"
 	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
+	"time"
 
 	"usdt-balance-checker/tron"
 
@@ -14,177 +15,438 @@ import (
 	"github.com/xuri/excelize/v2"
 )
 
-// LoadAddressesFromFile 从文件加载地址列表
-func LoadAddressesFromFile(filepath string) ([]string, error) {
-	file, err := os.Open(filepath)
-	if err != nil {
-		return nil, errors.New("打开文件失败: %v")
+// LoadOptions 地址加载选项（零值即默认行为：去重）
+type LoadOptions struct {
+	KeepDuplicates bool   // 保留重复地址（每个输入行对应一个结果，查询时仍只查一次）
+	KeepInvalid    bool   // 保留无效地址（查询时不请求，结果状态为 StatusInvalid，便于审计对照）
+	Sheet          string // Excel 工作表名称，空为第一个工作表
+	Column         string // 只读取该列：表头名（如 wallet_address）或列字母（如 C），空为默认（Excel 第一列，CSV 所有单元格）；JSON 为地址字段名
+
+	// MaxAddresses 最多加载的地址数（含保留的重复和无效地址），0 不限制；超过时返回 ErrTooManyAddresses，
+	// 开启 TruncateAddresses 时只保留前 MaxAddresses 个。超过上限后不再保存地址，防止误导入超大文件耗尽内存
+	MaxAddresses      int
+	TruncateAddresses bool
+
+	// Progress 从文件加载时的进度回调，参数为已处理的比例（0~1），每完成约 1% 调用一次（在加载的线程中调用）
+	Progress func(fraction float64)
+}
+
+// addressCollector 按加载选项收集地址（去重、校验、可选保留无效地址）
+type addressCollector struct {
+	opts      LoadOptions
+	seen      map[string]bool
+	addresses []string
+	valid     int  // 有效地址数量
+	overflow  bool // 地址数超过 opts.MaxAddresses，之后的地址被丢弃
+	report    LoadReport
+}
+
+// LoadReport 加载地址时需要提示用户的情况（地址仍按规则跳过或保留为无效地址）
+type LoadReport struct {
+	EVMAddresses int // 以太坊等 EVM 格式的地址（0x 开头）出现的次数，见 tron.IsEVMAddress
+	IgnoredExtra int // 只有一个有效地址、其余内容（余额、备注等附加列）被忽略的行数，见 LoadAddressesFromTextWithReport
+	Sanitized    int // 去掉了零宽字符、BOM 等不可见字符的单元格（文本输入为行）数，见 SanitizeAddress
+}
+
+// Text 返回提示文字，没有需要提示的情况时返回空字符串
+func (r LoadReport) Text() string {
+	var lines []string
+	if r.EVMAddresses > 0 {
+		lines = append(lines, fmt.Sprintf("%s（共 %d 个，不会查询）", tron.ErrEVMAddress.Error(), r.EVMAddresses))
 	}
-	defer file.Close()
+	if r.IgnoredExtra > 0 {
+		lines = append(lines, fmt.Sprintf("忽略附加列/数值 %d 处", r.IgnoredExtra))
+	}
+	if r.Sanitized > 0 {
+		lines = append(lines, fmt.Sprintf("已去掉 %d 处地址中的零宽字符、BOM 等不可见字符", r.Sanitized))
+	}
+	return strings.Join(lines, "\n")
+}
 
-	addresses := make([]string, 0)
-	seen := make(map[string]bool)
+// noValidAddressError 没有有效地址时的错误：有 EVM 地址时说明原因，否则使用 message
+func (c *addressCollector) noValidAddressError(message string) error {
+	if c.report.EVMAddresses > 0 {
+		return errors.New(c.report.Text())
+	}
+	return errors.New(message)
+}
 
-	// 判断文件类型
-	ext := strings.ToLower(filepath[len(filepath)-4:])
-
-	if ext == ".csv" {
-		// 读取 CSV 文件
-		reader := csv.NewReader(file)
-		records, err := reader.ReadAll()
-		if err != nil {
-			return nil, errors.New("读取 CSV 失败: %v")
-		}
-
-		for _, record := range records {
-			for _, field := range record {
-				addr := strings.TrimSpace(field)
-				if addr != "" && !seen[addr] {
-					if tron.ValidateAddress(addr) {
-						addresses = append(addresses, addr)
-						seen[addr] = true
-					}
-				}
-			}
-		}
+// result 返回收集到的地址，超过上限且不截断时返回 ErrTooManyAddresses
+func (c *addressCollector) result() ([]string, error) {
+	if c.overflow && !c.opts.TruncateAddresses {
+		return nil, fmt.Errorf("%w（最多 %s 个），请拆分文件或只导入前面的部分", ErrTooManyAddresses, formatThousands(int64(c.opts.MaxAddresses)))
+	}
+	return c.addresses, nil
+}
+
+func newAddressCollector(opts LoadOptions) *addressCollector {
+	return &addressCollector{
+		opts:      opts,
+		seen:      make(map[string]bool),
+		addresses: make([]string, 0),
+	}
+}
+
+// add 处理一个候选地址
+func (c *addressCollector) add(addr string) {
+	c.addWith(addr, tron.ValidateAddress)
+}
+
+// addValidated 同 add，地址已经校验过（见 validateCellsParallel），valid 为校验结果
+func (c *addressCollector) addValidated(addr string, valid bool) {
+	c.addWith(addr, func(string) bool { return valid })
+}
+
+// addWith 处理一个候选地址，validate 只在地址需要保存时调用（重复地址和超过上限的地址不校验）
+func (c *addressCollector) addWith(addr string, validate func(string) bool) {
+	addr = strings.TrimSpace(addr)
+	if addr == "" || (!c.opts.KeepDuplicates && c.seen[addr]) {
+		return
+	}
+	if c.opts.MaxAddresses > 0 && len(c.addresses) >= c.opts.MaxAddresses {
+		c.overflow = true
+		return
+	}
+	if validate(addr) {
+		c.valid++
 	} else {
-		// 读取 TXT 文件（每行一个地址）
-		scanner := bufio.NewScanner(file)
-		for scanner.Scan() {
-			line := strings.TrimSpace(scanner.Text())
-			if line == "" {
-				continue
-			}
+		if tron.IsEVMAddress(addr) {
+			c.report.EVMAddresses++
+		}
+		if !c.opts.KeepInvalid || !looksLikeAddress(addr) {
+			return
+		}
+	}
+	c.addresses = append(c.addresses, addr)
+	c.seen[addr] = true
+}
 
-			// 支持 CSV 格式（逗号分隔）
-			if strings.Contains(line, ",") {
-				parts := strings.Split(line, ",")
-				for _, part := range parts {
-					addr := strings.TrimSpace(part)
-					if addr != "" && !seen[addr] {
-						if tron.ValidateAddress(addr) {
-							addresses = append(addresses, addr)
-							seen[addr] = true
-						}
-					}
-				}
-			} else {
-				if !seen[line] {
-					if tron.ValidateAddress(line) {
-						addresses = append(addresses, line)
-						seen[line] = true
-					}
-				}
-			}
+// looksLikeAddress 判断无效内容是否像是地址（用于保留无效地址时排除表头、金额、备注等其他列）
+// 规则：只包含字母和数字，且长度不少于 20
+func looksLikeAddress(s string) bool {
+	if len(s) < 20 {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return false
 		}
+	}
+	return true
+}
 
-		if err := scanner.Err(); err != nil {
-			return nil, errors.New("读取文件失败: %v")
+// LoadAddressesFromFile 从文件加载地址列表（去重）
+func LoadAddressesFromFile(filepath string) ([]string, error) {
+	return LoadAddressesFromFileWithOptions(filepath, LoadOptions{})
+}
+
+// LoadAddressesFromFileWithOptions 按选项从文件加载地址列表
+// 支持 TXT、CSV、Excel (.xlsx) 和 JSON；指定列和工作表的规则见 readAddressCells
+func LoadAddressesFromFileWithOptions(filepath string, opts LoadOptions) ([]string, error) {
+	addresses, _, err := LoadAddressesFromFileWithReport(filepath, opts)
+	return addresses, err
+}
+
+// LoadAddressesFromFileWithReport 同 LoadAddressesFromFileWithOptions，同时返回需要提示用户的情况（见 LoadReport）
+// 地址在多个线程中分批校验，结果的顺序和去重与逐个加载相同（见 validateCellsParallel）
+func LoadAddressesFromFileWithReport(filepath string, opts LoadOptions) ([]string, LoadReport, error) {
+	collector := newAddressCollector(opts)
+	err := validateCellsParallel(func(visit func(AddressCell)) error {
+		return readAddressCells(filepath, opts, 0, visit)
+	}, func(cell AddressCell, valid bool) bool {
+		if cell.Sanitized {
+			collector.report.Sanitized++
 		}
+		collector.addValidated(cell.Value, valid)
+		return !collector.overflow
+	}, opts.Progress)
+	if err != nil {
+		return nil, collector.report, err
 	}
 
-	if len(addresses) == 0 {
-		return nil, errors.New("文件中没有找到有效的 TRON 地址。\nTRON 地址应该是 34 个字符，以 T 开头，并且通过校验码验证")
+	if collector.valid == 0 {
+		return nil, collector.report, collector.noValidAddressError("文件中没有找到有效的 TRON 地址。\nTRON 地址应该是 34 个字符，以 T 开头，并且通过校验码验证")
 	}
 
-	return addresses, nil
+	addresses, err := collector.result()
+	return addresses, collector.report, err
 }
 
-// LoadAddressesFromText 从文本加载地址（支持换行、逗号、空格分隔）
+// LoadAddressesFromText 从文本加载地址（支持换行、逗号、空格、制表符、分号分隔，去重）
 func LoadAddressesFromText(text string) ([]string, error) {
-	addresses := make([]string, 0)
-	seen := make(map[string]bool)
+	return LoadAddressesFromTextWithOptions(text, LoadOptions{})
+}
+
+// LoadAddressesFromTextWithOptions 按选项从文本加载地址
+func LoadAddressesFromTextWithOptions(text string, opts LoadOptions) ([]string, error) {
+	addresses, _, err := LoadAddressesFromTextWithReport(text, opts)
+	return addresses, err
+}
+
+// LoadAddressesFromTextWithReport 同 LoadAddressesFromTextWithOptions，同时返回需要提示用户的情况（见 LoadReport）
+//
+// 整行是一个有效地址时直接使用，否则按分隔符（见 isAddressSeparator）拆分后逐个校验；
+// 一行中恰好有一个有效地址时，其余内容（如 "TXxx... 1,234.56" 中的余额）视为附加列忽略，
+// 不计为无效地址，像地址的内容（见 looksLikeAddress、tron.IsEVMAddress）仍按地址处理
+func LoadAddressesFromTextWithReport(text string, opts LoadOptions) ([]string, LoadReport, error) {
+	collector := newAddressCollector(opts)
 
 	// 按行分割
 	lines := strings.Split(text, "\n")
 	for _, line := range lines {
-		line = strings.TrimSpace(line)
+		line, sanitized := SanitizeAddress(line)
 		if line == "" {
 			continue
 		}
+		if sanitized {
+			collector.report.Sanitized++
+		}
 
-		// 支持多种分隔符：逗号、空格、制表符
-		separators := []string{",", " ", "\t", ";"}
-		parts := []string{line}
+		// 单列文件每行就是一个地址，整行校验通过时不再拆分
+		if tron.ValidateAddress(line) {
+			collector.addValidated(line, true)
+			continue
+		}
 
-		for _, sep := range separators {
-			newParts := make([]string, 0)
-			for _, part := range parts {
-				if strings.Contains(part, sep) {
-					newParts = append(newParts, strings.Split(part, sep)...)
-				} else {
-					newParts = append(newParts, part)
-				}
-			}
-			parts = newParts
+		// 按分隔符拆分（一次扫描），连续分隔符和行尾分隔符不产生空的部分
+		parts := strings.FieldsFunc(line, isAddressSeparator)
+
+		if extra := singleAddressExtras(parts); extra != nil {
+			collector.report.IgnoredExtra++
+			parts = extra
 		}
 
 		for _, part := range parts {
-			addr := strings.TrimSpace(part)
-			if addr != "" && !seen[addr] {
-				if err := tron.ValidateAddressWithError(addr); err == nil {
-					addresses = append(addresses, addr)
-					seen[addr] = true
-				}
-				// 如果验证失败，跳过该地址（已在错误信息中说明）
-			}
+			// 验证失败的地址默认跳过（已在错误信息中说明），开启 KeepInvalid 时保留
+			collector.add(part)
 		}
 	}
 
-	if len(addresses) == 0 {
-		return nil, errors.New("没有找到有效的 TRON 地址。\nTRON 地址应该是 34 个字符，以 T 开头。\n如果地址格式正确但仍报错，可能是校验码错误（地址本身无效）")
+	if collector.valid == 0 {
+		return nil, collector.report, collector.noValidAddressError("没有找到有效的 TRON 地址。\nTRON 地址应该是 34 个字符，以 T 开头。\n如果地址格式正确但仍报错，可能是校验码错误（地址本身无效）")
+	}
+
+	addresses, err := collector.result()
+	return addresses, collector.report, err
+}
+
+// isAddressSeparator 文本输入中地址之间的分隔符：逗号、空格、制表符、分号
+func isAddressSeparator(r rune) bool {
+	return r == ',' || r == ' ' || r == '\t' || r == ';'
+}
+
+// singleAddressExtras 一行中恰好有一个有效地址且有其他内容时，返回去掉附加列后要处理的部分
+// （有效地址和像地址的内容）；否则返回 nil，整行按原样处理
+func singleAddressExtras(parts []string) []string {
+	valid, ignored := 0, false
+	var kept []string
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		switch {
+		case part == "":
+		case tron.ValidateAddress(part):
+			valid++
+			kept = append(kept, part)
+		case looksLikeAddress(part) || tron.IsEVMAddress(part):
+			kept = append(kept, part)
+		default:
+			ignored = true
+		}
 	}
+	if valid != 1 || !ignored {
+		return nil
+	}
+	return kept
+}
 
-	return addresses, nil
+// ExportOptions 导出选项
+type ExportOptions struct {
+	Summary    *RunSummary    // 查询汇总信息（非空时写入导出元数据）
+	Language   ExportLanguage // 表头和状态文案的语言，默认中文
+	SplitFiles bool           // Excel 超过单表行数上限时拆分为多个文件（默认拆分为多个工作表）
+	TotalRow   bool           // 在末尾追加一行合计（地址数、余额合计），Excel 中为粗体
+	RawHex     bool           // 增加"原始值"列，导出节点返回的原始 hex（审计用，默认不导出）
+	Tokens     []string       // 查询的代币符号（按查询顺序，见 QueryManager.SetTokens），多于一个时余额列按代币命名
+
+	// AllowFormulas 原样导出以 = + - @ 开头的单元格。默认在这些单元格前加一个单引号（见 neutralizeFormula），
+	// 防止错误信息、关注标签、代币符号等来自外部的内容在 Excel 中打开时被当作公式执行（CSV 注入）
+	AllowFormulas bool
+
+	// StatusLabels 自定义状态文案，覆盖 Language 中对应状态的文案（见 ParseStatusLabels），nil 为不覆盖
+	// 使用自定义文案导出的文件再次读取时（如对比、合并），无法识别的状态按失败处理
+	StatusLabels map[ResultStatus]string
+
+	// Progress Excel 导出进度回调（已写入的结果行数、总行数），每写入 excelProgressStep 行调用一次；
+	// 返回 false 时停止导出并返回 ErrExportCancelled（当前文件不保存，拆分为多个文件时已保存的文件保留）
+	Progress func(written, total int) bool
 }
 
-// ExportToCSV 导出结果到 CSV
+// ErrExportCancelled 导出被 ExportOptions.Progress 取消
+var ErrExportCancelled = errors.New("导出已取消")
+
+// ExportToCSV 导出结果到 CSV（兼容旧接口）
 func ExportToCSV(results []QueryResult, filepath string) error {
+	return ExportToCSVWithOptions(results, filepath, ExportOptions{})
+}
+
+// ExportToCSVWithOptions 按选项导出结果到 CSV
+// 有汇总信息时，在表头前写入一行以 # 开头的数据基准注释
+func ExportToCSVWithOptions(results []QueryResult, filepath string, opts ExportOptions) error {
 	file, err := os.Create(filepath)
 	if err != nil {
-		return errors.New("创建文件失败: %v")
+		return fmt.Errorf("创建文件失败: %v", err)
 	}
 	defer file.Close()
 
+	if opts.Summary != nil {
+		if _, err := fmt.Fprintf(file, "# %s\n", opts.Summary.baselineText(labelsFor(opts.Language).summary)); err != nil {
+			return fmt.Errorf("写入元数据失败: %v", err)
+		}
+		if opts.Summary.InputHash != "" {
+			if _, err := fmt.Fprintf(file, "# %s: %s\n", labelsFor(opts.Language).inputHash, opts.Summary.InputHash); err != nil {
+				return fmt.Errorf("写入元数据失败: %v", err)
+			}
+		}
+	}
+
 	writer := csv.NewWriter(file)
 	defer writer.Flush()
 
+	cols := exportColumnsFor(results, opts.Language)
+	cols.rawHex = opts.RawHex
+	cols.allowFormulas = opts.AllowFormulas
+	cols.labels = cols.labels.withStatuses(opts.StatusLabels)
+	cols.setTokens(opts.Tokens)
+
 	// 写入表头
-	if err := writer.Write([]string{"地址", "余额", "状态", "错误信息"}); err != nil {
-		return errors.New("写入表头失败: %v")
+	if err := writer.Write(exportHeaders(cols)); err != nil {
+		return fmt.Errorf("写入表头失败: %v", err)
 	}
 
 	// 写入数据
 	for _, result := range results {
-		status := "成功"
-		if result.Status == "error" {
-			status = "失败"
-		} else if result.Status == "cancelled" {
-			status = "已取消"
+		if err := writer.Write(exportRecord(result, cols)); err != nil {
+			return fmt.Errorf("写入数据失败: %v", err)
 		}
+	}
 
-		balance := result.Balance
-		if balance == "" {
-			balance = "0.000000"
+	if opts.TotalRow {
+		if err := writer.Write(exportTotalRecord(results, cols)); err != nil {
+			return fmt.Errorf("写入合计行失败: %v", err)
 		}
+	}
+
+	return nil
+}
+
+// ExportToExcel 导出结果到 Excel（兼容旧接口）
+func ExportToExcel(results []QueryResult, filepath string) error {
+	return ExportToExcelWithOptions(results, filepath, ExportOptions{})
+}
+
+// ExcelMaxRows Excel 单个工作表的最大行数（含表头）
+const ExcelMaxRows = 1048576
+
+// excelRowsPerSheet 每个工作表最多写入的数据行数（扣除表头），测试时调小
+var excelRowsPerSheet = ExcelMaxRows - 1
+
+// excelProgressStep Excel 导出每写入多少行报告一次进度
+const excelProgressStep = 1000
+
+// exportProgress Excel 导出进度（多个工作表、多个文件累计）
+type exportProgress struct {
+	written, total int
+	callback       func(written, total int) bool
+}
+
+// add 记录又写入了 n 行并通知回调，返回 false 时取消导出
+func (p *exportProgress) add(n int) bool {
+	p.written += n
+	if p.callback == nil {
+		return true
+	}
+	return p.callback(p.written, p.total)
+}
 
-		record := []string{
-			result.Address,
-			balance,
-			status,
-			result.Error,
+// ExcelSplitCount 返回导出指定数量的结果需要的工作表（或文件）数量，不超过上限时为 1
+func ExcelSplitCount(rows int) int {
+	if rows <= excelRowsPerSheet {
+		return 1
+	}
+	return (rows + excelRowsPerSheet - 1) / excelRowsPerSheet
+}
+
+// ExcelSplitNotice 返回超出 Excel 行数上限时的拆分说明，未超出时返回空字符串
+func ExcelSplitNotice(rows int, opts ExportOptions) string {
+	parts := ExcelSplitCount(rows)
+	if parts <= 1 {
+		return ""
+	}
+	if opts.SplitFiles {
+		return fmt.Sprintf("结果共 %d 行，超过 Excel 单表上限（%d 行数据），已拆分为 %d 个文件（_1、_2 …）", rows, excelRowsPerSheet, parts)
+	}
+	return fmt.Sprintf("结果共 %d 行，超过 Excel 单表上限（%d 行数据），已拆分为 %d 个工作表（Sheet1、Sheet2 …）", rows, excelRowsPerSheet, parts)
+}
+
+// ExportToExcelWithOptions 按选项导出结果到 Excel
+// 有汇总信息时，额外写入一个"汇总"工作表；
+// 结果超过单表上限时按 ExportOptions.SplitFiles 拆分到多个工作表或多个文件，见 ExcelSplitNotice。
+// 结果行按流式写入（excelize.StreamWriter），大量结果时内存占用较小；进度和取消见 ExportOptions.Progress
+func ExportToExcelWithOptions(results []QueryResult, filepath string, opts ExportOptions) error {
+	progress := &exportProgress{total: len(results), callback: opts.Progress}
+	cols := exportColumnsFor(results, opts.Language)
+	cols.rawHex = opts.RawHex
+	cols.allowFormulas = opts.AllowFormulas
+	cols.labels = cols.labels.withStatuses(opts.StatusLabels)
+	cols.setTokens(opts.Tokens)
+	chunks := splitResults(results, excelRowsPerSheet)
+
+	// 合计行写在最后一个工作表的末尾；最后一个工作表已写满时单独放到新的工作表
+	var totalRow []string
+	if opts.TotalRow {
+		totalRow = exportTotalRecord(results, cols)
+		if len(chunks[len(chunks)-1]) == excelRowsPerSheet {
+			chunks = append(chunks, nil)
 		}
+	}
 
-		if err := writer.Write(record); err != nil {
-			return errors.New("写入数据失败: %v")
+	if opts.SplitFiles && len(chunks) > 1 {
+		// 扩展名（兼容 Windows 路径分隔符）
+		ext := ""
+		if i := strings.LastIndexAny(filepath, "./\\"); i >= 0 && filepath[i] == '.' {
+			ext = filepath[i:]
+		}
+		base := strings.TrimSuffix(filepath, ext)
+		for i, chunk := range chunks {
+			var fileTotal []string
+			if i == len(chunks)-1 {
+				fileTotal = totalRow
+			}
+			if err := writeExcelFile(fmt.Sprintf("%s_%d%s", base, i+1, ext), [][]QueryResult{chunk}, cols, opts, fileTotal, progress); err != nil {
+				return err
+			}
 		}
+		return nil
 	}
+	return writeExcelFile(filepath, chunks, cols, opts, totalRow, progress)
+}
 
-	return nil
+// splitResults 按每组最多 size 行拆分结果（不复制数据），空结果返回一个空分组
+func splitResults(results []QueryResult, size int) [][]QueryResult {
+	chunks := make([][]QueryResult, 0, ExcelSplitCount(len(results)))
+	for start := 0; start < len(results); start += size {
+		end := min(start+size, len(results))
+		chunks = append(chunks, results[start:end])
+	}
+	if len(chunks) == 0 {
+		chunks = append(chunks, results)
+	}
+	return chunks
 }
 
-// ExportToExcel 导出结果到 Excel
-func ExportToExcel(results []QueryResult, filepath string) error {
+// writeExcelFile 将每组结果写入一个工作表（Sheet1、Sheet2 …）并保存
+// totalRow 非空时以粗体追加到最后一个工作表的末尾；被取消时不保存文件
+func writeExcelFile(filepath string, chunks [][]QueryResult, cols exportColumns, opts ExportOptions, totalRow []string, progress *exportProgress) error {
 	f := excelize.NewFile()
 	defer func() {
 		if err := f.Close(); err != nil {
@@ -193,57 +455,389 @@ func ExportToExcel(results []QueryResult, filepath string) error {
 	}()
 
 	// 使用默认的 Sheet1
-	sheetName := "Sheet1"
 	f.SetActiveSheet(0)
+	for i, chunk := range chunks {
+		sheetName := fmt.Sprintf("Sheet%d", i+1)
+		if i > 0 {
+			if _, err := f.NewSheet(sheetName); err != nil {
+				return fmt.Errorf("创建工作表失败: %v", err)
+			}
+		}
+		var sheetTotal []string
+		if i == len(chunks)-1 {
+			sheetTotal = totalRow
+		}
+		if err := writeResultsSheet(f, sheetName, chunk, cols, sheetTotal, progress); err != nil {
+			return err
+		}
+	}
 
-	// 写入表头
-	headers := []string{"地址", "余额", "状态", "错误信息"}
-	for i, header := range headers {
-		cell := fmt.Sprintf("%c1", 'A'+i)
-		f.SetCellValue(sheetName, cell, header)
+	// 写入汇总工作表
+	if opts.Summary != nil {
+		writeSummarySheet(f, *opts.Summary, cols.labels)
+		if len(opts.Summary.KeyUsage) > 0 {
+			writeKeyUsageSheet(f, opts.Summary.KeyUsage)
+		}
 	}
 
-	// 设置表头样式
-	headerStyle, err := f.NewStyle(&excelize.Style{
+	// 保存文件
+	if err := f.SaveAs(filepath); err != nil {
+		return fmt.Errorf("保存文件失败: %v", err)
+	}
+
+	return nil
+}
+
+// writeResultsSheet 在指定工作表流式写入表头、结果和合计行（totalRow 非空时，粗体）
+// 流式写入要求按行顺序写入，列宽需在写入行之前设置
+func writeResultsSheet(f *excelize.File, sheetName string, results []QueryResult, cols exportColumns, totalRow []string, progress *exportProgress) error {
+	sw, err := f.NewStreamWriter(sheetName)
+	if err != nil {
+		return fmt.Errorf("创建工作表失败: %v", err)
+	}
+	headers := exportHeaders(cols)
+
+	// 设置列宽
+	sw.SetColWidth(1, 1, 50) // 地址列
+	sw.SetColWidth(2, 2, 20) // 余额列
+	sw.SetColWidth(3, 3, 10) // 状态列
+	sw.SetColWidth(4, 4, 50) // 错误信息列
+	if len(headers) > 4 {
+		sw.SetColWidth(5, len(headers), 12) // 可选列（地址类型、重复、关注、标签）
+	}
+	if len(cols.extraTokens) > 0 {
+		first := 5
+		if cols.addressType {
+			first++
+		}
+		if cols.duplicate {
+			first++
+		}
+		if cols.flagged {
+			first++
+		}
+		if cols.tagged {
+			first++
+		}
+		sw.SetColWidth(first, first+len(cols.extraTokens)-1, 20) // 其他代币余额列
+	}
+	if cols.rawHex {
+		sw.SetColWidth(len(headers), len(headers), 70) // 原始 hex 列（64 个字符）
+	}
+
+	// 写入表头（粗体、灰色背景）
+	headerStyle, _ := f.NewStyle(&excelize.Style{
 		Font: &excelize.Font{Bold: true},
 		Fill: excelize.Fill{Type: "pattern", Color: []string{"#E0E0E0"}, Pattern: 1},
 	})
-	if err == nil {
-		f.SetCellStyle(sheetName, "A1", "D1", headerStyle)
+	if err := sw.SetRow("A1", styledRow(headers, headerStyle)); err != nil {
+		return fmt.Errorf("写入表头失败: %v", err)
 	}
 
 	// 写入数据
 	for i, result := range results {
-		row := i + 2
+		cell, _ := excelize.CoordinatesToCellName(1, i+2)
+		if err := sw.SetRow(cell, styledRow(exportRecord(result, cols), 0)); err != nil {
+			return fmt.Errorf("写入数据失败: %v", err)
+		}
+		if (i+1)%excelProgressStep == 0 && !progress.add(excelProgressStep) {
+			return ErrExportCancelled
+		}
+	}
+	if !progress.add(len(results) % excelProgressStep) {
+		return ErrExportCancelled
+	}
 
-		status := "成功"
-		if result.Status == "error" {
-			status = "失败"
-		} else if result.Status == "cancelled" {
-			status = "已取消"
+	// 合计行
+	if totalRow != nil {
+		totalStyle, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+		cell, _ := excelize.CoordinatesToCellName(1, len(results)+2)
+		if err := sw.SetRow(cell, styledRow(totalRow, totalStyle)); err != nil {
+			return fmt.Errorf("写入合计行失败: %v", err)
 		}
+	}
+
+	if err := sw.Flush(); err != nil {
+		return fmt.Errorf("写入工作表失败: %v", err)
+	}
+	return nil
+}
+
+// styledRow 将一行文本转换为流式写入的单元格（styleID 为 0 时不设置样式）
+func styledRow(values []string, styleID int) []interface{} {
+	row := make([]interface{}, len(values))
+	for i, value := range values {
+		row[i] = excelize.Cell{StyleID: styleID, Value: value}
+	}
+	return row
+}
 
-		balance := result.Balance
-		if balance == "" {
-			balance = "0.000000"
+// exportTotalRecord 返回合计行，列数与 exportHeaders 一致：地址列为地址数，余额列为精确合计（见 SumBalances）
+func exportTotalRecord(results []QueryResult, cols exportColumns) []string {
+	record := make([]string, len(exportHeaders(cols)))
+	record[0] = fmt.Sprintf(cols.labels.total, len(results))
+	record[1] = SumBalances(results)
+	first := len(exportHeaders(exportColumns{addressType: cols.addressType, duplicate: cols.duplicate, flagged: cols.flagged, tagged: cols.tagged}))
+	for i, symbol := range cols.extraTokens {
+		record[first+i] = SumTokenBalances(results, symbol)
+	}
+	return cols.safeRow(record)
+}
+
+// safeRow 按 allowFormulas 处理一行导出内容中以公式字符开头的单元格（见 neutralizeFormula）
+func (c exportColumns) safeRow(row []string) []string {
+	if c.allowFormulas {
+		return row
+	}
+	return neutralizeFormulas(row)
+}
+
+// exportColumns 导出时的可选列和文案
+type exportColumns struct {
+	addressType bool         // 地址类型列（开启合约检查时）
+	duplicate   bool         // 重复标记列（保留重复地址时）
+	flagged     bool         // 关注列（有结果在关注列表中时），内容为标签，没有标签时为"是"
+	tagged      bool         // 标签列（有结果设置了标签时，见 Tags）
+	rawHex      bool         // 原始 hex 列（ExportOptions.RawHex）
+	labels      exportLabels // 表头和状态文案
+
+	allowFormulas bool // 不处理以公式字符开头的单元格（ExportOptions.AllowFormulas）
+
+	primaryToken string   // 第一个代币的符号（余额列表头为"余额_USDT"），未知时为空
+	extraTokens  []string // 其他代币的余额列（QueryResult.TokenBalances）
+}
+
+// exportColumnsFor 根据结果内容决定需要哪些可选列
+func exportColumnsFor(results []QueryResult, lang ExportLanguage) exportColumns {
+	return exportColumns{
+		addressType: hasAddressType(results),
+		duplicate:   hasDuplicate(results),
+		flagged:     hasFlagged(results),
+		tagged:      hasTag(results),
+		labels:      labelsFor(lang),
+		extraTokens: tokenSymbols(results),
+	}
+}
+
+// setTokens 按查询的代币顺序设置余额列（只查 USDT 时保持原来的"余额"列）
+func (c *exportColumns) setTokens(symbols []string) {
+	if len(symbols) > 1 || len(symbols) == 1 && symbols[0] != tron.USDTToken.Symbol {
+		c.primaryToken = symbols[0]
+		c.extraTokens = symbols[1:]
+	}
+}
+
+// tokenSymbols 返回结果中出现的其他代币符号（按符号排序，未指定查询顺序时使用）
+func tokenSymbols(results []QueryResult) []string {
+	seen := make(map[string]bool)
+	var symbols []string
+	for _, result := range results {
+		for symbol := range result.TokenBalances {
+			if !seen[symbol] {
+				seen[symbol] = true
+				symbols = append(symbols, symbol)
+			}
 		}
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// tokenBalanceHeader 返回代币余额列的表头，如"余额_USDC"
+func tokenBalanceHeader(l exportLabels, symbol string) string {
+	return l.balance + "_" + symbol
+}
 
-		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), result.Address)
-		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), balance)
-		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), status)
-		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), result.Error)
+// exportHeaders 返回导出表头（CSV、Excel、Google Sheets 共用）
+func exportHeaders(cols exportColumns) []string {
+	l := cols.labels
+	balance := l.balance
+	if cols.primaryToken != "" {
+		balance = tokenBalanceHeader(l, cols.primaryToken)
+	}
+	headers := []string{l.address, balance, l.status, l.errorMsg}
+	if cols.addressType {
+		headers = append(headers, l.addressType)
+	}
+	if cols.duplicate {
+		headers = append(headers, l.duplicate)
+	}
+	if cols.flagged {
+		headers = append(headers, l.flagged)
+	}
+	if cols.tagged {
+		headers = append(headers, l.tag)
+	}
+	for _, symbol := range cols.extraTokens {
+		headers = append(headers, tokenBalanceHeader(l, symbol))
+	}
+	if cols.rawHex {
+		headers = append(headers, l.rawHex)
 	}
+	return cols.safeRow(headers)
+}
 
-	// 设置列宽
-	f.SetColWidth(sheetName, "A", "A", 50) // 地址列
-	f.SetColWidth(sheetName, "B", "B", 20) // 余额列
-	f.SetColWidth(sheetName, "C", "C", 10) // 状态列
-	f.SetColWidth(sheetName, "D", "D", 50) // 错误信息列
+// UniqueResults 返回按地址合并后的结果（每个地址只保留首次出现的一行）
+func UniqueResults(results []QueryResult) []QueryResult {
+	unique := make([]QueryResult, 0, len(results))
+	for _, result := range results {
+		if !result.Duplicate {
+			unique = append(unique, result)
+		}
+	}
+	return unique
+}
 
-	// 保存文件
-	if err := f.SaveAs(filepath); err != nil {
-		return errors.New("保存文件失败: %v")
+// exportRecord 返回单条结果的导出行，列顺序与 exportHeaders 一致
+func exportRecord(result QueryResult, cols exportColumns) []string {
+	record := []string{
+		result.Address,
+		result.DisplayBalance(),
+		cols.labels.statusText(result.Status),
+		result.Error,
+	}
+	if cols.addressType {
+		record = append(record, cols.labels.addressTypeText(result.AddressType))
+	}
+	if cols.duplicate {
+		duplicate := ""
+		if result.Duplicate {
+			duplicate = cols.labels.yes
+		}
+		record = append(record, duplicate)
+	}
+	if cols.flagged {
+		flagged := ""
+		if result.Flagged {
+			flagged = result.WatchTag
+			if flagged == "" {
+				flagged = cols.labels.yes
+			}
+		}
+		record = append(record, flagged)
+	}
+	if cols.tagged {
+		record = append(record, result.Tag)
 	}
+	for _, symbol := range cols.extraTokens {
+		record = append(record, result.DisplayTokenBalance(symbol))
+	}
+	if cols.rawHex {
+		record = append(record, result.RawHex)
+	}
+	return cols.safeRow(record)
+}
 
-	return nil
+// writeSummarySheet 在 Excel 中写入汇总工作表（工作表名、行标签和说明文案按导出语言）
+func writeSummarySheet(f *excelize.File, summary RunSummary, labels exportLabels) {
+	sheetName := labels.summarySheet
+	if _, err := f.NewSheet(sheetName); err != nil {
+		return
+	}
+	l := labels.summary
+
+	blockNumber := l.blockUnavailable
+	if !summary.BlockFallback && summary.BlockNumber > 0 {
+		blockNumber = formatThousands(summary.BlockNumber)
+	}
+
+	rows := [][]string{
+		{l.baseline, summary.baselineText(l)},
+		{l.blockNumber, blockNumber},
+		{l.blockTime, formatSummaryTime(summary.BlockTime)},
+		{l.startTime, formatSummaryTime(summary.StartTime)},
+		{l.endTime, formatSummaryTime(summary.EndTime)},
+		{l.total, fmt.Sprintf("%d", summary.Total)},
+		{l.success, fmt.Sprintf("%d", summary.Success)},
+		{l.failed, fmt.Sprintf("%d", summary.Failed)},
+	}
+	// 失败按错误类别分行列出
+	for _, failure := range summary.FailedByKind {
+		rows = append(rows, []string{fmt.Sprintf(l.failedKind, l.errorKindText(failure.Kind)), fmt.Sprintf("%d", failure.Count)})
+	}
+	for _, token := range summary.Tokens {
+		rows = append(rows, []string{l.token, token})
+	}
+	rows = append(rows, [][]string{
+		{l.retries, summary.retryText(l)},
+	}...)
+	for _, pass := range summary.AutoRetries {
+		rows = append(rows, []string{fmt.Sprintf(l.autoRetryPass, pass.Pass), fmt.Sprintf(l.recovered, pass.Recovered, pass.Failed)})
+	}
+	if summary.AutoThreads > 0 {
+		threads := fmt.Sprintf("%d", summary.AutoThreads)
+		if !summary.AutoThreadsConverged {
+			threads += l.notConverged
+		}
+		rows = append(rows, []string{l.autoThreads, threads})
+	}
+	if summary.FinishReason != FinishNone {
+		rows = append(rows, []string{l.finishReason, l.finishReasonText(summary.FinishReason)})
+	}
+	rows = append(rows, []string{labels.inputHash, summary.InputHash})
+	for i, row := range rows {
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", i+1), row[0])
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", i+1), row[1])
+	}
+	f.SetColWidth(sheetName, "A", "A", 16)
+	f.SetColWidth(sheetName, "B", "B", 80)
+}
+
+// formatSummaryTime 格式化汇总中的时间（零值显示为空）
+func formatSummaryTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02 15:04:05 UTC")
+}
+
+// addressTypeText 将地址类型转换为导出用的文案
+func (l exportLabels) addressTypeText(addressType string) string {
+	switch addressType {
+	case "contract":
+		return l.contract
+	case "wallet":
+		return l.wallet
+	}
+	return ""
+}
+
+// hasAddressType 判断结果中是否包含地址类型信息（开启合约检查时才有）
+func hasAddressType(results []QueryResult) bool {
+	for _, result := range results {
+		if result.AddressType != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasFlagged 判断结果中是否有地址在关注列表中（见 Watchlist.Apply）
+func hasFlagged(results []QueryResult) bool {
+	for _, result := range results {
+		if result.Flagged {
+			return true
+		}
+	}
+	return false
+}
+
+// hasTag 判断结果中是否有地址设置了标签（见 Tags.Apply）
+func hasTag(results []QueryResult) bool {
+	for _, result := range results {
+		if result.Tag != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// hasDuplicate 判断结果中是否包含重复地址（保留重复地址导入时才有）
+func hasDuplicate(results []QueryResult) bool {
+	for _, result := range results {
+		if result.Duplicate {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,279 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+
+	"github.com/xuri/excelize/v2"
+
+	"usdt-balance-checker/tron"
+)
+
+func TestLoadAddressesFromText(t *testing.T) {
+	a := testAddresses(3)
+	// 校验码错误的地址（像地址，保留无效地址时保留）
+	bad := a[2][:33] + "1"
+	if a[2][33] == '1' {
+		bad = a[2][:33] + "2"
+	}
+	cases := []struct {
+		name  string
+		text  string
+		want  []string // 保留无效地址时加载的地址（只保留像地址的无效内容，见 looksLikeAddress）
+		extra int      // LoadReport.IgnoredExtra
+	}{
+		{"每行一个地址", a[0] + "\n" + a[1] + "\r\n  " + a[2] + "  \n", []string{a[0], a[1], a[2]}, 0},
+		{"混合分隔符", a[0] + ", " + a[1] + ";\t" + a[2], []string{a[0], a[1], a[2]}, 0},
+		{"无效内容", a[0] + "\nnot-an-address\n" + bad + "\n" + a[1], []string{a[0], bad, a[1]}, 0},
+		{"行尾逗号", a[0] + ",\n" + a[1] + ",", []string{a[0], a[1]}, 0},
+		{"连续分隔符", a[0] + ",,;  \t" + a[1] + " ,, " + a[2], []string{a[0], a[1], a[2]}, 0},
+		{"地址后的余额列", a[0] + "\t1,234.56\n" + a[1] + ",0", []string{a[0], a[1]}, 2},
+		{"空行和只有分隔符的行", "\n , ;\n" + a[0] + "\n\n", []string{a[0]}, 0},
+	}
+	for _, c := range cases {
+		got, report, err := LoadAddressesFromTextWithReport(c.text, LoadOptions{KeepInvalid: true})
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if !slices.Equal(got, c.want) {
+			t.Errorf("%s: 加载 %q, want %q", c.name, got, c.want)
+		}
+		if report.IgnoredExtra != c.extra {
+			t.Errorf("%s: IgnoredExtra = %d, want %d", c.name, report.IgnoredExtra, c.extra)
+		}
+	}
+
+	// 默认跳过无效地址；没有有效地址时返回错误
+	got, err := LoadAddressesFromTextWithOptions(a[0]+"\nnot-an-address", LoadOptions{})
+	if err != nil || !slices.Equal(got, []string{a[0]}) {
+		t.Fatalf("跳过无效地址: %q, %v", got, err)
+	}
+	if _, err := LoadAddressesFromTextWithOptions("not-an-address, ,\n", LoadOptions{}); err == nil {
+		t.Fatal("没有有效地址时应返回错误")
+	}
+}
+
+// exportExcelCells 逐个单元格写入的 Excel 导出（改为流式写入之前的做法），只用于基准测试对比
+func exportExcelCells(results []QueryResult, path string) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	cols := exportColumnsFor(results, LangChinese)
+	rows := [][]string{exportHeaders(cols)}
+	for _, result := range results {
+		rows = append(rows, exportRecord(result, cols))
+	}
+	for i, row := range rows {
+		for j, value := range row {
+			cell, _ := excelize.CoordinatesToCellName(j+1, i+1)
+			if err := f.SetCellValue("Sheet1", cell, value); err != nil {
+				return err
+			}
+		}
+	}
+	return f.SaveAs(path)
+}
+
+// BenchmarkExportToExcel 比较 10 万行结果逐个单元格写入（cells）和流式写入（stream，ExportToExcel）的耗时和内存
+func BenchmarkExportToExcel(b *testing.B) {
+	const rows = 100000
+	results := make([]QueryResult, rows)
+	for i := range results {
+		results[i] = QueryResult{Address: fmt.Sprintf("T%033d", i), Balance: fmt.Sprintf("%d.5", i), Status: StatusSuccess}
+	}
+	path := filepath.Join(b.TempDir(), "results.xlsx")
+
+	b.Run("cells", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if err := exportExcelCells(results, path); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("stream", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if err := ExportToExcel(results, path); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestExcelSplitCountBoundary(t *testing.T) {
+	n := ExcelMaxRows - 1 // 单表数据行数上限（扣除表头）
+	for _, c := range []struct{ rows, want int }{
+		{0, 1}, {n - 1, 1}, {n, 1}, {n + 1, 2}, {2 * n, 2}, {2*n + 1, 3},
+	} {
+		if got := ExcelSplitCount(c.rows); got != c.want {
+			t.Errorf("ExcelSplitCount(%d) = %d, want %d", c.rows, got, c.want)
+		}
+		if notice := ExcelSplitNotice(c.rows, ExportOptions{}); (notice != "") != (c.want > 1) {
+			t.Errorf("ExcelSplitNotice(%d) = %q", c.rows, notice)
+		}
+	}
+}
+
+// excelSheetRows 返回 Excel 文件中每个工作表的行数（含表头）
+func excelSheetRows(t *testing.T, path string) map[string]int {
+	t.Helper()
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	counts := make(map[string]int)
+	for _, sheet := range f.GetSheetList() {
+		rows, err := f.GetRows(sheet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[sheet] = len(rows)
+	}
+	return counts
+}
+
+// 结果行数正好等于单表上限（N）时不拆分，N+1 时拆分到两个工作表或两个文件（单表上限调小为 3 行）
+func TestExportExcelRowLimit(t *testing.T) {
+	old := excelRowsPerSheet
+	excelRowsPerSheet = 3
+	defer func() { excelRowsPerSheet = old }()
+
+	results := make([]QueryResult, 4)
+	for i, addr := range testAddresses(len(results)) {
+		results[i] = QueryResult{Address: addr, Balance: "1", Status: StatusSuccess}
+	}
+	cases := []struct {
+		name  string
+		rows  int
+		opts  ExportOptions
+		files map[string]map[string]int // 文件名后缀 -> 工作表 -> 行数（含表头）
+	}{
+		{"N 行", 3, ExportOptions{}, map[string]map[string]int{"": {"Sheet1": 4}}},
+		{"N+1 行拆分工作表", 4, ExportOptions{}, map[string]map[string]int{"": {"Sheet1": 4, "Sheet2": 2}}},
+		{"N+1 行拆分文件", 4, ExportOptions{SplitFiles: true}, map[string]map[string]int{"_1": {"Sheet1": 4}, "_2": {"Sheet1": 2}}},
+		{"N 行加合计行", 3, ExportOptions{TotalRow: true}, map[string]map[string]int{"": {"Sheet1": 4, "Sheet2": 2}}},
+	}
+	for _, c := range cases {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "results.xlsx")
+		if err := ExportToExcelWithOptions(results[:c.rows], path, c.opts); err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != len(c.files) {
+			t.Errorf("%s: 写入了 %d 个文件, want %d", c.name, len(entries), len(c.files))
+		}
+		for suffix, want := range c.files {
+			got := excelSheetRows(t, filepath.Join(dir, "results"+suffix+".xlsx"))
+			if fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("%s: results%s.xlsx 工作表行数 %v, want %v", c.name, suffix, got, want)
+			}
+		}
+	}
+}
+
+// 导入地址数正好等于上限（N）时全部加载，N+1 时报错或截断为 N 个
+func TestLoadAddressesLimitBoundary(t *testing.T) {
+	const limit = 5
+	addrs := testAddresses(limit + 1)
+	for _, c := range []struct {
+		name     string
+		count    int
+		truncate bool
+		want     int
+		err      error
+	}{
+		{"N 个", limit, false, limit, nil},
+		{"N+1 个", limit + 1, false, 0, ErrTooManyAddresses},
+		{"N+1 个截断", limit + 1, true, limit, nil},
+	} {
+		path := writeInputFile(t, "input.txt", addrs[:c.count])
+		got, err := LoadAddressesFromFileWithOptions(path, LoadOptions{MaxAddresses: limit, TruncateAddresses: c.truncate})
+		if !errors.Is(err, c.err) || len(got) != c.want || (c.want > 0 && !slices.Equal(got, addrs[:c.want])) {
+			t.Errorf("%s: 加载 %d 个地址, err %v；want %d 个, err %v", c.name, len(got), err, c.want, c.err)
+		}
+	}
+}
+
+// 英文导出的汇总工作表（行标签、失败分类、结束原因等）和 CSV 数据基准注释不含中文；中文导出的行标签不变
+func TestExportSummaryLanguage(t *testing.T) {
+	summary := RunSummary{
+		BlockNumber:          61234567,
+		BlockTime:            time.Date(2024, 6, 3, 14, 20, 0, 0, time.UTC),
+		Total:                10,
+		Success:              7,
+		Failed:               3,
+		FailedByKind:         []FailureCount{{Kind: tron.ErrorKindRateLimited, Count: 2}, {Kind: tron.ErrorKindUnknown, Count: 1}},
+		Tokens:               []string{"USDT"},
+		RetryBudget:          -1,
+		AutoRetries:          []AutoRetryPass{{Pass: 1, Failed: 5, Recovered: 2}},
+		AutoThreads:          8,
+		AutoThreadsConverged: false,
+		FinishReason:         FinishKeysExhausted,
+		InputHash:            "abc",
+	}
+	results := []QueryResult{{Address: testAddresses(1)[0], Balance: "1", Status: StatusSuccess}}
+	hasHan := func(s string) bool {
+		return strings.ContainsFunc(s, func(r rune) bool { return unicode.Is(unicode.Han, r) })
+	}
+
+	for _, c := range []struct {
+		lang        ExportLanguage
+		sheet       string
+		wantLabels  []string
+		wantChinese bool
+	}{
+		{LangEnglish, "Summary", []string{"Data Baseline", "  429 rate limited", "Auto Retry Pass 1", "Auto Threads", "Finish Reason"}, false},
+		{LangChinese, "汇总", []string{"数据基准", "  其中 429 限流", "自动重试第 1 轮", "自动线程数", "结束原因"}, true},
+	} {
+		dir := t.TempDir()
+		xlsxPath := filepath.Join(dir, "results.xlsx")
+		if err := ExportToExcelWithOptions(results, xlsxPath, ExportOptions{Summary: &summary, Language: c.lang}); err != nil {
+			t.Fatal(err)
+		}
+		f, err := excelize.OpenFile(xlsxPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows, err := f.GetRows(c.sheet)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		labels := make([]string, len(rows))
+		for i, row := range rows {
+			labels[i] = row[0]
+			if !c.wantChinese && hasHan(strings.Join(row, " ")) {
+				t.Errorf("%s: 汇总行 %q 含中文", c.lang, row)
+			}
+		}
+		for _, want := range c.wantLabels {
+			if !slices.Contains(labels, want) {
+				t.Errorf("%s: 汇总工作表缺少行 %q（%q）", c.lang, want, labels)
+			}
+		}
+
+		csvPath := filepath.Join(dir, "results.csv")
+		if err := ExportToCSVWithOptions(results, csvPath, ExportOptions{Summary: &summary, Language: c.lang}); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(csvPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		baseline, _, _ := strings.Cut(string(data), "\n")
+		if hasHan(baseline) != c.wantChinese {
+			t.Errorf("%s: CSV 数据基准注释 %q", c.lang, baseline)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package core
+
+import (
+	"strconv"
+	"strings"
+)
+
+// formulaTriggers 表格软件打开 CSV 时会把以这些字符开头的单元格当作公式（CSV 注入）
+const formulaTriggers = "=+-@\t\r"
+
+// neutralizeFormula 单元格以公式字符开头时在前面加一个单引号，使其在 Excel 等软件中按文本显示
+// 数字（如变化列的 "+1.5"、"-2"）不是公式，保持不变
+func neutralizeFormula(value string) string {
+	if value == "" || !strings.ContainsRune(formulaTriggers, rune(value[0])) {
+		return value
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value
+	}
+	return "'" + value
+}
+
+// neutralizeFormulas 对一行中的每个单元格调用 neutralizeFormula（原地修改），返回该行
+func neutralizeFormulas(row []string) []string {
+	for i, value := range row {
+		row[i] = neutralizeFormula(value)
+	}
+	return row
+}
+
+// restoreFormula 去掉 neutralizeFormula 加上的单引号（读取本程序导出的文件时使用，对比、合并后再导出时不会重复添加）
+func restoreFormula(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune(formulaTriggers, rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
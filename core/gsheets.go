@@ -0,0 +1,271 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Google Sheets 相关地址
+const (
+	googleTokenURL   = "https://oauth2.googleapis.com/token"
+	googleSheetScope = "https://www.googleapis.com/auth/spreadsheets"
+)
+
+// googleSheetsAPI Sheets API 地址（测试时替换为模拟服务器）
+var googleSheetsAPI = "https://sheets.googleapis.com/v4/spreadsheets"
+
+// googleSheetsTimeout 单次 Google API 请求超时时间
+const googleSheetsTimeout = 30 * time.Second
+
+// googleSheetsChunkRows 每次 batchUpdate 写入的行数，避免单个请求体过大被 API 拒绝
+const googleSheetsChunkRows = 5000
+
+// GoogleCredentials Google 服务账号凭证（Google Cloud 控制台下载的 JSON 密钥文件）
+// 目标表格需要共享给 ClientEmail 并授予编辑权限
+type GoogleCredentials struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// LoadGoogleCredentials 从服务账号 JSON 文件加载凭证
+func LoadGoogleCredentials(path string) (*GoogleCredentials, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取凭证文件失败: %v", err)
+	}
+
+	var creds GoogleCredentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("解析凭证文件失败: %v", err)
+	}
+	if creds.ClientEmail == "" || creds.PrivateKey == "" {
+		return nil, errors.New("凭证文件缺少 client_email 或 private_key，请使用服务账号密钥文件")
+	}
+	return &creds, nil
+}
+
+// ExportToGoogleSheets 将结果写入 Google 表格的第一个工作表
+// 写入前会清空整个工作表；数据按原始文本写入（不解析公式），每 googleSheetsChunkRows 行一个请求
+func ExportToGoogleSheets(results []QueryResult, spreadsheetID string, credentials *GoogleCredentials) error {
+	if spreadsheetID == "" {
+		return errors.New("表格 ID 不能为空")
+	}
+	if credentials == nil {
+		return errors.New("缺少 Google 凭证")
+	}
+
+	chunks := (len(results) + 1 + googleSheetsChunkRows - 1) / googleSheetsChunkRows
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(3+chunks)*googleSheetsTimeout)
+	defer cancel()
+
+	client := &http.Client{Timeout: googleSheetsTimeout}
+
+	token, err := fetchGoogleToken(ctx, client, credentials)
+	if err != nil {
+		return err
+	}
+
+	cols := exportColumnsFor(results, LangChinese)
+	cols.allowFormulas = true // 按 RAW 写入，单元格内容不会被当作公式，不需要加单引号
+	values := make([][]string, 0, len(results)+1)
+	values = append(values, exportHeaders(cols))
+	for _, result := range results {
+		values = append(values, exportRecord(result, cols))
+	}
+
+	base := googleSheetsAPI + "/" + url.PathEscape(spreadsheetID)
+
+	// 第一个工作表的名称，范围只写名称时表示整个工作表
+	var meta struct {
+		Sheets []struct {
+			Properties struct {
+				Title string `json:"title"`
+			} `json:"properties"`
+		} `json:"sheets"`
+	}
+	if err := googleSheetsRequest(ctx, client, token, "GET", base+"?fields=sheets.properties.title", nil, &meta); err != nil {
+		return fmt.Errorf("读取表格信息失败: %v", err)
+	}
+	if len(meta.Sheets) == 0 {
+		return errors.New("表格中没有工作表")
+	}
+	sheet := quoteSheetName(meta.Sheets[0].Properties.Title)
+
+	// 清空整个工作表，避免上次导出的多余行、列残留
+	clearURL := base + "/values/" + url.PathEscape(sheet) + ":clear"
+	if err := googleSheetsRequest(ctx, client, token, "POST", clearURL, struct{}{}, nil); err != nil {
+		return fmt.Errorf("清空表格失败: %v", err)
+	}
+
+	updateURL := base + "/values:batchUpdate"
+	for start := 0; start < len(values); start += googleSheetsChunkRows {
+		end := min(start+googleSheetsChunkRows, len(values))
+		body := map[string]interface{}{
+			"valueInputOption": "RAW",
+			"data": []map[string]interface{}{{
+				"range":          fmt.Sprintf("%s!A%d", sheet, start+1),
+				"majorDimension": "ROWS",
+				"values":         values[start:end],
+			}},
+		}
+		if err := googleSheetsRequest(ctx, client, token, "POST", updateURL, body, nil); err != nil {
+			return fmt.Errorf("写入表格失败（第 %d-%d 行）: %v", start+1, end, err)
+		}
+	}
+	return nil
+}
+
+// quoteSheetName 返回 A1 表示法中的工作表名称（单引号包围，名称中的单引号写两次）
+func quoteSheetName(name string) string {
+	return "'" + strings.ReplaceAll(name, "'", "''") + "'"
+}
+
+// fetchGoogleToken 使用服务账号签发 JWT 并换取访问令牌
+func fetchGoogleToken(ctx context.Context, client *http.Client, creds *GoogleCredentials) (string, error) {
+	tokenURL := creds.TokenURI
+	if tokenURL == "" {
+		tokenURL = googleTokenURL
+	}
+
+	assertion, err := signGoogleJWT(creds, tokenURL, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	form.Set("assertion", assertion)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("创建请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("获取访问令牌失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("读取响应失败: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("获取访问令牌失败 (HTTP %d): %s", resp.StatusCode, string(body))
+	}
+
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(body, &tokenResp); err != nil || tokenResp.AccessToken == "" {
+		return "", errors.New("访问令牌响应格式错误")
+	}
+	return tokenResp.AccessToken, nil
+}
+
+// signGoogleJWT 生成服务账号授权用的 RS256 JWT
+func signGoogleJWT(creds *GoogleCredentials, audience string, now time.Time) (string, error) {
+	key, err := parseGooglePrivateKey(creds.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   creds.ClientEmail,
+		"scope": googleSheetScope,
+		"aud":   audience,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+
+	enc := base64.RawURLEncoding
+	signingInput := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("签名失败: %v", err)
+	}
+	return signingInput + "." + enc.EncodeToString(signature), nil
+}
+
+// parseGooglePrivateKey 解析服务账号的 PEM 私钥（PKCS#8 或 PKCS#1）
+func parseGooglePrivateKey(pemKey string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, errors.New("私钥格式错误")
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("私钥不是 RSA 类型")
+		}
+		return rsaKey, nil
+	}
+
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.New("私钥格式错误")
+	}
+	return key, nil
+}
+
+// googleSheetsRequest 发送 Sheets API 请求，非 200 响应视为错误
+// reqBody 为 nil 时不发送请求体；respBody 不为 nil 时解析响应到 respBody
+func googleSheetsRequest(ctx context.Context, client *http.Client, token, method, reqURL string, reqBody, respBody interface{}) error {
+	var body io.Reader
+	if reqBody != nil {
+		jsonData, err := json.Marshal(reqBody)
+		if err != nil {
+			return fmt.Errorf("请求序列化失败: %v", err)
+		}
+		body = bytes.NewReader(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, body)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+	if reqBody != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("API 返回错误 (HTTP %d): %s", resp.StatusCode, string(body))
+	}
+	if respBody != nil {
+		if err := json.NewDecoder(resp.Body).Decode(respBody); err != nil {
+			return fmt.Errorf("解析响应失败: %v", err)
+		}
+	}
+	return nil
+}
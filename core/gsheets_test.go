@@ -0,0 +1,82 @@
+package core
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// 结果超过 googleSheetsChunkRows 行时分批写入，写入前清空整个第一个工作表
+func TestExportToGoogleSheetsChunks(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	var mu sync.Mutex
+	var cleared []string
+	var ranges []string
+	var rows []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.URL.Path == "/token":
+			fmt.Fprint(w, `{"access_token":"test-token"}`)
+		case r.Method == "GET":
+			fmt.Fprint(w, `{"sheets":[{"properties":{"title":"It's data"}},{"properties":{"title":"Other"}}]}`)
+		case strings.HasSuffix(r.URL.Path, ":clear"):
+			cleared = append(cleared, strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/sheets/id/values/"), ":clear"))
+			fmt.Fprint(w, `{}`)
+		case strings.HasSuffix(r.URL.Path, "/values:batchUpdate"):
+			var body struct {
+				ValueInputOption string `json:"valueInputOption"`
+				Data             []struct {
+					Range  string     `json:"range"`
+					Values [][]string `json:"values"`
+				} `json:"data"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.ValueInputOption != "RAW" || len(body.Data) != 1 {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			ranges = append(ranges, body.Data[0].Range)
+			rows = append(rows, len(body.Data[0].Values))
+			fmt.Fprint(w, `{}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	oldAPI := googleSheetsAPI
+	googleSheetsAPI = srv.URL + "/sheets"
+	defer func() { googleSheetsAPI = oldAPI }()
+
+	results := make([]QueryResult, 2*googleSheetsChunkRows+10)
+	for i := range results {
+		results[i] = QueryResult{Address: fmt.Sprintf("T%033d", i), Balance: "1", Status: StatusSuccess}
+	}
+	creds := &GoogleCredentials{ClientEmail: "test@example.com", PrivateKey: string(pemKey), TokenURI: srv.URL + "/token"}
+	if err := ExportToGoogleSheets(results, "id", creds); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cleared) != 1 || cleared[0] != "'It''s data'" {
+		t.Fatalf("清空范围 = %q, want 整个第一个工作表", cleared)
+	}
+	wantRanges := []string{"'It''s data'!A1", fmt.Sprintf("'It''s data'!A%d", googleSheetsChunkRows+1), fmt.Sprintf("'It''s data'!A%d", 2*googleSheetsChunkRows+1)}
+	wantRows := []int{googleSheetsChunkRows, googleSheetsChunkRows, 11} // 表头 + 结果
+	if fmt.Sprint(ranges) != fmt.Sprint(wantRanges) || fmt.Sprint(rows) != fmt.Sprint(wantRows) {
+		t.Fatalf("写入范围 %q 行数 %v, want %q %v", ranges, rows, wantRanges, wantRows)
+	}
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"usdt-balance-checker/tron"
+)
+
+// balanceResponse 余额为 1（最小单位）的 triggerconstantcontract 响应
+var balanceResponse = fmt.Sprintf(`{"result":{"result":true},"constant_result":["%s"]}`, strings.Repeat("0", 63)+"1")
+
+// newTestNode 启动模拟节点，handler 为 nil 时对所有请求返回 balanceResponse
+func newTestNode(t testing.TB, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	if handler == nil {
+		handler = func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, balanceResponse)
+		}
+	}
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// testAddresses 生成 n 个不同的有效 TRON 地址
+func testAddresses(n int) []string {
+	addresses := make([]string, n)
+	for i := range addresses {
+		raw := make([]byte, 21)
+		raw[0] = 0x41
+		raw[1], raw[2], raw[3], raw[20] = byte(i>>8), byte(i), byte(i>>16), byte(i*7)
+		addresses[i] = tron.EncodeBase58Address(raw)
+	}
+	return addresses
+}
+
+// newTestKeyManager 创建有 keys 个 Key、不读写统计文件的 Key 管理器
+func newTestKeyManager(t testing.TB, keys int) *APIKeyManager {
+	t.Helper()
+	km := NewAPIKeyManager()
+	km.SetStatsPersistence(false)
+	lines := make([]string, keys)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("test-key-%d", i)
+	}
+	if err := km.LoadKeysFromLines(lines); err != nil {
+		t.Fatal(err)
+	}
+	return km
+}
+
+// newTestManager 创建连接到 srv、不限制总速率的查询管理器
+func newTestManager(km *APIKeyManager, srv *httptest.Server) *QueryManager {
+	qm := NewQueryManager(km, srv.URL)
+	qm.SetRateLimit(1000)
+	return qm
+}
@@ -0,0 +1,205 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"usdt-balance-checker/tron"
+)
+
+// ExportLanguage 导出文件的表头和状态文案语言
+type ExportLanguage string
+
+const (
+	LangChinese ExportLanguage = "zh" // 中文（默认）
+	LangEnglish ExportLanguage = "en" // 英文
+)
+
+// ParseExportLanguage 解析语言参数（"" / "zh" / "en"），空字符串为中文
+func ParseExportLanguage(s string) (ExportLanguage, error) {
+	switch s {
+	case "", "zh":
+		return LangChinese, nil
+	case "en":
+		return LangEnglish, nil
+	}
+	return LangChinese, fmt.Errorf("不支持的语言: %s（可选: zh, en）", s)
+}
+
+// ParseStatusLabels 解析自定义状态文案，格式为逗号分隔的 "状态=文案"，如 "success=OK,error=Failed"
+// 状态使用内部值：pending、success、error、cancelled、skipped、invalid；空字符串返回 nil
+func ParseStatusLabels(s string) (map[ResultStatus]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	labels := make(map[ResultStatus]string)
+	for _, part := range strings.Split(s, ",") {
+		name, text, ok := strings.Cut(part, "=")
+		name, text = strings.TrimSpace(name), strings.TrimSpace(text)
+		if !ok || text == "" {
+			return nil, fmt.Errorf("状态文案格式错误: %s（应为 状态=文案）", part)
+		}
+		status := ResultStatus(strings.ToLower(name))
+		switch status {
+		case StatusPending, StatusSuccess, StatusError, StatusCancelled, StatusSkipped, StatusInvalid:
+		default:
+			return nil, fmt.Errorf("未知的状态: %s（可选: pending, success, error, cancelled, skipped, invalid）", name)
+		}
+		labels[status] = text
+	}
+	return labels, nil
+}
+
+// statusLabels 各语言的状态文案，见 ResultStatus.Label
+var statusLabels = map[ExportLanguage]map[ResultStatus]string{
+	LangChinese: {
+		StatusPending:   "待查询",
+		StatusSuccess:   "成功",
+		StatusError:     "失败",
+		StatusCancelled: "已取消",
+		StatusSkipped:   "已跳过",
+		StatusInvalid:   "无效地址",
+	},
+	LangEnglish: {
+		StatusPending:   "Pending",
+		StatusSuccess:   "Success",
+		StatusError:     "Failed",
+		StatusCancelled: "Cancelled",
+		StatusSkipped:   "Skipped",
+		StatusInvalid:   "Invalid",
+	},
+}
+
+// exportLabels 导出用到的文案
+type exportLabels struct {
+	address, balance, status, errorMsg string
+	addressType, duplicate, rawHex     string
+	flagged, tag                       string
+	contract, wallet, yes              string
+	total                              string // 合计行的地址列文案（%d 为地址数）
+	summarySheet, inputHash            string // 汇总工作表名和输入指纹的标签（读取时两种语言都识别，见 ReadResultsInputHash）
+	summary                            summaryLabels
+	statuses                           map[ResultStatus]string
+}
+
+// summaryLabels 汇总工作表的行标签和说明文案（含 % 的为格式字符串）
+type summaryLabels struct {
+	baseline, blockNumber, blockTime, startTime, endTime string
+	total, success, failed, failedKind, token            string // failedKind: 失败分类行（%s 为错误类别）
+	retries, autoRetryPass, recovered                    string // autoRetryPass: %d 为轮数；recovered: 恢复数/失败数
+	autoThreads, notConverged, finishReason              string
+	blockUnavailable, retriesUnlimited                   string                    // 获取块高失败时的块高；不限制重试时的重试次数（%d）
+	baselineUnknown, baselineLocal, baselineBlock        string                    // 数据基准：未知；本地时间（%s）；块高和块时间（%s, %s）
+	errorKinds                                           map[tron.ErrorKind]string // 为 nil 时使用 ErrorKind.String
+	finishReasons                                        map[FinishReason]string   // 为 nil 时使用 FinishReason.String
+}
+
+// chineseSummaryLabels 中文汇总文案（RunSummary 的文本方法也使用这里的文案）
+var chineseSummaryLabels = summaryLabels{
+	baseline: "数据基准", blockNumber: "块高", blockTime: "块时间 (UTC)", startTime: "开始时间", endTime: "结束时间",
+	total: "总计", success: "成功", failed: "失败", failedKind: "  其中 %s", token: "代币",
+	retries: "重试次数", autoRetryPass: "自动重试第 %d 轮", recovered: "恢复 %d/%d",
+	autoThreads: "自动线程数", notConverged: "（尚未收敛）", finishReason: "结束原因",
+	blockUnavailable: "获取失败", retriesUnlimited: "%d（不限制）",
+	baselineUnknown: "数据基准: 未知", baselineLocal: "数据基准: 本地时间 %s（获取块高失败）", baselineBlock: "数据基准: 块高 %s (%s)",
+}
+
+// englishSummaryLabels 英文汇总文案
+var englishSummaryLabels = summaryLabels{
+	baseline: "Data Baseline", blockNumber: "Block Number", blockTime: "Block Time (UTC)", startTime: "Start Time", endTime: "End Time",
+	total: "Total", success: "Success", failed: "Failed", failedKind: "  %s", token: "Token",
+	retries: "Retries", autoRetryPass: "Auto Retry Pass %d", recovered: "Recovered %d/%d",
+	autoThreads: "Auto Threads", notConverged: " (not converged)", finishReason: "Finish Reason",
+	blockUnavailable: "Unavailable", retriesUnlimited: "%d (unlimited)",
+	baselineUnknown: "Baseline: unknown", baselineLocal: "Baseline: local time %s (block height unavailable)", baselineBlock: "Baseline: block %s (%s)",
+	errorKinds: map[tron.ErrorKind]string{
+		tron.ErrorKindUnknown:     "Other error",
+		tron.ErrorKindRateLimited: "429 rate limited",
+		tron.ErrorKindNetwork:     "Network error",
+		tron.ErrorKindHTTP:        "HTTP error",
+		tron.ErrorKindResponse:    "Invalid response",
+		tron.ErrorKindCancelled:   "Cancelled",
+		tron.ErrorKindTimeout:     "Network timeout",
+		tron.ErrorKindServer:      "Server error",
+	},
+	finishReasons: map[FinishReason]string{
+		FinishNone:          "Not finished",
+		FinishCompleted:     "All queries completed",
+		FinishCancelled:     "Stopped by user",
+		FinishKeysExhausted: "Stopped: API key quota exhausted",
+		FinishMemoryLimit:   "Stopped: memory limit reached",
+		FinishDeadline:      "Stopped: query time limit reached",
+	},
+}
+
+// errorKindText 返回错误类别的文案
+func (l summaryLabels) errorKindText(kind tron.ErrorKind) string {
+	if text, ok := l.errorKinds[kind]; ok {
+		return text
+	}
+	return kind.String()
+}
+
+// finishReasonText 返回结束原因的文案
+func (l summaryLabels) finishReasonText(reason FinishReason) string {
+	if text, ok := l.finishReasons[reason]; ok {
+		return text
+	}
+	return reason.String()
+}
+
+// labelsFor 返回指定语言的导出文案（未知语言使用中文）
+// 错误信息列保持原文，不做翻译
+func labelsFor(lang ExportLanguage) exportLabels {
+	if lang == LangEnglish {
+		return exportLabels{
+			address: "Address", balance: "Balance", status: "Status", errorMsg: "Error",
+			addressType: "Address Type", duplicate: "Duplicate", rawHex: "Raw Hex",
+			flagged: "Watchlist", tag: "Tag",
+			contract: "Contract", wallet: "Wallet", yes: "Yes",
+			total:        "Total (%d addresses)",
+			summarySheet: "Summary", inputHash: "Input Hash",
+			summary:  englishSummaryLabels,
+			statuses: statusLabels[LangEnglish],
+		}
+	}
+	return exportLabels{
+		address: "地址", balance: "余额", status: "状态", errorMsg: "错误信息",
+		addressType: "地址类型", duplicate: "重复", rawHex: "原始值 (hex)",
+		flagged: "关注", tag: "标签",
+		contract: "合约", wallet: "钱包", yes: "是",
+		total:        "合计（%d 个地址）",
+		summarySheet: "汇总", inputHash: "输入指纹",
+		summary:  chineseSummaryLabels,
+		statuses: statusLabels[LangChinese],
+	}
+}
+
+// isSummarySheet 是否为汇总工作表（任一语言），读取结果时跳过
+func isSummarySheet(name string) bool {
+	return name == labelsFor(LangChinese).summarySheet || name == labelsFor(LangEnglish).summarySheet
+}
+
+// withStatuses 返回用 custom 覆盖状态文案后的导出文案（不修改原来的映射）
+func (l exportLabels) withStatuses(custom map[ResultStatus]string) exportLabels {
+	if len(custom) == 0 {
+		return l
+	}
+	statuses := make(map[ResultStatus]string, len(l.statuses)+len(custom))
+	for status, text := range l.statuses {
+		statuses[status] = text
+	}
+	for status, text := range custom {
+		statuses[status] = text
+	}
+	l.statuses = statuses
+	return l
+}
+
+// statusText 返回状态的导出文案，未知状态返回内部值
+func (l exportLabels) statusText(status ResultStatus) string {
+	if text, ok := l.statuses[status]; ok {
+		return text
+	}
+	return string(status)
+}
@@ -0,0 +1,41 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+const (
+	// LargeInputFileSize 超过该大小（200MB）的输入文件在导入前提示确认，避免误选超大文件导致内存耗尽
+	LargeInputFileSize = 200 * 1024 * 1024
+	// DefaultMaxAddresses 界面导入地址的默认上限（500 万个）
+	DefaultMaxAddresses = 5000000
+)
+
+// ErrTooManyAddresses 加载的地址数超过 LoadOptions.MaxAddresses
+var ErrTooManyAddresses = errors.New("地址数量超过上限")
+
+// LargeInputFileError 输入文件过大
+type LargeInputFileError struct {
+	Path string // 文件路径
+	Size int64  // 文件大小（字节）
+}
+
+func (e *LargeInputFileError) Error() string {
+	return fmt.Sprintf("输入文件较大: %s 共 %.1f MB（超过 %d MB），读取可能占用大量内存和时间",
+		e.Path, float64(e.Size)/1024/1024, LargeInputFileSize/1024/1024)
+}
+
+// CheckInputFileSize 检查输入文件大小，超过 LargeInputFileSize 时返回 *LargeInputFileError
+// 无法获取文件信息时不阻止操作（打开文件时会报告具体错误）
+func CheckInputFileSize(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	if info.Size() > LargeInputFileSize {
+		return &LargeInputFileError{Path: path, Size: info.Size()}
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"usdt-balance-checker/tron"
+)
+
+// JobStateVersion 查询状态（SaveState）的格式版本，格式不兼容地变化时递增
+const JobStateVersion = 1
+
+// jobState 可序列化的查询状态：地址和已完成的结果、重试计数和查询选项，不包含 API Key
+type jobState struct {
+	Version int         `json:"version"`
+	SavedAt time.Time   `json:"saved_at"`
+	Options jobOptions  `json:"options"`
+	Retries int         `json:"retries"` // 之前累计消耗的重试次数
+	Results []jobResult `json:"results"` // 与输入地址一一对应，未完成的地址为 pending / cancelled
+}
+
+// jobOptions 状态中保存的查询选项（请求认证拦截器等无法序列化的选项不保存）
+type jobOptions struct {
+	BaseURL        string             `json:"base_url,omitempty"`
+	MaxConcurrent  int                `json:"max_concurrent"`
+	ContractFilter ContractFilterMode `json:"contract_filter"`
+	Shuffle        bool               `json:"shuffle,omitempty"`
+	RetryBudget    float64            `json:"retry_budget"`
+	Tokens         []tron.Token       `json:"tokens"`
+	JSONRPCBatch   int                `json:"jsonrpc_batch,omitempty"`
+	OwnerAddress   string             `json:"owner_address,omitempty"`
+	KeepAlive      time.Duration      `json:"keep_alive,omitempty"`
+	RateLimit      int                `json:"rate_limit"`
+	RetryPolicy    *RetryPolicy       `json:"retry_policy,omitempty"`
+	AutoRetry      int                `json:"auto_retry,omitempty"`
+	AutoThreads    bool               `json:"auto_threads,omitempty"`
+}
+
+// jobResult 状态中保存的单个结果（不保存使用的 API Key 和界面标记）
+type jobResult struct {
+	Address       string            `json:"address"`
+	Balance       string            `json:"balance,omitempty"`
+	Status        ResultStatus      `json:"status"`
+	Error         string            `json:"error,omitempty"`
+	ErrorKind     tron.ErrorKind    `json:"error_kind,omitempty"`
+	AddressType   string            `json:"address_type,omitempty"`
+	Duplicate     bool              `json:"duplicate,omitempty"`
+	QueriedAt     time.Time         `json:"queried_at"`
+	RawHex        string            `json:"raw_hex,omitempty"`
+	TokenBalances map[string]string `json:"token_balances,omitempty"`
+}
+
+// SaveState 将查询状态写入 w（JSON），用于停止后在另一个进程中用 LoadState 和 Resume 继续
+//
+// 保存完整地址列表、已完成的结果、累计重试次数和查询选项，不保存 API Key，
+// 继续时可以使用不同的 Key。查询中也可以调用（保存调用时的快照，进行中的地址按未完成保存）
+func (qm *QueryManager) SaveState(w io.Writer) error {
+	qm.mu.RLock()
+	results := qm.results
+	policy := qm.retryPolicy
+	if qm.resumeBase != nil {
+		results = mergeResume(qm.resumeBase, qm.resumeIndices, qm.results)
+	}
+	state := jobState{
+		Version: JobStateVersion,
+		SavedAt: time.Now().UTC(),
+		Options: jobOptions{
+			BaseURL:        qm.baseURL,
+			MaxConcurrent:  qm.maxConcurrent,
+			ContractFilter: qm.contractMode,
+			Shuffle:        qm.shuffle,
+			RetryBudget:    qm.retryBudgetRatio,
+			Tokens:         qm.tokens,
+			JSONRPCBatch:   qm.rpcBatchSize,
+			OwnerAddress:   qm.ownerAddress,
+			KeepAlive:      qm.keepAlive,
+			RetryPolicy:    &policy,
+			AutoRetry:      qm.autoRetry,
+			AutoThreads:    qm.autoThreads.enabled,
+		},
+		Retries: qm.resumedRetries + qm.retryBudget.used,
+		Results: make([]jobResult, len(results)),
+	}
+	for i, r := range results {
+		state.Results[i] = jobResult{
+			Address:       r.Address,
+			Balance:       r.Balance,
+			Status:        r.Status,
+			Error:         r.Error,
+			ErrorKind:     r.ErrorKind,
+			AddressType:   r.AddressType,
+			Duplicate:     r.Duplicate,
+			QueriedAt:     r.QueriedAt,
+			RawHex:        r.RawHex,
+			TokenBalances: r.TokenBalances,
+		}
+	}
+	qm.mu.RUnlock()
+	state.Options.RateLimit = qm.RateLimit()
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(state); err != nil {
+		return fmt.Errorf("保存查询状态失败: %v", err)
+	}
+	return nil
+}
+
+// LoadState 从 r 读取 SaveState 保存的查询状态，恢复结果和查询选项，之后调用 Resume 继续查询
+// 只能在查询开始前调用；Key 使用创建查询管理器时传入的 Key 管理器
+func (qm *QueryManager) LoadState(r io.Reader) error {
+	var state jobState
+	if err := json.NewDecoder(r).Decode(&state); err != nil {
+		return fmt.Errorf("读取查询状态失败: %v", err)
+	}
+	if state.Version < 1 || state.Version > JobStateVersion {
+		return fmt.Errorf("不支持的查询状态版本: %d（当前版本 %d）", state.Version, JobStateVersion)
+	}
+
+	results := make([]QueryResult, len(state.Results))
+	for i, r := range state.Results {
+		results[i] = QueryResult{
+			Address:       r.Address,
+			Balance:       r.Balance,
+			Status:        r.Status,
+			Error:         r.Error,
+			ErrorKind:     r.ErrorKind,
+			AddressType:   r.AddressType,
+			Duplicate:     r.Duplicate,
+			QueriedAt:     r.QueriedAt,
+			RawHex:        r.RawHex,
+			TokenBalances: r.TokenBalances,
+		}
+	}
+
+	qm.mu.Lock()
+	if qm.state != StateIdle {
+		qm.mu.Unlock()
+		return fmt.Errorf("只能在查询开始前读取查询状态（当前: %s）", qm.state)
+	}
+	qm.baseURL = state.Options.BaseURL
+	qm.contractMode = state.Options.ContractFilter
+	qm.shuffle = state.Options.Shuffle
+	qm.results = results
+	qm.stats = CountResultStats(results)
+	qm.failures = countFailures(results)
+	qm.resumedRetries = state.Retries
+	qm.mu.Unlock()
+
+	qm.SetMaxConcurrent(state.Options.MaxConcurrent)
+	qm.SetRetryBudget(state.Options.RetryBudget)
+	qm.SetTokens(state.Options.Tokens)
+	qm.SetJSONRPCBatch(state.Options.JSONRPCBatch)
+	_ = qm.SetOwnerAddress(state.Options.OwnerAddress)
+	qm.SetKeepAlive(state.Options.KeepAlive)
+	qm.SetAutoRetry(state.Options.AutoRetry)
+	qm.SetAutoThreads(state.Options.AutoThreads)
+	if state.Options.RateLimit > 0 {
+		qm.SetRateLimit(state.Options.RateLimit)
+	}
+	if state.Options.RetryPolicy != nil {
+		qm.SetRetryPolicy(*state.Options.RetryPolicy)
+	}
+	return nil
+}
+
+// Resume 继续查询当前结果中未完成的地址（见 ResultStatus.IsFinal），阻塞直到全部完成或被取消
+//
+// 通常在 LoadState 之后调用。结果回调的 index 和进度都按完整地址列表计算；
+// 结束后 GetResults 返回完整列表的结果，重试次数累计之前保存的次数
+func (qm *QueryManager) Resume(progressCallback func(current, total int)) error {
+	qm.mu.Lock()
+	all := append([]QueryResult(nil), qm.results...)
+	addresses := make([]string, len(all))
+	var remaining []string
+	var indices []int
+	for i, r := range all {
+		addresses[i] = r.Address
+		if !r.Status.IsFinal() {
+			remaining = append(remaining, r.Address)
+			indices = append(indices, i)
+		}
+	}
+	resultCallback := qm.resultCallback
+	if resultCallback != nil {
+		qm.resultCallback = func(index int, result QueryResult) {
+			resultCallback(indices[index], result)
+		}
+	}
+	qm.resumeBase, qm.resumeIndices = all, indices
+	qm.mu.Unlock()
+
+	defer func() {
+		qm.mu.Lock()
+		qm.resultCallback = resultCallback
+		qm.resumeBase, qm.resumeIndices = nil, nil
+		qm.mu.Unlock()
+	}()
+
+	if len(remaining) == 0 {
+		return nil
+	}
+
+	done := len(all) - len(remaining)
+	var callback func(current, total int)
+	if progressCallback != nil {
+		callback = func(current, total int) {
+			progressCallback(done+current, len(all))
+		}
+	}
+	if err := qm.QueryAddresses(remaining, callback); err != nil {
+		return err
+	}
+
+	qm.mu.Lock()
+	qm.results = mergeResume(all, indices, qm.results)
+	qm.stats = CountResultStats(qm.results)
+	qm.failures = countFailures(qm.results)
+	if qm.inputHash == "" {
+		qm.summary.InputHash = InputHash(addresses)
+	}
+	qm.mu.Unlock()
+	return nil
+}
+
+// mergeResume 将继续查询的结果按索引合并到完整结果的副本中，保留原来的重复标记
+func mergeResume(all []QueryResult, indices []int, partial []QueryResult) []QueryResult {
+	merged := append([]QueryResult(nil), all...)
+	for k, i := range indices {
+		if k < len(partial) && partial[k].Address == all[i].Address {
+			result := partial[k]
+			result.Duplicate = all[i].Duplicate
+			merged[i] = result
+		}
+	}
+	return merged
+}
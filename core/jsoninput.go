@@ -0,0 +1,88 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DefaultJSONAddressKey JSON 对象数组中默认读取的地址字段名
+const DefaultJSONAddressKey = "address"
+
+// errJSONFormat JSON 输入的格式不是地址数组或对象数组
+var errJSONFormat = errors.New(`JSON 输入应为地址数组或对象数组，如 ["T..."] 或 [{"address":"T..."}]`)
+
+// isJSONFile 是否为 JSON 文件
+func isJSONFile(filepath string) bool {
+	return strings.HasSuffix(strings.ToLower(filepath), ".json")
+}
+
+// readJSONAddressCells 逐个读取 JSON 数组中的地址，maxRows > 0 时只读取前 maxRows 个元素
+//
+// 数组元素可以是字符串（地址），也可以是对象：读取 key 字段（空为 DefaultJSONAddressKey，
+// 字段名先精确匹配再不区分大小写匹配），没有该字段或不是字符串的对象跳过。
+// AddressCell.Row 为元素序号（从 1 开始）；数组按元素流式解析，不需要一次读入整个文件
+func readJSONAddressCells(filepath, key string, maxRows int, visit func(AddressCell)) error {
+	if key == "" {
+		key = DefaultJSONAddressKey
+	}
+
+	file, err := os.Open(filepath)
+	if err != nil {
+		return fmt.Errorf("打开文件失败: %v", err)
+	}
+	defer file.Close()
+
+	var size int64
+	if info, err := file.Stat(); err == nil {
+		size = info.Size()
+	}
+	counter := &countingReader{r: file}
+	decoder := json.NewDecoder(counter)
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return errJSONFormat
+	}
+	for row := 1; decoder.More(); row++ {
+		if maxRows > 0 && row > maxRows {
+			break
+		}
+		var element json.RawMessage
+		if err := decoder.Decode(&element); err != nil {
+			return fmt.Errorf("读取 JSON 失败（第 %d 个元素）: %v", row, err)
+		}
+		value, err := jsonAddressValue(element, key)
+		if err != nil {
+			return fmt.Errorf("%w（第 %d 个元素）", err, row)
+		}
+		visit(AddressCell{Row: row, Value: strings.TrimSpace(value), progress: counter.fraction(size)})
+	}
+	return nil
+}
+
+// jsonAddressValue 返回数组元素中的地址：字符串元素为其本身，对象元素为 key 字段的值（没有时为空字符串）
+func jsonAddressValue(element json.RawMessage, key string) (string, error) {
+	var value string
+	if err := json.Unmarshal(element, &value); err == nil {
+		return value, nil
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(element, &object); err != nil {
+		return "", errJSONFormat
+	}
+	field, ok := object[key]
+	if !ok {
+		for name, v := range object {
+			if strings.EqualFold(name, key) {
+				field, ok = v, true
+				break
+			}
+		}
+	}
+	if !ok || json.Unmarshal(field, &value) != nil {
+		return "", nil
+	}
+	return value, nil
+}
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// SetKeepAlive 设置连接保活间隔，0 为关闭（默认）
+// 开启后查询期间若超过该间隔没有新结果（如限流等待、重试退避），向节点发送一次轻量的 HEAD 请求，
+// 让连接池中的连接保持可用，避免空闲连接被关闭后下一个请求重新进行 TLS 握手；适合长时间运行的查询
+func (qm *QueryManager) SetKeepAlive(interval time.Duration) {
+	if interval < 0 {
+		interval = 0
+	}
+	qm.mu.Lock()
+	qm.keepAlive = interval
+	qm.mu.Unlock()
+}
+
+// startKeepAlive 开启保活时在后台定期检查空闲时长并 Ping 节点，返回停止函数
+func (qm *QueryManager) startKeepAlive() func() {
+	qm.mu.RLock()
+	interval := qm.keepAlive
+	qm.mu.RUnlock()
+	if interval <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		// 保活请求不带 API Key，不消耗 Key 的额度
+		client := qm.newClient("")
+		for {
+			select {
+			case <-done:
+				return
+			case <-qm.ctx.Done():
+				return
+			case <-ticker.C:
+				if qm.GetActivity().IdleFor < interval {
+					continue
+				}
+				if err := client.Ping(qm.ctx); err != nil && qm.ctx.Err() == nil {
+					log.Debug("连接保活失败: %v", err)
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// KeyUsage 单个 Key 在一次查询中的使用次数，见 QueryManager.KeyUsage
+type KeyUsage struct {
+	Key         string
+	DisplayName string // 显示名称（备注名，未设置时为 "Key 1", "Key 2"）
+	RunUsed     int    // 本次查询使用的次数
+	Used        int    // 累计使用次数（含之前的查询和其他实例合并的次数）
+	MaxLimit    int
+}
+
+// snapshotUsage 返回每个 Key 当前的累计使用次数（Key -> 次数），作为一次查询的基准
+func (m *APIKeyManager) snapshotUsage() map[string]int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	usage := make(map[string]int, len(m.keys))
+	for _, keyInfo := range m.keys {
+		usage[keyInfo.Key] = keyInfo.Used
+	}
+	return usage
+}
+
+// KeyUsage 返回每个 Key 在本次查询中的使用次数（相对查询开始时的快照）和累计使用次数，顺序与 APIKeyManager.GetKeyStatus 相同
+// 每次查询开始时重新记录快照；还没有开始查询时本次使用次数都为 0。查询中新加入的 Key 按 0 为基准
+func (qm *QueryManager) KeyUsage() []KeyUsage {
+	qm.mu.RLock()
+	baseline := qm.keyBaseline
+	qm.mu.RUnlock()
+
+	status := qm.keyManager.GetKeyStatus()
+	usage := make([]KeyUsage, len(status))
+	for i, keyStatus := range status {
+		usage[i] = KeyUsage{
+			Key:         keyStatus.Key,
+			DisplayName: keyStatus.DisplayName,
+			Used:        keyStatus.Used,
+			MaxLimit:    keyStatus.MaxLimit,
+		}
+		if baseline != nil {
+			usage[i].RunUsed = max(0, keyStatus.Used-baseline[keyStatus.Key])
+		}
+	}
+	return usage
+}
+
+// KeyUsageText 返回每个 Key 的使用次数，如 "Key 1: 本次 120，累计 5,320 / 100,000"，每个 Key 一行；没有 Key 时返回空
+func (s RunSummary) KeyUsageText() []string {
+	lines := make([]string, len(s.KeyUsage))
+	for i, usage := range s.KeyUsage {
+		lines[i] = fmt.Sprintf("%s: 本次 %s，累计 %s / %s", usage.DisplayName,
+			formatThousands(int64(usage.RunUsed)), formatThousands(int64(usage.Used)), formatThousands(int64(usage.MaxLimit)))
+	}
+	return lines
+}
+
+// writeKeyUsageSheet 在 Excel 中写入"Key 使用"工作表：本次查询的使用次数和累计使用次数（不写 Key 本身）
+func writeKeyUsageSheet(f *excelize.File, usage []KeyUsage) {
+	sheetName := "Key 使用"
+	if _, err := f.NewSheet(sheetName); err != nil {
+		return
+	}
+
+	runTotal := 0
+	rows := [][]any{{"Key", "本次使用", "累计使用", "额度"}}
+	for _, u := range usage {
+		rows = append(rows, []any{u.DisplayName, u.RunUsed, u.Used, u.MaxLimit})
+		runTotal += u.RunUsed
+	}
+	rows = append(rows, []any{"合计", runTotal})
+	for i, row := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		f.SetSheetRow(sheetName, cell, &row)
+	}
+	f.SetColWidth(sheetName, "A", "A", 20)
+	f.SetColWidth(sheetName, "B", "D", 12)
+}
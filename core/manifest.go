@@ -0,0 +1,129 @@
+package core
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"usdt-balance-checker/tron"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// inputHashPrefix 输入指纹的前缀（标明算法）
+const inputHashPrefix = "sha256:"
+
+// InputHash 计算地址列表的输入指纹（用于证明结果对应特定的输入列表）
+//
+// 指纹按输入文件加载的完整地址列表计算（CLI 在 -start-index、-only-previously-nonzero 筛选之前计算，
+// 见 QueryManager.SetInputHash），校验时用相同的加载选项重新加载输入文件（见 VerifyInputFile）。
+//
+// 规范化规则（修改会导致旧结果无法校验）：
+//  1. 每个地址去掉首尾空白，空字符串忽略
+//  2. 只计入有效的 TRON 地址：-keep-invalid 保留在结果中的无效地址不计入，是否保留无效地址不影响指纹
+//  3. 地址区分大小写（Base58 本身区分大小写），不做大小写转换
+//  4. 去重后按字节序升序排序，因此输入顺序、重复地址和打乱查询顺序都不影响指纹
+//  5. 每个地址后跟一个 "\n" 拼接，计算 SHA-256，结果为 "sha256:" + 小写 hex
+func InputHash(addresses []string) string {
+	seen := make(map[string]bool, len(addresses))
+	normalized := make([]string, 0, len(addresses))
+	for _, addr := range addresses {
+		addr = strings.TrimSpace(addr)
+		if addr == "" || seen[addr] {
+			continue
+		}
+		if !tron.ValidateAddress(addr) {
+			continue
+		}
+		seen[addr] = true
+		normalized = append(normalized, addr)
+	}
+	sort.Strings(normalized)
+
+	h := sha256.New()
+	for _, addr := range normalized {
+		h.Write([]byte(addr))
+		h.Write([]byte("\n"))
+	}
+	return inputHashPrefix + hex.EncodeToString(h.Sum(nil))
+}
+
+// isInputHashLabel 是否为输入指纹的标签（中英文导出都识别）
+func isInputHashLabel(label string) bool {
+	return label == labelsFor(LangChinese).inputHash || label == labelsFor(LangEnglish).inputHash
+}
+
+// ReadResultsInputHash 从导出的结果文件中读取记录的输入指纹
+// CSV 从开头的 # 注释行读取，Excel 从汇总工作表读取（中英文导出都识别）
+func ReadResultsInputHash(path string) (string, error) {
+	if strings.HasSuffix(strings.ToLower(path), ".xlsx") {
+		f, err := excelize.OpenFile(path)
+		if err != nil {
+			return "", errors.New("打开结果文件失败")
+		}
+		defer f.Close()
+
+		found := false
+		for _, sheet := range f.GetSheetList() {
+			if !isSummarySheet(sheet) {
+				continue
+			}
+			found = true
+			rows, err := f.GetRows(sheet)
+			if err != nil {
+				return "", fmt.Errorf("读取汇总工作表失败: %v", err)
+			}
+			for _, row := range rows {
+				if len(row) >= 2 && isInputHashLabel(row[0]) {
+					return row[1], nil
+				}
+			}
+		}
+		if !found {
+			return "", errors.New("结果文件中没有汇总工作表")
+		}
+		return "", errors.New("结果文件中没有记录输入指纹")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", errors.New("打开结果文件失败")
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if !strings.HasPrefix(line, "#") {
+			break // 注释行只出现在表头之前
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "#"))
+		if label, value, ok := strings.Cut(line, ":"); ok && isInputHashLabel(label) {
+			return strings.TrimSpace(value), nil
+		}
+	}
+	return "", errors.New("结果文件中没有记录输入指纹")
+}
+
+// VerifyInputFile 校验输入文件与结果文件中记录的输入指纹是否一致
+// opts 需与查询时加载输入文件的选项相同（工作表、列、地址数上限），与查询时一样按 LoadAddressesFromFileWithOptions 加载；
+// 查询时跳过的部分（-start-index 等）不影响指纹。返回是否一致、结果文件中记录的指纹和根据输入文件计算的指纹
+func VerifyInputFile(inputPath, resultsPath string, opts LoadOptions) (bool, string, string, error) {
+	expected, err := ReadResultsInputHash(resultsPath)
+	if err != nil {
+		return false, "", "", err
+	}
+
+	addresses, err := LoadAddressesFromFileWithOptions(inputPath, opts)
+	if err != nil {
+		return false, expected, "", err
+	}
+
+	actual := InputHash(addresses)
+	return actual == expected, expected, actual, nil
+}
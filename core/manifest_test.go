@@ -0,0 +1,148 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInputHashNormalization(t *testing.T) {
+	addrs := testAddresses(3)
+	want := InputHash(addrs)
+
+	cases := map[string][]string{
+		"顺序不同":    {addrs[2], addrs[0], addrs[1]},
+		"重复和空白":   {" " + addrs[0] + " ", addrs[1], addrs[1], "", addrs[2]},
+		"包含无效地址":  {addrs[0], "not-an-address", addrs[1], "T" + strings.Repeat("1", 33), addrs[2]},
+		"包含以太坊地址": {addrs[0], addrs[1], "0x" + strings.Repeat("ab", 20), addrs[2]},
+	}
+	for name, input := range cases {
+		if got := InputHash(input); got != want {
+			t.Errorf("%s: InputHash = %s, want %s", name, got, want)
+		}
+	}
+	if got := InputHash(addrs[:2]); got == want {
+		t.Errorf("少一个地址时指纹不应相同")
+	}
+}
+
+// writeInputFile 将地址按行写入临时的 TXT 输入文件
+func writeInputFile(t *testing.T, name string, lines []string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// exportWithSummary 按 CLI 的方式导出结果（带汇总信息），返回结果文件路径
+func exportWithSummary(t *testing.T, qm *QueryManager, ext string, lang ExportLanguage) string {
+	t.Helper()
+	summary := qm.GetSummary()
+	path := filepath.Join(t.TempDir(), "results"+ext)
+	opts := ExportOptions{Summary: &summary, Language: lang}
+	var err error
+	if ext == ".xlsx" {
+		err = ExportToExcelWithOptions(qm.GetResults(), path, opts)
+	} else {
+		err = ExportToCSVWithOptions(qm.GetResults(), path, opts)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// 保留无效地址（-keep-invalid）和跳过前 N 个地址（-start-index）的查询，结果文件都能用原输入文件校验通过；
+// 换成其他输入文件时校验不通过
+func TestVerifyInputFile(t *testing.T) {
+	srv := newTestNode(t, nil)
+	addrs := testAddresses(6)
+	lines := append([]string{"invalid-address"}, addrs...)
+	lines = append(lines, addrs[0]) // 重复地址
+	input := writeInputFile(t, "input.txt", lines)
+	other := writeInputFile(t, "other.txt", addrs[1:])
+	loadOpts := LoadOptions{KeepInvalid: true, KeepDuplicates: true}
+
+	loaded, err := LoadAddressesFromFileWithOptions(input, loadOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runs := map[string]func(qm *QueryManager) error{
+		"保留无效地址": func(qm *QueryManager) error {
+			return qm.QueryAddresses(loaded, nil)
+		},
+		"跳过前 3 个地址": func(qm *QueryManager) error {
+			qm.SetInputHash(InputHash(loaded))
+			return qm.QueryAddresses(loaded[3:], nil)
+		},
+	}
+	for name, run := range runs {
+		for _, format := range []struct {
+			ext  string
+			lang ExportLanguage
+		}{{".csv", LangChinese}, {".csv", LangEnglish}, {".xlsx", LangChinese}, {".xlsx", LangEnglish}} {
+			qm := newTestManager(newTestKeyManager(t, 1), srv)
+			if err := run(qm); err != nil {
+				t.Fatal(err)
+			}
+			results := exportWithSummary(t, qm, format.ext, format.lang)
+
+			ok, expected, actual, err := VerifyInputFile(input, results, loadOpts)
+			if err != nil || !ok {
+				t.Errorf("%s %s/%s: 原输入文件校验不通过 (记录 %s, 计算 %s, err %v)", name, format.ext, format.lang, expected, actual, err)
+			}
+			ok, _, _, err = VerifyInputFile(other, results, loadOpts)
+			if err != nil || ok {
+				t.Errorf("%s %s/%s: 其他输入文件不应校验通过 (err %v)", name, format.ext, format.lang, err)
+			}
+		}
+	}
+}
+
+// 从保存的状态继续查询（含无效地址行）后，指纹仍与原输入文件一致
+func TestVerifyInputFileAfterResume(t *testing.T) {
+	srv := newTestNode(t, nil)
+	addrs := testAddresses(3)
+	input := writeInputFile(t, "input.txt", append([]string{"invalid-address"}, addrs...))
+
+	state := fmt.Sprintf(`{"version":1,"options":{"base_url":%q,"max_concurrent":2,"rate_limit":1000},"results":[
+		{"address":"invalid-address","status":"invalid"},
+		{"address":%q,"balance":"1","status":"success"},
+		{"address":%q,"status":"pending"},
+		{"address":%q,"status":"cancelled"}]}`, srv.URL, addrs[0], addrs[1], addrs[2])
+	qm := newTestManager(newTestKeyManager(t, 1), srv)
+	if err := qm.LoadState(strings.NewReader(state)); err != nil {
+		t.Fatal(err)
+	}
+	if err := qm.Resume(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	results := exportWithSummary(t, qm, ".csv", LangChinese)
+	ok, expected, actual, err := VerifyInputFile(input, results, LoadOptions{KeepInvalid: true})
+	if err != nil || !ok {
+		t.Fatalf("继续查询后校验不通过 (记录 %s, 计算 %s, err %v)", expected, actual, err)
+	}
+
+	// 保存的状态只包含输入文件的一部分（跳过了前面的地址）时，继续查询保留按完整输入文件设置的指纹
+	full := append(testAddresses(5)[3:], addrs...)
+	fullInput := writeInputFile(t, "full.txt", full)
+	qm = newTestManager(newTestKeyManager(t, 1), srv)
+	if err := qm.LoadState(strings.NewReader(state)); err != nil {
+		t.Fatal(err)
+	}
+	qm.SetInputHash(InputHash(full))
+	if err := qm.Resume(nil); err != nil {
+		t.Fatal(err)
+	}
+	results = exportWithSummary(t, qm, ".csv", LangChinese)
+	ok, expected, actual, err = VerifyInputFile(fullInput, results, LoadOptions{})
+	if err != nil || !ok {
+		t.Fatalf("跳过部分地址后继续查询，校验不通过 (记录 %s, 计算 %s, err %v)", expected, actual, err)
+	}
+}
@@ -0,0 +1,150 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MemoryGuardInterval 内存守护读取内存统计的间隔
+const MemoryGuardInterval = 2 * time.Second
+
+// memoryDegradeRatio 内存占用达到上限的该比例时切换到低内存模式
+const memoryDegradeRatio = 0.8
+
+// SetMemoryGuard 开启内存守护：查询中每隔 MemoryGuardInterval 读取一次内存统计（runtime.MemStats），limit 为上限（字节），0 关闭
+//
+// 占用达到上限的 80% 时先回收内存，仍然超过时切换到低内存模式（见 LowMemory）并警告；
+// 达到上限时自动暂停查询（未查询的地址标记为已取消，见 MemoryExceeded），避免内存耗尽后进程崩溃丢失全部结果
+func (qm *QueryManager) SetMemoryGuard(limit uint64) {
+	qm.mu.Lock()
+	qm.memoryLimit = limit
+	qm.mu.Unlock()
+}
+
+// LowMemory 本次查询是否已切换到低内存模式：已完成和之后的结果不再保存原始值（RawHex）和使用的 Key
+func (qm *QueryManager) LowMemory() bool {
+	qm.mu.RLock()
+	defer qm.mu.RUnlock()
+	return qm.lowMemory
+}
+
+// MemoryExceeded 本次查询是否因内存占用达到上限而自动暂停（见 SetMemoryGuard）
+func (qm *QueryManager) MemoryExceeded() bool {
+	qm.mu.RLock()
+	defer qm.mu.RUnlock()
+	return qm.memoryExceeded
+}
+
+// startMemoryGuard 开启内存守护时在后台定期检查内存占用，返回停止函数
+func (qm *QueryManager) startMemoryGuard() func() {
+	qm.mu.RLock()
+	limit := qm.memoryLimit
+	qm.mu.RUnlock()
+	if limit == 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(MemoryGuardInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-qm.ctx.Done():
+				return
+			case <-ticker.C:
+				qm.checkMemory(limit)
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// checkMemory 检查一次内存占用（堆上已分配的字节数），超过阈值时回收内存后再判断是否降级或暂停
+func (qm *QueryManager) checkMemory(limit uint64) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	threshold := uint64(float64(limit) * memoryDegradeRatio)
+	if stats.HeapAlloc < threshold {
+		return
+	}
+
+	// 堆中可能有大量尚未回收的临时对象（响应、JSON 解析），先回收再判断
+	debug.FreeOSMemory()
+	runtime.ReadMemStats(&stats)
+	used := stats.HeapAlloc
+
+	if used >= limit {
+		qm.mu.Lock()
+		first := !qm.memoryExceeded
+		qm.memoryExceeded = true
+		qm.mu.Unlock()
+		if first {
+			qm.warn(fmt.Sprintf("内存占用 %s 已达到上限 %s，查询已自动暂停，请导出已完成的结果", FormatMemorySize(used), FormatMemorySize(limit)))
+			qm.CancelWithReason(FinishMemoryLimit)
+		}
+		return
+	}
+	if used < threshold {
+		return
+	}
+
+	qm.mu.Lock()
+	if qm.lowMemory {
+		qm.mu.Unlock()
+		return
+	}
+	qm.lowMemory = true
+	for i := range qm.results {
+		qm.results[i].RawHex, qm.results[i].APIKey = "", ""
+	}
+	qm.mu.Unlock()
+	debug.FreeOSMemory()
+	qm.warn(fmt.Sprintf("内存占用 %s 接近上限 %s，已切换到低内存模式：结果不再保存原始值和使用的 Key", FormatMemorySize(used), FormatMemorySize(limit)))
+}
+
+// RuntimeMemoryLimit 返回 Go 运行时的内存上限（GOMEMLIMIT 环境变量），未设置时返回 0
+// 用作内存守护的默认上限
+func RuntimeMemoryLimit() uint64 {
+	limit := debug.SetMemoryLimit(-1)
+	if limit <= 0 || limit == math.MaxInt64 {
+		return 0
+	}
+	return uint64(limit)
+}
+
+// ParseMemorySize 解析内存大小，如 "4GB"、"512MB"、"1.5G"，不带单位时按 MB；空字符串返回 0
+func ParseMemorySize(s string) (uint64, error) {
+	text := strings.ToUpper(strings.TrimSpace(s))
+	if text == "" {
+		return 0, nil
+	}
+	text = strings.TrimSuffix(strings.TrimSuffix(text, "B"), "I")
+	unit := float64(1 << 20)
+	switch {
+	case strings.HasSuffix(text, "G"):
+		unit, text = 1<<30, strings.TrimSuffix(text, "G")
+	case strings.HasSuffix(text, "M"):
+		text = strings.TrimSuffix(text, "M")
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("内存大小格式错误: %s（如 4GB、512MB）", s)
+	}
+	return uint64(value * unit), nil
+}
+
+// FormatMemorySize 格式化内存大小，如 "512 MB"、"3.5 GB"
+func FormatMemorySize(n uint64) string {
+	if n >= 1<<30 {
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	}
+	return fmt.Sprintf("%d MB", n>>20)
+}
@@ -0,0 +1,52 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MergeResults 合并多组查询结果（如分片查询的多个结果文件），每个地址只保留一行
+//
+// 合并规则：
+//   - 同一地址出现多次时，查询成功的行优先于失败、跳过等其他状态
+//   - 状态同为成功（或同为未成功）时，后面的行优先（按 lists 的顺序，越靠后视为越新）
+//   - 结果按地址第一次出现的顺序排列，Duplicate 标记被清除
+func MergeResults(lists ...[]QueryResult) []QueryResult {
+	var merged []QueryResult
+	index := make(map[string]int)
+	for _, results := range lists {
+		for _, result := range results {
+			result.Duplicate = false
+			i, ok := index[result.Address]
+			if !ok {
+				index[result.Address] = len(merged)
+				merged = append(merged, result)
+				continue
+			}
+			if result.Status == StatusSuccess || merged[i].Status != StatusSuccess {
+				merged[i] = result
+			}
+		}
+	}
+	return merged
+}
+
+// MergeResultFiles 读取多个结果文件（见 LoadResultsFromFile）并按 MergeResults 的规则合并
+// 同时返回合并前的总行数
+func MergeResultFiles(paths []string) ([]QueryResult, int, error) {
+	if len(paths) == 0 {
+		return nil, 0, errors.New("没有要合并的结果文件")
+	}
+
+	lists := make([][]QueryResult, 0, len(paths))
+	total := 0
+	for _, path := range paths {
+		results, err := LoadResultsFromFile(path)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s: %v", path, err)
+		}
+		lists = append(lists, results)
+		total += len(results)
+	}
+	return MergeResults(lists...), total, nil
+}
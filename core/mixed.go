@@ -0,0 +1,108 @@
+package core
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"usdt-balance-checker/tron"
+)
+
+// MixedImport 同时包含 API Key 和地址的文件的逐行识别结果，见 ParseMixedFile
+type MixedImport struct {
+	Keys      []string // 识别为 API Key 的行（保留 "key,备注名" 格式），可传给 APIKeyManager.LoadKeysFromLines
+	Addresses []string // 识别为地址的行（按 LoadOptions 去重、校验）
+	Invalid   int      // 既不是 Key 也不是有效地址的行数
+	Report    LoadReport
+}
+
+// Text 返回识别结果的说明文字
+func (m MixedImport) Text() string {
+	text := fmt.Sprintf("识别到 %d 个 API Key、%d 个地址，%d 行无法识别", len(m.Keys), len(m.Addresses), m.Invalid)
+	if report := m.Report.Text(); report != "" {
+		text += "\n" + report
+	}
+	return text
+}
+
+// ParseMixedFile 逐行识别文本文件中的 API Key 和地址（TXT、CSV，不支持 Excel）
+//
+// 每行取第一个逗号前的内容：UUID 格式（TronGrid Key）为 Key，有效的 TRON 地址为地址，其他计为无法识别；
+// 空行和 # 开头的注释行忽略。地址按 opts 去重、保留无效地址和限制数量，见 LoadOptions
+func ParseMixedFile(path string, opts LoadOptions) (MixedImport, error) {
+	var mixed MixedImport
+	if strings.HasSuffix(strings.ToLower(path), ".xlsx") {
+		return mixed, errors.New("自动识别只支持 TXT、CSV 文件")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return mixed, fmt.Errorf("打开文件失败: %v", err)
+	}
+	defer file.Close()
+
+	collector := newAddressCollector(opts)
+	seenKeys := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, sanitized := SanitizeAddress(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if sanitized {
+			collector.report.Sanitized++
+		}
+		first, _, _ := strings.Cut(line, ",")
+		first = strings.Trim(strings.TrimSpace(first), `"`)
+		switch {
+		case isKeyLike(first):
+			if !seenKeys[first] {
+				seenKeys[first] = true
+				mixed.Keys = append(mixed.Keys, line)
+			}
+		case tron.ValidateAddress(first):
+			collector.add(first)
+		default:
+			mixed.Invalid++
+			collector.add(first) // 开启保留无效地址时，像地址的内容仍然保留
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return mixed, fmt.Errorf("读取文件失败: %v", err)
+	}
+
+	mixed.Report = collector.report
+	addresses, err := collector.result()
+	if err != nil {
+		return mixed, err
+	}
+	if collector.valid > 0 {
+		mixed.Addresses = addresses
+	}
+	if len(mixed.Keys) == 0 && len(mixed.Addresses) == 0 {
+		return mixed, errors.New("文件中既没有找到 API Key，也没有找到有效的 TRON 地址")
+	}
+	return mixed, nil
+}
+
+// isKeyLike 判断是否为 UUID 格式（8-4-4-4-12 位十六进制，TronGrid API Key 的格式）
+func isKeyLike(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i, r := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if r != '-' {
+				return false
+			}
+		default:
+			if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f' || r >= 'A' && r <= 'F') {
+				return false
+			}
+		}
+	}
+	return true
+}
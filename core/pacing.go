@@ -0,0 +1,74 @@
+package core
+
+import "time"
+
+// NextQuotaReset 返回下一次每日额度重置的时间（TronGrid 按 UTC 零点重置）
+func NextQuotaReset(now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+}
+
+// keyPace 计算 Key 的可持续速率（次/秒）：剩余额度平均分配到距离重置的时间内
+func keyPace(remaining int, now time.Time) float64 {
+	if remaining <= 0 {
+		return 0
+	}
+	seconds := NextQuotaReset(now).Sub(now).Seconds()
+	if seconds < 1 {
+		seconds = 1
+	}
+	return float64(remaining) / seconds
+}
+
+// SetPacing 设置是否平滑使用额度（默认关闭）
+// 开启后每个 Key 按"剩余额度 / 距离重置的时间"限速，让每日额度均匀分布到全天（适合长时间监控）；
+// 速率随剩余额度和时间动态变化，见 APIKeyStatus.Pace
+func (m *APIKeyManager) SetPacing(enabled bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pacing = enabled
+	if !enabled {
+		m.paceNext = nil
+	}
+}
+
+// PacingEnabled 是否开启平滑使用额度
+func (m *APIKeyManager) PacingEnabled() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.pacing
+}
+
+// reservePace 为 Key 预留下一个请求时段，返回需要等待的时间
+// 未开启平滑使用额度或找不到该 Key 时返回 0
+func (m *APIKeyManager) reservePace(key string) time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.pacing {
+		return 0
+	}
+
+	now := time.Now()
+	for _, keyInfo := range m.keys {
+		if keyInfo.Key != key {
+			continue
+		}
+		// GetNextKey 已计入本次请求，因此剩余额度加回 1
+		pace := keyPace(keyInfo.MaxLimit-keyInfo.Used+1, now)
+		if pace <= 0 {
+			return 0
+		}
+
+		if m.paceNext == nil {
+			m.paceNext = make(map[string]time.Time)
+		}
+		slot := now
+		if next := m.paceNext[key]; next.After(now) {
+			slot = next
+		}
+		m.paceNext[key] = slot.Add(time.Duration(float64(time.Second) / pace))
+		return slot.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"usdt-balance-checker/tron"
+)
+
+// loadChunkCells 加载文件时每批交给一个线程校验的单元格数
+const loadChunkCells = 4096
+
+// loadChunk 一批按读取顺序排列的单元格及其校验结果，校验完成后关闭 done
+type loadChunk struct {
+	cells []AddressCell
+	valid []bool
+	done  chan struct{}
+}
+
+// validateCellsParallel 将 read 读取的单元格分批交给多个线程校验（base58 解码和双 SHA256 是加载大文件的主要耗时），
+// 再按读取顺序逐个交给 merge（去重、计数等依赖顺序的处理仍在一个线程中进行，结果与逐个校验完全相同）。
+// merge 返回 false 时之后的单元格不再校验（valid 都为 false，如地址数已超过上限）。同时在处理中的批数有上限，内存占用不随文件大小增长。progress 非 nil 时每完成约 1% 调用一次，
+// 参数为已读取的比例（见 AddressCell.progress），全部完成时为 1
+func validateCellsParallel(read func(visit func(AddressCell)) error, merge func(cell AddressCell, valid bool) bool, progress func(fraction float64)) error {
+	workers := runtime.GOMAXPROCS(0)
+	var skip atomic.Bool
+	jobs := make(chan *loadChunk, workers)
+	ordered := make(chan *loadChunk, workers*2)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range jobs {
+				for i, cell := range chunk.cells {
+					if skip.Load() {
+						break
+					}
+					value := strings.TrimSpace(cell.Value)
+					chunk.valid[i] = value != "" && tron.ValidateAddress(value)
+				}
+				close(chunk.done)
+			}
+		}()
+	}
+
+	merged := make(chan struct{})
+	go func() {
+		defer close(merged)
+		reported := 0.0
+		for chunk := range ordered {
+			<-chunk.done
+			for i, cell := range chunk.cells {
+				if !merge(cell, chunk.valid[i]) {
+					skip.Store(true)
+				}
+			}
+			if fraction := chunk.cells[len(chunk.cells)-1].progress; progress != nil && fraction-reported >= 0.01 {
+				reported = fraction
+				progress(fraction)
+			}
+		}
+	}()
+
+	chunk := &loadChunk{done: make(chan struct{})}
+	submit := func() {
+		if len(chunk.cells) == 0 {
+			return
+		}
+		chunk.valid = make([]bool, len(chunk.cells))
+		// 先按顺序排队再交给线程：排在前面的批一定已经交给了线程，合并时不会互相等待
+		ordered <- chunk
+		jobs <- chunk
+		chunk = &loadChunk{cells: make([]AddressCell, 0, loadChunkCells), done: make(chan struct{})}
+	}
+	err := read(func(cell AddressCell) {
+		chunk.cells = append(chunk.cells, cell)
+		if len(chunk.cells) >= loadChunkCells {
+			submit()
+		}
+	})
+	submit()
+	close(jobs)
+	close(ordered)
+	<-merged
+	wg.Wait()
+
+	if progress != nil && err == nil {
+		progress(1)
+	}
+	return err
+}
+
+// countingReader 记录已读取的字节数，用于按文件大小计算读取进度
+type countingReader struct {
+	r    io.Reader
+	read int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.read += int64(n)
+	return n, err
+}
+
+// fraction 返回已读取的比例（0~1），size 为文件大小
+func (c *countingReader) fraction(size int64) float64 {
+	if size <= 0 {
+		return 0
+	}
+	return min(1, float64(c.read)/float64(size))
+}
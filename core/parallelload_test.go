@@ -0,0 +1,87 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// loadAddressesSerial 逐个校验地址的加载（改为分批并行校验之前的做法），用于对比结果和基准测试
+func loadAddressesSerial(path string, opts LoadOptions) ([]string, LoadReport, error) {
+	collector := newAddressCollector(opts)
+	err := readAddressCells(path, opts, 0, func(cell AddressCell) {
+		if cell.Sanitized {
+			collector.report.Sanitized++
+		}
+		collector.add(cell.Value)
+	})
+	if err != nil {
+		return nil, collector.report, err
+	}
+	addresses, err := collector.result()
+	return addresses, collector.report, err
+}
+
+// writeLoadFile 生成 n 行的地址文件：大部分为有效地址，每 10 行有 1 个重复地址、1 个无效内容，每 50 行有 1 个 EVM 地址
+func writeLoadFile(tb testing.TB, n int) string {
+	tb.Helper()
+	addrs := testAddresses(n)
+	var b strings.Builder
+	for i, addr := range addrs {
+		switch {
+		case i%50 == 7:
+			fmt.Fprintf(&b, "0x%040x\n", i)
+		case i%10 == 3:
+			b.WriteString(addrs[i/2] + "\n")
+		case i%10 == 5:
+			b.WriteString(addr[:33] + "x\n")
+		default:
+			b.WriteString(addr + "\n")
+		}
+	}
+	path := filepath.Join(tb.TempDir(), "addresses.txt")
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		tb.Fatal(err)
+	}
+	return path
+}
+
+// 分批并行校验与逐个校验的结果（顺序、去重、上限和提示）完全相同
+func TestParallelLoadMatchesSerial(t *testing.T) {
+	path := writeLoadFile(t, 3*loadChunkCells+100)
+	for name, opts := range map[string]LoadOptions{
+		"默认":      {},
+		"保留无效和重复": {KeepInvalid: true, KeepDuplicates: true},
+		"截断":      {MaxAddresses: 5000, TruncateAddresses: true},
+		"超过上限":    {MaxAddresses: 5000},
+	} {
+		want, wantReport, wantErr := loadAddressesSerial(path, opts)
+		got, report, err := LoadAddressesFromFileWithReport(path, opts)
+		if !slices.Equal(got, want) || report != wantReport || fmt.Sprint(err) != fmt.Sprint(wantErr) {
+			t.Errorf("%s: 并行加载 %d 个地址 %+v %v，逐个加载 %d 个地址 %+v %v", name, len(got), report, err, len(want), wantReport, wantErr)
+		}
+	}
+}
+
+// BenchmarkLoadAddresses 比较 50 万行地址文件逐个校验（serial）和分批并行校验（parallel）的加载耗时，
+// 并行的加速比取决于 CPU 核数（GOMAXPROCS），可用 -cpu 1,8 对比
+func BenchmarkLoadAddresses(b *testing.B) {
+	path := writeLoadFile(b, 500000)
+	b.Run("serial", func(b *testing.B) {
+		for b.Loop() {
+			if _, _, err := loadAddressesSerial(path, LoadOptions{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("parallel", func(b *testing.B) {
+		for b.Loop() {
+			if _, _, err := LoadAddressesFromFileWithReport(path, LoadOptions{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ProfilesFileName 配置方案文件名（与统计文件保存在同一目录）
+const ProfilesFileName = "profiles.json"
+
+// Profile 命名的配置方案，切换方案时一次性应用所有设置
+// 零值字段表示不修改对应设置（例如 NodeURL 为空时使用 TronGrid）
+type Profile struct {
+	Name      string `json:"name"`
+	RateLimit int    `json:"rate,omitempty"`     // 每秒请求数
+	Threads   int    `json:"threads,omitempty"`  // 并发线程数
+	NodeURL   string `json:"node_url,omitempty"` // 节点 URL，留空使用 TronGrid
+	Tokens    string `json:"tokens,omitempty"`   // 要查询的代币（见 tron.ParseTokens），留空只查 USDT
+}
+
+// profilesFile 配置方案文件结构
+type profilesFile struct {
+	Profiles []Profile `json:"profiles"`
+}
+
+// GetProfilesFilePath 获取配置方案文件路径
+func GetProfilesFilePath() (string, error) {
+	return appFilePath(ProfilesFileName)
+}
+
+// LoadProfiles 读取所有配置方案，文件不存在时返回空列表
+func LoadProfiles() ([]Profile, error) {
+	path, err := GetProfilesFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取配置方案失败: %v", err)
+	}
+
+	var file profilesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("解析配置方案失败: %v", err)
+	}
+	return file.Profiles, nil
+}
+
+// FindProfile 按名称查找配置方案（不区分大小写）
+func FindProfile(name string) (Profile, error) {
+	profiles, err := LoadProfiles()
+	if err != nil {
+		return Profile{}, err
+	}
+	for _, profile := range profiles {
+		if strings.EqualFold(profile.Name, strings.TrimSpace(name)) {
+			return profile, nil
+		}
+	}
+	return Profile{}, fmt.Errorf("配置方案 %q 不存在", name)
+}
+
+// SaveProfile 保存配置方案，同名（不区分大小写）方案会被覆盖
+func SaveProfile(profile Profile) error {
+	profile.Name = strings.TrimSpace(profile.Name)
+	if profile.Name == "" {
+		return errors.New("配置方案名称不能为空")
+	}
+
+	profiles, err := LoadProfiles()
+	if err != nil {
+		return err
+	}
+
+	replaced := false
+	for i := range profiles {
+		if strings.EqualFold(profiles[i].Name, profile.Name) {
+			profiles[i] = profile
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		profiles = append(profiles, profile)
+	}
+
+	data, err := json.MarshalIndent(profilesFile{Profiles: profiles}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("保存配置方案失败: %v", err)
+	}
+
+	path, err := GetProfilesFilePath()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("保存配置方案失败: %v", err)
+	}
+	return nil
+}
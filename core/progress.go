@@ -0,0 +1,95 @@
+package core
+
+import "fmt"
+
+// Progress 查询进度
+//
+// Completed 包含 Skipped：无效地址和重复地址不发送请求，但同样计入完成，
+// 因此全部结束时 Completed 等于 Total；被取消而未下发的地址不计入完成
+type Progress struct {
+	Total      int // 地址总数（含无效地址、重复地址和继续查询前已完成的地址）
+	Dispatched int // 已下发查询（发送了请求）的地址数
+	Completed  int // 已完成的地址数（含跳过和已取消）
+	Skipped    int // 未发送请求即完成的地址数（无效地址、重复地址、继续查询前已完成的地址）
+}
+
+// Fraction 返回完成比例（0-1），没有地址时为 0
+func (p Progress) Fraction() float64 {
+	if p.Total == 0 {
+		return 0
+	}
+	return float64(p.Completed) / float64(p.Total)
+}
+
+// Text 返回进度文案，如 "完成 700（含跳过 300）/ 1000"，没有跳过时省略括号部分
+func (p Progress) Text() string {
+	if p.Skipped == 0 {
+		return fmt.Sprintf("完成 %d / %d", p.Completed, p.Total)
+	}
+	return fmt.Sprintf("完成 %d（含跳过 %d）/ %d", p.Completed, p.Skipped, p.Total)
+}
+
+// progressMilestones 触发里程碑回调的完成百分比，见 SetMilestoneCallback
+var progressMilestones = []int{25, 50, 75, 100}
+
+// Progress 返回当前查询进度；Resume 进行中时按完整地址列表计算，之前已完成的地址计为跳过
+func (qm *QueryManager) Progress() Progress {
+	qm.mu.RLock()
+	defer qm.mu.RUnlock()
+	return qm.progressLocked()
+}
+
+// progressLocked 返回当前查询进度，调用方需持有锁
+func (qm *QueryManager) progressLocked() Progress {
+	progress := Progress{
+		Total:      len(qm.results),
+		Dispatched: qm.dispatched,
+		Completed:  qm.completed,
+		Skipped:    qm.skipped,
+	}
+	// results 已替换为未完成的地址（Resume 开始查询后）时才累加之前完成的部分
+	if qm.resumeBase != nil && len(qm.results) == len(qm.resumeIndices) {
+		done := len(qm.resumeBase) - len(qm.resumeIndices)
+		progress.Total += done
+		progress.Completed += done
+		progress.Skipped += done
+	}
+	return progress
+}
+
+// SetMilestoneCallback 设置进度里程碑回调：完成比例达到 25%、50%、75%、100% 时各调用一次（每次查询重新计算）
+// 一次更新跨过多个里程碑时按顺序逐个调用；可能在 worker goroutine 中调用
+func (qm *QueryManager) SetMilestoneCallback(callback func(percent int)) {
+	qm.mu.Lock()
+	qm.milestoneCallback = callback
+	qm.mu.Unlock()
+}
+
+// reachMilestonesLocked 返回本次进度更新新跨过的里程碑（已触发过的不再返回），调用方需持有写锁
+func (qm *QueryManager) reachMilestonesLocked() []int {
+	if qm.milestoneCallback == nil {
+		return nil
+	}
+	progress := qm.progressLocked()
+	var reached []int
+	for _, percent := range progressMilestones {
+		if percent > qm.milestone && progress.Total > 0 && progress.Completed*100 >= percent*progress.Total {
+			reached = append(reached, percent)
+			qm.milestone = percent
+		}
+	}
+	return reached
+}
+
+// notifyMilestones 调用里程碑回调（不持有锁时调用）
+func (qm *QueryManager) notifyMilestones(reached []int) {
+	if len(reached) == 0 {
+		return
+	}
+	qm.mu.RLock()
+	callback := qm.milestoneCallback
+	qm.mu.RUnlock()
+	for _, percent := range reached {
+		callback(percent)
+	}
+}
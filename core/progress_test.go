@@ -0,0 +1,127 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestProgressText(t *testing.T) {
+	cases := []struct {
+		progress Progress
+		text     string
+		fraction float64
+	}{
+		{Progress{}, "完成 0 / 0", 0},
+		{Progress{Total: 10, Completed: 4}, "完成 4 / 10", 0.4},
+		{Progress{Total: 1000, Completed: 700, Skipped: 300}, "完成 700（含跳过 300）/ 1000", 0.7},
+	}
+	for _, c := range cases {
+		if got := c.progress.Text(); got != c.text {
+			t.Errorf("%+v.Text() = %q, want %q", c.progress, got, c.text)
+		}
+		if got := c.progress.Fraction(); got != c.fraction {
+			t.Errorf("%+v.Fraction() = %v, want %v", c.progress, got, c.fraction)
+		}
+	}
+}
+
+// checkProgress 检查查询过程中的进度：完成数不超过总数、不减少，跳过数包含在完成数中，结束前不到 100%
+func checkProgress(t *testing.T, name string, seen []Progress, total, skipped int) {
+	t.Helper()
+	if len(seen) == 0 {
+		t.Fatalf("%s: 没有进度回调", name)
+	}
+	for i, p := range seen {
+		if p.Total != total || p.Skipped < skipped || p.Completed < p.Skipped || p.Completed > p.Total {
+			t.Fatalf("%s: 第 %d 次进度 %+v (总数 %d, 至少跳过 %d)", name, i, p, total, skipped)
+		}
+		if i > 0 && p.Completed < seen[i-1].Completed {
+			t.Fatalf("%s: 进度倒退 %+v -> %+v", name, seen[i-1], p)
+		}
+		if i < len(seen)-1 && p.Completed == p.Total {
+			t.Fatalf("%s: 第 %d 次（共 %d 次）进度已到 100%%: %+v", name, i, len(seen), p)
+		}
+	}
+	if last := seen[len(seen)-1]; last.Completed != total {
+		t.Fatalf("%s: 最后的进度 %+v，未到 100%%", name, last)
+	}
+}
+
+// 30% 的输入不发送请求（无效地址和重复地址）：跳过的地址计入完成，进度不提前到 100%，结束时正好 100%
+func TestProgressWithSkippedInput(t *testing.T) {
+	srv := newTestNode(t, nil)
+	valid := testAddresses(7)
+	input := append(slices.Clone(valid), "invalid-1", "T"+strings.Repeat("1", 33), valid[0]) // 2 个无效、1 个重复
+
+	qm := newTestManager(newTestKeyManager(t, 1), srv)
+	qm.SetMaxConcurrent(2)
+	var mu sync.Mutex
+	var seen []Progress
+	var milestones []int
+	qm.SetMilestoneCallback(func(percent int) {
+		mu.Lock()
+		milestones = append(milestones, percent)
+		mu.Unlock()
+	})
+	if err := qm.QueryAddresses(input, func(current, total int) {
+		mu.Lock()
+		seen = append(seen, qm.Progress())
+		mu.Unlock()
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	checkProgress(t, "查询", seen, 10, 2)
+	want := Progress{Total: 10, Dispatched: 7, Completed: 10, Skipped: 3}
+	if got := qm.Progress(); got != want {
+		t.Fatalf("结束后 Progress = %+v, want %+v", got, want)
+	}
+	if got, text := qm.Progress().Text(), "完成 10（含跳过 3）/ 10"; got != text {
+		t.Fatalf("Text = %q, want %q", got, text)
+	}
+	if !slices.Equal(milestones, []int{25, 50, 75, 100}) {
+		t.Fatalf("里程碑 = %v, want [25 50 75 100]", milestones)
+	}
+}
+
+// 继续查询时之前已完成的 30% 计为跳过，进度按完整地址列表计算
+func TestProgressResumeSkipped(t *testing.T) {
+	srv := newTestNode(t, nil)
+	addrs := testAddresses(10)
+	var rows []string
+	for i, addr := range addrs {
+		status := "pending"
+		if i%3 == 0 && i < 9 {
+			status = "success"
+		}
+		rows = append(rows, fmt.Sprintf(`{"address":%q,"balance":"1","status":%q}`, addr, status))
+	}
+	state := fmt.Sprintf(`{"version":1,"options":{"base_url":%q,"max_concurrent":2,"rate_limit":1000},"results":[%s]}`, srv.URL, strings.Join(rows, ","))
+
+	qm := newTestManager(newTestKeyManager(t, 1), srv)
+	if err := qm.LoadState(strings.NewReader(state)); err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	var seen []Progress
+	var currents []int
+	if err := qm.Resume(func(current, total int) {
+		mu.Lock()
+		seen = append(seen, qm.Progress())
+		currents = append(currents, current)
+		mu.Unlock()
+		if total != 10 {
+			t.Errorf("进度回调 total = %d, want 10", total)
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	checkProgress(t, "继续查询", seen, 10, 3)
+	if currents[0] < 3 || currents[len(currents)-1] != 10 {
+		t.Fatalf("进度回调 current = %v，应从 3 之后开始、到 10 结束", currents)
+	}
+}
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"usdt-balance-checker/tron"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// QRCodeSize 导出的地址二维码图片边长（像素）
+const QRCodeSize = 256
+
+// AddressQRCode 生成地址二维码的 PNG 图片，内容为地址本身（钱包扫码即可识别），size 为图片边长（像素）
+func AddressQRCode(address string, size int) ([]byte, error) {
+	if !tron.ValidateAddress(address) {
+		return nil, fmt.Errorf("无效的 TRON 地址: %s", address)
+	}
+	png, err := qrcode.Encode(address, qrcode.Medium, size)
+	if err != nil {
+		return nil, fmt.Errorf("生成二维码失败: %v", err)
+	}
+	return png, nil
+}
+
+// ExportQRCodes 为结果中的每个有效地址在目录 dir 中生成二维码图片（文件名为 "地址.png"），返回生成的图片数
+// 重复地址只生成一次，无效地址跳过；目录不存在时自动创建
+func ExportQRCodes(results []QueryResult, dir string) (int, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, fmt.Errorf("创建目录失败: %v", err)
+	}
+
+	written := 0
+	seen := make(map[string]bool)
+	for _, result := range results {
+		if seen[result.Address] || !tron.ValidateAddress(result.Address) {
+			continue
+		}
+		seen[result.Address] = true
+
+		png, err := AddressQRCode(result.Address, QRCodeSize)
+		if err != nil {
+			return written, err
+		}
+		if err := os.WriteFile(filepath.Join(dir, result.Address+".png"), png, 0644); err != nil {
+			return written, fmt.Errorf("写入二维码图片失败: %v", err)
+		}
+		written++
+	}
+	return written, nil
+}
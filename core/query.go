@@ -2,17 +2,65 @@ package core
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
 	"sync"
+	"time"
 
 	"usdt-balance-checker/tron"
 )
 
 // QueryResult 查询结果
 type QueryResult struct {
-	Address string
-	Balance string
-	Status  string // "success", "error"
-	Error   string
+	Address     string
+	Balance     string
+	Status      ResultStatus // 结果状态，见 StatusPending 等常量
+	Error       string
+	ErrorKind   tron.ErrorKind // 失败时的错误类别（Status 为 StatusError 时有效），见 FailureBreakdown
+	AddressType string         // 地址类型："contract", "wallet"；未检查时为空
+	Duplicate   bool           // 是否为重复地址（与前面某一行相同，复用其查询结果）
+	QueriedAt   time.Time      // 查询完成时间；未发送请求时为零值
+	APIKey      string         // 查询使用的 API Key（重试换 Key 时为最后一次请求使用的 Key）
+	RawHex      string         // 节点返回的原始 constant_result[0]（未经处理），Balance 可由 tron.FormatBalanceHex(RawHex) 重新得到
+
+	// TokenBalances 额外代币的余额（代币符号 -> 余额），只查询一种代币时为 nil
+	// Balance 始终是第一个代币（默认 USDT）的余额，见 QueryManager.SetTokens
+	TokenBalances map[string]string
+
+	Bookmarked bool // 是否已加入书签（仅界面使用，查询不会设置，也不导出），见 Bookmarks
+
+	// Flagged 地址是否在关注列表中，WatchTag 为其标签；查询不会设置，由 Watchlist.Apply 标记，导出时增加"关注"列
+	Flagged  bool
+	WatchTag string
+
+	Tag string // 用户为地址设置的标签；查询不会设置，由 Tags.Apply 按地址设置，导出时增加"标签"列
+}
+
+// ContractFilterMode 合约地址检查模式
+type ContractFilterMode int
+
+const (
+	// ContractFilterOff 不检查地址类型（默认）
+	ContractFilterOff ContractFilterMode = iota
+	// ContractFilterFlag 检查并在结果中标记合约地址
+	ContractFilterFlag
+	// ContractFilterExclude 检查并跳过合约地址，不查询其余额
+	ContractFilterExclude
+)
+
+// ParseContractFilterMode 解析合约检查模式字符串（"", "flag", "exclude"）
+func ParseContractFilterMode(mode string) (ContractFilterMode, error) {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", "off":
+		return ContractFilterOff, nil
+	case "flag":
+		return ContractFilterFlag, nil
+	case "exclude":
+		return ContractFilterExclude, nil
+	}
+	return ContractFilterOff, errors.New("无效的合约检查模式（可选: off, flag, exclude）")
 }
 
 // QueryManager 查询管理器
@@ -20,24 +68,236 @@ type QueryManager struct {
 	keyManager    *APIKeyManager
 	baseURL       string
 	results       []QueryResult
+	state         QueryState   // 运行状态，见 State
+	stopReason    FinishReason // 取消的原因（第一次取消时记录），见 CancelWithReason
+	finishReason  FinishReason // 最近一次查询结束的原因，见 FinishReason
 	mu            sync.RWMutex
 	cancel        context.CancelFunc
 	ctx           context.Context
-	maxConcurrent int // 最大并发数
+	maxConcurrent int                // 最大并发数
+	contractMode  ContractFilterMode // 合约地址检查模式
+	shuffle       bool               // 是否打乱查询顺序
+	summary       RunSummary         // 本次查询的汇总信息
+
+	// 运行状态（用于心跳提示和进度，见 Progress）
+	inFlight       int                    // 正在请求中的地址数（含重试退避等待）
+	retrying       map[tron.ErrorKind]int // 正在重试的请求数（按触发重试的错误类别），见 Activity.Retrying
+	dispatched     int                    // 已下发查询的地址数
+	completed      int                    // 已完成的地址数（含跳过）
+	skipped        int                    // 未发送请求即完成的地址数（无效地址、重复地址）
+	lastCompletion time.Time              // 最近一次完成的时间
+
+	resultCallback func(index int, result QueryResult) // 单个地址完成时的回调（可选）
+	sink           *filteredSink                       // 条件写入（可选），见 SetFilteredSink
+
+	retryBudgetRatio float64                   // 重试预算比例（相对地址数）
+	retryPolicy      RetryPolicy               // 按错误类别的重试次数和退避，见 SetRetryPolicy
+	retryBudget      retryBudget               // 本次查询的重试预算
+	warningCallback  func(message string)      // 运行警告回调（可选）
+	tokensCallback   func(tokens []tron.Token) // 代币符号查询完成的回调（可选），见 SetTokensCallback
+
+	milestoneCallback func(percent int) // 进度里程碑回调（可选），见 SetMilestoneCallback
+	milestone         int               // 本次查询已触发的最高里程碑（百分比）
+
+	requestSigner tron.RequestSigner // 私有节点的请求认证拦截器（可选）
+
+	limiter *tron.RateLimiter // 所有请求共享的限流器，可在查询中实时调整，见 SetRateLimit
+	slotID  int               // 在 Key 管理器的请求调度中的消费者编号，见 APIKeyManager.SetKeyRateLimit
+
+	tokens []tron.Token // 要查询的代币（第一个写入 Balance，其余写入 TokenBalances），默认只有 USDT
+
+	ownerAddress string        // 余额查询固定使用的 owner_address，为空时使用被查询的地址，见 SetOwnerAddress
+	keepAlive    time.Duration // 连接保活间隔，0 为关闭，见 SetKeepAlive
+
+	rpcBatchSize     int  // JSON-RPC 批量调用每批的地址数，0 表示逐个查询，见 SetJSONRPCBatch
+	rpcBatchDisabled bool // 本次查询中节点不支持批量调用（非临时错误），其余地址改为逐个查询
+
+	keysExhausted bool // 本次查询因 Key 额度用完而自动暂停，见 KeysExhausted
+
+	autoRetry        int // 主查询结束后自动重试失败地址的最多轮数，见 SetAutoRetry
+	autoRetryPass    int // 正在进行的自动重试轮次，0 表示不在自动重试中
+	autoRetryPending int // 本轮自动重试的地址数
+
+	memoryLimit    uint64 // 内存守护的上限（字节），0 为关闭，见 SetMemoryGuard
+	lowMemory      bool   // 本次查询已切换到低内存模式，见 LowMemory
+	memoryExceeded bool   // 本次查询因内存占用达到上限而自动暂停，见 MemoryExceeded
+
+	autoThreads autoThreadsState // 自动线程数的调整状态，见 SetAutoThreads
+
+	keyBaseline map[string]int // 本次查询开始时每个 Key 的累计使用次数，见 KeyUsage
+
+	inputHash string // 汇总中记录的输入指纹，为空时按本次查询的地址计算，见 SetInputHash
+
+	maxErrorLength int               // 结果中错误信息的最大长度，<0 不截断，见 SetMaxErrorLength
+	errorTexts     map[string]string // 截断后的错误信息去重表（跨查询保留）
+	errorLog       *errorLog         // 错误日志（可选），见 SetErrorLog
+
+	stats    ResultStats   // results 的统计，每次更新结果时增量维护，见 GetStats
+	failures failureCounts // results 中按错误类别的失败数量，与 stats 一样增量维护，见 GetSummary
+
+	// 继续查询（见 LoadState、Resume）
+	resumedRetries int           // 之前保存的状态中累计消耗的重试次数
+	resumeBase     []QueryResult // Resume 进行中时的完整结果，results 只包含未完成的地址
+	resumeIndices  []int         // Resume 进行中时 results 中每个地址在完整结果中的索引
+}
+
+// defaultRateLimit 默认每秒请求数
+const defaultRateLimit = 12
+
+// QueryOptions 查询管理器选项（零值即默认配置）
+type QueryOptions struct {
+	BaseURL        string             // 自定义节点 URL，留空使用 TronGrid
+	MaxConcurrent  int                // 最大并发数（1-50），<1 时按 1 处理
+	ContractFilter ContractFilterMode // 合约地址检查模式
+	Shuffle        bool               // 是否打乱查询顺序
+	RetryBudget    float64            // 重试预算比例（相对地址数），0 使用默认 20%，<0 不限制
+	RequestSigner  tron.RequestSigner // 请求认证拦截器（私有节点自定义认证），默认不设
+	Tokens         []tron.Token       // 要查询的代币，默认只有 USDT
+	JSONRPCBatch   int                // JSON-RPC 批量调用每批的地址数，0 逐个查询
+	OwnerAddress   string             // 余额查询固定使用的 owner_address，留空使用被查询的地址（无效地址按留空处理）
+	KeepAlive      time.Duration      // 连接保活间隔，0 为关闭
+	RetryPolicy    *RetryPolicy       // 按错误类别的重试次数和退避，nil 使用 DefaultRetryPolicy
+	AutoRetry      int                // 主查询结束后自动重试失败地址的最多轮数，0 关闭
+	MemoryLimit    uint64             // 内存守护的上限（字节），0 关闭
+	AutoThreads    bool               // 自动调整线程数（MaxConcurrent 为上限）
+	MaxErrorLength int                // 结果中错误信息的最大长度，0 使用默认 300，<0 不截断
 }
 
 // NewQueryManager 创建查询管理器（支持多 Key）
 func NewQueryManager(keyManager *APIKeyManager, baseURL string) *QueryManager {
+	return NewQueryManagerWithOptions(keyManager, QueryOptions{BaseURL: baseURL})
+}
+
+// NewQueryManagerWithOptions 按选项创建查询管理器
+func NewQueryManagerWithOptions(keyManager *APIKeyManager, opts QueryOptions) *QueryManager {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &QueryManager{
+	qm := &QueryManager{
 		keyManager:    keyManager,
-		baseURL:       baseURL,
+		baseURL:       opts.BaseURL,
 		results:       make([]QueryResult, 0),
+		failures:      make(failureCounts),
+		state:         StateIdle,
 		ctx:           ctx,
 		cancel:        cancel,
 		maxConcurrent: 1, // 默认1个线程
+		contractMode:  opts.ContractFilter,
+		shuffle:       opts.Shuffle,
+		requestSigner: opts.RequestSigner,
+		limiter:       tron.NewRateLimiter(defaultRateLimit, time.Second),
+		retryPolicy:   DefaultRetryPolicy,
+		slotID:        keyManager.scheduler.register(),
+	}
+	if opts.RetryPolicy != nil {
+		qm.retryPolicy = *opts.RetryPolicy
+	}
+	qm.SetMaxConcurrent(opts.MaxConcurrent)
+	qm.SetRetryBudget(opts.RetryBudget)
+	qm.SetTokens(opts.Tokens)
+	qm.SetJSONRPCBatch(opts.JSONRPCBatch)
+	_ = qm.SetOwnerAddress(opts.OwnerAddress)
+	qm.SetKeepAlive(opts.KeepAlive)
+	qm.SetAutoRetry(opts.AutoRetry)
+	qm.SetMemoryGuard(opts.MemoryLimit)
+	qm.SetAutoThreads(opts.AutoThreads)
+	qm.SetMaxErrorLength(opts.MaxErrorLength)
+	return qm
+}
+
+// SetInputHash 设置汇总中记录的输入指纹（见 InputHash），为空时按每次查询的地址计算
+// 只查询输入文件的一部分时（如跳过前 N 个地址），按完整的输入文件计算后设置，结果文件才能用输入文件校验
+func (qm *QueryManager) SetInputHash(hash string) {
+	qm.mu.Lock()
+	qm.inputHash = hash
+	qm.mu.Unlock()
+}
+
+// SetTokens 设置要查询的代币（每个地址每种代币一次请求），为空时只查询 USDT
+// 第一个代币的余额写入 QueryResult.Balance，其余写入 QueryResult.TokenBalances
+func (qm *QueryManager) SetTokens(tokens []tron.Token) {
+	if len(tokens) == 0 {
+		tokens = []tron.Token{tron.USDTToken}
+	}
+	qm.mu.Lock()
+	qm.tokens = append([]tron.Token(nil), tokens...)
+	qm.mu.Unlock()
+}
+
+// resolveTokens 为只写了合约地址的代币查询符号和名称（见 tron.ResolveTokenSymbols），更新要查询的代币并返回
+// 只在 QueryAddresses 占用查询状态后调用，查到后通知 SetTokensCallback 设置的回调；符号都已知时不发送请求
+func (qm *QueryManager) resolveTokens() []tron.Token {
+	tokens := qm.Tokens()
+	if !tron.NeedsSymbolLookup(tokens) {
+		return tokens
+	}
+	apiKey, _ := qm.keyManager.GetNextKey() // 没有可用的 Key 时不带 Key 查询
+	ctx, cancel := context.WithTimeout(qm.ctx, 15*time.Second)
+	defer cancel()
+	tokens = tron.ResolveTokenSymbols(ctx, qm.newClient(apiKey), tokens)
+	qm.SetTokens(tokens)
+
+	qm.mu.RLock()
+	callback := qm.tokensCallback
+	qm.mu.RUnlock()
+	if callback != nil {
+		callback(tokens)
+	}
+	return tokens
+}
+
+// SetTokensCallback 设置代币符号查询完成的回调：只写了合约地址的代币在查询开始时查询符号，
+// 表头和日志据此显示查到的符号；在调用 QueryAddresses 的 goroutine 中调用
+func (qm *QueryManager) SetTokensCallback(callback func(tokens []tron.Token)) {
+	qm.mu.Lock()
+	qm.tokensCallback = callback
+	qm.mu.Unlock()
+}
+
+// tokenSummary 返回汇总中的代币说明，如 "USDC（USD Coin） TEkxiTehnzSmSe2XqrBj4w32RUN966rdz8"
+func tokenSummary(tokens []tron.Token) []string {
+	summary := make([]string, len(tokens))
+	for i, token := range tokens {
+		summary[i] = token.Label() + " " + token.Contract
+	}
+	return summary
+}
+
+// Tokens 返回要查询的代币
+func (qm *QueryManager) Tokens() []tron.Token {
+	qm.mu.RLock()
+	defer qm.mu.RUnlock()
+	return append([]tron.Token(nil), qm.tokens...)
+}
+
+// SetJSONRPCBatch 设置 JSON-RPC 批量调用每批的地址数（最多 tron.MaxJSONRPCBatch），<=1 时逐个查询
+// 开启后一批地址只发送一次请求（每种代币一次），节点需支持 /jsonrpc 批量调用；
+// 批量请求失败或单个地址失败时，这些地址改为逐个查询。开启合约地址检查时不使用批量调用
+func (qm *QueryManager) SetJSONRPCBatch(size int) {
+	if size <= 1 {
+		size = 0
+	}
+	if size > tron.MaxJSONRPCBatch {
+		size = tron.MaxJSONRPCBatch
+	}
+	qm.mu.Lock()
+	qm.rpcBatchSize = size
+	qm.mu.Unlock()
+}
+
+// SetOwnerAddress 设置余额查询固定使用的 owner_address（空字符串恢复为使用被查询的地址）
+// 部分节点版本在被查询地址从未上链时查询失败，固定使用一个已存在的地址（如 tron.USDTContractAddress
+// 或 tron.BurnAddress）可以避免；不同节点版本行为不同，因此默认不开启。地址无效时返回错误，设置不变
+func (qm *QueryManager) SetOwnerAddress(address string) error {
+	address = strings.TrimSpace(address)
+	if address != "" {
+		if err := tron.ValidateAddressWithError(address); err != nil {
+			return fmt.Errorf("owner_address 无效: %v", err)
+		}
 	}
+	qm.mu.Lock()
+	qm.ownerAddress = address
+	qm.mu.Unlock()
+	return nil
 }
 
 // SetMaxConcurrent 设置最大并发数
@@ -53,26 +313,167 @@ func (qm *QueryManager) SetMaxConcurrent(max int) {
 	qm.mu.Unlock()
 }
 
-// SetRateLimit 设置限流（每秒请求数）- 现在由每个客户端独立管理
+// SetContractFilter 设置合约地址检查模式
+// 开启后每个地址会额外发送一次 /wallet/getcontract 请求
+func (qm *QueryManager) SetContractFilter(mode ContractFilterMode) {
+	qm.mu.Lock()
+	qm.contractMode = mode
+	qm.mu.Unlock()
+}
+
+// SetRequestSigner 设置请求认证拦截器，所有请求（含块信息、合约检查）发送前都会调用
+func (qm *QueryManager) SetRequestSigner(signer tron.RequestSigner) {
+	qm.mu.Lock()
+	qm.requestSigner = signer
+	qm.mu.Unlock()
+}
+
+// SetShuffle 设置是否打乱查询顺序（结果仍按输入顺序返回）
+func (qm *QueryManager) SetShuffle(shuffle bool) {
+	qm.mu.Lock()
+	qm.shuffle = shuffle
+	qm.mu.Unlock()
+}
+
+// SetResultCallback 设置单个地址完成时的回调，index 为地址在输入中的位置
+// 回调可能在多个 worker goroutine 中并发调用，调用方需自行加锁；传 nil 取消回调
+func (qm *QueryManager) SetResultCallback(callback func(index int, result QueryResult)) {
+	qm.mu.Lock()
+	qm.resultCallback = callback
+	qm.mu.Unlock()
+}
+
+// ShuffleAddresses 返回打乱顺序后的地址副本（原切片不变）
+func ShuffleAddresses(addrs []string) []string {
+	shuffled := make([]string, len(addrs))
+	for i, j := range rand.Perm(len(addrs)) {
+		shuffled[i] = addrs[j]
+	}
+	return shuffled
+}
+
+// SetRateLimit 设置所有请求共享的限流（每秒请求数，<1 时按 1 处理）
+// 可以在查询过程中调用，立即对后续请求生效（如遇到 429 时手动调低）
 func (qm *QueryManager) SetRateLimit(rate int) {
-	// 限流由每个 APIClient 独立管理，这里保留接口兼容性
+	qm.limiter.SetRate(rate)
+}
+
+// RateLimit 返回当前的限流（每秒请求数）
+func (qm *QueryManager) RateLimit() int {
+	return qm.limiter.Rate()
 }
 
-// QueryAddresses 批量查询地址余额（支持多线程并发）
-func (qm *QueryManager) QueryAddresses(addresses []string, progressCallback func(current, total int)) {
+// RateLimiterStats 返回共享限流器的运行统计（当前令牌数、等待次数和时长、实际速率）
+func (qm *QueryManager) RateLimiterStats() tron.RateLimiterStats {
+	return qm.limiter.Stats()
+}
+
+// QueryAddresses 批量查询地址余额（支持多线程并发），阻塞直到全部完成或被取消
+//
+// 结果与 addresses 一一对应（GetResults()[i] 对应 addresses[i]），开始时全部初始化为 StatusPending。
+// progressCallback 在每个地址完成（含失败、取消）后调用一次，current 为已完成数量，total 为地址总数；
+// 回调可能在多个 worker goroutine 中并发调用，调用方需自行加锁。
+// 被取消时，尚未下发的地址保持 StatusPending，已下发但未执行的地址为 StatusCancelled。
+// addresses 中的重复地址只查询一次，结果复制到每个重复行并标记 Duplicate；
+// 无效地址（见 LoadOptions.KeepInvalid）不发送请求，直接标记为 StatusInvalid。
+// 同一个 QueryManager 同时只能有一个查询：查询中再次调用返回 ErrAlreadyRunning，取消后调用返回 ErrQueryCancelled（见 State）。
+func (qm *QueryManager) QueryAddresses(addresses []string, progressCallback func(current, total int)) error {
+	keyBaseline := qm.keyManager.snapshotUsage() // 查询代币符号也计入本次使用
+
+	qm.mu.Lock()
+	if err := qm.beginLocked(); err != nil {
+		qm.mu.Unlock()
+		return err
+	}
+	qm.keyBaseline = keyBaseline
+	qm.mu.Unlock()
+	defer qm.finish()
+
+	// 先占用查询状态再查询代币符号（网络请求）：同时开始的另一个查询直接返回 ErrAlreadyRunning，
+	// 不会重复查询符号或改写要查询的代币；查询符号时被取消会提前结束
+	tokens := qm.resolveTokens()
+
 	qm.mu.Lock()
 	qm.results = make([]QueryResult, len(addresses))
 	// 初始化所有结果为待查询状态，确保地址能正确显示
+	// 同时记录重复地址：duplicates[首次出现的索引] = 后续重复行的索引
+	firstIndex := make(map[string]int, len(addresses))
+	duplicates := make(map[int][]int)
+	var invalidIndices []int
+	invalidErrors := make(map[int]string)
 	for i, addr := range addresses {
+		if err := tron.ValidateAddressWithError(addr); err != nil {
+			qm.results[i] = QueryResult{
+				Address: addr,
+				Status:  StatusInvalid,
+				Error:   "无效地址: " + err.Error(),
+			}
+			invalidIndices = append(invalidIndices, i)
+			invalidErrors[i] = qm.results[i].Error
+			continue
+		}
 		qm.results[i] = QueryResult{
 			Address: addr,
-			Status:  "pending",
+			Status:  StatusPending,
 			Balance: "",
 			Error:   "",
 		}
+		if first, ok := firstIndex[addr]; ok {
+			duplicates[first] = append(duplicates[first], i)
+			qm.results[i].Duplicate = true
+		} else {
+			firstIndex[addr] = i
+		}
 	}
+	qm.stats = ResultStats{Total: len(qm.results)} // 初始化的结果都是待查询或无效地址
+	qm.failures = make(failureCounts)
 	maxConcurrent := qm.maxConcurrent
+	contractMode := qm.contractMode
+	shuffle := qm.shuffle
+	resultCallback := qm.resultCallback
+	batchSize := qm.rpcBatchSize
+	qm.rpcBatchDisabled = false
+	qm.keysExhausted = false
+	qm.lowMemory, qm.memoryExceeded = false, false
+	qm.resetAutoThreadsLocked()
+	if batchSize < 1 || contractMode != ContractFilterOff {
+		batchSize = 1
+	}
+	inputHash := qm.inputHash
+	if inputHash == "" {
+		inputHash = InputHash(addresses)
+	}
+	qm.summary = RunSummary{StartTime: time.Now(), InputHash: inputHash, Tokens: tokenSummary(tokens)}
+	qm.retryBudget = newRetryBudget(len(firstIndex), qm.retryBudgetRatio)
+	qm.inFlight = 0
+	qm.retrying = nil
+	qm.dispatched = 0
+	qm.completed = len(invalidIndices)
+	qm.skipped = len(invalidIndices)
+	qm.lastCompletion = qm.summary.StartTime
+	qm.milestone = 0
+	reached := qm.reachMilestonesLocked()
 	qm.mu.Unlock()
+	qm.notifyMilestones(reached)
+
+	// 无效地址不会查询，直接通知结果回调
+	if resultCallback != nil {
+		for _, i := range invalidIndices {
+			resultCallback(i, QueryResult{Address: addresses[i], Status: StatusInvalid, Error: invalidErrors[i]})
+		}
+	}
+
+	defer func() {
+		keyUsage := qm.KeyUsage()
+		qm.mu.Lock()
+		qm.summary.EndTime = time.Now()
+		qm.summary.KeyUsage = keyUsage
+		if qm.autoThreads.enabled {
+			qm.summary.AutoThreads = qm.autoThreads.threads
+			qm.summary.AutoThreadsConverged = qm.autoThreads.stable >= autoThreadsStableWindows
+		}
+		qm.mu.Unlock()
+	}()
 
 	// 检查是否有 KEY
 	keyCount := qm.keyManager.GetKeyCount()
@@ -80,130 +481,607 @@ func (qm *QueryManager) QueryAddresses(addresses []string, progressCallback func
 		// 没有 KEY，无法查询
 		for i := range addresses {
 			qm.mu.Lock()
-			qm.results[i] = QueryResult{
-				Address: addresses[i],
-				Status:  "error",
-				Error:   "没有可用的 API Key",
+			if qm.results[i].Status == StatusInvalid {
+				qm.mu.Unlock()
+				continue
 			}
+			qm.setResultLocked(i, QueryResult{
+				Address:   addresses[i],
+				Status:    StatusError,
+				Error:     "没有可用的 API Key",
+				Duplicate: qm.results[i].Duplicate,
+			})
+			result := qm.results[i]
 			qm.mu.Unlock()
+			if resultCallback != nil {
+				resultCallback(i, result)
+			}
 		}
+		qm.mu.Lock()
+		qm.summary.BlockTime = qm.summary.StartTime
+		qm.summary.BlockFallback = true
+		qm.completed = len(addresses)
+		reached := qm.reachMilestonesLocked()
+		qm.mu.Unlock()
+		qm.notifyMilestones(reached)
 		if progressCallback != nil {
 			progressCallback(len(addresses), len(addresses))
 		}
-		return
+		return nil
 	}
 
-	// 使用 worker pool 模式实现多线程查询
-	// 使用无缓冲 channel，这样可以在取消时立即停止发送新任务
-	jobs := make(chan int)
-	var wg sync.WaitGroup
+	// 记录数据基准（当前块高和时间），便于不同批次结果对比
+	qm.fetchBlockContext()
+
+	// 可选：空闲时保持连接
+	stopKeepAlive := qm.startKeepAlive()
+	defer stopKeepAlive()
+
+	// 可选：内存占用接近上限时降级或暂停
+	stopMemoryGuard := qm.startMemoryGuard()
+	defer stopMemoryGuard()
+
+	// 可选：按吞吐量和 429 自动调整线程数
+	stopAutoThreads := qm.startAutoThreads()
+	defer stopAutoThreads()
+
 	var progressMu sync.Mutex
-	completedCount := 0
+	completedCount := len(invalidIndices)
+	if completedCount > 0 && progressCallback != nil {
+		progressCallback(completedCount, len(addresses))
+	}
 
-	// 启动 worker goroutines
-	for w := 0; w < maxConcurrent; w++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for i := range jobs {
-				// 检查是否取消
-				select {
-				case <-qm.ctx.Done():
-					qm.mu.Lock()
-					qm.results[i] = QueryResult{
-						Address: addresses[i],
-						Status:  "cancelled",
-						Error:   "已取消",
+	// runPass 使用 worker pool 模式多线程查询 order 中的地址（结果写入原索引位置，重复行复用同一结果）
+	// 使用无缓冲 channel，这样可以在取消时立即停止发送新任务
+	// 每个任务是一组地址索引，未开启 JSON-RPC 批量调用时每组只有一个地址
+	// retry 为 true 时是自动重试（见 SetAutoRetry）：不计入完成数和进度，取消时保留原来的结果
+	// 自动调整线程数时按上限启动 worker，编号不小于当前线程数的 worker 等待（见 waitThreadSlot）
+	runPass := func(order []int, retry bool) {
+		jobs := make(chan []int)
+		var wg sync.WaitGroup
+
+		// 启动 worker goroutines
+		for w := 0; w < maxConcurrent; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					qm.waitThreadSlot(w)
+					chunk, ok := <-jobs
+					if !ok {
+						return
 					}
-					qm.mu.Unlock()
-					// 更新进度
-					progressMu.Lock()
-					completedCount++
-					current := completedCount
-					progressMu.Unlock()
-					if progressCallback != nil {
-						progressCallback(current, len(addresses))
+					chunkAddrs := make([]string, len(chunk))
+					for k, i := range chunk {
+						chunkAddrs[k] = addresses[i]
 					}
-					continue
-				default:
-				}
+					var chunkResults []QueryResult
+
+					// 检查是否取消
+					select {
+					case <-qm.ctx.Done():
+						if retry {
+							continue // 自动重试中取消时保留上一轮的结果
+						}
+						chunkResults = make([]QueryResult, len(chunk))
+						for k, address := range chunkAddrs {
+							chunkResults[k] = QueryResult{
+								Address: address,
+								Status:  StatusCancelled,
+								Error:   "已取消",
+							}
+						}
+					default:
+						qm.mu.Lock()
+						qm.inFlight += len(chunk)
+						if !retry {
+							qm.dispatched += len(chunk)
+						}
+						qm.mu.Unlock()
+
+						if len(chunk) == 1 {
+							result := qm.queryOne(chunkAddrs[0], contractMode)
+							result.QueriedAt = time.Now()
+							chunkResults = []QueryResult{result}
+						} else {
+							chunkResults = qm.queryBatch(chunkAddrs)
+						}
 
-				// 获取下一个可用的 API Key（轮询使用）
-				apiKey, err := qm.keyManager.GetNextKey()
-				if err != nil {
-					qm.mu.Lock()
-					qm.results[i] = QueryResult{
-						Address: addresses[i],
-						Status:  "error",
-						Error:   "API Key 获取失败: " + err.Error(),
+						qm.mu.Lock()
+						qm.inFlight -= len(chunk)
+						qm.mu.Unlock()
 					}
-					qm.mu.Unlock()
-					// 更新进度
-					progressMu.Lock()
-					completedCount++
-					current := completedCount
-					progressMu.Unlock()
-					if progressCallback != nil {
-						progressCallback(current, len(addresses))
+
+					for k, i := range chunk {
+						result := chunkResults[k]
+						if retry && result.Status == StatusCancelled {
+							continue
+						}
+
+						// 更新结果（重复行复用同一结果），完整的错误信息只写入错误日志
+						qm.writeErrorLog(result)
+						qm.mu.Lock()
+						result.Error = qm.compactErrorLocked(result.Error)
+						if qm.lowMemory {
+							result.RawHex, result.APIKey = "", ""
+						}
+						dupResult := result
+						dupResult.Duplicate = true
+						qm.setResultLocked(i, result)
+						for _, j := range duplicates[i] {
+							qm.setResultLocked(j, dupResult)
+						}
+						qm.lastCompletion = time.Now()
+						var reached []int
+						if !retry {
+							qm.completed += 1 + len(duplicates[i])
+							qm.skipped += len(duplicates[i])
+							reached = qm.reachMilestonesLocked()
+						}
+						qm.mu.Unlock()
+
+						qm.writeSink(result)
+						if resultCallback != nil {
+							resultCallback(i, result)
+							for _, j := range duplicates[i] {
+								resultCallback(j, dupResult)
+							}
+						}
+
+						if retry {
+							continue
+						}
+
+						// 更新进度
+						progressMu.Lock()
+						completedCount += 1 + len(duplicates[i])
+						current := completedCount
+						progressMu.Unlock()
+						if progressCallback != nil {
+							progressCallback(current, len(addresses))
+						}
+						qm.notifyMilestones(reached)
 					}
-					continue
 				}
+			}()
+		}
 
-				// 创建客户端
-				client := tron.NewAPIClient(apiKey)
-				if qm.baseURL != "" {
-					client.SetBaseURL(qm.baseURL)
+		// 发送任务到 jobs channel，并检查是否取消
+		go func() {
+			defer close(jobs)
+			for start := 0; start < len(order); start += batchSize {
+				end := start + batchSize
+				if end > len(order) {
+					end = len(order)
 				}
+				// 检查是否取消
+				select {
+				case <-qm.ctx.Done():
+					// 取消了，停止发送新任务
+					return
+				case jobs <- order[start:end]:
+					// 成功发送任务
+				}
+			}
+		}()
+
+		// 等待所有 worker 完成
+		wg.Wait()
+	}
+
+	// 任务下发顺序：打乱时按随机排列下发，结果仍写入原索引位置，保证与输入顺序对应
+	// 只下发每个地址首次出现的索引
+	order := make([]int, 0, len(firstIndex))
+	for i := range addresses {
+		if first, ok := firstIndex[addresses[i]]; ok && first == i {
+			order = append(order, i)
+		}
+	}
+	if shuffle {
+		rand.Shuffle(len(order), func(a, b int) {
+			order[a], order[b] = order[b], order[a]
+		})
+	}
+
+	runPass(order, false)
+
+	// 自动重试：主查询结束后重新查询失败的地址
+	qm.autoRetryFailed(order, runPass)
+	return nil
+}
+
+// fetchBlockContext 在查询开始时获取一次当前块高和时间，失败时回退为本地时间
+func (qm *QueryManager) fetchBlockContext() {
+	number, blockTime, err := qm.queryNowBlock()
+
+	qm.mu.Lock()
+	defer qm.mu.Unlock()
+	if err != nil {
+		qm.summary.BlockNumber = 0
+		qm.summary.BlockTime = qm.summary.StartTime
+		qm.summary.BlockFallback = true
+		return
+	}
+	qm.summary.BlockNumber = number
+	qm.summary.BlockTime = blockTime
+	qm.summary.BlockFallback = false
+}
+
+// queryNowBlock 使用一个 Key 查询节点当前块信息
+func (qm *QueryManager) queryNowBlock() (int64, time.Time, error) {
+	apiKey, err := qm.keyManager.GetNextKey()
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return qm.newClient(apiKey).GetNowBlock(qm.ctx)
+}
+
+// queryOne 查询单个地址（获取 Key、可选的地址类型检查、查询余额）
+func (qm *QueryManager) queryOne(address string, contractMode ContractFilterMode) QueryResult {
+	// 获取下一个可用的 API Key（轮询使用）
+	apiKey, err := qm.keyManager.GetNextKey()
+	if errors.Is(err, ErrKeyExhausted) {
+		return qm.pauseOnKeysExhausted(address)
+	}
+	if err != nil {
+		return QueryResult{
+			Address: address,
+			Status:  StatusError,
+			Error:   "API Key 获取失败: " + err.Error(),
+		}
+	}
+	if !qm.waitKeyPace(apiKey) {
+		return QueryResult{Address: address, Status: StatusCancelled, Error: "请求已取消"}
+	}
+
+	// 创建客户端
+	client := qm.newClient(apiKey)
+	result := qm.queryWithClient(client, address, contractMode)
+	if result.APIKey == "" {
+		result.APIKey = apiKey
+	}
+	return result
+}
+
+// pauseOnKeysExhausted Key 额度用完时自动暂停：第一次时提示并取消查询，
+// 该地址和其余未完成的地址按已取消处理（不计为失败），补充 Key 或额度重置后可以继续查询
+func (qm *QueryManager) pauseOnKeysExhausted(address string) QueryResult {
+	qm.mu.Lock()
+	first := !qm.keysExhausted
+	qm.keysExhausted = true
+	qm.mu.Unlock()
+	if first {
+		qm.warn("所有 API Key 都已达到使用上限，查询已自动暂停")
+		qm.CancelWithReason(FinishKeysExhausted)
+	}
+	return QueryResult{Address: address, Status: StatusCancelled, Error: "API Key 额度已用完"}
+}
+
+// KeysExhausted 返回本次查询是否因所有 API Key 额度用完而自动暂停（查询已取消，未完成的地址可以继续）
+func (qm *QueryManager) KeysExhausted() bool {
+	qm.mu.RLock()
+	defer qm.mu.RUnlock()
+	return qm.keysExhausted
+}
+
+// queryBatch 使用 JSON-RPC 批量调用查询一组地址（每种代币一次请求）
+// 批量请求失败时整组改为逐个查询，单个地址的调用失败时只有该地址改为逐个查询（均含重试）
+func (qm *QueryManager) queryBatch(addresses []string) []QueryResult {
+	results := make([]QueryResult, len(addresses))
+	queryEach := func(k int) {
+		results[k] = qm.queryOne(addresses[k], ContractFilterOff)
+		results[k].QueriedAt = time.Now()
+	}
+
+	qm.mu.RLock()
+	disabled := qm.rpcBatchDisabled
+	qm.mu.RUnlock()
+	if disabled {
+		for k := range addresses {
+			queryEach(k)
+		}
+		return results
+	}
 
-				// 查询余额（传入 context 以支持取消）
-				balance, err := client.QueryBalanceWithContext(qm.ctx, addresses[i])
+	apiKey, err := qm.keyManager.GetNextKey()
+	if errors.Is(err, ErrKeyExhausted) {
+		for k, address := range addresses {
+			results[k] = qm.pauseOnKeysExhausted(address)
+		}
+		return results
+	}
+	if err != nil {
+		for k, address := range addresses {
+			results[k] = QueryResult{Address: address, Status: StatusError, Error: "API Key 获取失败: " + err.Error()}
+		}
+		return results
+	}
+	if !qm.waitKeyPace(apiKey) {
+		for k, address := range addresses {
+			results[k] = QueryResult{Address: address, Status: StatusCancelled, Error: "请求已取消"}
+		}
+		return results
+	}
 
-				// 更新结果
+	client := qm.newClient(apiKey)
+	tokens := qm.Tokens()
+	balances := make([][]tron.BatchBalance, len(tokens))
+	for t, token := range tokens {
+		batch, err := client.QueryBalancesJSONRPCBatch(qm.ctx, addresses, token)
+		qm.recordThroughput(err)
+		if err != nil {
+			if tron.IsRetryable(err) {
+				qm.recordHealth(false)
+			} else if tron.KindOf(err) != tron.ErrorKindCancelled {
+				// 节点不支持批量调用等非临时错误：只提示一次，本次查询的其余地址都逐个查询
 				qm.mu.Lock()
-				if err != nil {
-					qm.results[i] = QueryResult{
-						Address: addresses[i],
-						Status:  "error",
-						Error:   err.Error(),
-					}
-				} else {
-					qm.results[i] = QueryResult{
-						Address: addresses[i],
-						Balance: balance,
-						Status:  "success",
-					}
-				}
+				first := !qm.rpcBatchDisabled
+				qm.rpcBatchDisabled = true
 				qm.mu.Unlock()
+				if first {
+					qm.warn("JSON-RPC 批量调用失败，改为逐个查询: " + err.Error())
+				}
+			}
+			for k := range addresses {
+				queryEach(k)
+			}
+			return results
+		}
+		balances[t] = batch
+	}
+	qm.recordHealth(true)
+
+	for k, address := range addresses {
+		failed := false
+		for t := range tokens {
+			if balances[t][k].Err != nil {
+				failed = true
+				break
+			}
+		}
+		if failed {
+			queryEach(k)
+			continue
+		}
+
+		results[k] = QueryResult{
+			Address:   address,
+			Balance:   balances[0][k].Balance,
+			Status:    StatusSuccess,
+			QueriedAt: time.Now(),
+			APIKey:    apiKey,
+			RawHex:    balances[0][k].RawHex,
+		}
+		if len(tokens) > 1 {
+			results[k].TokenBalances = make(map[string]string, len(tokens)-1)
+			for t, token := range tokens[1:] {
+				results[k].TokenBalances[token.Symbol] = balances[t+1][k].Balance
+			}
+		}
+	}
+	return results
+}
+
+// queryWithClient 使用已取得 Key 的客户端完成单个地址的查询
+func (qm *QueryManager) queryWithClient(client *tron.APIClient, address string, contractMode ContractFilterMode) QueryResult {
+	var err error
+
+	// 检查地址类型（可选，额外消耗一次请求）
+	addressType := ""
+	if contractMode != ContractFilterOff {
+		addressType, err = qm.checkAddressType(address)
+		if errors.Is(err, ErrKeyExhausted) {
+			return qm.pauseOnKeysExhausted(address)
+		}
+		if err != nil {
+			return QueryResult{
+				Address:   address,
+				Status:    StatusError,
+				Error:     "地址类型检查失败: " + err.Error(),
+				ErrorKind: tron.KindOf(err),
+			}
+		}
+	}
+
+	if contractMode == ContractFilterExclude && addressType == "contract" {
+		return QueryResult{
+			Address:     address,
+			Status:      StatusSkipped,
+			Error:       errSkippedContract.Error(),
+			AddressType: addressType,
+		}
+	}
+
+	tokens := qm.Tokens()
 
-				// 更新进度
-				progressMu.Lock()
-				completedCount++
-				current := completedCount
-				progressMu.Unlock()
-				if progressCallback != nil {
-					progressCallback(current, len(addresses))
+	// 查询余额（传入 context 以支持取消；失败重试时会更换 Key）
+	balance, rawHex, usedKey, err := qm.queryBalanceWithRetry(client, address, tokens[0])
+	if errors.Is(err, ErrKeyExhausted) {
+		return qm.pauseOnKeysExhausted(address)
+	}
+	if tron.KindOf(err) == tron.ErrorKindCancelled {
+		// 请求进行中被取消（暂停、超时）：不算失败，继续查询时重新查询
+		return QueryResult{Address: address, Status: StatusCancelled, Error: err.Error()}
+	}
+	if err != nil {
+		return QueryResult{
+			Address:     address,
+			Status:      StatusError,
+			Error:       err.Error(),
+			ErrorKind:   tron.KindOf(err),
+			AddressType: addressType,
+			APIKey:      usedKey,
+			RawHex:      rawHex,
+		}
+	}
+	result := QueryResult{
+		Address:     address,
+		Balance:     balance,
+		Status:      StatusSuccess,
+		AddressType: addressType,
+		APIKey:      usedKey,
+		RawHex:      rawHex,
+	}
+
+	// 其余代币：任意一个失败时整行标记为失败，已查到的余额保留
+	if len(tokens) > 1 {
+		result.TokenBalances = make(map[string]string, len(tokens)-1)
+		var failures []string
+		for _, token := range tokens[1:] {
+			tokenBalance, _, _, err := qm.queryBalanceWithRetry(client, address, token)
+			if errors.Is(err, ErrKeyExhausted) {
+				return qm.pauseOnKeysExhausted(address)
+			}
+			if err != nil {
+				failures = append(failures, token.Symbol+": "+err.Error())
+				if len(failures) == 1 {
+					result.ErrorKind = tron.KindOf(err)
 				}
+				continue
 			}
-		}()
+			result.TokenBalances[token.Symbol] = tokenBalance
+		}
+		if len(failures) > 0 {
+			result.Status = StatusError
+			result.Error = strings.Join(failures, "; ")
+		}
 	}
+	return result
+}
 
-	// 发送任务到 jobs channel，并检查是否取消
-	go func() {
-		defer close(jobs)
-		for i := range addresses {
-			// 检查是否取消
-			select {
-			case <-qm.ctx.Done():
-				// 取消了，停止发送新任务
-				return
-			case jobs <- i:
-				// 成功发送任务
+// queryBalanceWithRetry 查询指定代币的余额，失败时按错误类别的重试次数（见 RetryPolicy）换一个 Key 重试
+// 同一个 Key 被限流时继续用它重试往往还是失败，因此每次重试都重新通过 GetNextKey 取 Key；
+// 重试次数受本次查询共享的重试预算限制，见 SetRetryBudget；
+// 返回余额、节点的原始 hex 值（解析失败时也保留，便于排查）和最后一次请求使用的 Key
+func (qm *QueryManager) queryBalanceWithRetry(client *tron.APIClient, address string, token tron.Token) (string, string, string, error) {
+	qm.mu.RLock()
+	policy := qm.retryPolicy
+	qm.mu.RUnlock()
+
+	var lastErr error
+	var rawHex string
+	retries := make(map[tron.ErrorKind]int) // 每个类别已重试的次数
+
+	// 从第一次重试开始到返回，该请求计入重试状态（界面显示"正在重试"）
+	retrying, retryKind := false, tron.ErrorKindUnknown
+	endRetry := func() {
+		if retrying {
+			qm.endRetry(retryKind)
+			retrying = false
+		}
+	}
+	defer endRetry()
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			kind := tron.KindOf(lastErr)
+			if retries[kind] >= policy.retries(kind) {
+				break
+			}
+			if !qm.takeRetry() {
+				return "", rawHex, client.APIKey, lastErr
+			}
+			// 退避等待后换一个 Key
+			retries[kind]++
+			endRetry()
+			qm.beginRetry(kind)
+			retrying, retryKind = true, kind
+			if !tron.SleepWithContext(qm.ctx, policy.backoff(lastErr, retries[kind]-1)) {
+				return "", rawHex, client.APIKey, errRequestCancelled
+			}
+			// 重试时 Key 额度用完：返回 ErrKeyExhausted（由调用方自动暂停），而不是上一次请求的错误
+			apiKey, err := qm.keyManager.GetNextKey()
+			if err != nil {
+				return "", rawHex, client.APIKey, err
 			}
+			if !qm.waitKeyPace(apiKey) {
+				return "", rawHex, client.APIKey, errRequestCancelled
+			}
+			client = qm.newClient(apiKey)
 		}
-	}()
 
-	// 等待所有 worker 完成
-	wg.Wait()
+		balance, raw, err := client.QueryTokenBalanceRawOnce(qm.ctx, address, token)
+		rawHex = raw
+		qm.recordThroughput(err)
+		if err == nil {
+			qm.recordHealth(true)
+			return balance, rawHex, client.APIKey, nil
+		}
+		lastErr = err
+		if tron.IsRetryable(err) {
+			qm.recordHealth(false)
+		}
+	}
+	return "", rawHex, client.APIKey, lastErr
+}
+
+// waitKeyPace 开启平滑使用额度时等待该 Key 的下一个请求时段，被取消时返回 false
+func (qm *QueryManager) waitKeyPace(apiKey string) bool {
+	delay := qm.keyManager.reservePace(apiKey)
+	if delay <= 0 {
+		return qm.ctx.Err() == nil
+	}
+	return tron.SleepWithContext(qm.ctx, delay)
+}
+
+// newClient 使用指定 Key 创建 API 客户端（应用自定义节点 URL）
+// 每个请求发送前除了本查询的限流器，还要等待 Key 管理器分配的该 Key 的时段
+func (qm *QueryManager) newClient(apiKey string) *tron.APIClient {
+	qm.mu.RLock()
+	signer := qm.requestSigner
+	owner := qm.ownerAddress
+	qm.mu.RUnlock()
+
+	return tron.NewAPIClientWithOptions(tron.ClientOptions{
+		APIKey:       apiKey,
+		BaseURL:      qm.baseURL,
+		Limiter:      qm.limiter,
+		Signer:       signer,
+		OwnerAddress: owner,
+		Slot: func(ctx context.Context) error {
+			return qm.keyManager.scheduler.acquire(ctx, qm.slotID, apiKey)
+		},
+	})
+}
+
+// GetSummary 获取本次查询的汇总信息（包含数据基准和统计）
+func (qm *QueryManager) GetSummary() RunSummary {
+	stats := qm.GetStats()
+
+	qm.mu.RLock()
+	summary := qm.summary
+	summary.Retries = qm.resumedRetries + qm.retryBudget.used
+	summary.RetryBudget = qm.retryBudget.limit
+	summary.FailedByKind = qm.failures.breakdown()
+	qm.mu.RUnlock()
+
+	summary.Total = stats.Total
+	summary.Success = stats.Success
+	summary.Failed = stats.Failed
+	return summary
+}
+
+// errRequestCancelled 重试等待期间查询被取消时使用的错误（类别为已取消，结果不计为失败）
+var errRequestCancelled = &tron.QueryError{Kind: tron.ErrorKindCancelled, Message: "请求已取消"}
+
+// errSkippedContract 合约地址被排除时使用的错误
+var errSkippedContract = errors.New("合约地址，已跳过")
+
+// checkAddressType 使用单独的 Key 查询地址类型，返回 "contract" 或 "wallet"
+func (qm *QueryManager) checkAddressType(address string) (string, error) {
+	apiKey, err := qm.keyManager.GetNextKey()
+	if err != nil {
+		return "", err
+	}
+
+	isContract, err := qm.newClient(apiKey).IsContract(qm.ctx, address)
+	if err != nil {
+		return "", err
+	}
+	if isContract {
+		return "contract", nil
+	}
+	return "wallet", nil
 }
 
 // GetResults 获取查询结果
@@ -217,11 +1095,10 @@ func (qm *QueryManager) GetResults() []QueryResult {
 	return result
 }
 
-// Cancel 取消查询
+// Cancel 取消查询（结束原因为 FinishCancelled，见 CancelWithReason）
+// 查询中取消时，QueryAddresses 返回后状态才变为 StateCancelled
 func (qm *QueryManager) Cancel() {
-	if qm.cancel != nil {
-		qm.cancel()
-	}
+	qm.CancelWithReason(FinishCancelled)
 }
 
 // Ctx 返回 context
@@ -229,18 +1106,10 @@ func (qm *QueryManager) Ctx() context.Context {
 	return qm.ctx
 }
 
-// GetStats 获取统计信息
-func (qm *QueryManager) GetStats() (total, success, failed int) {
+// GetStats 获取统计信息（总数、成功、失败、有余额、无余额）
+// 统计在每次更新结果时增量维护，不遍历结果，可以在进度刷新中频繁调用
+func (qm *QueryManager) GetStats() ResultStats {
 	qm.mu.RLock()
 	defer qm.mu.RUnlock()
-
-	total = len(qm.results)
-	for _, r := range qm.results {
-		if r.Status == "success" {
-			success++
-		} else if r.Status == "error" {
-			failed++
-		}
-	}
-	return
+	return qm.stats
 }
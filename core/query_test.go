@@ -0,0 +1,177 @@
+package core
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"usdt-balance-checker/tron"
+)
+
+// 只写了合约地址的代币在 QueryAddresses 开始时查询一次符号并通知回调，符号已知时不再查询
+func TestTokensCallback(t *testing.T) {
+	srv := newTestNode(t, nil)
+	qm := newTestManager(newTestKeyManager(t, 1), srv)
+	qm.SetTokens([]tron.Token{tron.USDTToken, {Contract: tron.USDCToken.Contract, Decimals: 6}})
+
+	var calls [][]tron.Token
+	qm.SetTokensCallback(func(tokens []tron.Token) {
+		calls = append(calls, tokens)
+	})
+	for run := 0; run < 2; run++ {
+		if err := qm.QueryAddresses(testAddresses(2), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(calls) != 1 {
+		t.Fatalf("回调调用 %d 次, want 1", len(calls))
+	}
+	// 模拟节点的 symbol() 结果无法解析，使用缩短的合约地址
+	want := tron.ShortContract(tron.USDCToken.Contract)
+	if got := calls[0][1].Symbol; got != want {
+		t.Fatalf("回调中的符号 = %q, want %q", got, want)
+	}
+	if got := qm.Tokens()[1].Symbol; got != want {
+		t.Fatalf("Tokens()[1].Symbol = %q, want %q", got, want)
+	}
+}
+
+// 结果的 RawHex 为节点返回的 constant_result[0]，Balance 可由 tron.FormatBalanceHex(RawHex) 重新得到；
+// 导出默认不含原始值列，ExportOptions.RawHex 时导出
+func TestResultRawHex(t *testing.T) {
+	addrs := testAddresses(3)
+	raws := map[string]string{}
+	for i, addr := range addrs {
+		raws[addr] = fmt.Sprintf("%064x", []int64{0, 1500000, 987654321987}[i])
+	}
+	srv := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Parameter string `json:"parameter"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		for addr, raw := range raws {
+			if containsAddressParam(body.Parameter, addr) {
+				fmt.Fprintf(w, `{"result":{"result":true},"constant_result":[%q]}`, raw)
+				return
+			}
+		}
+		fmt.Fprint(w, `{}`) // 区块高度等其他请求
+	})
+	qm := newTestManager(newTestKeyManager(t, 1), srv)
+	if err := qm.QueryAddresses(addrs, nil); err != nil {
+		t.Fatal(err)
+	}
+	results := qm.GetResults()
+	for _, result := range results {
+		if result.RawHex != raws[result.Address] {
+			t.Errorf("%s: RawHex = %q, want %q", result.Address, result.RawHex, raws[result.Address])
+		}
+		if balance, err := tron.FormatBalanceHex(result.RawHex); err != nil || balance != result.Balance {
+			t.Errorf("%s: FormatBalanceHex(RawHex) = %q, %v, Balance = %q", result.Address, balance, err, result.Balance)
+		}
+	}
+
+	for _, rawHex := range []bool{false, true} {
+		path := filepath.Join(t.TempDir(), "results.csv")
+		if err := ExportToCSVWithOptions(results, path, ExportOptions{RawHex: rawHex, Language: LangEnglish}); err != nil {
+			t.Fatal(err)
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows, err := csv.NewReader(file).ReadAll()
+		file.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		col := slices.Index(rows[0], "Raw Hex")
+		if (col >= 0) != rawHex {
+			t.Fatalf("RawHex %v: 表头 %v", rawHex, rows[0])
+		}
+		if !rawHex {
+			continue
+		}
+		for i, result := range results {
+			if rows[i+1][col] != result.RawHex {
+				t.Errorf("%s: 导出的原始值 %q, want %q", result.Address, rows[i+1][col], result.RawHex)
+			}
+		}
+	}
+}
+
+// 固定 owner_address（SetOwnerAddress）时，已上链地址的余额与使用被查询地址时完全相同，请求中的 owner_address 为固定地址；
+// 模拟的节点在 owner_address 为从未上链的地址时返回错误，固定 owner_address 后该地址也能查询
+func TestOwnerAddressSameBalances(t *testing.T) {
+	addrs := testAddresses(4)
+	active, inactive := addrs[:3], addrs[3]
+	balances := map[string]int64{active[0]: 0, active[1]: 1500000, active[2]: 987654321987, inactive: 0}
+
+	var mu sync.Mutex
+	owners := map[string]bool{}
+	srv := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			OwnerAddress string `json:"owner_address"`
+			Parameter    string `json:"parameter"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Parameter == "" {
+			fmt.Fprint(w, `{}`) // 区块高度等其他请求
+			return
+		}
+		mu.Lock()
+		owners[body.OwnerAddress] = true
+		mu.Unlock()
+		if body.OwnerAddress == inactive {
+			fmt.Fprint(w, `{"result":{"result":false,"code":"CONTRACT_VALIDATE_ERROR","message":"account does not exist"}}`)
+			return
+		}
+		for addr, balance := range balances {
+			if containsAddressParam(body.Parameter, addr) {
+				fmt.Fprintf(w, `{"result":{"result":true},"constant_result":["%064x"]}`, balance)
+				return
+			}
+		}
+		http.Error(w, "unknown address", http.StatusBadRequest)
+	})
+
+	query := func(owner string) []QueryResult {
+		t.Helper()
+		mu.Lock()
+		clear(owners)
+		mu.Unlock()
+		qm := newTestManager(newTestKeyManager(t, 1), srv)
+		qm.SetRetryBudget(-1)
+		if err := qm.SetOwnerAddress(owner); err != nil {
+			t.Fatal(err)
+		}
+		if err := qm.QueryAddresses(addrs, nil); err != nil {
+			t.Fatal(err)
+		}
+		return qm.GetResults()
+	}
+
+	byQueried := query("")
+	for _, owner := range []string{tron.BurnAddress, tron.USDTToken.Contract} {
+		fixed := query(owner)
+		if len(owners) != 1 || !owners[owner] {
+			t.Errorf("固定 owner_address %s 时请求使用了 %v", owner, owners)
+		}
+		for i := range active {
+			if byQueried[i].Status != StatusSuccess || fixed[i].Status != StatusSuccess || byQueried[i].Balance != fixed[i].Balance || byQueried[i].RawHex != fixed[i].RawHex {
+				t.Errorf("%s: 使用被查询地址 %v %q，固定 owner_address %s 时 %v %q",
+					active[i], byQueried[i].Status, byQueried[i].Balance, owner, fixed[i].Status, fixed[i].Balance)
+			}
+		}
+		if byQueried[3].Status != StatusError || fixed[3].Status != StatusSuccess || fixed[3].Balance != "0" {
+			t.Errorf("未上链地址: 使用被查询地址 %v，固定 owner_address %s 时 %v %q", byQueried[3].Status, owner, fixed[3].Status, fixed[3].Balance)
+		}
+	}
+}
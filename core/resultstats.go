@@ -0,0 +1,64 @@
+package core
+
+// ResultStats 查询结果的统计：总数、成功、失败，以及成功结果中有余额（>0）和无余额的数量
+type ResultStats struct {
+	Total          int
+	Success        int
+	Failed         int
+	WithBalance    int // 查询成功且余额大于 0
+	WithoutBalance int // 查询成功且余额为 0（无法解析的余额也计入这里）
+}
+
+// CountResultStats 遍历 results 统计（O(n)，只在需要一次性统计时使用；查询中的统计见 QueryManager.GetStats）
+func CountResultStats(results []QueryResult) ResultStats {
+	var stats ResultStats
+	for _, result := range results {
+		stats.Add(result)
+	}
+	return stats
+}
+
+// Add 将一个结果计入统计
+func (s *ResultStats) Add(result QueryResult) {
+	s.Total++
+	s.count(result, 1)
+}
+
+// Plus 返回两份统计之和（如继续查询时之前的结果加上本次查询的结果）
+func (s ResultStats) Plus(other ResultStats) ResultStats {
+	return ResultStats{
+		Total:          s.Total + other.Total,
+		Success:        s.Success + other.Success,
+		Failed:         s.Failed + other.Failed,
+		WithBalance:    s.WithBalance + other.WithBalance,
+		WithoutBalance: s.WithoutBalance + other.WithoutBalance,
+	}
+}
+
+// replace 同一位置的结果由 old 变为 new 时更新统计（总数不变）
+func (s *ResultStats) replace(old, new QueryResult) {
+	s.count(old, -1)
+	s.count(new, 1)
+}
+
+// count 按结果状态将对应的计数加上 delta
+func (s *ResultStats) count(result QueryResult, delta int) {
+	switch result.Status {
+	case StatusSuccess:
+		s.Success += delta
+		if balance, err := ParseBalance(result.Balance); err == nil && balance > 0 {
+			s.WithBalance += delta
+		} else {
+			s.WithoutBalance += delta
+		}
+	case StatusError:
+		s.Failed += delta
+	}
+}
+
+// setResultLocked 更新第 i 个结果并同步增量统计，调用方需持有 qm.mu
+func (qm *QueryManager) setResultLocked(i int, result QueryResult) {
+	qm.stats.replace(qm.results[i], result)
+	qm.failures.replace(qm.results[i], result)
+	qm.results[i] = result
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"usdt-balance-checker/tron"
+)
+
+// failingNode 余额请求的前 failures 次返回 status（body 为响应内容），之后返回 balanceResponse；calls 记录余额请求次数
+func failingNode(t *testing.T, failures int32, status int, body string, calls *atomic.Int32) *QueryManager {
+	t.Helper()
+	srv := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/triggerconstantcontract") {
+			fmt.Fprint(w, `{}`)
+			return
+		}
+		if calls.Add(1) <= failures {
+			w.WriteHeader(status)
+			fmt.Fprint(w, body)
+			return
+		}
+		fmt.Fprint(w, balanceResponse)
+	})
+	qm := newTestManager(newTestKeyManager(t, 2), srv)
+	policy := DefaultRetryPolicy
+	policy.ServerBackoff = time.Millisecond
+	qm.SetRetryPolicy(policy)
+	qm.SetRetryBudget(-1) // 只查询一个地址，不受重试预算限制
+	return qm
+}
+
+func TestRetryServerErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		status   int
+		body     string
+		failures int32
+		want     ResultStatus
+		calls    int32
+		kind     tron.ErrorKind
+	}{
+		{"503 后恢复", http.StatusServiceUnavailable, `{"Error":"busy"}`, 2, StatusSuccess, 3, tron.ErrorKindUnknown},
+		{"网关返回 502 错误页后恢复", http.StatusBadGateway, "<html>502 Bad Gateway</html>", 1, StatusSuccess, 2, tron.ErrorKindUnknown},
+		{"5xx 超过重试次数", http.StatusInternalServerError, `{}`, 10, StatusError, 3, tron.ErrorKindServer},
+		{"4xx 不重试", http.StatusBadRequest, `{}`, 10, StatusError, 1, tron.ErrorKindHTTP},
+	}
+	for _, c := range cases {
+		var calls atomic.Int32
+		qm := failingNode(t, c.failures, c.status, c.body, &calls)
+		if err := qm.QueryAddresses(testAddresses(1), nil); err != nil {
+			t.Fatal(err)
+		}
+		result := qm.GetResults()[0]
+		if result.Status != c.want || calls.Load() != c.calls || result.ErrorKind != c.kind {
+			t.Errorf("%s: 状态 %v（%s），类别 %v，请求 %d 次；want %v，类别 %v，请求 %d 次",
+				c.name, result.Status, result.Error, result.ErrorKind, calls.Load(), c.want, c.kind, c.calls)
+		}
+	}
+}
+
+// 重试时 Key 额度用完：自动暂停（结果为已取消，结束原因为 Key 额度用完），而不是返回上一次请求的错误
+func TestRetryKeysExhausted(t *testing.T) {
+	var calls atomic.Int32
+	qm := failingNode(t, 10, http.StatusServiceUnavailable, `{}`, &calls)
+	km := qm.keyManager
+	km.mu.Lock()
+	for i := range km.keys {
+		km.keys[i].MaxLimit = 1 // 一个用于查询块高，一个用于第一次请求
+	}
+	km.mu.Unlock()
+
+	if err := qm.QueryAddresses(testAddresses(1), nil); err != nil {
+		t.Fatal(err)
+	}
+	result := qm.GetResults()[0]
+	if result.Status != StatusCancelled || !qm.KeysExhausted() || qm.FinishReason() != FinishKeysExhausted {
+		t.Fatalf("状态 %v（%s），KeysExhausted %v，结束原因 %s", result.Status, result.Error, qm.KeysExhausted(), qm.FinishReason())
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("请求 %d 次, want 1", calls.Load())
+	}
+}
+
+func TestParseRetryPolicyServer(t *testing.T) {
+	policy, err := ParseRetryPolicy("server=5,invalid=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.Server != 5 || policy.Invalid != 1 || policy.Network != DefaultRetryPolicy.Network {
+		t.Fatalf("policy = %+v", policy)
+	}
+	if got := policy.retries(tron.ErrorKindServer); got != 5 {
+		t.Fatalf("retries(5xx) = %d, want 5", got)
+	}
+}
@@ -0,0 +1,105 @@
+package core
+
+import "github.com/ethereum/go-ethereum/log"
+
+// DefaultRetryBudgetRatio 默认重试预算：本次查询（去重后）地址数的 20%
+const DefaultRetryBudgetRatio = 0.2
+
+// retryBudgetRecoveryStreak 预算耗尽后连续成功多少次视为节点恢复健康，重置预算
+const retryBudgetRecoveryStreak = 20
+
+// retryBudget 一次查询任务内所有地址共享的重试预算
+// 节点故障时每个地址都重试会让请求量成倍放大，预算耗尽后失败直接记录，不再重试
+type retryBudget struct {
+	limit     int  // 预算总数，<0 表示不限制
+	remaining int  // 剩余可用次数
+	used      int  // 本次查询累计消耗的重试次数
+	exhausted bool // 是否已耗尽（耗尽提示只发一次，恢复后重新计）
+	streak    int  // 连续成功次数
+}
+
+// newRetryBudget 按地址数和比例创建重试预算，ratio<0 表示不限制
+func newRetryBudget(addressCount int, ratio float64) retryBudget {
+	if ratio < 0 {
+		return retryBudget{limit: -1}
+	}
+	limit := int(float64(addressCount) * ratio)
+	if limit < 1 {
+		limit = 1
+	}
+	return retryBudget{limit: limit, remaining: limit}
+}
+
+// SetRetryBudget 设置重试预算比例（相对本次查询的地址数），0 使用默认 20%，<0 不限制
+func (qm *QueryManager) SetRetryBudget(ratio float64) {
+	if ratio == 0 {
+		ratio = DefaultRetryBudgetRatio
+	}
+	qm.mu.Lock()
+	qm.retryBudgetRatio = ratio
+	qm.mu.Unlock()
+}
+
+// SetWarningCallback 设置运行警告回调（如重试预算耗尽），可能在 worker goroutine 中调用
+func (qm *QueryManager) SetWarningCallback(callback func(message string)) {
+	qm.mu.Lock()
+	qm.warningCallback = callback
+	qm.mu.Unlock()
+}
+
+// takeRetry 从预算中取一次重试，预算耗尽时返回 false
+func (qm *QueryManager) takeRetry() bool {
+	qm.mu.Lock()
+	budget := &qm.retryBudget
+	if budget.limit < 0 || budget.remaining > 0 {
+		if budget.limit >= 0 {
+			budget.remaining--
+		}
+		budget.used++
+		qm.mu.Unlock()
+		return true
+	}
+	firstExhausted := !budget.exhausted
+	budget.exhausted = true
+	budget.streak = 0
+	qm.mu.Unlock()
+
+	if firstExhausted {
+		qm.warn("重试预算已用完，后续失败的地址将不再重试（节点恢复后自动重置）")
+	}
+	return false
+}
+
+// recordHealth 记录一次请求结果；预算耗尽后连续成功达到阈值时重置预算
+func (qm *QueryManager) recordHealth(success bool) {
+	qm.mu.Lock()
+	budget := &qm.retryBudget
+	if !success {
+		budget.streak = 0
+		qm.mu.Unlock()
+		return
+	}
+	budget.streak++
+	recovered := budget.exhausted && budget.streak >= retryBudgetRecoveryStreak
+	if recovered {
+		budget.remaining = budget.limit
+		budget.exhausted = false
+		budget.streak = 0
+	}
+	qm.mu.Unlock()
+
+	if recovered {
+		qm.warn("节点已恢复，重试预算已重置")
+	}
+}
+
+// warn 输出运行警告并通知回调
+func (qm *QueryManager) warn(message string) {
+	log.Warn(message)
+	qm.mu.RLock()
+	callback := qm.warningCallback
+	qm.mu.RUnlock()
+	if callback != nil {
+		callback(message)
+	}
+}
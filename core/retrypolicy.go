@@ -0,0 +1,109 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"usdt-balance-checker/tron"
+)
+
+// RetryPolicy 按错误类别设置单个请求的最多重试次数（不含首次请求）和退避时间
+// 重试仍受本次查询共享的重试预算限制，见 SetRetryBudget
+type RetryPolicy struct {
+	RateLimited int `json:"rate_limited"` // 被限流（HTTP 429），等待后恢复的可能性较大
+	Timeout     int `json:"timeout"`      // 请求超时
+	Network     int `json:"network"`      // 其他网络错误（连接失败等）
+	Server      int `json:"server"`       // 节点或网关的服务器错误（HTTP 5xx）
+	Invalid     int `json:"invalid"`      // 4xx 等 HTTP 错误、响应异常（如地址无效）等重试通常不会改变结果的错误
+
+	// 退避基数：第 n 次重试前等待 n 倍基数，0 使用默认（限流 2s，其他 1s，见 tron.RetryBackoff）
+	RateLimitedBackoff time.Duration `json:"rate_limited_backoff,omitempty"`
+	TimeoutBackoff     time.Duration `json:"timeout_backoff,omitempty"`
+	NetworkBackoff     time.Duration `json:"network_backoff,omitempty"`
+	ServerBackoff      time.Duration `json:"server_backoff,omitempty"`
+}
+
+// DefaultRetryPolicy 默认重试策略：限流、超时、网络错误和 5xx 最多重试 2 次（共 3 次请求），其他错误不重试
+var DefaultRetryPolicy = RetryPolicy{RateLimited: 2, Timeout: 2, Network: 2, Server: 2, Invalid: 0}
+
+// ParseRetryPolicy 解析重试策略，格式为逗号分隔的 "类别=次数"，如 "rate-limited=5,timeout=3,network=2,server=2,invalid=0"
+// 类别：rate-limited、timeout、network、server、invalid；未写的类别使用 DefaultRetryPolicy 的次数，空字符串返回默认策略
+func ParseRetryPolicy(s string) (RetryPolicy, error) {
+	policy := DefaultRetryPolicy
+	if strings.TrimSpace(s) == "" {
+		return policy, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		count, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || err != nil || count < 0 {
+			return DefaultRetryPolicy, fmt.Errorf("重试策略格式错误: %s（应为 类别=次数）", part)
+		}
+		switch name {
+		case "rate-limited", "ratelimited", "429":
+			policy.RateLimited = count
+		case "timeout":
+			policy.Timeout = count
+		case "network":
+			policy.Network = count
+		case "server", "5xx":
+			policy.Server = count
+		case "invalid":
+			policy.Invalid = count
+		default:
+			return DefaultRetryPolicy, fmt.Errorf("未知的错误类别: %s（可选: rate-limited, timeout, network, server, invalid）", name)
+		}
+	}
+	return policy, nil
+}
+
+// retries 返回该类别错误的最多重试次数（已取消的请求不重试）
+func (p RetryPolicy) retries(kind tron.ErrorKind) int {
+	switch kind {
+	case tron.ErrorKindRateLimited:
+		return p.RateLimited
+	case tron.ErrorKindTimeout:
+		return p.Timeout
+	case tron.ErrorKindNetwork:
+		return p.Network
+	case tron.ErrorKindServer:
+		return p.Server
+	case tron.ErrorKindCancelled:
+		return 0
+	}
+	return p.Invalid
+}
+
+// maxRetries 返回各类别中最多的重试次数（用于预估请求数）
+func (p RetryPolicy) maxRetries() int {
+	return max(p.RateLimited, p.Timeout, p.Network, p.Server, p.Invalid)
+}
+
+// backoff 返回该错误第 attempt 次（从 0 开始）重试前的等待时间
+func (p RetryPolicy) backoff(err error, attempt int) time.Duration {
+	var base time.Duration
+	switch tron.KindOf(err) {
+	case tron.ErrorKindRateLimited:
+		base = p.RateLimitedBackoff
+	case tron.ErrorKindTimeout:
+		base = p.TimeoutBackoff
+	case tron.ErrorKindNetwork:
+		base = p.NetworkBackoff
+	case tron.ErrorKindServer:
+		base = p.ServerBackoff
+	}
+	if base <= 0 {
+		return tron.RetryBackoff(err, attempt)
+	}
+	return time.Duration(attempt+1) * base
+}
+
+// SetRetryPolicy 设置按错误类别的重试次数和退避，见 RetryPolicy
+func (qm *QueryManager) SetRetryPolicy(policy RetryPolicy) {
+	qm.mu.Lock()
+	qm.retryPolicy = policy
+	qm.mu.Unlock()
+}
@@ -0,0 +1,27 @@
+package core
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SanitizeAddress 去掉输入中的零宽字符、BOM、方向控制符等不可见的格式字符（Unicode Cf 类）和首尾空白，
+// 返回清理后的内容以及是否去掉了不可见字符。
+// 从聊天软件、网页复制的地址常带有这些字符，看起来完全正确却无法通过校验（提示校验码错误）
+func SanitizeAddress(s string) (string, bool) {
+	if strings.IndexFunc(s, isInvisible) < 0 {
+		return strings.TrimSpace(s), false
+	}
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		if isInvisible(r) {
+			return -1
+		}
+		return r
+	}, s)), true
+}
+
+// isInvisible 是否为不可见的格式字符：零宽空格 U+200B、零宽连接符 U+200C/U+200D、BOM U+FEFF、
+// 方向标记 U+200E/U+200F、方向嵌入和隔离 U+202A~U+202E/U+2066~U+2069、软连字符 U+00AD 等
+func isInvisible(r rune) bool {
+	return unicode.Is(unicode.Cf, r)
+}
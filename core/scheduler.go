@@ -0,0 +1,178 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultKeyRateLimit 每个 Key 默认的每秒请求数上限（同一个 Key 管理器下所有查询合计）
+// 默认不限制（0），请求速率只受查询的总速率（SetRateLimit）限制；多个查询共用 Key 时可通过 SetKeyRateLimit 开启
+const DefaultKeyRateLimit = 0
+
+// keyScheduler 进程级的按 Key 请求调度
+//
+// 同一个 Key 管理器下的所有查询管理器（多个标签页、同时运行的查询）在发送每个请求前向调度器申请时段，
+// 每个 Key 的请求间隔不小于 1/rate 秒和 minInterval 中较大的一个；多个查询同时等待同一个 Key 时按查询轮流分配时段，
+// 并发数多的查询不会挤占其他查询。每日额度由 GetNextKey 统一计数，本身就是进程级的
+type keyScheduler struct {
+	mu     sync.Mutex
+	rate   int                  // 每个 Key 每秒请求数，<=0 不限制
+	lastID int                  // 最近分配的消费者编号
+	keys   map[string]*keySlots // Key -> 时段分配状态
+
+	minInterval time.Duration // 同一个 Key 两次请求的最小间隔，0 不限制，见 SetPerKeyMinInterval
+}
+
+// keySlots 单个 Key 的时段分配状态
+type keySlots struct {
+	next    time.Time               // 下一个可用时段
+	queues  map[int][]chan struct{} // 消费者 -> 等待中的请求（按到达顺序）
+	order   []int                   // 有等待请求的消费者（轮流分配的顺序）
+	running bool                    // 是否有分配协程在运行
+}
+
+// register 分配一个消费者编号（每个查询管理器一个）
+func (s *keyScheduler) register() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastID++
+	return s.lastID
+}
+
+// interval 返回同一个 Key 两次请求之间的最小间隔（0 为不限制），调用方需持有锁
+func (s *keyScheduler) interval() time.Duration {
+	interval := s.minInterval
+	if s.rate > 0 {
+		interval = max(interval, time.Second/time.Duration(s.rate))
+	}
+	return interval
+}
+
+// acquire 等待 Key 的下一个发送时段，ctx 被取消时返回其错误
+func (s *keyScheduler) acquire(ctx context.Context, consumer int, key string) error {
+	s.mu.Lock()
+	if s.interval() <= 0 {
+		s.mu.Unlock()
+		return nil
+	}
+	if s.keys == nil {
+		s.keys = make(map[string]*keySlots)
+	}
+	slots := s.keys[key]
+	if slots == nil {
+		slots = &keySlots{queues: make(map[int][]chan struct{})}
+		s.keys[key] = slots
+	}
+
+	// 没有等待的请求且时段可用时直接发送
+	now := time.Now()
+	if len(slots.order) == 0 && !slots.next.After(now) {
+		slots.next = now.Add(s.interval())
+		s.mu.Unlock()
+		return nil
+	}
+
+	ready := make(chan struct{})
+	if len(slots.queues[consumer]) == 0 {
+		slots.order = append(slots.order, consumer)
+	}
+	slots.queues[consumer] = append(slots.queues[consumer], ready)
+	if !slots.running {
+		slots.running = true
+		go s.dispatch(slots)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		slots.remove(consumer, ready)
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// dispatch 按时段依次唤醒等待的请求，每次轮到下一个查询，没有等待的请求时退出
+func (s *keyScheduler) dispatch(slots *keySlots) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for len(slots.order) > 0 {
+		if wait := time.Until(slots.next); wait > 0 {
+			s.mu.Unlock()
+			time.Sleep(wait)
+			s.mu.Lock()
+			continue
+		}
+
+		consumer := slots.order[0]
+		queue := slots.queues[consumer]
+		close(queue[0])
+		slots.order = slots.order[1:]
+		if len(queue) > 1 {
+			slots.queues[consumer] = queue[1:]
+			slots.order = append(slots.order, consumer)
+		} else {
+			delete(slots.queues, consumer)
+		}
+
+		slots.next = time.Now().Add(s.interval())
+	}
+	slots.running = false
+}
+
+// remove 移除被取消的等待请求（已被唤醒时什么也不做），调用方需持有锁
+func (slots *keySlots) remove(consumer int, ready chan struct{}) {
+	queue := slots.queues[consumer]
+	for i, ch := range queue {
+		if ch != ready {
+			continue
+		}
+		queue = append(queue[:i:i], queue[i+1:]...)
+		if len(queue) > 0 {
+			slots.queues[consumer] = queue
+			return
+		}
+		delete(slots.queues, consumer)
+		for j, id := range slots.order {
+			if id == consumer {
+				slots.order = append(slots.order[:j:j], slots.order[j+1:]...)
+				break
+			}
+		}
+		return
+	}
+}
+
+// SetKeyRateLimit 设置每个 Key 每秒请求数的上限（<=0 不限制），立即对后续请求生效
+// 上限由同一个 Key 管理器下的所有查询管理器共享（如多个标签页同时查询），多个查询同时使用一个 Key 时平分
+func (m *APIKeyManager) SetKeyRateLimit(perSecond int) {
+	m.scheduler.mu.Lock()
+	defer m.scheduler.mu.Unlock()
+	m.scheduler.rate = max(perSecond, 0)
+}
+
+// SetPerKeyMinInterval 设置同一个 Key 两次请求的最小间隔（<=0 不限制），立即对后续请求生效
+// 用于对突发请求敏感的 Key（如免费版）：与 SetKeyRateLimit 同时生效，取间隔较大的一个；
+// 间隔不够时请求等待到该 Key 的下一个时段，所有查询管理器共享
+func (m *APIKeyManager) SetPerKeyMinInterval(d time.Duration) {
+	m.scheduler.mu.Lock()
+	defer m.scheduler.mu.Unlock()
+	m.scheduler.minInterval = max(d, 0)
+}
+
+// PerKeyMinInterval 返回同一个 Key 两次请求的最小间隔，0 为不限制
+func (m *APIKeyManager) PerKeyMinInterval() time.Duration {
+	m.scheduler.mu.Lock()
+	defer m.scheduler.mu.Unlock()
+	return m.scheduler.minInterval
+}
+
+// KeyRateLimit 返回每个 Key 每秒请求数的上限，0 为不限制
+func (m *APIKeyManager) KeyRateLimit() int {
+	m.scheduler.mu.Lock()
+	defer m.scheduler.mu.Unlock()
+	return m.scheduler.rate
+}
@@ -0,0 +1,96 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"usdt-balance-checker/tron"
+)
+
+// 两个查询管理器同时使用同一个 Key：合计速率不超过 SetKeyRateLimit，两个查询轮流获得时段
+func TestKeySchedulerSharedRate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("需要约 2 秒")
+	}
+	const rate, perManager = 40, 40
+
+	var mu sync.Mutex
+	var times []time.Time
+	var owners []string // 每个请求查询的地址（按到达顺序）
+	srv := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/triggerconstantcontract") {
+			fmt.Fprint(w, `{}`) // 区块高度等其他请求不计入
+			return
+		}
+		var body struct {
+			Parameter string `json:"parameter"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		times = append(times, time.Now())
+		owners = append(owners, body.Parameter)
+		mu.Unlock()
+		fmt.Fprint(w, balanceResponse)
+	})
+
+	km := newTestKeyManager(t, 1)
+	km.SetKeyRateLimit(rate)
+	addresses := testAddresses(2 * perManager)
+	batches := [][]string{addresses[:perManager], addresses[perManager:]}
+
+	var wg sync.WaitGroup
+	for _, batch := range batches {
+		qm := newTestManager(km, srv)
+		qm.SetMaxConcurrent(8)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := qm.QueryAddresses(batch, nil); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(times) != 2*perManager {
+		t.Fatalf("请求数 = %d, want %d", len(times), 2*perManager)
+	}
+	// 任意 1 秒内的请求数不超过 rate（允许 1 个的误差：窗口两端各落一个请求）
+	for i := range times {
+		count := 0
+		for _, at := range times[i:] {
+			if at.Sub(times[i]) < time.Second {
+				count++
+			}
+		}
+		if count > rate+1 {
+			t.Fatalf("1 秒内有 %d 个请求，合计速率上限为 %d", count, rate)
+		}
+	}
+
+	// 前一半请求中两个查询都有（轮流分配，没有一个查询独占 Key）
+	first := map[int]int{}
+	for _, param := range owners[:perManager] {
+		for b, batch := range batches {
+			for _, addr := range batch {
+				if param != "" && containsAddressParam(param, addr) {
+					first[b]++
+				}
+			}
+		}
+	}
+	if first[0] < perManager/4 || first[1] < perManager/4 {
+		t.Fatalf("前 %d 个请求中两个查询各占 %d、%d 个，没有轮流分配", perManager, first[0], first[1])
+	}
+}
+
+// containsAddressParam ABI 参数是否为 addr 的地址体（参数末尾 40 位为地址体 hex）
+func containsAddressParam(param, addr string) bool {
+	raw, err := tron.AddressToHex(addr)
+	return err == nil && len(param) >= 40 && param[len(param)-40:] == raw[2:]
+}
@@ -0,0 +1,69 @@
+package core
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// filteredSink 条件写入：满足条件的结果在完成时立即写入，见 SetFilteredSink
+type filteredSink struct {
+	mu     sync.Mutex
+	filter func(QueryResult) bool
+	writer *csv.Writer
+	failed bool // 写入失败后不再写入（只提示一次）
+}
+
+// HasBalance 判断结果是否查询成功且余额大于 0（可直接作为 SetFilteredSink 的条件）
+func HasBalance(r QueryResult) bool {
+	balance, err := ParseBalance(r.Balance)
+	return r.Status == StatusSuccess && err == nil && balance > 0
+}
+
+// SetFilteredSink 设置条件写入：每完成一个地址，满足 filter 的结果立即以 CSV 行写入 w 并刷新，
+// 不必等查询结束即可处理（如一边查询一边取出有余额的地址）
+//
+// 每行为 地址,余额，查询多个代币时后面依次是其他代币的余额，不写表头；重复地址只写第一次出现的行。
+// 多个 worker 并发完成时按完成顺序逐行写入，行不会交错；写入失败时提示一次警告并停止写入，不影响查询。
+// filter 或 w 为 nil 时取消
+func (qm *QueryManager) SetFilteredSink(filter func(QueryResult) bool, w io.Writer) {
+	var sink *filteredSink
+	if filter != nil && w != nil {
+		sink = &filteredSink{filter: filter, writer: csv.NewWriter(w)}
+	}
+	qm.mu.Lock()
+	qm.sink = sink
+	qm.mu.Unlock()
+}
+
+// writeSink 将满足条件的结果写入条件写入目标（未设置时不做任何事）
+func (qm *QueryManager) writeSink(result QueryResult) {
+	qm.mu.RLock()
+	sink := qm.sink
+	tokens := qm.tokens
+	qm.mu.RUnlock()
+	if sink == nil || !sink.filter(result) {
+		return
+	}
+
+	record := []string{result.Address, result.DisplayBalance()}
+	for _, token := range tokens[min(1, len(tokens)):] {
+		record = append(record, result.DisplayTokenBalance(token.Symbol))
+	}
+
+	sink.mu.Lock()
+	if sink.failed {
+		sink.mu.Unlock()
+		return
+	}
+	sink.writer.Write(record)
+	sink.writer.Flush()
+	err := sink.writer.Error()
+	sink.failed = err != nil
+	sink.mu.Unlock()
+
+	if err != nil {
+		qm.warn(fmt.Sprintf("实时写入结果失败，之后的结果不再写入: %v", err))
+	}
+}
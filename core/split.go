@@ -0,0 +1,45 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SplitAddressFiles 将地址按每份 size 个写入多个 TXT 文件（每行一个地址），返回写入的文件路径
+//
+// 文件名为 prefix 加序号，如 prefix 为 "out/part" 时写入 out/part_001.txt、out/part_002.txt…；
+// 序号位数按份数补零，便于按文件名排序后依次查询
+func SplitAddressFiles(addresses []string, size int, prefix string) ([]string, error) {
+	if size <= 0 {
+		return nil, errors.New("每份地址数必须大于 0")
+	}
+	if len(addresses) == 0 {
+		return nil, errors.New("没有可拆分的地址")
+	}
+
+	count := (len(addresses) + size - 1) / size
+	width := len(fmt.Sprint(count))
+	if width < 3 {
+		width = 3
+	}
+	if dir := filepath.Dir(prefix); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("创建目录失败: %v", err)
+		}
+	}
+
+	var paths []string
+	for i := 0; i < count; i++ {
+		end := min((i+1)*size, len(addresses))
+		path := fmt.Sprintf("%s_%0*d.txt", prefix, width, i+1)
+		content := strings.Join(addresses[i*size:end], "\n") + "\n"
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return paths, fmt.Errorf("写入文件失败: %v", err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
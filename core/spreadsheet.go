@@ -0,0 +1,242 @@
+package core
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// AddressCell 输入文件中被当作候选地址读取的一个单元格
+type AddressCell struct {
+	Row   int    // 行号（从 1 开始，与表格软件中显示的一致）；JSON 文件为数组元素序号
+	Ref   string // 单元格位置，如 "C2"；TXT、JSON 文件为空
+	Value string // 单元格内容（已去掉首尾空白和不可见字符）
+
+	Sanitized bool // 原内容中有零宽字符、BOM 等不可见字符（已去掉），见 SanitizeAddress
+
+	progress float64 // 读取到该单元格时输入文件已读取的比例（0~1），用于加载进度，见 LoadOptions.Progress
+}
+
+// isSpreadsheetFile 是否按表格读取（CSV 或 Excel）
+func isSpreadsheetFile(filepath string) bool {
+	lower := strings.ToLower(filepath)
+	return strings.HasSuffix(lower, ".csv") || strings.HasSuffix(lower, ".xlsx")
+}
+
+// isExcelFile 是否为 Excel 文件
+func isExcelFile(filepath string) bool {
+	return strings.HasSuffix(strings.ToLower(filepath), ".xlsx")
+}
+
+// readSpreadsheetRows 读取 CSV 或 Excel 的所有行
+// Excel 未指定工作表时读取第一个工作表；CSV 不支持指定工作表
+func readSpreadsheetRows(filepath, sheet string) ([][]string, error) {
+	if !isExcelFile(filepath) {
+		if sheet != "" {
+			return nil, fmt.Errorf("只有 Excel (.xlsx) 输入支持指定工作表，当前文件: %s", filepath)
+		}
+
+		file, err := os.Open(filepath)
+		if err != nil {
+			return nil, fmt.Errorf("打开文件失败: %v", err)
+		}
+		defer file.Close()
+
+		reader := csv.NewReader(file)
+		reader.FieldsPerRecord = -1
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("读取 CSV 失败: %v", err)
+		}
+		if len(rows) > 0 && len(rows[0]) > 0 {
+			rows[0][0] = strings.TrimPrefix(rows[0][0], "\ufeff") // 文件开头的 BOM 是编码标记，不是地址中的不可见字符
+		}
+		return rows, nil
+	}
+
+	f, err := excelize.OpenFile(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("打开 Excel 文件失败: %v", err)
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, errors.New("Excel 文件中没有工作表")
+	}
+	if sheet == "" {
+		sheet = sheets[0]
+	} else if idx, _ := f.GetSheetIndex(sheet); idx < 0 {
+		return nil, fmt.Errorf("工作表 %q 不存在（可选: %s）", sheet, strings.Join(sheets, ", "))
+	}
+
+	rows, err := f.GetRows(sheet)
+	if err != nil {
+		return nil, fmt.Errorf("读取工作表 %s 失败: %v", sheet, err)
+	}
+	return rows, nil
+}
+
+// resolveColumn 解析列参数，返回列下标（从 0 开始）和是否按表头名匹配
+//
+// 匹配规则（按顺序）：
+//  1. 与第一行某个单元格相同（不区分大小写）时按表头名匹配，第一行不作为数据读取
+//  2. 否则按列字母解析（A、C、AA），所有行都作为数据读取
+//  3. 都不匹配时返回错误
+func resolveColumn(header []string, column string) (int, bool, error) {
+	column = strings.TrimSpace(column)
+	for i, name := range header {
+		if strings.EqualFold(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")), column) {
+			return i, true, nil
+		}
+	}
+
+	if isColumnLetters(column) {
+		number, err := excelize.ColumnNameToNumber(column)
+		if err == nil {
+			return number - 1, false, nil
+		}
+	}
+	return 0, false, fmt.Errorf("列 %q 不存在：既不是表头中的列名，也不是有效的列字母（如 A、C）", column)
+}
+
+// isColumnLetters 是否为 1~3 个字母组成的列名
+func isColumnLetters(s string) bool {
+	if len(s) == 0 || len(s) > 3 {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// readAddressCells 按加载选项依次读取输入文件中的候选地址单元格
+// maxRows > 0 时只读取前 maxRows 个数据行（预览用）
+//
+// 读取规则：
+//   - 指定了列（LoadOptions.Column）时只读取该列，仅支持 CSV 和 Excel
+//   - 未指定列时，Excel 读取第一列，CSV 读取所有单元格，TXT 按行读取并支持逗号分隔
+//   - JSON 读取地址数组或对象数组，LoadOptions.Column 为对象中的地址字段名（默认 address），见 readJSONAddressCells
+//   - 所有格式的单元格内容都先去掉不可见字符（见 SanitizeAddress），并设置 AddressCell.Sanitized
+func readAddressCells(filepath string, opts LoadOptions, maxRows int, visit func(AddressCell)) error {
+	visitCell := visit
+	visit = func(cell AddressCell) {
+		cell.Value, cell.Sanitized = SanitizeAddress(cell.Value)
+		visitCell(cell)
+	}
+
+	if isJSONFile(filepath) {
+		if opts.Sheet != "" {
+			return fmt.Errorf("只有 Excel (.xlsx) 输入支持指定工作表，当前文件: %s", filepath)
+		}
+		return readJSONAddressCells(filepath, opts.Column, maxRows, visit)
+	}
+	if !isSpreadsheetFile(filepath) {
+		if opts.Column != "" || opts.Sheet != "" {
+			return fmt.Errorf("只有 CSV、Excel (.xlsx) 或 JSON 输入支持指定列，只有 Excel 支持指定工作表，当前文件: %s", filepath)
+		}
+		return readTextAddressCells(filepath, maxRows, visit)
+	}
+
+	rows, err := readSpreadsheetRows(filepath, opts.Sheet)
+	if err != nil {
+		return err
+	}
+
+	column, start := -1, 0
+	if opts.Column == "" && isExcelFile(filepath) {
+		column = 0 // Excel 默认只读第一列（与导出格式一致，避免把其他列的内容当作地址）
+	}
+	if opts.Column != "" {
+		if len(rows) == 0 {
+			return fmt.Errorf("文件为空，无法定位列 %q", opts.Column)
+		}
+		idx, byHeader, err := resolveColumn(rows[0], opts.Column)
+		if err != nil {
+			return err
+		}
+		column = idx
+		if byHeader {
+			start = 1
+		}
+	}
+
+	for i := start; i < len(rows); i++ {
+		if maxRows > 0 && i-start >= maxRows {
+			break
+		}
+		for j, value := range rows[i] {
+			if column >= 0 && j != column {
+				continue
+			}
+			ref, _ := excelize.CoordinatesToCellName(j+1, i+1)
+			visit(AddressCell{Row: i + 1, Ref: ref, Value: strings.TrimSpace(value), progress: float64(i+1) / float64(len(rows))})
+		}
+	}
+	return nil
+}
+
+// readTextAddressCells 按行读取 TXT 文件（每行一个地址，支持逗号分隔）
+func readTextAddressCells(filepath string, maxRows int, visit func(AddressCell)) error {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return fmt.Errorf("打开文件失败: %v", err)
+	}
+	defer file.Close()
+
+	var size int64
+	if info, err := file.Stat(); err == nil {
+		size = info.Size()
+	}
+	counter := &countingReader{r: file}
+	scanner := bufio.NewScanner(counter)
+	lineNo, dataRows := 0, 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if lineNo == 1 {
+			line = strings.TrimPrefix(line, "\ufeff") // 文件开头的 BOM 是编码标记，不是地址中的不可见字符
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if maxRows > 0 && dataRows >= maxRows {
+			break
+		}
+		dataRows++
+
+		progress := counter.fraction(size)
+		for _, part := range strings.Split(line, ",") {
+			visit(AddressCell{Row: lineNo, Value: strings.TrimSpace(part), progress: progress})
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("读取文件失败: %v", err)
+	}
+	return nil
+}
+
+// PreviewAddressCells 返回前 maxRows 个数据行中会被当作地址读取的单元格（用于 -dry-run 检查列映射）
+// 空单元格不返回；校验结果可用 tron.ValidateAddress 判断
+func PreviewAddressCells(filepath string, opts LoadOptions, maxRows int) ([]AddressCell, error) {
+	var cells []AddressCell
+	err := readAddressCells(filepath, opts, maxRows, func(cell AddressCell) {
+		if cell.Value != "" {
+			cells = append(cells, cell)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cells, nil
+}
@@ -0,0 +1,143 @@
+package core
+
+import "errors"
+
+// QueryState 查询管理器的运行状态
+//
+// 状态转换：Idle → Running → Done / Cancelled；Done 后可以再次查询，
+// Cancelled 后 context 不可恢复，继续查询需创建新的 QueryManager（GUI 的暂停和停止都是取消）
+type QueryState string
+
+const (
+	StateIdle      QueryState = "idle"      // 未开始
+	StateRunning   QueryState = "running"   // 查询中
+	StateDone      QueryState = "done"      // 已完成
+	StateCancelled QueryState = "cancelled" // 已取消（暂停或停止）
+)
+
+// String 返回状态的中文显示文案
+func (s QueryState) String() string {
+	switch s {
+	case StateIdle:
+		return "未开始"
+	case StateRunning:
+		return "查询中"
+	case StateDone:
+		return "已完成"
+	case StateCancelled:
+		return "已取消"
+	}
+	return string(s)
+}
+
+// FinishReason 查询结束的原因，见 QueryManager.FinishReason
+type FinishReason string
+
+const (
+	FinishNone          FinishReason = ""               // 尚未结束（未开始或查询中）
+	FinishCompleted     FinishReason = "completed"      // 全部地址查询完成
+	FinishCancelled     FinishReason = "cancelled"      // 用户取消（暂停或停止）
+	FinishKeysExhausted FinishReason = "keys_exhausted" // 所有 API Key 额度用完，自动暂停
+	FinishMemoryLimit   FinishReason = "memory_limit"   // 内存占用达到上限，自动暂停
+	FinishDeadline      FinishReason = "deadline"       // 达到查询时长上限
+)
+
+// String 返回结束原因的中文说明（如"因 Key 额度耗尽而停止"）
+func (r FinishReason) String() string {
+	switch r {
+	case FinishNone:
+		return "尚未结束"
+	case FinishCompleted:
+		return "全部查询完成"
+	case FinishCancelled:
+		return "因用户取消而停止"
+	case FinishKeysExhausted:
+		return "因 Key 额度耗尽而停止"
+	case FinishMemoryLimit:
+		return "因内存占用达到上限而停止"
+	case FinishDeadline:
+		return "因达到查询时长上限而停止"
+	}
+	return string(r)
+}
+
+var (
+	// ErrAlreadyRunning 查询进行中时再次调用 QueryAddresses
+	ErrAlreadyRunning = errors.New("查询正在进行中，不能重复开始")
+	// ErrQueryCancelled 查询管理器已取消，不能再开始查询
+	ErrQueryCancelled = errors.New("查询已取消，继续查询需创建新的查询管理器")
+)
+
+// State 返回当前运行状态
+func (qm *QueryManager) State() QueryState {
+	qm.mu.RLock()
+	defer qm.mu.RUnlock()
+	return qm.state
+}
+
+// beginLocked 检查能否开始查询并切换到 Running，调用方需持有 qm.mu
+func (qm *QueryManager) beginLocked() error {
+	switch qm.state {
+	case StateRunning:
+		return ErrAlreadyRunning
+	case StateCancelled:
+		return ErrQueryCancelled
+	}
+	if qm.ctx.Err() != nil {
+		qm.state = StateCancelled
+		return ErrQueryCancelled
+	}
+	qm.state = StateRunning
+	qm.finishReason = FinishNone
+	return nil
+}
+
+// finish 查询结束后按是否被取消切换到 Done 或 Cancelled，并记录结束原因（每次查询只记录一次）
+func (qm *QueryManager) finish() {
+	qm.mu.Lock()
+	defer qm.mu.Unlock()
+	if qm.ctx.Err() != nil {
+		qm.state = StateCancelled
+		qm.finishReason = qm.stopReasonLocked()
+	} else {
+		qm.state = StateDone
+		qm.finishReason = FinishCompleted
+	}
+	qm.summary.FinishReason = qm.finishReason
+}
+
+// FinishReason 返回最近一次查询结束的原因，查询中或未开始时为 FinishNone
+func (qm *QueryManager) FinishReason() FinishReason {
+	qm.mu.RLock()
+	defer qm.mu.RUnlock()
+	return qm.finishReason
+}
+
+// CancelWithReason 取消查询并记录原因；已经取消过时保留第一次的原因（如额度用完自动暂停后再点停止）
+func (qm *QueryManager) CancelWithReason(reason FinishReason) {
+	qm.mu.Lock()
+	if qm.stopReason == FinishNone {
+		qm.stopReason = reason
+	}
+	qm.mu.Unlock()
+	if qm.cancel != nil {
+		qm.cancel()
+	}
+
+	qm.mu.Lock()
+	if qm.state != StateRunning {
+		qm.state = StateCancelled
+		if qm.finishReason == FinishNone {
+			qm.finishReason = qm.stopReasonLocked()
+		}
+	}
+	qm.mu.Unlock()
+}
+
+// stopReasonLocked 返回取消的原因，没有通过 CancelWithReason 取消时视为用户取消
+func (qm *QueryManager) stopReasonLocked() FinishReason {
+	if qm.stopReason == FinishNone {
+		return FinishCancelled
+	}
+	return qm.stopReason
+}
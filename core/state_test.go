@@ -0,0 +1,140 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"usdt-balance-checker/tron"
+)
+
+// 同时开始和取消查询（配合 -race 运行）：只有占用了查询状态的查询才查询代币符号，
+// 被拒绝的查询（ErrAlreadyRunning、ErrQueryCancelled）不发送请求、不改写要查询的代币
+func TestQueryStartCancelRace(t *testing.T) {
+	contract := tron.USDCToken.Contract
+	addresses := testAddresses(5)
+
+	for round := 0; round < 30; round++ {
+		// 每轮使用新的节点，上一轮被取消的请求不计入本轮
+		var lookups atomic.Int32
+		srv := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+			var body struct {
+				FunctionSelector string `json:"function_selector"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			if body.FunctionSelector == "symbol()" {
+				lookups.Add(1)
+				time.Sleep(time.Millisecond) // 查询符号期间其他查询尝试开始
+			}
+			fmt.Fprint(w, balanceResponse)
+		})
+		qm := newTestManager(newTestKeyManager(t, 2), srv)
+		qm.SetTokens([]tron.Token{{Contract: contract, Decimals: 6}})
+
+		var started atomic.Int32
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := qm.QueryAddresses(addresses, nil)
+				switch {
+				case err == nil:
+					started.Add(1)
+				case !errors.Is(err, ErrAlreadyRunning) && !errors.Is(err, ErrQueryCancelled):
+					t.Error(err)
+				}
+			}()
+		}
+		if round%2 == 1 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				qm.Cancel()
+			}()
+		}
+		wg.Wait()
+
+		if got := lookups.Load(); got > started.Load() {
+			t.Fatalf("第 %d 轮: 查询符号 %d 次，只有 %d 个查询开始", round, got, started.Load())
+		}
+		for _, token := range qm.Tokens() {
+			if started.Load() > 0 && token.Symbol == "" {
+				t.Fatalf("第 %d 轮: 查询开始后代币符号仍为空", round)
+			}
+			if token.Contract != contract {
+				t.Fatalf("第 %d 轮: 要查询的代币被改写为 %+v", round, token)
+			}
+		}
+	}
+}
+
+// 每种结束原因：查询结束后 FinishReason 和汇总中的结束原因一致，之后再停止不改变第一次的原因
+func TestFinishReason(t *testing.T) {
+	cases := []struct {
+		name  string
+		limit int                 // 每个 Key 的上限，0 为默认
+		stop  func(*QueryManager) // 第一个余额请求到达后调用，nil 为不中断
+		state QueryState
+		want  FinishReason
+	}{
+		{"全部完成", 0, nil, StateDone, FinishCompleted},
+		{"用户取消", 0, (*QueryManager).Cancel, StateCancelled, FinishCancelled},
+		{"达到查询时长上限", 0, func(qm *QueryManager) { qm.CancelWithReason(FinishDeadline) }, StateCancelled, FinishDeadline},
+		{"Key 额度用完", 3, nil, StateCancelled, FinishKeysExhausted},
+		{"内存占用达到上限", 0, func(qm *QueryManager) { qm.checkMemory(1) }, StateCancelled, FinishMemoryLimit},
+	}
+	for _, c := range cases {
+		var qm *QueryManager
+		var once sync.Once
+		finished := make(chan struct{})
+		srv := newTestNode(t, func(w http.ResponseWriter, r *http.Request) {
+			if !strings.HasSuffix(r.URL.Path, "/triggerconstantcontract") {
+				fmt.Fprint(w, `{}`)
+				return
+			}
+			if c.stop != nil {
+				// 第一个请求中断查询，请求都等到查询结束（不返回结果）
+				once.Do(func() { c.stop(qm) })
+				select {
+				case <-r.Context().Done():
+				case <-finished:
+				}
+				return
+			}
+			fmt.Fprint(w, balanceResponse)
+		})
+		km := newTestKeyManager(t, 1)
+		if c.limit > 0 {
+			setKeyLimits(km, []int{c.limit}, []int{0}, []bool{true})
+		}
+		qm = newTestManager(km, srv)
+		qm.SetMaxConcurrent(2)
+
+		err := qm.QueryAddresses(testAddresses(6), nil)
+		close(finished)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if qm.State() != c.state || qm.FinishReason() != c.want || qm.GetSummary().FinishReason != c.want {
+			t.Errorf("%s: 状态 %v，结束原因 %q，汇总中为 %q；want %v，%q",
+				c.name, qm.State(), qm.FinishReason(), qm.GetSummary().FinishReason, c.state, c.want)
+		}
+		if c.want == FinishCompleted {
+			continue
+		}
+		if c.want.String() == string(c.want) {
+			t.Errorf("%s: 结束原因 %q 没有说明", c.name, c.want)
+		}
+		qm.Cancel()
+		if qm.FinishReason() != c.want {
+			t.Errorf("%s: 再次停止后结束原因变为 %q", c.name, qm.FinishReason())
+		}
+	}
+}
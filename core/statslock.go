@@ -0,0 +1,242 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// 统计文件锁：多个程序实例（如界面和定时运行的 CLI）共用一个 apikey_stats.json 时，
+// 只有持有锁的实例写统计文件；其他实例进入只读模式，使用次数记为增量，
+// 锁释放后在文件中的最新值上累加，退出时锁仍被占用则写入待合并文件，由持有锁的实例合并
+
+// statsLockRetryInterval 只读模式下重新尝试获取统计文件锁的间隔
+const statsLockRetryInterval = 5 * time.Second
+
+// statsLockGrace 刚创建、还没写入 PID 的锁文件视为有效的时长
+const statsLockGrace = 2 * time.Second
+
+// statsLockPath 返回统计文件锁的路径（与统计文件同目录，内容为持有者的 PID）
+func statsLockPath(statsPath string) string {
+	return statsPath + ".lock"
+}
+
+// pendingStatsPath 返回只读实例退出时写入的待合并文件路径（内容为各 Key 的使用增量）
+func pendingStatsPath(statsPath string, pid int) string {
+	return fmt.Sprintf("%s.pending.%d", statsPath, pid)
+}
+
+// lockStatsFile 尝试获取统计文件锁，成功（或本进程已持有）时返回 0，
+// 被其他仍在运行的进程持有时返回该进程的 PID；持有者已退出的残留锁会被清除
+func lockStatsFile(statsPath string) (int, error) {
+	lockPath := statsLockPath(statsPath)
+	for attempt := 0; attempt < 2; attempt++ {
+		file, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = fmt.Fprintf(file, "%d", os.Getpid())
+			file.Close()
+			if err != nil {
+				os.Remove(lockPath)
+				return 0, err
+			}
+			return 0, nil
+		}
+		if !os.IsExist(err) {
+			return 0, err
+		}
+
+		data, err := os.ReadFile(lockPath)
+		if err != nil {
+			return 0, err
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil {
+			// 其他进程刚创建锁文件、还没写入 PID
+			if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) < statsLockGrace {
+				return -1, nil
+			}
+		} else if pid == os.Getpid() {
+			return 0, nil
+		} else if processAlive(pid) {
+			return pid, nil
+		}
+		// 持有者已退出（或锁文件损坏）：清除残留的锁后重试
+		os.Remove(lockPath)
+	}
+	return 0, errors.New("获取统计文件锁失败")
+}
+
+// unlockStatsFile 释放本进程持有的统计文件锁
+func unlockStatsFile(statsPath string) {
+	lockPath := statsLockPath(statsPath)
+	data, err := os.ReadFile(lockPath)
+	if err == nil && strings.TrimSpace(string(data)) == strconv.Itoa(os.Getpid()) {
+		os.Remove(lockPath)
+	}
+}
+
+// readStatsFile 读取统计文件，文件不存在或无法解析时返回空记录
+func readStatsFile(path string) *KeyStatsFile {
+	stats := &KeyStatsFile{Keys: make(map[string]int), Names: make(map[string]string)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return stats
+	}
+	if err := json.Unmarshal(data, stats); err != nil {
+		return &KeyStatsFile{Keys: make(map[string]int), Names: make(map[string]string)}
+	}
+	if stats.Keys == nil {
+		stats.Keys = make(map[string]int)
+	}
+	if stats.Names == nil {
+		stats.Names = make(map[string]string)
+	}
+	return stats
+}
+
+// ownStatsFile 确认本实例可以写统计文件（持有锁），调用方需持有 statsMu
+// 锁被其他实例持有时进入只读模式并返回 false；无法创建锁文件（如目录只读）时按没有锁的方式直接写入
+func (m *APIKeyManager) ownStatsFile(statsPath string) bool {
+	if m.statsOwner {
+		return true
+	}
+	if m.statsLockOwner != 0 && time.Since(m.statsLockTried) < statsLockRetryInterval {
+		return false
+	}
+	m.statsLockTried = time.Now()
+
+	owner, err := lockStatsFile(statsPath)
+	if err != nil {
+		return true
+	}
+	if owner != 0 {
+		if m.statsLockOwner == 0 {
+			log.Warn(StatsLockedText(owner))
+			// 记录进入只读模式时的使用次数，之后的使用记为增量
+			m.mu.Lock()
+			m.statsBase = make(map[string]int, len(m.keys))
+			for _, keyInfo := range m.keys {
+				m.statsBase[keyInfo.Key] = keyInfo.Used
+			}
+			m.mu.Unlock()
+		}
+		m.statsLockOwner = owner
+		return false
+	}
+
+	m.statsOwner = true
+	if m.statsLockOwner != 0 {
+		// 锁已释放：在文件中的最新值上累加只读期间的使用次数
+		m.statsLockOwner = 0
+		stats := readStatsFile(statsPath)
+		m.mu.Lock()
+		for i := range m.keys {
+			keyInfo := &m.keys[i]
+			delta := keyInfo.Used - m.statsBase[keyInfo.Key]
+			keyInfo.Used = stats.Keys[keyInfo.Key] + delta
+		}
+		m.statsBase = nil
+		m.mu.Unlock()
+		log.Info("统计文件锁已释放，已合并本实例的使用次数\n")
+	}
+	return true
+}
+
+// mergePendingStats 合并只读实例退出时留下的使用增量，返回不属于当前 Key 的增量（保留到统计文件中）
+// 调用方需持有 statsMu 且持有统计文件锁
+func (m *APIKeyManager) mergePendingStats(statsPath string) map[string]int {
+	files, _ := filepath.Glob(statsPath + ".pending.*")
+	if len(files) == 0 {
+		return nil
+	}
+
+	others := make(map[string]int)
+	m.mu.Lock()
+	index := make(map[string]int, len(m.keys))
+	for i, keyInfo := range m.keys {
+		index[keyInfo.Key] = i
+	}
+	for _, file := range files {
+		pending := readStatsFile(file)
+		for key, delta := range pending.Keys {
+			if i, ok := index[key]; ok {
+				m.keys[i].Used += delta
+			} else {
+				others[key] += delta
+			}
+		}
+		os.Remove(file)
+	}
+	m.mu.Unlock()
+	return others
+}
+
+// writePendingStats 只读实例退出时，把本实例的使用增量写入待合并文件
+func (m *APIKeyManager) writePendingStats(statsPath string) error {
+	pending := KeyStatsFile{Keys: make(map[string]int)}
+	m.mu.RLock()
+	for _, keyInfo := range m.keys {
+		if delta := keyInfo.Used - m.statsBase[keyInfo.Key]; delta > 0 {
+			pending.Keys[keyInfo.Key] = delta
+		}
+	}
+	m.mu.RUnlock()
+	if len(pending.Keys) == 0 {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(pending, "", "  ")
+	if err != nil {
+		return fmt.Errorf("保存使用增量失败: %v", err)
+	}
+	if err := os.WriteFile(pendingStatsPath(statsPath, os.Getpid()), data, 0644); err != nil {
+		return fmt.Errorf("保存使用增量失败: %v", err)
+	}
+	return nil
+}
+
+// StatsLockedText 返回统计文件被其他实例占用时的提示文案
+func StatsLockedText(owner int) string {
+	holder := "另一个程序实例"
+	if owner > 0 {
+		holder = fmt.Sprintf("另一个程序实例（PID %d）", owner)
+	}
+	return fmt.Sprintf("统计文件 %s 正被%s使用，本实例的 Key 使用次数暂不写入，锁释放后或退出时自动合并", StatsFileName, holder)
+}
+
+// StatsLockOwner 返回持有统计文件锁的其他实例的 PID（未知时为 -1），本实例可以写统计文件时返回 0
+func (m *APIKeyManager) StatsLockOwner() int {
+	m.statsMu.Lock()
+	defer m.statsMu.Unlock()
+	return m.statsLockOwner
+}
+
+// CloseStats 程序退出时调用：写入统计并释放统计文件锁
+// 只读模式下如果锁已释放则直接合并写入，否则把本实例的使用增量写入待合并文件，由持有锁的实例下次保存时合并
+func (m *APIKeyManager) CloseStats() error {
+	if !m.StatsPersistenceEnabled() {
+		return nil
+	}
+	statsPath, err := getStatsPath()
+	if err != nil {
+		return err
+	}
+
+	m.statsMu.Lock()
+	defer m.statsMu.Unlock()
+	m.statsLockTried = time.Time{} // 立即重新尝试获取锁
+	if !m.ownStatsFile(statsPath) {
+		return m.writePendingStats(statsPath)
+	}
+	err = m.writeStats(statsPath)
+	unlockStatsFile(statsPath)
+	m.statsOwner = false
+	return err
+}
@@ -0,0 +1,94 @@
+package core
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// exitedPID 启动一个立即退出的子进程（测试程序本身，不运行任何测试），返回它的 PID
+func exitedPID(t *testing.T) int {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	return cmd.Process.Pid
+}
+
+// writeLockFile 写入统计文件锁，内容为 content
+func writeLockFile(t *testing.T, statsPath, content string) {
+	t.Helper()
+	if err := os.WriteFile(statsLockPath(statsPath), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLockStatsFile(t *testing.T) {
+	statsPath := filepath.Join(t.TempDir(), StatsFileName)
+
+	if owner, err := lockStatsFile(statsPath); err != nil || owner != 0 {
+		t.Fatalf("首次获取锁: owner = %d, err = %v", owner, err)
+	}
+	data, _ := os.ReadFile(statsLockPath(statsPath))
+	if string(data) != strconv.Itoa(os.Getpid()) {
+		t.Fatalf("锁文件内容 = %q, want 本进程 PID", data)
+	}
+	if owner, err := lockStatsFile(statsPath); err != nil || owner != 0 {
+		t.Fatalf("本进程再次获取锁: owner = %d, err = %v", owner, err)
+	}
+	unlockStatsFile(statsPath)
+	if _, err := os.Stat(statsLockPath(statsPath)); !os.IsNotExist(err) {
+		t.Fatalf("释放后锁文件仍存在: %v", err)
+	}
+}
+
+func TestLockStatsFileHeldByOther(t *testing.T) {
+	statsPath := filepath.Join(t.TempDir(), StatsFileName)
+	parent := os.Getppid() // 运行测试的 go 命令，测试期间一直在运行
+	writeLockFile(t, statsPath, strconv.Itoa(parent))
+
+	if owner, err := lockStatsFile(statsPath); err != nil || owner != parent {
+		t.Fatalf("owner = %d, err = %v, want %d", owner, err, parent)
+	}
+	// 不释放其他进程持有的锁
+	unlockStatsFile(statsPath)
+	if _, err := os.Stat(statsLockPath(statsPath)); err != nil {
+		t.Fatalf("其他进程的锁被释放: %v", err)
+	}
+}
+
+func TestLockStatsFileStale(t *testing.T) {
+	cases := map[string]string{
+		"持有者已退出": strconv.Itoa(exitedPID(t)),
+		"锁文件损坏":  "not-a-pid",
+	}
+	for name, content := range cases {
+		statsPath := filepath.Join(t.TempDir(), StatsFileName)
+		writeLockFile(t, statsPath, content)
+		// 损坏的锁文件超过宽限期才视为残留
+		old := time.Now().Add(-2 * statsLockGrace)
+		if err := os.Chtimes(statsLockPath(statsPath), old, old); err != nil {
+			t.Fatal(err)
+		}
+		if owner, err := lockStatsFile(statsPath); err != nil || owner != 0 {
+			t.Fatalf("%s: owner = %d, err = %v, want 清除残留锁后获取成功", name, owner, err)
+		}
+		data, _ := os.ReadFile(statsLockPath(statsPath))
+		if string(data) != strconv.Itoa(os.Getpid()) {
+			t.Fatalf("%s: 锁文件内容 = %q, want 本进程 PID", name, data)
+		}
+	}
+}
+
+// 其他进程刚创建、还没写入 PID 的锁文件视为被占用（持有者未知）
+func TestLockStatsFileBeingCreated(t *testing.T) {
+	statsPath := filepath.Join(t.TempDir(), StatsFileName)
+	writeLockFile(t, statsPath, "")
+	if owner, err := lockStatsFile(statsPath); err != nil || owner != -1 {
+		t.Fatalf("owner = %d, err = %v, want -1", owner, err)
+	}
+}
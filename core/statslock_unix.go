@@ -0,0 +1,17 @@
+//go:build !windows
+
+package core
+
+import (
+	"errors"
+	"syscall"
+)
+
+// processAlive 判断进程是否仍在运行（发送信号 0，无权限时说明进程存在）
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
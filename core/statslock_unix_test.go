@@ -0,0 +1,28 @@
+//go:build !windows
+
+package core
+
+import (
+	"os"
+	"testing"
+)
+
+func TestProcessAliveUnix(t *testing.T) {
+	cases := []struct {
+		name string
+		pid  int
+		want bool
+	}{
+		{"本进程", os.Getpid(), true},
+		{"父进程", os.Getppid(), true},
+		{"init（非 root 时为 EPERM）", 1, true},
+		{"已退出的进程", exitedPID(t), false},
+		{"PID 0", 0, false},
+		{"负数 PID", -1, false},
+	}
+	for _, c := range cases {
+		if got := processAlive(c.pid); got != c.want {
+			t.Errorf("%s: processAlive(%d) = %v, want %v", c.name, c.pid, got, c.want)
+		}
+	}
+}
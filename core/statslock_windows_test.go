@@ -0,0 +1,27 @@
+//go:build windows
+
+package core
+
+import (
+	"os"
+	"testing"
+)
+
+func TestProcessAliveWindows(t *testing.T) {
+	cases := []struct {
+		name string
+		pid  int
+		want bool
+	}{
+		{"本进程", os.Getpid(), true},
+		{"父进程", os.Getppid(), true},
+		{"System 进程（无权限打开时为 ERROR_ACCESS_DENIED）", 4, true},
+		{"已退出的进程", exitedPID(t), false},
+		{"PID 0", 0, false},
+	}
+	for _, c := range cases {
+		if got := processAlive(c.pid); got != c.want {
+			t.Errorf("%s: processAlive(%d) = %v, want %v", c.name, c.pid, got, c.want)
+		}
+	}
+}
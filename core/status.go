@@ -0,0 +1,29 @@
+package core
+
+// ResultStatus 查询结果状态
+type ResultStatus string
+
+const (
+	StatusPending   ResultStatus = "pending"   // 待查询
+	StatusSuccess   ResultStatus = "success"   // 成功
+	StatusError     ResultStatus = "error"     // 失败
+	StatusCancelled ResultStatus = "cancelled" // 已取消
+	StatusSkipped   ResultStatus = "skipped"   // 已跳过（如被排除的合约地址）
+	StatusInvalid   ResultStatus = "invalid"   // 无效地址（导入时保留，不发送请求）
+)
+
+// Label 返回状态在指定语言下的显示文案（与导出的状态列相同，见 labelsFor），未知状态返回内部值
+func (s ResultStatus) Label(lang ExportLanguage) string {
+	return labelsFor(lang).statusText(s)
+}
+
+// String 返回状态的中文显示文案（界面使用），同 Label(LangChinese)；导出和 CLI 按语言使用 Label
+func (s ResultStatus) String() string {
+	return s.Label(LangChinese)
+}
+
+// IsFinal 是否为最终状态（成功/失败/跳过/无效）
+// 暂停后继续查询时，处于最终状态的地址不会重新查询
+func (s ResultStatus) IsFinal() bool {
+	return s == StatusSuccess || s == StatusError || s == StatusSkipped || s == StatusInvalid
+}
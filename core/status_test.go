@@ -0,0 +1,58 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResultStatusLabel(t *testing.T) {
+	cases := []struct {
+		status ResultStatus
+		zh, en string
+	}{
+		{StatusSuccess, "成功", "Success"},
+		{StatusError, "失败", "Failed"},
+		{StatusInvalid, "无效地址", "Invalid"},
+		{ResultStatus("unknown"), "unknown", "unknown"},
+	}
+	for _, c := range cases {
+		if got := c.status.Label(LangChinese); got != c.zh {
+			t.Errorf("%s.Label(zh) = %q, want %q", c.status, got, c.zh)
+		}
+		if got := c.status.Label(LangEnglish); got != c.en {
+			t.Errorf("%s.Label(en) = %q, want %q", c.status, got, c.en)
+		}
+		if got := c.status.String(); got != c.zh {
+			t.Errorf("%s.String() = %q, want %q", c.status, got, c.zh)
+		}
+	}
+}
+
+// 模板的 .StatusText 按导出语言和自定义文案输出
+func TestExportWithTemplateStatusText(t *testing.T) {
+	results := []QueryResult{{Address: "TA", Status: StatusSuccess}, {Address: "TB", Status: StatusError}}
+	const tmpl = "{{.Address}},{{.StatusText}},{{statusCode .Status}}\n"
+
+	cases := map[string]struct {
+		opts ExportOptions
+		want string
+	}{
+		"默认中文":  {ExportOptions{}, "TA,成功,success\nTB,失败,error\n"},
+		"英文":    {ExportOptions{Language: LangEnglish}, "TA,Success,success\nTB,Failed,error\n"},
+		"自定义文案": {ExportOptions{Language: LangEnglish, StatusLabels: map[ResultStatus]string{StatusError: "NG"}}, "TA,Success,success\nTB,NG,error\n"},
+	}
+	for name, c := range cases {
+		path := filepath.Join(t.TempDir(), "out.txt")
+		if err := ExportWithTemplateOptions(results, tmpl, path, c.opts); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != c.want {
+			t.Errorf("%s: 输出 %q, want %q", name, data, c.want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// RunSummary 一次查询任务的汇总信息（用于界面显示和导出元数据）
+type RunSummary struct {
+	BlockNumber   int64     // 查询开始时节点的最新块高（获取失败时为 0）
+	BlockTime     time.Time // 块时间（UTC）；获取失败时为本地时钟时间
+	BlockFallback bool      // 是否因获取块信息失败而回退为本地时间
+	StartTime     time.Time // 查询开始时间
+	EndTime       time.Time // 查询结束时间
+	Total         int       // 地址总数
+	Success       int       // 成功数量
+	Failed        int       // 失败数量
+	InputHash     string    // 输入地址列表的指纹，见 InputHash
+	Retries       int       // 消耗的重试次数
+	RetryBudget   int       // 重试预算总数（<0 表示不限制）
+
+	FailedByKind []FailureCount  // 按错误类别统计的失败数量，见 FailureBreakdown
+	Tokens       []string        // 查询的代币（名称和合约地址），见 QueryManager.SetTokensCallback
+	AutoRetries  []AutoRetryPass // 每轮自动重试的结果，见 QueryManager.SetAutoRetry
+
+	AutoThreads          int  // 自动线程数结束时的值，未开启时为 0，见 QueryManager.SetAutoThreads
+	AutoThreadsConverged bool // 自动线程数是否已收敛
+
+	KeyUsage []KeyUsage // 每个 Key 本次查询和累计的使用次数，见 QueryManager.KeyUsage
+
+	FinishReason FinishReason // 查询结束的原因，见 QueryManager.FinishReason
+}
+
+// RetryText 返回重试消耗 / 预算，例如 "120 / 2000"
+func (s RunSummary) RetryText() string {
+	return s.retryText(chineseSummaryLabels)
+}
+
+// retryText 按 labels 的语言返回重试消耗 / 预算
+func (s RunSummary) retryText(labels summaryLabels) string {
+	if s.RetryBudget < 0 {
+		return fmt.Sprintf(labels.retriesUnlimited, s.Retries)
+	}
+	return fmt.Sprintf("%d / %d", s.Retries, s.RetryBudget)
+}
+
+// BaselineText 返回数据基准描述，例如 "数据基准: 块高 61,234,567 (2024-06-03 14:20 UTC)"
+func (s RunSummary) BaselineText() string {
+	return s.baselineText(chineseSummaryLabels)
+}
+
+// baselineText 按 labels 的语言返回数据基准描述
+func (s RunSummary) baselineText(labels summaryLabels) string {
+	if s.BlockFallback || s.BlockNumber == 0 {
+		if s.BlockTime.IsZero() {
+			return labels.baselineUnknown
+		}
+		return fmt.Sprintf(labels.baselineLocal, s.BlockTime.UTC().Format("2006-01-02 15:04 UTC"))
+	}
+	return fmt.Sprintf(labels.baselineBlock, formatThousands(s.BlockNumber), s.BlockTime.UTC().Format("2006-01-02 15:04 UTC"))
+}
+
+// formatThousands 将整数格式化为千分位形式（如 61234567 -> 61,234,567）
+func formatThousands(n int64) string {
+	str := strconv.FormatInt(n, 10)
+	sign := ""
+	if n < 0 {
+		sign = "-"
+		str = str[1:]
+	}
+
+	out := make([]byte, 0, len(str)+len(str)/3)
+	for i := range str {
+		if i > 0 && (len(str)-i)%3 == 0 {
+			out = append(out, ',')
+		}
+		out = append(out, str[i])
+	}
+	return sign + string(out)
+}
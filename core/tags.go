@@ -0,0 +1,151 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// TagsFileName 地址标签文件名（与统计文件保存在同一目录）
+const TagsFileName = "tags.json"
+
+// MaxTagLength 标签的最大长度（字符数）
+const MaxTagLength = 50
+
+// Tags 用户为地址设置的标签（如"交易所"、"可疑"），按地址记录，之后的查询结果按地址自动带上标签
+// 每次修改后立即保存到标签文件，下次启动时恢复；界面和命令行共用
+type Tags struct {
+	mu   sync.RWMutex
+	tags map[string]string // 地址 -> 标签
+}
+
+// tagsFile 标签文件结构
+type tagsFile struct {
+	Tags map[string]string `json:"tags"`
+}
+
+// GetTagsFilePath 获取标签文件路径
+func GetTagsFilePath() (string, error) {
+	return appFilePath(TagsFileName)
+}
+
+// LoadTags 读取保存的标签，文件不存在时返回空标签
+func LoadTags() (*Tags, error) {
+	t := &Tags{tags: make(map[string]string)}
+
+	path, err := GetTagsFilePath()
+	if err != nil {
+		return t, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return t, nil
+	}
+	if err != nil {
+		return t, fmt.Errorf("读取标签失败: %v", err)
+	}
+
+	var file tagsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return t, fmt.Errorf("解析标签失败: %v", err)
+	}
+	for addr, tag := range file.Tags {
+		// 标签文件可能被手动编辑过，按 sanitizeTag 清理
+		if tag = sanitizeTag(tag); tag != "" {
+			t.tags[addr] = tag
+		}
+	}
+	return t, nil
+}
+
+// Get 返回地址的标签，没有标签时为空（t 为 nil 时同样返回空）
+func (t *Tags) Get(address string) string {
+	if t == nil {
+		return ""
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.tags[address]
+}
+
+// Count 返回有标签的地址数
+func (t *Tags) Count() int {
+	if t == nil {
+		return 0
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.tags)
+}
+
+// Set 设置地址的标签并保存，tag 为空时删除该地址的标签
+func (t *Tags) Set(address, tag string) error {
+	tag = strings.TrimSpace(tag)
+	if strings.ContainsAny(tag, "\r\n") {
+		return errors.New("标签不能包含换行")
+	}
+	if len([]rune(tag)) > MaxTagLength {
+		return fmt.Errorf("标签过长（最多 %d 个字符）", MaxTagLength)
+	}
+	tag = sanitizeTag(tag)
+
+	t.mu.Lock()
+	defer t.mu.Unlock() // 保存时仍持有锁，避免并发修改时旧内容覆盖新内容
+	if tag == "" {
+		delete(t.tags, address)
+	} else {
+		t.tags[address] = tag
+	}
+	return saveTags(t.tags)
+}
+
+// Apply 按标签设置每个结果的 Tag（没有标签的清空），返回有标签的行数
+func (t *Tags) Apply(results []QueryResult) int {
+	tagged := 0
+	for i := range results {
+		results[i].Tag = t.Get(results[i].Address)
+		if results[i].Tag != "" {
+			tagged++
+		}
+	}
+	return tagged
+}
+
+// sanitizeTag 清理不是在界面中输入的标签（标签文件、关注列表）：去掉不可见的格式字符（见 SanitizeAddress），
+// 换行、制表符等控制字符换成空格，去掉首尾空白，超过 MaxTagLength 的部分截断。
+// 以公式字符开头的标签在导出时处理（见 neutralizeFormula）
+func sanitizeTag(tag string) string {
+	tag = strings.TrimSpace(strings.Map(func(r rune) rune {
+		switch {
+		case isInvisible(r):
+			return -1
+		case unicode.IsControl(r):
+			return ' '
+		}
+		return r
+	}, tag))
+	if runes := []rune(tag); len(runes) > MaxTagLength {
+		tag = strings.TrimSpace(string(runes[:MaxTagLength]))
+	}
+	return tag
+}
+
+// saveTags 将标签写入标签文件（JSON 按地址排序，便于查看和比较）
+func saveTags(tags map[string]string) error {
+	data, err := json.MarshalIndent(tagsFile{Tags: tags}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("保存标签失败: %v", err)
+	}
+	path, err := GetTagsFilePath()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("保存标签失败: %v", err)
+	}
+	return nil
+}
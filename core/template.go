@@ -0,0 +1,86 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// templateRow 模板中每个结果可用的数据：QueryResult 的全部字段（如 .Address、.Balance、.Status、.Error、
+// .TokenBalances），导出语言的状态文案 .StatusText，以及行号 .Index（从 1 开始）和结果总数 .Total
+type templateRow struct {
+	QueryResult
+	StatusText string
+	Index      int
+	Total      int
+}
+
+// templateFuncs 模板中可用的函数
+var templateFuncs = template.FuncMap{
+	"statusCode": func(s ResultStatus) string { return string(s) }, // 状态代码（如 success），.Status 直接输出为中文文案，按导出语言输出用 .StatusText
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"join":       strings.Join,
+}
+
+// ParseExportTemplate 解析导出模板（Go text/template 语法），用于查询前提前检查模板是否有误
+func ParseExportTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("export").Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("模板格式错误: %v", err)
+	}
+	return tmpl, nil
+}
+
+// ExportWithTemplate 使用 Go text/template 模板导出结果，对每个结果渲染一次模板
+//
+// 模板数据见 templateRow，例如 "{{.Address}};{{.Balance}};{{statusCode .Status}}"；
+// 渲染结果不以换行结尾时自动补一个换行。需要表头时可以写 {{if eq .Index 1}}表头{{"\n"}}{{end}}。
+// 渲染失败时删除已写入的文件并返回出错的行号
+func ExportWithTemplate(results []QueryResult, tmpl string, filepath string) error {
+	return ExportWithTemplateOptions(results, tmpl, filepath, ExportOptions{})
+}
+
+// ExportWithTemplateOptions 同 ExportWithTemplate，.StatusText 按 opts.Language 和 opts.StatusLabels 输出（其他选项不使用）
+func ExportWithTemplateOptions(results []QueryResult, tmpl string, filepath string, opts ExportOptions) error {
+	labels := labelsFor(opts.Language).withStatuses(opts.StatusLabels)
+	t, err := ParseExportTemplate(tmpl)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(filepath)
+	if err != nil {
+		return fmt.Errorf("创建文件失败: %v", err)
+	}
+	writer := bufio.NewWriter(file)
+
+	var buf bytes.Buffer
+	for i, result := range results {
+		buf.Reset()
+		if err := t.Execute(&buf, templateRow{QueryResult: result, StatusText: labels.statusText(result.Status), Index: i + 1, Total: len(results)}); err != nil {
+			file.Close()
+			os.Remove(filepath)
+			return fmt.Errorf("渲染模板失败（第 %d 行，地址 %s）: %v", i+1, result.Address, err)
+		}
+		if buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		if _, err := writer.Write(buf.Bytes()); err != nil {
+			file.Close()
+			return fmt.Errorf("写入文件失败: %v", err)
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("写入文件失败: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("写入文件失败: %v", err)
+	}
+	return nil
+}
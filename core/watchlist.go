@@ -0,0 +1,85 @@
+package core
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"usdt-balance-checker/tron"
+)
+
+// Watchlist 关注列表：需要重点关注的地址及其标签，匹配的结果标记为 Flagged
+type Watchlist struct {
+	tags map[string]string // 地址 -> 标签（可为空）
+}
+
+// LoadWatchlist 从文本或 CSV 文件加载关注列表
+//
+// 每行一个地址，地址后可跟一个标签（用逗号、制表符或空格分隔），如 "TXxx...,交易所热钱包"；
+// 空行、# 开头的注释行和无效地址（如表头）被忽略，同一地址出现多次时使用最后的标签；标签按 sanitizeTag 清理
+func LoadWatchlist(path string) (*Watchlist, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开关注列表失败: %v", err)
+	}
+	defer file.Close()
+
+	w := &Watchlist{tags: make(map[string]string)}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		address, tag := line, ""
+		if i := strings.IndexAny(line, ",\t "); i >= 0 {
+			address, tag = line[:i], sanitizeTag(strings.Trim(strings.TrimSpace(line[i+1:]), `"`))
+		}
+		address = strings.Trim(address, `"`)
+		if !tron.ValidateAddress(address) {
+			continue
+		}
+		w.tags[address] = tag
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取关注列表失败: %v", err)
+	}
+	if len(w.tags) == 0 {
+		return nil, errors.New("关注列表中没有找到有效的 TRON 地址")
+	}
+	return w, nil
+}
+
+// Count 返回关注的地址数
+func (w *Watchlist) Count() int {
+	if w == nil {
+		return 0
+	}
+	return len(w.tags)
+}
+
+// Lookup 返回地址是否在关注列表中及其标签
+func (w *Watchlist) Lookup(address string) (tag string, ok bool) {
+	if w == nil {
+		return "", false
+	}
+	tag, ok = w.tags[address]
+	return tag, ok
+}
+
+// Apply 按关注列表设置每个结果的 Flagged 和 WatchTag（不在列表中的清除标记），返回标记的行数
+// w 为 nil 时清除所有标记
+func (w *Watchlist) Apply(results []QueryResult) int {
+	flagged := 0
+	for i := range results {
+		tag, ok := w.Lookup(results[i].Address)
+		results[i].Flagged = ok
+		results[i].WatchTag = tag
+		if ok {
+			flagged++
+		}
+	}
+	return flagged
+}
@@ -6,6 +6,7 @@ require (
 	fyne.io/fyne/v2 v2.7.0
 	github.com/btcsuite/btcutil v1.0.2
 	github.com/ethereum/go-ethereum v1.16.7
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 	github.com/xuri/excelize/v2 v2.8.1
 )
 
@@ -2,24 +2,30 @@ package main
 
 import (
 	"flag"
+	"os"
+	"strings"
+
+	"usdt-balance-checker/core"
+	"usdt-balance-checker/tron"
 	"usdt-balance-checker/view"
 
 	"fyne.io/fyne/v2/app"
 )
 
 func main() {
-	cliMode := flag.Bool("cli", false, "运行在 CLI 模式")
-	inputFile := flag.String("input", "", "输入文件路径 (TXT/CSV)")
-	outputFile := flag.String("output", "results.csv", "输出文件路径 (CSV/Excel)")
-	apiKey := flag.String("api-key", "", "TronGrid API Key (可选)")
-	nodeURL := flag.String("node-url", "", "自定义 TRON 节点 URL (可选)")
-	rateLimit := flag.Int("rate", 12, "每秒请求数 (默认: 12)")
+	// 子命令：query、convert、merge、validate、split，见 runCommand
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		runCommand(os.Args[1], os.Args[2:])
+		return
+	}
 
+	cliMode := flag.Bool("cli", false, "运行在 CLI 模式（等同于 query 子命令）")
+	options := defineQueryFlags(flag.CommandLine)
 	flag.Parse()
 
 	if *cliMode {
 		// CLI 模式
-		view.RunCLI(*inputFile, *outputFile, *apiKey, *nodeURL, *rateLimit)
+		view.RunCLI(options())
 	} else {
 		// GUI 模式
 		myApp := app.NewWithID("usdt.balance.checker")
@@ -28,3 +34,123 @@ func main() {
 		myApp.Run()
 	}
 }
+
+// defineQueryFlags 在 fs 上定义查询使用的命令行标志，返回在 fs 解析后生成 CLI 选项的函数
+func defineQueryFlags(fs *flag.FlagSet) func() view.CLIOptions {
+	inputFile := fs.String("input", "", "输入文件路径 (TXT/CSV/XLSX/JSON)")
+	outputFile := fs.String("output", "results.csv", "输出文件路径 (CSV/Excel)")
+	apiKey := fs.String("api-key", "", "TronGrid API Key (可选)")
+	nodeURL := fs.String("node-url", "", "自定义 TRON 节点地址或网关前缀，如 https://gw.example.com/tron (可选)")
+	rateLimit := fs.Int("rate", 12, "每秒请求数 (默认: 12)")
+	threads := fs.Int("threads", 1, "并发线程数 (默认: 1)；配合 -auto-threads 时为自动调整的上限")
+	autoThreads := fs.Bool("auto-threads", false, "自动调整线程数：从 2 开始，成功率高时增加、出现 429 时减少，结束时输出收敛的值 (上限为 -threads，未指定时为 20)")
+	startIndex := fs.Int("start-index", 0, "跳过前 N 个已加载的地址，从第 N+1 个开始查询")
+	resumeFrom := fs.String("resume", "", "上次的结果文件：跳过其中已完成的地址，从 -start-index 之后第一个未完成的地址继续查询 (可选)")
+	shuffle := fs.Bool("shuffle", false, "打乱查询顺序（结果仍按输入顺序导出）")
+	contractFilter := fs.String("contract-filter", "", "合约地址检查: flag 标记合约, exclude 跳过合约 (可选，每个地址额外一次请求)")
+	verifyFile := fs.String("verify", "", "校验 -input 文件是否与该结果文件记录的输入指纹一致（不执行查询）")
+	gsheetID := fs.String("gsheet-id", "", "同时导出到该 Google 表格 ID (可选，需配合 -gsheet-credentials)")
+	gsheetCreds := fs.String("gsheet-credentials", "", "Google 服务账号凭证 JSON 文件路径")
+	keepDuplicates := fs.Bool("keep-duplicates", false, "保留输入中的重复地址，每行输出一个结果（同一地址只查询一次）")
+	keepInvalid := fs.Bool("keep-invalid", false, "保留输入中的无效地址，结果中标记为无效（便于审计对照）")
+	lang := fs.String("lang", "zh", "导出表头和状态文案的语言: zh 中文, en 英文")
+	splitFiles := fs.Bool("split-files", false, "Excel 超过 1,048,576 行上限时拆分为多个文件（默认拆分为多个工作表）")
+	compareWith := fs.String("compare-with", "", "上次的结果文件（CSV 或 Excel），指定后输出文件只包含余额发生变化的地址及新旧余额")
+	onlyNonzero := fs.String("only-previously-nonzero", "", "上次的结果文件 (CSV 或 Excel)，只重新查询其中有余额的地址，上次余额为 0 的跳过 (新增地址和上次失败的地址仍查询)")
+	sheet := fs.String("sheet", "", "Excel 输入的工作表名称 (默认第一个工作表)")
+	column := fs.String("column", "", "只读取 CSV/Excel 输入中的该列：表头名 (如 wallet_address) 或列字母 (如 C)；JSON 输入为对象中的地址字段名 (默认 address)")
+	dryRun := fs.Bool("dry-run", false, "只打印前 10 行被识别为地址的单元格，不执行查询")
+	noStats := fs.Bool("no-stats", false, "不读写 API Key 使用统计文件 (apikey_stats.json)，使用次数只在本次运行中有效")
+	paceKeys := fs.Bool("pace-keys", false, "平滑使用额度：按剩余额度和距离每日重置 (UTC 零点) 的时间给每个 Key 限速")
+	allowFormulas := fs.Bool("allow-formulas", false, "导出时原样保留以 = + - @ 开头的单元格 (默认在前面加单引号，防止在 Excel 中打开时被当作公式执行)")
+	rawHex := fs.Bool("raw-hex", false, "导出时增加一列节点返回的原始 hex 值 (constant_result[0])，用于审计")
+	tokens := fs.String("tokens", "", "要查询的代币，逗号分隔 (默认 USDT)：内置 USDT,USDC,USDD，或自定义 符号:合约地址:小数位数、合约地址:小数位数 (自动查询符号)")
+	profile := fs.String("profile", "", "使用已保存的配置方案 (profiles.json)：速率、线程数、节点、代币，命令行参数优先")
+	openOutput := fs.Bool("open", false, "导出完成后用系统默认程序打开结果文件")
+	rpcBatch := fs.Int("rpc-batch", 0, "使用节点 /jsonrpc 批量调用，每批查询 N 个地址 (最多 100，默认 0 逐个查询；不支持时自动改为逐个查询)")
+	ownerAddress := fs.String("owner-address", "", "余额查询固定使用的 owner_address (如黑洞地址 "+tron.BurnAddress+")，默认使用被查询的地址；部分节点查询从未上链的地址失败时使用")
+	maxAddresses := fs.Int("max-addresses", 0, "最多读取的地址数，超过时报错退出 (默认 0 不限制)，防止误用超大文件")
+	keepAlive := fs.Duration("keep-alive", 0, "查询中空闲超过该时长时 Ping 节点保持连接，如 30s (默认 0 关闭；适合长时间、限流较多的查询)")
+	autoRetry := fs.Int("auto-retry", 0, "查询结束后自动重新查询失败的地址，最多 N 轮，每轮之前等待 5s、10s… (默认 0 不自动重试，最多 10 轮)")
+	memoryLimit := fs.String("memory-limit", "", "内存守护上限，如 4GB、512MB：接近上限时切换到低内存模式，达到上限时自动暂停并导出已完成的结果 (默认使用 GOMEMLIMIT 环境变量，未设置时不开启)")
+	retryPolicy := fs.String("retry-policy", "", "按错误类别设置重试次数，逗号分隔的 类别=次数，如 rate-limited=5,timeout=3,network=2,server=2,invalid=0 (默认限流、超时、网络错误、5xx 各 2 次，其他不重试)")
+	timeout := fs.Duration("timeout", 0, "查询的总时长上限，如 30m；到时停止查询，导出已完成的部分结果并以退出码 3 退出 (默认 0 不限制)")
+	keyInterval := fs.Duration("key-min-interval", 0, "同一个 API Key 两次请求的最小间隔，如 100ms (默认 0 不限制；适合对突发请求敏感的免费 Key)")
+	qrDir := fs.String("qr-dir", "", "为有余额的地址在该目录生成地址二维码图片 (地址.png)，便于扫码核对")
+	keyRate := fs.Int("key-rate", core.DefaultKeyRateLimit, "每个 API Key 每秒请求数上限 (与 -rate 同时生效；0 不限制)")
+	statusLabels := fs.String("status-labels", "", "自定义导出的状态文案，逗号分隔的 状态=文案，如 success=OK,error=Failed (状态: pending, success, error, cancelled, skipped, invalid)")
+	watchlist := fs.String("watchlist", "", "关注列表文件 (每行一个地址，可跟逗号分隔的标签)，匹配的地址在导出中增加\"关注\"列")
+	mergeFiles := fs.String("merge", "", "合并多个结果文件 (逗号分隔，如 a.csv,b.csv)，按地址去重后导出到 -output，不执行查询")
+	templateFile := fs.String("template", "", "导出模板文件 (Go text/template)，对每个结果渲染一次，如 {{.Address}};{{.Balance}}，指定后不再按 -output 扩展名导出 CSV/Excel")
+	errorLog := fs.String("error-log", "", "将失败地址的完整错误信息追加写入该文件 (每行: 时间、地址、错误类别、错误信息)，结果和导出中的错误信息按 -max-error-length 截断")
+	maxErrorLength := fs.Int("max-error-length", core.DefaultMaxErrorLength, "结果和导出中错误信息的最大字符数，超过时截断 (-1 不截断)")
+	balanceSink := fs.String("balance-sink", "", "查询中每查到一个有余额的地址立即追加写入该文件 (CSV：地址,余额，不写表头)，不必等全部完成")
+	alertRules := fs.String("alert-rules", "", "告警规则文件 (每行: 地址,阈值；阈值前加 < 表示低于该值告警)，查询后余额跨越阈值的地址写入告警文件")
+	alertOutput := fs.String("alert-output", "", "告警文件路径 (.csv 或 .xlsx，默认为输出文件名加 _alerts.csv)，需要 -alert-rules")
+	showTotal := fs.Bool("sum", false, "查询完成时在汇总中显示成功地址的余额合计 (大数精确计算，重复地址只计一次)")
+	streamJSONL := fs.Bool("stream-jsonl", false, "每完成一个地址立即向 stdout 输出一行 JSON，进度和日志输出到 stderr")
+
+	return func() view.CLIOptions {
+		// 命令行中显式指定的参数（优先于配置方案）
+		explicitFlags := make(map[string]bool)
+		fs.Visit(func(f *flag.Flag) {
+			explicitFlags[f.Name] = true
+		})
+
+		return view.CLIOptions{
+			InputFile:      *inputFile,
+			OutputFile:     *outputFile,
+			APIKey:         *apiKey,
+			NodeURL:        *nodeURL,
+			RateLimit:      *rateLimit,
+			Threads:        *threads,
+			AutoThreads:    *autoThreads,
+			ContractFilter: *contractFilter,
+			Shuffle:        *shuffle,
+			StartIndex:     *startIndex,
+			ResumeFrom:     *resumeFrom,
+			VerifyResults:  *verifyFile,
+			GoogleSheetID:  *gsheetID,
+			GoogleCredFile: *gsheetCreds,
+			StreamJSONL:    *streamJSONL,
+			KeepDuplicates: *keepDuplicates,
+			KeepInvalid:    *keepInvalid,
+			Language:       *lang,
+			SplitFiles:     *splitFiles,
+			CompareWith:    *compareWith,
+			OnlyNonzero:    *onlyNonzero,
+			Sheet:          *sheet,
+			Column:         *column,
+			DryRun:         *dryRun,
+			NoStats:        *noStats,
+			PaceKeys:       *paceKeys,
+			RawHex:         *rawHex,
+			AllowFormulas:  *allowFormulas,
+			Tokens:         *tokens,
+			Open:           *openOutput,
+			RPCBatch:       *rpcBatch,
+			MergeFiles:     *mergeFiles,
+			OwnerAddress:   *ownerAddress,
+			MaxAddresses:   *maxAddresses,
+			Watchlist:      *watchlist,
+			AlertRules:     *alertRules,
+			AlertOutput:    *alertOutput,
+			StatusLabels:   *statusLabels,
+			TemplateFile:   *templateFile,
+			BalanceSink:    *balanceSink,
+			ErrorLog:       *errorLog,
+			MaxErrorLength: *maxErrorLength,
+			ShowTotal:      *showTotal,
+			RetryPolicy:    *retryPolicy,
+			AutoRetry:      *autoRetry,
+			MemoryLimit:    *memoryLimit,
+			KeyRateLimit:   *keyRate,
+			QRDir:          *qrDir,
+			KeepAlive:      *keepAlive,
+			KeyInterval:    *keyInterval,
+			Timeout:        *timeout,
+			Profile:        *profile,
+			ExplicitFlags:  explicitFlags,
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package tron
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
@@ -36,42 +37,34 @@ func ValidateAddress(address string) bool {
 		return false
 	}
 
-	// 分离地址和校验码
-	addrBytes := decoded[:21]
-	checkSum := decoded[21:]
+	// 分离地址和校验码，检查校验码（双 SHA256 的前 4 字节）
+	return bytes.Equal(decoded[21:], addressChecksum(decoded[:21]))
+}
 
-	// 计算校验码（TRON 使用标准 SHA256，不是 SHA3）
-	firstHash := sha256.Sum256(addrBytes)
-	secondHash := sha256.Sum256(firstHash[:])
+// ErrEVMAddress 输入的是以太坊等 EVM 链的地址（0x 加 40 位十六进制），常见于混用多个数据来源时误粘贴
+var ErrEVMAddress = errors.New("检测到以太坊地址，本工具仅支持 TRON (T开头) 地址")
 
-	// 检查校验码（取第二次哈希的前4字节）
-	for i := 0; i < 4; i++ {
-		if checkSum[i] != secondHash[i] {
-			return false
-		}
+// IsEVMAddress 判断是否为 EVM 格式的地址（0x 或 0X 开头，后跟 40 位十六进制）
+func IsEVMAddress(s string) bool {
+	if len(s) != 42 || s[0] != '0' || (s[1] != 'x' && s[1] != 'X') {
+		return false
 	}
-
-	return true
+	_, err := hex.DecodeString(s[2:])
+	return err == nil
 }
 
-// ValidateAddressWithError 验证地址并返回错误信息
+// ValidateAddressWithError 验证地址并返回错误信息（EVM 格式的地址返回 ErrEVMAddress）
 func ValidateAddressWithError(address string) error {
+	if IsEVMAddress(address) {
+		return ErrEVMAddress
+	}
 	decoded := base58.Decode(address)
 	if len(decoded) != 25 {
 		return errors.New("地址长度不正确")
 	}
 
-	addrBytes := decoded[:21]
-	checkSum := decoded[21:]
-
-	// 计算校验码（TRON 使用标准 SHA256，不是 SHA3）
-	firstHash := sha256.Sum256(addrBytes)
-	secondHash := sha256.Sum256(firstHash[:])
-
-	for i := 0; i < 4; i++ {
-		if checkSum[i] != secondHash[i] {
-			return errors.New("地址校验码错误")
-		}
+	if !bytes.Equal(decoded[21:], addressChecksum(decoded[:21])) {
+		return errors.New("地址校验码错误")
 	}
 
 	return nil
@@ -92,3 +85,33 @@ func AddressToHex(address string) (string, error) {
 	// 转换为 hex 字符串（不带 0x 前缀，TRON API 要求，应该是42个字符）
 	return hex.EncodeToString(addressBytes), nil
 }
+
+// EncodeBase58Address 将 21 字节的地址（1 字节版本 41 + 20 字节地址主体）编码为 Base58Check 地址
+// 校验码为双 SHA256 的前 4 字节，与 ValidateAddress 的校验方式一致；不检查版本字节和长度
+func EncodeBase58Address(raw []byte) string {
+	checksum := addressChecksum(raw)
+	payload := make([]byte, 0, len(raw)+len(checksum))
+	payload = append(payload, raw...)
+	payload = append(payload, checksum...)
+	return base58.Encode(payload)
+}
+
+// addressChecksum 返回地址的校验码：双 SHA256 的前 4 字节（TRON 使用标准 SHA256，不是 SHA3）
+func addressChecksum(raw []byte) []byte {
+	firstHash := sha256.Sum256(raw)
+	secondHash := sha256.Sum256(firstHash[:])
+	return secondHash[:4]
+}
+
+// HexToAddress 将 hex 格式的地址转换为 TRON Base58 地址（见 EncodeBase58Address）
+// 支持 41 开头的 21 字节（42 个字符）和 0x 开头的 20 字节（EVM 格式，补上版本字节 41）
+func HexToAddress(s string) (string, error) {
+	if IsEVMAddress(s) {
+		s = "41" + s[2:]
+	}
+	raw, err := hex.DecodeString(s)
+	if err != nil || len(raw) != 21 || raw[0] != 0x41 {
+		return "", errors.New("无效的 hex 地址（应为 41 开头的 42 位十六进制）")
+	}
+	return EncodeBase58Address(raw), nil
+}
@@ -0,0 +1,64 @@
+package tron
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// 主网上的真实地址及其 hex 形式
+var addressVectors = []struct {
+	name, base58, hex string
+}{
+	{"USDT 合约", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", "41a614f803b6fd780986a42c78ec9c7f77e6ded13c"},
+	{"USDC 合约", "TEkxiTehnzSmSe2XqrBj4w32RUN966rdz8", "413487b63d30b5b2c87fb7ffa8bcfade38eaac1abe"},
+	{"黑洞地址", "T9yD14Nj9j7xAB4dbGeiX9h8unkKHxuWwb", "410000000000000000000000000000000000000000"},
+	{"文档示例地址", "TLa2f6VPqDgRE67v1736s7bJ8Ray5wYjU7", "4174472e7d35395a6b5add427eecb7f4b62ad2b071"},
+}
+
+func TestAddressHexRoundTrip(t *testing.T) {
+	for _, v := range addressVectors {
+		if got, err := AddressToHex(v.base58); err != nil || got != v.hex {
+			t.Errorf("%s: AddressToHex(%s) = %q, %v, want %q", v.name, v.base58, got, err, v.hex)
+		}
+		if got, err := HexToAddress(v.hex); err != nil || got != v.base58 {
+			t.Errorf("%s: HexToAddress(%s) = %q, %v, want %q", v.name, v.hex, got, err, v.base58)
+		}
+		// EVM 格式（0x 加 20 字节地址主体）补上版本字节后相同
+		if got, err := HexToAddress("0x" + v.hex[2:]); err != nil || got != v.base58 {
+			t.Errorf("%s: HexToAddress(0x%s) = %q, %v, want %q", v.name, v.hex[2:], got, err, v.base58)
+		}
+		raw, _ := hex.DecodeString(v.hex)
+		if got := EncodeBase58Address(raw); got != v.base58 {
+			t.Errorf("%s: EncodeBase58Address = %q, want %q", v.name, got, v.base58)
+		}
+		if !ValidateAddress(v.base58) {
+			t.Errorf("%s: ValidateAddress(%s) = false", v.name, v.base58)
+		}
+	}
+}
+
+func TestAddressBadChecksum(t *testing.T) {
+	// USDT 合约地址改动最后一个字符：长度和 Base58 字符都有效，只有校验码错误
+	bad := "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6u"
+	if ValidateAddress(bad) {
+		t.Fatalf("ValidateAddress(%s) = true, want false", bad)
+	}
+	if err := ValidateAddressWithError(bad); err == nil || err.Error() != "地址校验码错误" {
+		t.Fatalf("ValidateAddressWithError(%s) = %v, want 地址校验码错误", bad, err)
+	}
+}
+
+func TestHexToAddressInvalid(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"a614f803b6fd780986a42c78ec9c7f77e6ded13c",     // 缺少版本字节
+		"42a614f803b6fd780986a42c78ec9c7f77e6ded13c",   // 版本字节不是 41
+		"41a614f803b6fd780986a42c78ec9c7f77e6ded1",     // 长度不足
+		"41a614f803b6fd780986a42c78ec9c7f77e6ded13g",   // 非十六进制
+		"41a614f803b6fd780986a42c78ec9c7f77e6ded13c00", // 长度过长
+	} {
+		if got, err := HexToAddress(s); err == nil {
+			t.Errorf("HexToAddress(%q) = %q, want error", s, got)
+		}
+	}
+}
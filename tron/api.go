@@ -2,6 +2,7 @@ package tron
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"errors"
@@ -9,6 +10,7 @@ import (
 	"io"
 	"math/big"
 	"net/http"
+	neturl "net/url"
 	"strings"
 	"time"
 )
@@ -16,35 +18,354 @@ import (
 const (
 	// USDT 合约地址
 	USDTContractAddress = "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
-	// TronGrid API 地址
-	TronGridAPI = "https://api.trongrid.io/wallet/triggerconstantcontract"
+	// TronGrid 节点地址（前缀形式，各接口路径在调用时拼接）
+	TronGridBaseURL = "https://api.trongrid.io"
+	// TriggerConstantContractPath 余额查询使用的接口路径
+	TriggerConstantContractPath = "/wallet/triggerconstantcontract"
+	// TronGrid API 地址（完整接口地址，保留以兼容旧代码）
+	TronGridAPI = TronGridBaseURL + TriggerConstantContractPath
 	// balanceOf 函数签名（完整函数签名字符串）
 	BalanceOfSelector = "balanceOf(address)"
+	// BurnAddress TRON 黑洞地址（全零地址），可作为固定的 owner_address，见 ClientOptions.OwnerAddress
+	BurnAddress = "T9yD14Nj9j7xAB4dbGeiX9h8unkKHxuWwb"
 )
 
 // APIClient TronGrid API 客户端
 type APIClient struct {
 	APIKey      string
-	BaseURL     string
+	BaseURL     string // 节点地址前缀（如 https://gw.example.com/tron），兼容带 /wallet/triggerconstantcontract 的完整地址
 	HTTPClient  *http.Client
 	RateLimiter *RateLimiter
+
+	// OwnerAddress 余额查询固定使用的 owner_address（Base58），为空时使用被查询的地址
+	OwnerAddress string
+
+	requestSigner RequestSigner // 发送前的请求拦截器（可选）
+	slot          SlotWaiter    // 发送前等待发送时段（可选）
+}
+
+// RequestSigner 请求拦截器，在每个请求发送前调用，用于添加私有节点需要的认证信息
+// （如 HMAC 签名、Bearer token）。需要读取请求体时使用 req.GetBody()，不要直接消费 req.Body。
+// 返回错误时请求不会发送。
+type RequestSigner func(req *http.Request) error
+
+// SlotWaiter 在每个请求发送前（限流器之后）调用，等待调用方分配的发送时段，
+// 用于多个客户端共享同一个 Key 的速率（如进程级的按 Key 调度）。返回错误时请求不发送，按已取消处理
+type SlotWaiter func(ctx context.Context) error
+
+// ClientOptions API 客户端选项（零值字段使用默认值）
+type ClientOptions struct {
+	APIKey    string        // TronGrid API Key，可为空
+	BaseURL   string        // 节点地址前缀或完整接口地址，默认 TronGridBaseURL
+	Timeout   time.Duration // 单次 HTTP 请求超时，默认 30 秒
+	RateLimit int           // 每秒请求数，默认 12
+	Limiter   *RateLimiter  // 共享限流器（多个客户端共用一个速率），非空时忽略 RateLimit
+	Signer    RequestSigner // 请求拦截器，默认不设
+	Slot      SlotWaiter    // 发送时段等待，默认不设
+	// OwnerAddress 余额查询固定使用的 owner_address，默认为空（使用被查询的地址）
+	// 部分节点版本在被查询地址从未上链时返回错误，此时可改用已知存在的地址（如 USDT 合约地址或 BurnAddress），
+	// 被查询的地址只出现在 ABI 参数中，余额结果不变
+	OwnerAddress string
 }
 
 // NewAPIClient 创建新的 API 客户端
 func NewAPIClient(apiKey string) *APIClient {
+	return NewAPIClientWithOptions(ClientOptions{APIKey: apiKey})
+}
+
+// NewAPIClientWithOptions 按选项创建 API 客户端
+func NewAPIClientWithOptions(opts ClientOptions) *APIClient {
+	if opts.BaseURL == "" {
+		opts.BaseURL = TronGridBaseURL
+	} else if normalized, err := NormalizeNodeURL(opts.BaseURL); err == nil {
+		opts.BaseURL = normalized
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 30 * time.Second
+	}
+	if opts.RateLimit <= 0 {
+		opts.RateLimit = 12 // 默认每秒12次
+	}
+	limiter := opts.Limiter
+	if limiter == nil {
+		limiter = NewRateLimiter(opts.RateLimit, time.Second)
+	}
+
 	return &APIClient{
-		APIKey:  apiKey,
-		BaseURL: TronGridAPI,
+		APIKey:  opts.APIKey,
+		BaseURL: opts.BaseURL,
 		HTTPClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout: opts.Timeout,
 		},
-		RateLimiter: NewRateLimiter(12, time.Second), // 默认每秒12次
+		RateLimiter:   limiter,
+		OwnerAddress:  opts.OwnerAddress,
+		requestSigner: opts.Signer,
+		slot:          opts.Slot,
+	}
+}
+
+// SetRequestSigner 设置请求拦截器（传 nil 取消），在 TRON-PRO-API-KEY 头设置之后调用
+func (c *APIClient) SetRequestSigner(signer RequestSigner) {
+	c.requestSigner = signer
+}
+
+// wait 等待限流器和发送时段，等待发送时段时被取消返回 ErrorKindCancelled 错误
+func (c *APIClient) wait(ctx context.Context) error {
+	c.RateLimiter.Wait()
+	if c.slot != nil {
+		if err := c.slot(ctx); err != nil {
+			return &QueryError{Kind: ErrorKindCancelled, Message: "请求已取消"}
+		}
+	}
+	return nil
+}
+
+// prepareRequest 设置公共请求头并调用请求拦截器
+func (c *APIClient) prepareRequest(req *http.Request) error {
+	req.Header.Set("Content-Type", "application/json")
+	if c.APIKey != "" {
+		req.Header.Set("TRON-PRO-API-KEY", c.APIKey)
+	}
+	if c.requestSigner != nil {
+		if err := c.requestSigner(req); err != nil {
+			return fmt.Errorf("请求签名失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// gzipMagic gzip 数据的开头两个字节（JSON 响应不会以此开头）
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// readBody 读取响应体，内容是 gzip 压缩的数据时解压
+// http.Client 只在自己添加 Accept-Encoding 时自动解压；请求拦截器手动设置了 Accept-Encoding，
+// 或代理、CDN 未经请求就压缩了响应时，响应体仍是压缩的字节（按内容判断，不依赖 Content-Encoding 头）
+func readBody(resp *http.Response) ([]byte, error) {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || resp.Uncompressed || !bytes.HasPrefix(body, gzipMagic) {
+		return body, err
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("解压响应失败: %v", err)
+	}
+	defer reader.Close()
+	body, err = io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("解压响应失败: %v", err)
+	}
+	return body, nil
+}
+
+// nonJSONSnippetLength 非 JSON 响应在错误信息中保留的最大字符数
+const nonJSONSnippetLength = 200
+
+// nonJSONError 节点（或前面的网关、CDN）返回的不是 JSON（如 HTML 错误页、纯文本限流页）时返回说明错误，是 JSON 时返回 nil
+// Content-Type 不是 JSON 且内容也不以 { 或 [ 开头时才视为非 JSON（部分节点返回 JSON 时 Content-Type 为 text/plain）。
+// 状态码为 200 时类别为 ErrorKindResponse，否则按状态码为 ErrorKindServer 或 ErrorKindHTTP（见 httpErrorKind）
+func nonJSONError(resp *http.Response, body []byte) *QueryError {
+	contentType := resp.Header.Get("Content-Type")
+	if strings.Contains(strings.ToLower(contentType), "json") {
+		return nil
+	}
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		return nil
 	}
+
+	if contentType == "" {
+		contentType = "未知"
+	}
+	kind := ErrorKindResponse
+	if resp.StatusCode != http.StatusOK {
+		kind = httpErrorKind(resp.StatusCode)
+	}
+	return &QueryError{
+		Kind:       kind,
+		StatusCode: resp.StatusCode,
+		Message:    fmt.Sprintf("节点返回非 JSON 响应（可能是错误页或限流页），HTTP %d，Content-Type: %s，内容: %s", resp.StatusCode, contentType, responseSnippet(body)),
+	}
+}
+
+// responseSnippet 将响应内容压缩为一行（连续空白合并为一个空格），超过 nonJSONSnippetLength 个字符时截断
+func responseSnippet(body []byte) string {
+	text := strings.Join(strings.Fields(string(body)), " ")
+	if text == "" {
+		return "（空）"
+	}
+	if runes := []rune(text); len(runes) > nonJSONSnippetLength {
+		return string(runes[:nonJSONSnippetLength]) + "…"
+	}
+	return text
+}
+
+// SetBaseURL 设置自定义 TRON 节点地址（前缀或完整接口地址均可）
+// 缺少协议时自动补全 https://；地址无效时返回错误并保持原地址不变
+func (c *APIClient) SetBaseURL(url string) error {
+	normalized, err := NormalizeNodeURL(url)
+	if err != nil {
+		return err
+	}
+	if normalized == "" {
+		normalized = TronGridBaseURL
+	}
+	c.BaseURL = normalized
+	return nil
+}
+
+// NormalizeNodeURL 校验并规范化节点地址，返回去掉接口路径的前缀形式
+// 空字符串返回空（表示使用默认节点）；缺少协议时补全 https://；
+// 只接受 http/https 协议，且必须包含主机名。
+// 以下写法等价：https://gw.example.com/tron、https://gw.example.com/tron/、
+// https://gw.example.com/tron/wallet/triggerconstantcontract
+func NormalizeNodeURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", nil
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+
+	u, err := neturl.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("节点 URL 格式错误: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("节点 URL 只支持 http 或 https 协议，当前为 %q", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return "", errors.New("节点 URL 缺少主机名，示例: https://api.trongrid.io 或 https://gw.example.com/tron/")
+	}
+	u.Path = nodePathPrefix(u.Path)
+	u.RawPath = ""
+	return u.String(), nil
+}
+
+// nodePathPrefix 去掉路径末尾的接口路径和斜杠，得到节点路径前缀
+func nodePathPrefix(path string) string {
+	path = strings.TrimRight(path, "/")
+	return strings.TrimRight(strings.TrimSuffix(path, TriggerConstantContractPath), "/")
 }
 
-// SetBaseURL 设置自定义 TRON 节点地址
-func (c *APIClient) SetBaseURL(url string) {
-	c.BaseURL = url
+// endpoint 根据 BaseURL 前缀拼接接口地址（所有接口都通过这里推导，保留网关的路径前缀和查询参数）
+func (c *APIClient) endpoint(path string) string {
+	u, err := neturl.Parse(c.BaseURL)
+	if err != nil {
+		return nodePathPrefix(c.BaseURL) + path
+	}
+	u.Path = nodePathPrefix(u.Path) + path
+	u.RawPath = ""
+	return u.String()
+}
+
+// postJSON 向指定接口发送 JSON 请求并解析响应（不重试，供辅助查询使用）
+func (c *APIClient) postJSON(ctx context.Context, url string, reqBody interface{}, out interface{}) error {
+	// 等待限流
+	if err := c.wait(ctx); err != nil {
+		return err
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return fmt.Errorf("请求序列化失败: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+	if err := c.prepareRequest(req); err != nil {
+		return err
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return errors.New("请求已取消")
+		}
+		return fmt.Errorf("请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := readBody(resp)
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %v", err)
+	}
+	if err := nonJSONError(resp, body); err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API 返回错误 (HTTP %d): %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("解析响应失败: %v", err)
+	}
+	return nil
+}
+
+// Ping 向节点发送一个轻量的 HEAD 请求，使连接池中保留一个已建立的连接（不占用限流额度）
+// 只要收到响应（任意状态码）就视为成功，用于长时间空闲时保持连接，避免下一次请求重新握手
+func (c *APIClient) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "HEAD", c.endpoint("/"), nil)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+	if err := c.prepareRequest(req); err != nil {
+		return err
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求失败: %v", err)
+	}
+	// 读完响应体再关闭，连接才会放回连接池
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return nil
+}
+
+// GetNowBlock 获取节点当前最新块的块高和出块时间（/wallet/getnowblock）
+func (c *APIClient) GetNowBlock(ctx context.Context) (int64, time.Time, error) {
+	var blockResp struct {
+		BlockHeader struct {
+			RawData struct {
+				Number    int64 `json:"number"`
+				Timestamp int64 `json:"timestamp"` // 毫秒
+			} `json:"raw_data"`
+		} `json:"block_header"`
+	}
+	if err := c.postJSON(ctx, c.endpoint("/wallet/getnowblock"), struct{}{}, &blockResp); err != nil {
+		return 0, time.Time{}, err
+	}
+
+	raw := blockResp.BlockHeader.RawData
+	if raw.Number == 0 {
+		return 0, time.Time{}, errors.New("响应中没有块信息")
+	}
+	return raw.Number, time.UnixMilli(raw.Timestamp).UTC(), nil
+}
+
+// IsContract 通过 /wallet/getcontract 判断地址是否为合约地址
+// 普通钱包地址返回空对象 {}，合约地址返回合约信息
+func (c *APIClient) IsContract(ctx context.Context, address string) (bool, error) {
+	reqBody := struct {
+		Value   string `json:"value"`
+		Visible bool   `json:"visible"`
+	}{
+		Value:   address,
+		Visible: true,
+	}
+
+	var contractResp struct {
+		ContractAddress string `json:"contract_address"`
+		Bytecode        string `json:"bytecode"`
+	}
+	if err := c.postJSON(ctx, c.endpoint("/wallet/getcontract"), reqBody, &contractResp); err != nil {
+		return false, err
+	}
+
+	return contractResp.ContractAddress != "" || contractResp.Bytecode != "", nil
 }
 
 // TriggerConstantContractRequest 请求结构
@@ -63,23 +384,85 @@ func (c *APIClient) QueryBalance(address string) (string, error) {
 	return c.QueryBalanceWithContext(context.Background(), address)
 }
 
-// QueryBalanceWithContext 查询 USDT 余额（支持 context 取消）
+// QueryBalanceWithContext 查询 USDT 余额（支持 context 取消，限流和网络错误时自动重试）
 func (c *APIClient) QueryBalanceWithContext(ctx context.Context, address string) (string, error) {
+	var lastErr error
+	maxRetries := 3
+	for i := 0; i < maxRetries; i++ {
+		balance, err := c.QueryBalanceOnce(ctx, address)
+		if err == nil {
+			return balance, nil
+		}
+		lastErr = err
+		if !IsRetryable(err) || i == maxRetries-1 {
+			break
+		}
+		if !SleepWithContext(ctx, RetryBackoff(err, i)) {
+			return "", &QueryError{Kind: ErrorKindCancelled, Message: "请求已取消"}
+		}
+	}
+	return "", lastErr
+}
+
+// RetryBackoff 返回第 attempt 次（从 0 开始）失败后的等待时间
+// 429 限流等待更久：2s、4s…；网络错误：1s、2s…
+func RetryBackoff(err error, attempt int) time.Duration {
+	if KindOf(err) == ErrorKindRateLimited {
+		return time.Duration(attempt+1) * 2 * time.Second
+	}
+	return time.Duration(attempt+1) * time.Second
+}
+
+// SleepWithContext 等待指定时间，context 取消时提前返回 false
+func SleepWithContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// QueryBalanceOnce 查询 USDT 余额（单次请求，不重试）
+// 失败时返回 *QueryError，调用方可据此决定是否更换 Key 重试
+func (c *APIClient) QueryBalanceOnce(ctx context.Context, address string) (string, error) {
+	balance, _, err := c.QueryBalanceRawOnce(ctx, address)
+	return balance, err
+}
+
+// QueryBalanceRawOnce 查询 USDT 余额（单次请求，不重试），同时返回节点响应中未经处理的 constant_result[0]
+// 原始 hex 用于审计：格式化逻辑有误时可以据此核对，见 FormatBalanceHex
+func (c *APIClient) QueryBalanceRawOnce(ctx context.Context, address string) (string, string, error) {
+	return c.QueryTokenBalanceRawOnce(ctx, address, USDTToken)
+}
+
+// QueryTokenBalanceRawOnce 查询指定 TRC20 代币的余额（单次请求，不重试），按代币小数位数格式化
+// 同时返回未经处理的 constant_result[0]，见 FormatTokenHex
+func (c *APIClient) QueryTokenBalanceRawOnce(ctx context.Context, address string, token Token) (string, string, error) {
 	// 等待限流
-	c.RateLimiter.Wait()
+	if err := c.wait(ctx); err != nil {
+		return "", "", err
+	}
 
 	// 转换地址为参数格式（使用20字节地址主体）
 	param, err := AddressToParameter(address)
 	if err != nil {
-		return "", errors.New("地址转换失败: %v")
+		return "", "", fmt.Errorf("地址转换失败: %v", err)
 	}
 
 	// 构建请求
 	// 根据实际测试，使用 Base58 格式的 owner_address 配合 visible=true
 	// parameter 使用20字节地址主体的 ABI 编码（跳过版本字节）
+	// 设置了固定 owner_address 时用它发起调用，被查询的地址只出现在 parameter 中
+	owner := address
+	if c.OwnerAddress != "" {
+		owner = c.OwnerAddress
+	}
 	reqBody := TriggerConstantContractRequest{
-		OwnerAddress:     address, // Base58 格式
-		ContractAddress:  USDTContractAddress,
+		OwnerAddress:     owner, // Base58 格式
+		ContractAddress:  token.Contract,
 		FunctionSelector: BalanceOfSelector, // "balanceOf(address)"
 		Parameter:        param,             // ABI 编码（20字节地址主体，64个hex字符）
 		Visible:          true,              // true 表示地址使用 Base58 格式
@@ -87,71 +470,53 @@ func (c *APIClient) QueryBalanceWithContext(ctx context.Context, address string)
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", errors.New("请求序列化失败: %v")
+		return "", "", fmt.Errorf("请求序列化失败: %v", err)
 	}
 
 	// 创建 HTTP 请求（使用 context 支持取消）
-	req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.endpoint(TriggerConstantContractPath), bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", errors.New("创建请求失败: %v")
+		return "", "", fmt.Errorf("创建请求失败: %v", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	if c.APIKey != "" {
-		req.Header.Set("TRON-PRO-API-KEY", c.APIKey)
-	}
 	// 注意：根据 TronGrid 文档，主网请求强烈建议使用 API Key
 	// 没有 API Key 时请求可能被拒绝或严格限流
-
-	// 发送请求（带重试机制）
-	var resp *http.Response
-	var lastErr error
-	maxRetries := 3
-	for i := 0; i < maxRetries; i++ {
-		// 检查 context 是否已取消
-		if req.Context().Err() != nil {
-			return "", errors.New("请求已取消")
-		}
-		resp, lastErr = c.HTTPClient.Do(req)
-		if lastErr == nil && resp.StatusCode == http.StatusOK {
-			break
-		}
-
-		if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
-			// 429 错误，延迟后重试
-			waitTime := time.Duration(i+1) * 2 * time.Second
-			time.Sleep(waitTime)
-			if resp.Body != nil {
-				resp.Body.Close()
-			}
-			continue
-		}
-
-		if lastErr != nil {
-			if resp != nil && resp.Body != nil {
-				resp.Body.Close()
-			}
-			if i < maxRetries-1 {
-				time.Sleep(time.Duration(i+1) * time.Second)
-				continue
-			}
-		}
+	if err := c.prepareRequest(req); err != nil {
+		return "", "", &QueryError{Kind: ErrorKindUnknown, Message: err.Error()}
 	}
 
-	if lastErr != nil {
-		return "", errors.New("请求失败: %v")
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", "", &QueryError{Kind: ErrorKindCancelled, Message: "请求已取消"}
+		}
+		return "", "", requestError(err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
+	if resp.StatusCode == http.StatusTooManyRequests {
 		_, _ = io.ReadAll(resp.Body)
-		return "", errors.New("API 返回错误 (HTTP %d): %s")
+		return "", "", &QueryError{Kind: ErrorKindRateLimited, StatusCode: resp.StatusCode, Message: "请求被限流 (HTTP 429)"}
+	}
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := readBody(resp)
+		if err := nonJSONError(resp, respBody); err != nil {
+			return "", "", err
+		}
+		return "", "", &QueryError{
+			Kind:       httpErrorKind(resp.StatusCode),
+			StatusCode: resp.StatusCode,
+			Message:    fmt.Sprintf("API 返回错误 (HTTP %d): %s", resp.StatusCode, string(respBody)),
+		}
 	}
 
 	// 读取响应体
-	body, err := io.ReadAll(resp.Body)
+	body, err := readBody(resp)
 	if err != nil {
-		return "", errors.New("读取响应失败: %v")
+		return "", "", &QueryError{Kind: ErrorKindNetwork, Message: fmt.Sprintf("读取响应失败: %v", err)}
+	}
+	if err := nonJSONError(resp, body); err != nil {
+		return "", "", err
 	}
 
 	// 解析响应（按照 test.go 的方法）
@@ -168,7 +533,7 @@ func (c *APIClient) QueryBalanceWithContext(ctx context.Context, address string)
 	}
 
 	if err := json.Unmarshal(body, &apiResp); err != nil {
-		return "", errors.New("解析响应失败: %v, 响应内容: %s")
+		return "", "", &QueryError{Kind: ErrorKindResponse, StatusCode: resp.StatusCode, Message: fmt.Sprintf("解析响应失败: %v, 响应内容: %s", err, string(body))}
 	}
 
 	// 检查顶层错误（某些 API 错误可能在这里）
@@ -177,7 +542,7 @@ func (c *APIClient) QueryBalanceWithContext(ctx context.Context, address string)
 		if desc == "" {
 			desc = apiResp.Error
 		}
-		return "", errors.New("API 错误: %s (完整响应: %s)")
+		return "", "", fmt.Errorf("API 错误: %s (完整响应: %s)", desc, string(body))
 	}
 
 	// 检查结果
@@ -189,7 +554,7 @@ func (c *APIClient) QueryBalanceWithContext(ctx context.Context, address string)
 		if errorMsg == "" {
 			errorMsg = "未知错误"
 		}
-		return "", errors.New("查询失败: result=false, code=%s, 完整响应: %s")
+		return "", "", fmt.Errorf("查询失败: result=false, code=%s, 完整响应: %s", errorMsg, string(body))
 	}
 
 	// 获取 constant_result（可能在 result 下，也可能在顶层）
@@ -197,27 +562,22 @@ func (c *APIClient) QueryBalanceWithContext(ctx context.Context, address string)
 	if len(apiResp.ConstantResult) > 0 {
 		constantResults = apiResp.ConstantResult
 	} else {
-		return "", errors.New("查询失败: 响应中没有 constant_result (完整响应: %s)")
+		return "", "", fmt.Errorf("查询失败: 响应中没有 constant_result (完整响应: %s)", string(body))
 	}
 
-	// 解析余额（hex 转 decimal）
-	balanceHex := constantResults[0]
-
-	// 处理空字符串的情况
-	balanceHex = strings.TrimSpace(balanceHex)
-	if balanceHex == "" {
-		balanceHex = "0"
-	}
-
-	// 解析余额（按照 test.go 的方法：直接使用 hex 字符串，不 trim 前导零）
-	n := new(big.Int)
-	if _, ok := n.SetString(balanceHex, 16); !ok {
-		return "", errors.New("无法解析hex余额: %s")
+	// 解析余额（hex 转 decimal），原始值一并返回便于核对
+	rawHex := constantResults[0]
+	balance, err := FormatTokenHex(rawHex, token.Decimals)
+	if err != nil {
+		return "", rawHex, err
 	}
+	return balance, rawHex, nil
+}
 
-	// 格式化小数（按照 test.go 的方法）
-	balance := formatDecimals(n, 6)
-	return balance, nil
+// FormatBalanceHex 将 constant_result 中的原始 hex 值格式化为 USDT 余额（6 位小数，去掉末尾的 0）
+// 空字符串视为 0；QueryBalanceRawOnce 返回的余额始终可以由原始 hex 通过此函数重新得到
+func FormatBalanceHex(rawHex string) (string, error) {
+	return FormatTokenHex(rawHex, USDTToken.Decimals)
 }
 
 // formatDecimals 将大整数格式化为带小数点的字符串（按照 test.go 的方法）
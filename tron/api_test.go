@@ -0,0 +1,105 @@
+package tron
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// gzipBytes 返回 data 的 gzip 压缩数据
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadBodyGzipRoundTrip(t *testing.T) {
+	payload := []byte(`{"constant_result":["` + strings.Repeat("0123456789abcdef", 4096) + `"]}`)
+	for name, body := range map[string][]byte{"压缩": gzipBytes(t, payload), "未压缩": payload} {
+		resp := &http.Response{Body: io.NopCloser(bytes.NewReader(body))}
+		got, err := readBody(resp)
+		if err != nil || !bytes.Equal(got, payload) {
+			t.Fatalf("%s: readBody 返回 %d 字节, err %v；want 原始的 %d 字节", name, len(got), err, len(payload))
+		}
+	}
+
+	// 以 gzip 开头但数据损坏时返回错误，而不是把压缩字节当作响应
+	resp := &http.Response{Body: io.NopCloser(bytes.NewReader(gzipBytes(t, payload)[:20]))}
+	if _, err := readBody(resp); err == nil {
+		t.Fatal("损坏的 gzip 数据应返回错误")
+	}
+}
+
+// 节点返回 gzip 压缩的响应：拦截器手动设置 Accept-Encoding，或代理未经请求压缩（不带 Content-Encoding 头）时都能解析
+func TestQueryBalanceGzipResponse(t *testing.T) {
+	response := []byte(fmt.Sprintf(`{"result":{"result":true},"constant_result":["%064x"]}`, 1500000))
+	cases := []struct {
+		name            string
+		signer          RequestSigner
+		contentEncoding bool // 响应是否带 Content-Encoding: gzip 头
+	}{
+		{"拦截器设置 Accept-Encoding", func(req *http.Request) error {
+			req.Header.Set("Accept-Encoding", "gzip")
+			return nil
+		}, true},
+		{"代理未经请求压缩", nil, false},
+		{"代理压缩并带 Content-Encoding 头", nil, true},
+	}
+	for _, c := range cases {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			if c.contentEncoding {
+				w.Header().Set("Content-Encoding", "gzip")
+			}
+			w.Write(gzipBytes(t, response))
+		}))
+		client := NewAPIClientWithOptions(ClientOptions{BaseURL: srv.URL, RateLimit: 1000, Signer: c.signer})
+		balance, err := client.QueryBalanceOnce(context.Background(), "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t")
+		srv.Close()
+		if err != nil || balance != "1.5" {
+			t.Errorf("%s: 余额 %q, err %v, want 1.5", c.name, balance, err)
+		}
+	}
+}
+
+// 返回的原始 hex 与节点响应的 constant_result[0] 完全相同，余额可由 FormatBalanceHex(原始 hex) 重新得到
+func TestQueryBalanceRawHex(t *testing.T) {
+	cases := []struct {
+		raw, balance string
+	}{
+		{strings.Repeat("0", 64), "0"},
+		{fmt.Sprintf("%064x", 1), "0.000001"},
+		{fmt.Sprintf("%064x", 1500000), "1.5"},
+		{fmt.Sprintf("%064X", 123456789012), "123456.789012"},
+		{strings.Repeat("0", 47) + "1" + strings.Repeat("0", 16), "18446744073709.551616"}, // 2^64，超过 uint64
+		{"", "0"},
+	}
+	for _, c := range cases {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"result":{"result":true},"constant_result":[%q]}`, c.raw)
+		}))
+		client := NewAPIClientWithOptions(ClientOptions{BaseURL: srv.URL, RateLimit: 1000})
+		balance, raw, err := client.QueryBalanceRawOnce(context.Background(), "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t")
+		srv.Close()
+		if err != nil || raw != c.raw || balance != c.balance {
+			t.Errorf("constant_result %q: 余额 %q，原始值 %q，err %v；want 余额 %q", c.raw, balance, raw, err, c.balance)
+			continue
+		}
+		if formatted, err := FormatBalanceHex(raw); err != nil || formatted != balance {
+			t.Errorf("FormatBalanceHex(%q) = %q, %v, want %q", raw, formatted, err, balance)
+		}
+	}
+}
@@ -0,0 +1,7 @@
+// Package tron 封装 TRON 地址处理和 TronGrid（或兼容节点）的 HTTP 接口调用。
+//
+// APIClient 每个实例自带限流器，并发使用同一实例时会共享限流；
+// 通过 NewAPIClientWithOptions 可配置 API Key、节点地址、超时和速率；
+// 私有节点需要自定义认证时，可通过 ClientOptions.Signer 或 SetRequestSigner 在发送前修改请求。
+// 地址函数（ValidateAddress、AddressToHex 等）只处理 Base58Check 格式的 T 开头地址。
+package tron
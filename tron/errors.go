@@ -0,0 +1,105 @@
+package tron
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// ErrorKind 请求错误类别（用于决定是否重试以及统计失败原因）
+type ErrorKind int
+
+const (
+	// ErrorKindUnknown 未分类错误
+	ErrorKindUnknown ErrorKind = iota
+	// ErrorKindRateLimited 被限流（HTTP 429）
+	ErrorKindRateLimited
+	// ErrorKindNetwork 网络错误（连接失败、超时等）
+	ErrorKindNetwork
+	// ErrorKindHTTP 其他非 200 的 HTTP 状态码（4xx 等）
+	ErrorKindHTTP
+	// ErrorKindResponse 响应无法解析或内容异常
+	ErrorKindResponse
+	// ErrorKindCancelled 请求被取消
+	ErrorKindCancelled
+	// ErrorKindTimeout 请求超时（网络错误中单独区分，便于按类别设置重试次数）
+	ErrorKindTimeout
+	// ErrorKindServer 节点或网关的服务器错误（HTTP 5xx），通常是暂时的
+	ErrorKindServer
+)
+
+// String 返回错误类别的中文名称（用于失败统计）
+func (k ErrorKind) String() string {
+	switch k {
+	case ErrorKindRateLimited:
+		return "429 限流"
+	case ErrorKindNetwork:
+		return "网络错误"
+	case ErrorKindHTTP:
+		return "HTTP 错误"
+	case ErrorKindResponse:
+		return "无效响应"
+	case ErrorKindCancelled:
+		return "已取消"
+	case ErrorKindTimeout:
+		return "网络超时"
+	case ErrorKindServer:
+		return "服务器错误"
+	}
+	return "其他错误"
+}
+
+// QueryError 查询请求错误
+type QueryError struct {
+	Kind       ErrorKind // 错误类别
+	StatusCode int       // HTTP 状态码（未收到响应时为 0）
+	Message    string    // 错误描述
+}
+
+func (e *QueryError) Error() string {
+	return e.Message
+}
+
+// Retryable 是否值得重试（限流、网络错误、超时和 5xx 服务器错误通常是暂时的）
+func (e *QueryError) Retryable() bool {
+	switch e.Kind {
+	case ErrorKindRateLimited, ErrorKindNetwork, ErrorKindTimeout, ErrorKindServer:
+		return true
+	}
+	return false
+}
+
+// httpErrorKind 返回非 200 状态码的错误类别：5xx 为 ErrorKindServer，其他为 ErrorKindHTTP
+func httpErrorKind(statusCode int) ErrorKind {
+	if statusCode >= 500 {
+		return ErrorKindServer
+	}
+	return ErrorKindHTTP
+}
+
+// requestError 将 HTTP 请求发送失败的错误转换为 QueryError，超时为 ErrorKindTimeout，其他为 ErrorKindNetwork
+func requestError(err error) *QueryError {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return &QueryError{Kind: ErrorKindTimeout, Message: fmt.Sprintf("请求超时: %v", err)}
+	}
+	return &QueryError{Kind: ErrorKindNetwork, Message: fmt.Sprintf("请求失败: %v", err)}
+}
+
+// IsRetryable 判断错误是否值得重试
+func IsRetryable(err error) bool {
+	var queryErr *QueryError
+	if errors.As(err, &queryErr) {
+		return queryErr.Retryable()
+	}
+	return false
+}
+
+// KindOf 返回错误的类别，非 QueryError 时返回 ErrorKindUnknown
+func KindOf(err error) ErrorKind {
+	var queryErr *QueryError
+	if errors.As(err, &queryErr) {
+		return queryErr.Kind
+	}
+	return ErrorKindUnknown
+}
@@ -0,0 +1,176 @@
+package tron
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// JSONRPCPath 节点的以太坊兼容 JSON-RPC 接口路径（TronGrid 为 https://api.trongrid.io/jsonrpc）
+	JSONRPCPath = "/jsonrpc"
+	// balanceOfMethodID balanceOf(address) 的函数选择器（keccak256 前 4 字节）
+	balanceOfMethodID = "70a08231"
+	// MaxJSONRPCBatch 单次批量调用最多包含的地址数（节点通常限制批量请求的大小）
+	MaxJSONRPCBatch = 100
+)
+
+// BatchBalance 批量查询中单个地址的结果
+type BatchBalance struct {
+	Address string
+	Balance string // 按代币小数位数格式化后的余额，Err 非空时为空
+	RawHex  string // eth_call 返回的原始 hex（去掉 0x 前缀），与 constant_result[0] 格式一致
+	Err     error  // 该地址的调用错误（节点对单个调用返回的 error）
+}
+
+// jsonRPCRequest JSON-RPC 2.0 请求
+type jsonRPCRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      int           `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+// jsonRPCCall eth_call 的调用参数
+type jsonRPCCall struct {
+	To   string `json:"to"`
+	Data string `json:"data"`
+}
+
+// jsonRPCResponse JSON-RPC 2.0 响应
+type jsonRPCResponse struct {
+	ID     int    `json:"id"`
+	Result string `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// addressToEVMHex 将 TRON Base58 地址转换为 JSON-RPC 使用的 0x 开头的 20 字节地址
+func addressToEVMHex(address string) (string, error) {
+	addrHex, err := AddressToHex(address)
+	if err != nil {
+		return "", err
+	}
+	return "0x" + addrHex[2:], nil
+}
+
+// QueryBalancesJSONRPCBatch 通过 JSON-RPC 批量调用一次查询多个地址的代币余额
+//
+// 每个地址对应批量请求中的一个 eth_call（balanceOf），整个批量只占用一次限流额度；
+// 返回的结果与 addresses 一一对应，单个地址失败时记录在对应的 Err 中。
+// 整个请求失败（网络错误、HTTP 错误、节点不支持批量调用）时返回 QueryError，可按 Retryable 判断是否重试
+func (c *APIClient) QueryBalancesJSONRPCBatch(ctx context.Context, addresses []string, token Token) ([]BatchBalance, error) {
+	if len(addresses) == 0 {
+		return nil, nil
+	}
+	if len(addresses) > MaxJSONRPCBatch {
+		return nil, fmt.Errorf("批量调用最多 %d 个地址", MaxJSONRPCBatch)
+	}
+
+	contract, err := addressToEVMHex(token.Contract)
+	if err != nil {
+		return nil, fmt.Errorf("合约地址转换失败: %v", err)
+	}
+
+	// 构建批量请求，id 为地址在 addresses 中的位置（响应顺序不一定与请求相同）
+	calls := make([]jsonRPCRequest, len(addresses))
+	for i, address := range addresses {
+		param, err := AddressToParameter(address)
+		if err != nil {
+			return nil, fmt.Errorf("地址转换失败: %s: %v", address, err)
+		}
+		calls[i] = jsonRPCRequest{
+			JSONRPC: "2.0",
+			ID:      i,
+			Method:  "eth_call",
+			Params:  []interface{}{jsonRPCCall{To: contract, Data: "0x" + balanceOfMethodID + param}, "latest"},
+		}
+	}
+
+	jsonData, err := json.Marshal(calls)
+	if err != nil {
+		return nil, fmt.Errorf("请求序列化失败: %v", err)
+	}
+
+	// 等待限流（整个批量一次）
+	if err := c.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.endpoint(JSONRPCPath), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %v", err)
+	}
+	if err := c.prepareRequest(req); err != nil {
+		return nil, &QueryError{Kind: ErrorKindUnknown, Message: err.Error()}
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, &QueryError{Kind: ErrorKindCancelled, Message: "请求已取消"}
+		}
+		return nil, requestError(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := readBody(resp)
+	if err != nil {
+		return nil, &QueryError{Kind: ErrorKindNetwork, Message: fmt.Sprintf("读取响应失败: %v", err)}
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, &QueryError{Kind: ErrorKindRateLimited, StatusCode: resp.StatusCode, Message: "请求被限流 (HTTP 429)"}
+	}
+	if err := nonJSONError(resp, body); err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &QueryError{
+			Kind:       httpErrorKind(resp.StatusCode),
+			StatusCode: resp.StatusCode,
+			Message:    fmt.Sprintf("API 返回错误 (HTTP %d): %s", resp.StatusCode, string(body)),
+		}
+	}
+
+	// 不支持批量调用的节点会返回单个错误对象而不是数组
+	var responses []jsonRPCResponse
+	if err := json.Unmarshal(body, &responses); err != nil {
+		return nil, &QueryError{Kind: ErrorKindResponse, Message: fmt.Sprintf("解析批量响应失败（节点可能不支持 JSON-RPC 批量调用）: %s", string(body))}
+	}
+
+	results := make([]BatchBalance, len(addresses))
+	received := make([]bool, len(addresses))
+	for _, r := range responses {
+		if r.ID < 0 || r.ID >= len(addresses) {
+			continue
+		}
+		received[r.ID] = true
+		result := &results[r.ID]
+		result.Address = addresses[r.ID]
+		if r.Error != nil {
+			result.Err = &QueryError{Kind: ErrorKindResponse, Message: fmt.Sprintf("eth_call 失败 (%d): %s", r.Error.Code, r.Error.Message)}
+			continue
+		}
+		result.RawHex = strings.TrimPrefix(r.Result, "0x")
+		balance, err := FormatTokenHex(result.RawHex, token.Decimals)
+		if err != nil {
+			result.Err = &QueryError{Kind: ErrorKindResponse, Message: err.Error()}
+			continue
+		}
+		result.Balance = balance
+	}
+	for i := range results {
+		if !received[i] {
+			results[i] = BatchBalance{
+				Address: addresses[i],
+				Err:     &QueryError{Kind: ErrorKindResponse, Message: "批量响应中缺少该地址的结果"},
+			}
+		}
+	}
+	return results, nil
+}
@@ -1,6 +1,7 @@
 package tron
 
 import (
+	"fmt"
 	"sync"
 	"time"
 )
@@ -13,6 +14,28 @@ type RateLimiter struct {
 	maxTokens int           // 最大令牌数
 	lastRefill time.Time    // 上次补充令牌的时间
 	mu        sync.Mutex    // 互斥锁
+
+	// 统计（见 Stats）
+	started  time.Time     // 第一次 Wait 的时间
+	requests int64         // 累计获得令牌的次数
+	waits    int64         // 累计因令牌不足而等待的次数
+	waitTime time.Duration // 累计等待时长
+}
+
+// RateLimiterStats 限流器的运行统计，用于判断是否被限流卡住、速率设置是否合理
+type RateLimiterStats struct {
+	Tokens     int           // 当前令牌数（等待后借用的令牌不计，最小为 0）
+	Rate       int           // 设置的每秒请求数
+	Requests   int64         // 累计获得令牌的次数
+	Waits      int64         // 累计因令牌不足而等待的次数
+	WaitTime   time.Duration // 累计等待时长
+	ActualRate float64       // 平均实际速率（从第一次 Wait 起每秒获得令牌的次数）
+}
+
+// String 返回统计的显示文案（界面和日志使用）
+func (s RateLimiterStats) String() string {
+	return fmt.Sprintf("限流器: 剩余令牌 %d，等待 %d 次，累计等待 %s，实际速率 %.1f 次/秒（设置 %d 次/秒）",
+		s.Tokens, s.Waits, s.WaitTime.Round(time.Millisecond), s.ActualRate, s.Rate)
 }
 
 // NewRateLimiter 创建新的限流器
@@ -26,10 +49,55 @@ func NewRateLimiter(rate int, interval time.Duration) *RateLimiter {
 	}
 }
 
+// SetRate 动态调整每秒允许的请求数（<1 时按 1 处理），立即对后续的 Wait 生效
+// 多个客户端共享同一个限流器时，可以在查询过程中实时调整整体速度
+func (rl *RateLimiter) SetRate(rate int) {
+	if rate < 1 {
+		rate = 1
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.rate = rate
+	rl.maxTokens = rate
+	if rl.tokens > rate {
+		rl.tokens = rate
+	}
+}
+
+// Rate 返回当前每秒允许的请求数
+func (rl *RateLimiter) Rate() int {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.rate
+}
+
+// Stats 返回限流器的运行统计
+func (rl *RateLimiter) Stats() RateLimiterStats {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	stats := RateLimiterStats{
+		Tokens:   rl.tokens,
+		Rate:     rl.rate,
+		Requests: rl.requests,
+		Waits:    rl.waits,
+		WaitTime: rl.waitTime,
+	}
+	if stats.Tokens < 0 {
+		stats.Tokens = 0
+	}
+	if elapsed := time.Since(rl.started); !rl.started.IsZero() && elapsed > 0 {
+		stats.ActualRate = float64(rl.requests) / elapsed.Seconds()
+	}
+	return stats
+}
+
 // Wait 等待直到可以获得令牌
 func (rl *RateLimiter) Wait() {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
+	if rl.started.IsZero() {
+		rl.started = time.Now()
+	}
 
 	// 补充令牌
 	now := time.Now()
@@ -51,6 +119,8 @@ func (rl *RateLimiter) Wait() {
 		rl.mu.Unlock()
 		time.Sleep(waitTime)
 		rl.mu.Lock()
+		rl.waits++
+		rl.waitTime += waitTime
 		// 重新补充
 		now = time.Now()
 		elapsed = now.Sub(rl.lastRefill)
@@ -66,4 +136,5 @@ func (rl *RateLimiter) Wait() {
 
 	// 消耗一个令牌
 	rl.tokens--
+	rl.requests++
 }
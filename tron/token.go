@@ -0,0 +1,120 @@
+package tron
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Token TRC20 代币（查询余额用）
+type Token struct {
+	Symbol   string // 代币符号，用于表头（如 "USDT"）；只写了合约地址时为空，查询前由 ResolveTokenSymbols 填写
+	Contract string // 合约地址（Base58）
+	Decimals int    // 小数位数
+	Name     string // 代币名称（如 "Tether USD"），自定义合约由 ResolveTokenSymbols 查询，可为空
+}
+
+// Label 返回代币的显示文字，有名称时为 "USDC（USD Coin）"，否则为符号
+func (t Token) Label() string {
+	if t.Name == "" || t.Name == t.Symbol {
+		return t.Symbol
+	}
+	return t.Symbol + "（" + t.Name + "）"
+}
+
+// 内置代币
+var (
+	USDTToken = Token{Symbol: "USDT", Contract: USDTContractAddress, Decimals: 6, Name: "Tether USD"}
+	USDCToken = Token{Symbol: "USDC", Contract: "TEkxiTehnzSmSe2XqrBj4w32RUN966rdz8", Decimals: 6, Name: "USD Coin"}
+	USDDToken = Token{Symbol: "USDD", Contract: "TPYmHEhy5n8TCEfYGqW2rPxsghSfzghPDn", Decimals: 18, Name: "Decentralized USD"}
+)
+
+// KnownTokens 按符号查找的内置代币
+var KnownTokens = map[string]Token{
+	USDTToken.Symbol: USDTToken,
+	USDCToken.Symbol: USDCToken,
+	USDDToken.Symbol: USDDToken,
+}
+
+// ParseTokens 解析代币列表（逗号分隔），空字符串返回只有 USDT 的列表
+//
+// 每一项可以是内置代币符号（USDT、USDC、USDD，不区分大小写），
+// 也可以是 "符号:合约地址:小数位数" 形式的自定义代币，或省略符号的 "合约地址:小数位数"
+// （符号为空，查询前由 ResolveTokenSymbols 查询合约的 symbol()）；重复的符号或合约只保留第一个
+func ParseTokens(s string) ([]Token, error) {
+	if strings.TrimSpace(s) == "" {
+		return []Token{USDTToken}, nil
+	}
+
+	var tokens []Token
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		var token Token
+		if parts := strings.Split(item, ":"); len(parts) == 3 {
+			decimals, err := strconv.Atoi(strings.TrimSpace(parts[2]))
+			if err != nil || decimals < 0 || decimals > 36 {
+				return nil, fmt.Errorf("代币 %s 的小数位数无效", parts[0])
+			}
+			token = Token{
+				Symbol:   strings.ToUpper(strings.TrimSpace(parts[0])),
+				Contract: strings.TrimSpace(parts[1]),
+				Decimals: decimals,
+			}
+			if token.Symbol == "" {
+				return nil, fmt.Errorf("代币符号不能为空: %s", item)
+			}
+			if err := ValidateAddressWithError(token.Contract); err != nil {
+				return nil, fmt.Errorf("代币 %s 的合约地址无效: %v", token.Symbol, err)
+			}
+		} else if len(parts) == 2 {
+			decimals, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+			if err != nil || decimals < 0 || decimals > 36 {
+				return nil, fmt.Errorf("代币 %s 的小数位数无效", parts[0])
+			}
+			token = Token{Contract: strings.TrimSpace(parts[0]), Decimals: decimals}
+			if err := ValidateAddressWithError(token.Contract); err != nil {
+				return nil, fmt.Errorf("代币合约地址无效: %v", err)
+			}
+		} else if known, ok := KnownTokens[strings.ToUpper(item)]; ok {
+			token = known
+		} else {
+			return nil, fmt.Errorf("未知代币: %s（内置: USDT, USDC, USDD；自定义格式: 符号:合约地址:小数位数 或 合约地址:小数位数）", item)
+		}
+
+		key := token.Symbol
+		if key == "" {
+			key = token.Contract
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		tokens = append(tokens, token)
+	}
+
+	if len(tokens) == 0 {
+		return nil, errors.New("代币列表为空")
+	}
+	return tokens, nil
+}
+
+// FormatTokenHex 按代币的小数位数格式化 constant_result 中的原始 hex 值（去掉末尾的 0），空字符串视为 0
+func FormatTokenHex(rawHex string, decimals int) (string, error) {
+	balanceHex := strings.TrimSpace(rawHex)
+	if balanceHex == "" {
+		balanceHex = "0"
+	}
+
+	n := new(big.Int)
+	if _, ok := n.SetString(balanceHex, 16); !ok {
+		return "", fmt.Errorf("无法解析hex余额: %s", balanceHex)
+	}
+	return formatDecimals(n, decimals), nil
+}
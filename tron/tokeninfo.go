@@ -0,0 +1,142 @@
+package tron
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// tokenInfoCache 合约的 symbol()、name() 查询结果（节点地址|合约地址|函数 -> 字符串），进程内缓存
+// 代币的符号和名称部署后不会变化，同一个合约只查询一次
+var tokenInfoCache sync.Map
+
+// TokenSymbol 查询合约的 symbol()（结果按节点和合约缓存）
+func (c *APIClient) TokenSymbol(ctx context.Context, contract string) (string, error) {
+	return c.callStringConstant(ctx, contract, "symbol()")
+}
+
+// TokenName 查询合约的 name()（结果按节点和合约缓存）
+func (c *APIClient) TokenName(ctx context.Context, contract string) (string, error) {
+	return c.callStringConstant(ctx, contract, "name()")
+}
+
+// callStringConstant 调用合约返回字符串的无参常量函数，兼容返回 bytes32 的旧合约
+func (c *APIClient) callStringConstant(ctx context.Context, contract, selector string) (string, error) {
+	cacheKey := c.BaseURL + "|" + contract + "|" + selector
+	if value, ok := tokenInfoCache.Load(cacheKey); ok {
+		return value.(string), nil
+	}
+
+	reqBody := TriggerConstantContractRequest{
+		OwnerAddress:     BurnAddress,
+		ContractAddress:  contract,
+		FunctionSelector: selector,
+		Visible:          true,
+	}
+	var apiResp struct {
+		ConstantResult []string `json:"constant_result"`
+		Result         struct {
+			Result  bool   `json:"result"`
+			Message string `json:"message,omitempty"`
+		} `json:"result"`
+	}
+	if err := c.postJSON(ctx, c.endpoint(TriggerConstantContractPath), reqBody, &apiResp); err != nil {
+		return "", err
+	}
+	if !apiResp.Result.Result || len(apiResp.ConstantResult) == 0 {
+		return "", fmt.Errorf("调用 %s 失败: %s", selector, apiResp.Result.Message)
+	}
+
+	value, err := decodeABIString(apiResp.ConstantResult[0])
+	if err != nil {
+		return "", fmt.Errorf("解析 %s 结果失败: %v", selector, err)
+	}
+	tokenInfoCache.Store(cacheKey, value)
+	return value, nil
+}
+
+// decodeABIString 解码 ABI 编码的 string 返回值（偏移量 + 长度 + 内容）；
+// 只有 32 字节时按 bytes32 处理（去掉末尾的 0）
+func decodeABIString(rawHex string) (string, error) {
+	data, err := hex.DecodeString(strings.TrimSpace(rawHex))
+	if err != nil {
+		return "", err
+	}
+	if len(data) == 32 {
+		return validString(strings.TrimRight(string(data), "\x00"))
+	}
+	if len(data) < 64 {
+		return "", errors.New("返回值长度不足")
+	}
+	offset := new(big.Int).SetBytes(data[:32])
+	if !offset.IsInt64() || offset.Int64() > int64(len(data)-32) {
+		return "", errors.New("返回值偏移量无效")
+	}
+	start := int(offset.Int64())
+	length := new(big.Int).SetBytes(data[start : start+32])
+	if !length.IsInt64() || length.Int64() > int64(len(data)-start-32) {
+		return "", errors.New("返回值长度无效")
+	}
+	return validString(string(data[start+32 : start+32+int(length.Int64())]))
+}
+
+// validString 检查解码出的字符串是有效的 UTF-8 且不含控制字符
+func validString(s string) (string, error) {
+	if !utf8.ValidString(s) || strings.IndexFunc(s, func(r rune) bool { return r < 0x20 }) >= 0 {
+		return "", errors.New("返回值不是有效的文本")
+	}
+	return strings.TrimSpace(s), nil
+}
+
+// ShortContract 返回缩短的合约地址（如 "TEkxiT…rdz8"），代币符号查询失败时代替符号显示
+func ShortContract(contract string) string {
+	if len(contract) <= 12 {
+		return contract
+	}
+	return contract[:6] + "…" + contract[len(contract)-4:]
+}
+
+// ResolveTokenSymbols 为符号为空的代币（只写了合约地址，见 ParseTokens）查询 symbol() 和 name()
+//
+// 查询失败、符号为空或与列表中其他代币重复时使用 ShortContract 作为符号；已有符号的代币不查询。
+// 返回新的列表，不修改传入的列表
+func ResolveTokenSymbols(ctx context.Context, c *APIClient, tokens []Token) []Token {
+	resolved := append([]Token(nil), tokens...)
+	used := make(map[string]bool)
+	for _, token := range resolved {
+		if token.Symbol != "" {
+			used[token.Symbol] = true
+		}
+	}
+	for i := range resolved {
+		token := &resolved[i]
+		if token.Symbol != "" {
+			continue
+		}
+		symbol, err := c.TokenSymbol(ctx, token.Contract)
+		if err != nil || symbol == "" || used[symbol] {
+			symbol = ShortContract(token.Contract)
+		}
+		token.Symbol = symbol
+		used[symbol] = true
+		if token.Name == "" {
+			token.Name, _ = c.TokenName(ctx, token.Contract)
+		}
+	}
+	return resolved
+}
+
+// NeedsSymbolLookup 判断列表中是否有需要查询符号的代币
+func NeedsSymbolLookup(tokens []Token) bool {
+	for _, token := range tokens {
+		if token.Symbol == "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package view
+
+import (
+	"errors"
+	"sync"
+)
+
+// batchGroup 同一窗口中的所有批次（标签页），协调多个批次同时查询
+// 各批次共享同一个 APIKeyManager，额度统计和平滑使用额度的限速对所有批次统一生效
+type batchGroup struct {
+	mu         sync.Mutex
+	concurrent bool // 允许多个批次同时查询
+	notify     bool // 查询结束或自动暂停时发送系统通知
+	showTotal  bool // 查询完成时在状态栏显示余额合计
+	batches    []*MainViewModel
+}
+
+// prefNotify 是否发送系统通知在偏好设置中的键（默认开启）
+const prefNotify = "notify.enabled"
+
+// prefShowTotal 查询完成时是否在状态栏显示余额合计在偏好设置中的键（默认关闭）
+const prefShowTotal = "status.showTotal"
+
+// add 登记新的批次
+func (g *batchGroup) add(vm *MainViewModel) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.batches = append(g.batches, vm)
+}
+
+// remove 移除已关闭的批次
+func (g *batchGroup) remove(vm *MainViewModel) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for i, batch := range g.batches {
+		if batch == vm {
+			g.batches = append(g.batches[:i], g.batches[i+1:]...)
+			return
+		}
+	}
+}
+
+// setConcurrent 设置是否允许多个批次同时查询
+func (g *batchGroup) setConcurrent(enabled bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.concurrent = enabled
+}
+
+// setNotify 设置查询结束或自动暂停时是否发送系统通知
+func (g *batchGroup) setNotify(enabled bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.notify = enabled
+}
+
+// notifyEnabled 是否发送系统通知
+func (g *batchGroup) notifyEnabled() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.notify
+}
+
+// setShowTotal 设置查询完成时是否在状态栏显示余额合计
+func (g *batchGroup) setShowTotal(enabled bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.showTotal = enabled
+}
+
+// showTotalEnabled 查询完成时是否在状态栏显示余额合计
+func (g *batchGroup) showTotalEnabled() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.showTotal
+}
+
+// canStart 检查批次能否开始查询：未允许同时查询且其他批次正在查询时返回错误
+func (g *batchGroup) canStart(vm *MainViewModel) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.concurrent {
+		return nil
+	}
+	for _, batch := range g.batches {
+		if batch != vm && batch.QueryActive() {
+			return errors.New("其他批次正在查询\n\n请等待其完成，或勾选\"允许多个批次同时查询\"")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package view
+
+import (
+	"fmt"
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// statsChart 简单的统计柱状图（成功/失败/有余额/无余额），随查询实时更新
+type statsChart struct {
+	layouts   []*barLayout
+	bars      []*fyne.Container
+	valueText []*widget.Label
+	content   *fyne.Container
+}
+
+// 柱状图的分类与颜色
+var chartCategories = []struct {
+	name  string
+	color color.Color
+}{
+	{"成功", color.NRGBA{R: 0x43, G: 0xa0, B: 0x47, A: 0xff}},
+	{"失败", color.NRGBA{R: 0xe5, G: 0x39, B: 0x35, A: 0xff}},
+	{"有余额", color.NRGBA{R: 0x1e, G: 0x88, B: 0xe5, A: 0xff}},
+	{"无余额", color.NRGBA{R: 0x9e, G: 0x9e, B: 0x9e, A: 0xff}},
+}
+
+// newStatsChart 创建统计图表
+func newStatsChart() *statsChart {
+	chart := &statsChart{}
+	columns := make([]fyne.CanvasObject, 0, len(chartCategories))
+
+	for _, category := range chartCategories {
+		rect := canvas.NewRectangle(category.color)
+		layout := &barLayout{}
+		bar := container.New(layout, rect)
+		value := widget.NewLabelWithStyle("0", fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
+
+		chart.layouts = append(chart.layouts, layout)
+		chart.bars = append(chart.bars, bar)
+		chart.valueText = append(chart.valueText, value)
+
+		columns = append(columns, container.NewBorder(
+			value,
+			widget.NewLabelWithStyle(category.name, fyne.TextAlignCenter, fyne.TextStyle{}),
+			nil, nil,
+			bar,
+		))
+	}
+
+	chart.content = container.NewGridWithColumns(len(chartCategories), columns...)
+	return chart
+}
+
+// Update 更新图表数据（需在主线程调用）
+func (c *statsChart) Update(success, failed, withBalance, withoutBalance int) {
+	values := []int{success, failed, withBalance, withoutBalance}
+
+	maxValue := 0
+	for _, v := range values {
+		if v > maxValue {
+			maxValue = v
+		}
+	}
+
+	for i, v := range values {
+		ratio := float32(0)
+		if maxValue > 0 {
+			ratio = float32(v) / float32(maxValue)
+		}
+		c.layouts[i].ratio = ratio
+		c.bars[i].Refresh()
+		c.valueText[i].SetText(fmt.Sprintf("%d", v))
+	}
+}
+
+// Content 返回图表的界面对象
+func (c *statsChart) Content() fyne.CanvasObject {
+	return c.content
+}
+
+// barLayout 按比例从底部绘制柱子的布局
+type barLayout struct {
+	ratio float32 // 柱子高度占比（0-1）
+}
+
+// Layout 将柱子放在底部，高度按比例计算
+func (l *barLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	height := size.Height * l.ratio
+	for _, obj := range objects {
+		obj.Resize(fyne.NewSize(size.Width*0.6, height))
+		obj.Move(fyne.NewPos(size.Width*0.2, size.Height-height))
+	}
+}
+
+// MinSize 返回柱状图区域的最小尺寸
+func (l *barLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
+	return fyne.NewSize(40, 80)
+}
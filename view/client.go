@@ -1,33 +1,380 @@
 package view
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 	"usdt-balance-checker/core"
+	"usdt-balance-checker/tron"
 
 	"github.com/ethereum/go-ethereum/log"
 )
 
-func RunCLI(inputFile, outputFile, apiKey, nodeURL string, rateLimit int) {
+// CLIOptions CLI 模式的运行参数（对应 main.go 中的命令行标志）
+type CLIOptions struct {
+	InputFile      string // 输入文件路径
+	OutputFile     string // 输出文件路径
+	APIKey         string // TronGrid API Key
+	NodeURL        string // 自定义节点 URL
+	RateLimit      int    // 每秒请求数
+	ContractFilter string // 合约地址检查模式："", "flag", "exclude"
+	Shuffle        bool   // 是否打乱查询顺序
+	StartIndex     int    // 从第几个地址开始查询（跳过前 N 个已加载的地址）
+	ResumeFrom     string // 上次的结果文件，非空时从 StartIndex 之后第一个未完成的地址继续查询（见 core.ResumeIndex）
+	VerifyResults  string // 结果文件路径，非空时只校验输入文件的指纹，不执行查询
+	GoogleSheetID  string // Google 表格 ID，非空时额外导出到 Google 表格
+	GoogleCredFile string // Google 服务账号凭证文件路径
+	StreamJSONL    bool   // 每完成一个地址立即向 stdout 输出一行 JSON，日志改写到 stderr
+	KeepDuplicates bool   // 保留输入中的重复地址（每行一个结果，同一地址只查询一次）
+	KeepInvalid    bool   // 保留输入中的无效地址（结果状态为 invalid，不发送请求）
+	Language       string // 导出表头和状态文案语言："zh"（默认）或 "en"
+	SplitFiles     bool   // Excel 超过行数上限时拆分为多个文件（默认拆分为多个工作表）
+	CompareWith    string // 上次的结果文件，非空时只导出余额发生变化的地址
+	OnlyNonzero    string // 上次的结果文件，非空时只查询其中有余额的地址（和新增地址）
+	Sheet          string // Excel 输入的工作表名称，空为第一个工作表
+	Column         string // 只读取输入中的该列：表头名或列字母
+	DryRun         bool   // 只打印前 10 行被识别为地址的单元格，不执行查询
+	NoStats        bool   // 不读写 Key 使用统计文件，使用次数只保存在内存中
+	PaceKeys       bool   // 平滑使用额度：按剩余额度和距离重置的时间给每个 Key 限速
+	RawHex         bool   // 导出时增加节点原始 hex 列
+	AllowFormulas  bool   // 导出时原样保留以公式字符开头的单元格（见 core.ExportOptions.AllowFormulas）
+	Tokens         string // 要查询的代币（逗号分隔，见 tron.ParseTokens），空为只查 USDT
+	Open           bool   // 导出完成后用系统默认程序打开结果文件
+	RPCBatch       int    // JSON-RPC 批量调用每批的地址数，<=1 时逐个查询
+	MergeFiles     string // 要合并的结果文件（逗号分隔），非空时只合并导出，不执行查询
+	OwnerAddress   string // 余额查询固定使用的 owner_address，空为使用被查询的地址
+	MaxAddresses   int    // 最多读取的地址数，0 不限制
+	Watchlist      string // 关注列表文件，非空时标记匹配的结果并在导出中增加"关注"列
+	AlertRules     string // 告警规则文件（见 core.LoadAlertRules），非空时导出余额跨越阈值的地址
+	AlertOutput    string // 告警文件路径，空为输出文件名加 _alerts.csv
+	StatusLabels   string // 自定义导出的状态文案（见 core.ParseStatusLabels），覆盖 Language 中的文案
+	TemplateFile   string // 导出模板文件（Go text/template），非空时按模板导出，不再按扩展名导出 CSV/Excel
+	BalanceSink    string // 实时写入文件，非空时查询中每查到一个有余额的地址立即追加写入（见 core.QueryManager.SetFilteredSink）
+	ErrorLog       string // 错误日志文件，非空时追加写入失败地址的完整错误信息（见 core.QueryManager.SetErrorLog）
+	MaxErrorLength int    // 结果中错误信息的最大长度（见 core.QueryManager.SetMaxErrorLength），0 使用默认
+	ShowTotal      bool   // 查询完成时在汇总中显示余额合计（见 core.SumBalances）
+	RetryPolicy    string // 按错误类别的重试次数（见 core.ParseRetryPolicy），空为默认
+	AutoRetry      int    // 主查询结束后自动重试失败地址的最多轮数（见 core.QueryManager.SetAutoRetry），0 关闭
+	MemoryLimit    string // 内存守护的上限（见 core.ParseMemorySize），空为使用 GOMEMLIMIT，都没有时不开启
+	QRDir          string // 二维码图片目录，非空时为有余额的地址各生成一张地址二维码
+	KeyRateLimit   int    // 每个 Key 每秒请求数上限（见 APIKeyManager.SetKeyRateLimit），<=0 不限制
+	Profile        string // 配置方案名称（见 core.FindProfile），非空时用方案中的速率、线程数、节点和代币
+	Threads        int    // 并发线程数，<1 时为 1；开启 AutoThreads 时为上限
+	AutoThreads    bool   // 自动调整线程数（见 core.QueryManager.SetAutoThreads）
+
+	KeepAlive     time.Duration   // 连接保活间隔，0 为关闭
+	KeyInterval   time.Duration   // 同一个 Key 两次请求的最小间隔，0 不限制
+	Timeout       time.Duration   // 查询的总时长上限，到时取消查询并导出已完成的部分结果，退出码为 exitPartial；0 不限制
+	ExplicitFlags map[string]bool // 命令行中显式指定的参数名，这些参数不会被配置方案覆盖
+}
+
+// 查询提前结束、只导出了部分结果时 CLI 的退出码（按结束原因区分，见 finishExitCode）
+const (
+	exitPartial       = 3 // 达到查询时长上限（或其他原因取消）
+	exitKeysExhausted = 4 // 所有 API Key 额度用完
+	exitMemoryLimit   = 5 // 内存占用达到上限
+)
+
+// finishExitCode 返回查询结束原因对应的退出码，全部完成时为 0
+func finishExitCode(reason core.FinishReason) int {
+	switch reason {
+	case core.FinishCompleted:
+		return 0
+	case core.FinishKeysExhausted:
+		return exitKeysExhausted
+	case core.FinishMemoryLimit:
+		return exitMemoryLimit
+	}
+	return exitPartial
+}
+
+// cliLoadOptions 按命令行参数构建加载地址的选项（查询和 -verify 使用同一份选项，输入指纹才能一致）
+func cliLoadOptions(opts CLIOptions) core.LoadOptions {
+	return core.LoadOptions{
+		KeepDuplicates: opts.KeepDuplicates,
+		KeepInvalid:    opts.KeepInvalid,
+		Sheet:          opts.Sheet,
+		Column:         opts.Column,
+		MaxAddresses:   opts.MaxAddresses,
+	}
+}
+
+// applyProfile 将配置方案中的设置应用到 CLI 选项，命令行中显式指定的参数和方案中的空值不覆盖
+func applyProfile(opts CLIOptions, profile core.Profile) CLIOptions {
+	if profile.RateLimit > 0 && !opts.ExplicitFlags["rate"] {
+		opts.RateLimit = profile.RateLimit
+	}
+	if profile.Threads > 0 && !opts.ExplicitFlags["threads"] {
+		opts.Threads = profile.Threads
+	}
+	if profile.NodeURL != "" && !opts.ExplicitFlags["node-url"] {
+		opts.NodeURL = profile.NodeURL
+	}
+	if profile.Tokens != "" && !opts.ExplicitFlags["tokens"] {
+		opts.Tokens = profile.Tokens
+	}
+	return opts
+}
+
+// streamRecord -stream-jsonl 模式下每行输出的 JSON 对象
+type streamRecord struct {
+	Index       int    `json:"index"` // 地址在输入中的位置（从 0 开始，已扣除 -start-index）
+	Address     string `json:"address"`
+	Balance     string `json:"balance"`
+	Status      string `json:"status"` // success / error / cancelled / skipped
+	Error       string `json:"error,omitempty"`
+	AddressType string `json:"address_type,omitempty"`
+
+	TokenBalances map[string]string `json:"token_balances,omitempty"` // 其他代币的余额（-tokens 指定多个代币时）
+}
+
+func RunCLI(opts CLIOptions) {
 	// CLI 实现（基础版本）
 	// 可以通过命令行参数指定输入文件和输出文件
 	// 例如: ./usdt-balance-checker -cli -input addresses.txt -output results.csv -api-key YOUR_KEY
 
+	inputFile := opts.InputFile
+	outputFile := opts.OutputFile
+	apiKey := opts.APIKey
+	nodeURL := opts.NodeURL
+	rateLimit := opts.RateLimit
+
+	// 合并模式：合并多个结果文件后导出，不需要输入文件
+	if opts.MergeFiles != "" {
+		runMerge(opts)
+		return
+	}
+
 	if inputFile == "" {
 		os.Exit(1)
 	}
 
-	// 加载地址
-	addresses, err := core.LoadAddressesFromFile(inputFile)
+	// 流式输出时 stdout 只输出 JSON，日志和进度全部写到 stderr
+	if opts.StreamJSONL {
+		log.SetDefault(log.NewLogger(log.NewTerminalHandler(os.Stderr, false)))
+	}
+
+	// 校验模式：检查输入文件是否就是生成该结果文件时使用的地址列表
+	if opts.VerifyResults != "" {
+		ok, expected, actual, err := core.VerifyInputFile(inputFile, opts.VerifyResults, cliLoadOptions(opts))
+		if err != nil {
+			log.Error("错误: 校验失败: %v\n", err)
+			os.Exit(1)
+		}
+		log.Info("结果文件记录的指纹: %s\n", expected)
+		log.Info("输入文件计算的指纹: %s\n", actual)
+		if !ok {
+			log.Error("校验不通过: 输入文件与结果文件不匹配\n")
+			os.Exit(1)
+		}
+		log.Info("校验通过: 输入文件与结果文件匹配\n")
+		return
+	}
+
+	loadOpts := cliLoadOptions(opts)
+
+	// 试运行：打印前几行实际读取的单元格，便于确认列映射，不发送任何请求
+	if opts.DryRun {
+		runDryRun(inputFile, loadOpts)
+		return
+	}
+
+	// 配置方案：命令行中没有显式指定的设置使用方案中的值
+	if opts.Profile != "" {
+		profile, err := core.FindProfile(opts.Profile)
+		if err != nil {
+			log.Error("错误: %v\n", err)
+			os.Exit(1)
+		}
+		opts = applyProfile(opts, profile)
+		nodeURL = opts.NodeURL
+		rateLimit = opts.RateLimit
+		log.Info("使用配置方案: %s\n", profile.Name)
+	}
+
+	contractMode, err := core.ParseContractFilterMode(opts.ContractFilter)
+	if err != nil {
+		log.Error("错误: %v\n", err)
+		os.Exit(1)
+	}
+
+	exportLang, err := core.ParseExportLanguage(opts.Language)
+	if err != nil {
+		log.Error("错误: %v\n", err)
+		os.Exit(1)
+	}
+	statusLabels, err := core.ParseStatusLabels(opts.StatusLabels)
+	if err != nil {
+		log.Error("错误: %v\n", err)
+		os.Exit(1)
+	}
+
+	// 导出模板在查询前读取并检查，避免查询完成后才发现模板有误
+	exportTemplate := ""
+	if opts.TemplateFile != "" {
+		data, err := os.ReadFile(opts.TemplateFile)
+		if err != nil {
+			log.Error("错误: 读取导出模板失败: %v\n", err)
+			os.Exit(1)
+		}
+		exportTemplate = string(data)
+		if _, err := core.ParseExportTemplate(exportTemplate); err != nil {
+			log.Error("错误: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	tokens, err := tron.ParseTokens(opts.Tokens)
+	if err != nil {
+		log.Error("错误: %v\n", err)
+		os.Exit(1)
+	}
+	// 校验节点 URL（缺少协议时自动补全 https://）
+	nodeURL, err = tron.NormalizeNodeURL(nodeURL)
+	if err != nil {
+		log.Error("错误: %v\n", err)
+		os.Exit(1)
+	}
+
+	// 检查输出目录的磁盘空间，避免长时间查询后无法导出
+	if err := core.CheckDiskSpace(outputFile); err != nil {
+		log.Error("错误: %v\n", err)
+		os.Exit(1)
+	}
+
+	// 可选：提前加载 Google 凭证，避免查询结束后才发现配置错误
+	var googleCreds *core.GoogleCredentials
+	if opts.GoogleSheetID != "" {
+		if opts.GoogleCredFile == "" {
+			log.Error("错误: 导出到 Google 表格需要指定 -gsheet-credentials\n")
+			os.Exit(1)
+		}
+		googleCreds, err = core.LoadGoogleCredentials(opts.GoogleCredFile)
+		if err != nil {
+			log.Error("错误: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// 可选：提前加载上次的结果，用于只导出变化的地址
+	var previousResults []core.QueryResult
+	if opts.CompareWith != "" {
+		previousResults, err = core.LoadResultsFromFile(opts.CompareWith)
+		if err != nil {
+			log.Error("错误: 加载对比结果失败: %v\n", err)
+			os.Exit(1)
+		}
+		log.Info("已加载上次结果 %d 条，将只导出余额变化的地址\n", len(previousResults))
+	}
+
+	// 可选：关注列表
+	var watchlist *core.Watchlist
+	if opts.Watchlist != "" {
+		watchlist, err = core.LoadWatchlist(opts.Watchlist)
+		if err != nil {
+			log.Error("错误: 加载关注列表失败: %v\n", err)
+			os.Exit(1)
+		}
+		log.Info("已加载关注列表: %d 个地址\n", watchlist.Count())
+	}
+
+	// 可选：每个地址的告警阈值
+	var alertRules map[string]float64
+	if opts.AlertOutput != "" && opts.AlertRules == "" {
+		log.Error("错误: -alert-output 需要同时指定 -alert-rules\n")
+		os.Exit(1)
+	}
+	if opts.AlertRules != "" {
+		alertRules, err = core.LoadAlertRules(opts.AlertRules)
+		if err != nil {
+			log.Error("错误: 加载告警规则失败: %v\n", err)
+			os.Exit(1)
+		}
+		log.Info("已加载告警规则: %d 个地址\n", len(alertRules))
+	}
+
+	// 加载地址（文件过大时只提示，地址数上限见 -max-addresses）
+	if err := core.CheckInputFileSize(inputFile); err != nil {
+		log.Warn(err.Error())
+	}
+	addresses, report, err := core.LoadAddressesFromFileWithReport(inputFile, loadOpts)
 	if err != nil {
 		log.Error("错误: 加载地址失败: %v\n", err)
 		os.Exit(1)
 	}
+	if text := report.Text(); text != "" {
+		log.Warn(text)
+	}
 
 	log.Info("已加载 %d 个地址，开始查询...\n", len(addresses))
+	// 输入指纹按完整的输入文件计算（跳过、只查询部分地址之前），-verify 用同一个输入文件才能校验通过
+	inputHash := core.InputHash(addresses)
+
+	// 跳过前 N 个地址（用于从指定位置重新开始）
+	if opts.StartIndex < 0 || (opts.StartIndex > 0 && opts.StartIndex >= len(addresses)) {
+		log.Error("错误: 起始索引 %d 超出范围（共 %d 个地址）\n", opts.StartIndex, len(addresses))
+		os.Exit(1)
+	}
+	startIndex := opts.StartIndex
+	// 从上次的结果继续：跳过上次已完成的地址，从第一个未完成的地址开始
+	if opts.ResumeFrom != "" {
+		previous, err := core.LoadResultsFromFile(opts.ResumeFrom)
+		if err != nil {
+			log.Error("错误: 加载上次结果失败: %v\n", err)
+			os.Exit(1)
+		}
+		startIndex = core.ResumeIndex(addresses, previous, startIndex)
+		if startIndex >= len(addresses) {
+			log.Info("上次的结果中 %d 个地址都已完成，无需继续查询\n", len(addresses)-opts.StartIndex)
+			return
+		}
+		log.Info("从上次的结果继续: 第 %d 个地址之前都已完成\n", startIndex+1)
+	}
+	if startIndex > 0 {
+		addresses = addresses[startIndex:]
+		log.Info("跳过前 %d 个地址，从第 %d 个开始，剩余 %d 个\n", startIndex, startIndex+1, len(addresses))
+	}
+
+	// 只查询上次有余额的地址
+	if opts.OnlyNonzero != "" {
+		previous, err := core.LoadResultsFromFile(opts.OnlyNonzero)
+		if err != nil {
+			log.Error("错误: 加载上次结果失败: %v\n", err)
+			os.Exit(1)
+		}
+		total := len(addresses)
+		addresses = core.PreviouslyNonzero(addresses, previous)
+		log.Info("只查询上次有余额的地址（含新增地址）: %d 个，跳过上次余额为 0 的 %d 个\n", len(addresses), total-len(addresses))
+		if len(addresses) == 0 {
+			log.Info("上次结果中没有有余额的地址，无需查询\n")
+			return
+		}
+	}
 
 	// 创建 API Key Manager（CLI 模式支持单个 Key）
 	keyManager := core.NewAPIKeyManager()
+	keyManager.SetStatsPersistence(!opts.NoStats)
+	keyManager.SetPacing(opts.PaceKeys)
+	keyManager.SetKeyRateLimit(opts.KeyRateLimit)
+	keyManager.SetPerKeyMinInterval(opts.KeyInterval)
+	// 出错或查询提前结束（见 finishExitCode）时以非零退出码退出。os.Exit 不执行其他 defer，所以在保存统计之前注册，使其最后执行；
+	// 此后出错只设置 exitCode 并 return，不直接调用 os.Exit，否则统计不会保存、统计文件锁不会释放
+	exitCode := 0
+	defer func() {
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	}()
+	// 退出时写入使用统计并释放统计文件锁（统计文件被其他实例占用时合并本次的使用次数）
+	defer func() {
+		if err := keyManager.CloseStats(); err != nil {
+			log.Warn("保存 Key 使用统计失败: %v\n", err)
+		}
+	}()
 	if apiKey != "" {
 		// 创建临时文件添加单个 API Key
 		tempKeyFile := "temp_cli_key.txt"
@@ -44,30 +391,385 @@ func RunCLI(inputFile, outputFile, apiKey, nodeURL string, rateLimit int) {
 	// 创建查询管理器
 	qm := core.NewQueryManager(keyManager, nodeURL)
 	qm.SetRateLimit(rateLimit)
+	qm.SetMaxConcurrent(opts.Threads)
+	if opts.AutoThreads {
+		if opts.Threads <= 1 {
+			qm.SetMaxConcurrent(core.DefaultAutoThreadsMax)
+		}
+		qm.SetAutoThreads(true)
+	}
+	qm.SetContractFilter(contractMode)
+	qm.SetShuffle(opts.Shuffle)
+	qm.SetTokens(tokens)
+	qm.SetInputHash(inputHash)
+	qm.SetJSONRPCBatch(opts.RPCBatch)
+	qm.SetKeepAlive(opts.KeepAlive)
+	qm.SetMaxErrorLength(opts.MaxErrorLength)
+	if err := qm.SetOwnerAddress(opts.OwnerAddress); err != nil {
+		log.Error("错误: %v\n", err)
+		exitCode = 1
+		return
+	}
+	retryPolicy, err := core.ParseRetryPolicy(opts.RetryPolicy)
+	if err != nil {
+		log.Error("错误: %v\n", err)
+		exitCode = 1
+		return
+	}
+	qm.SetRetryPolicy(retryPolicy)
+	qm.SetAutoRetry(opts.AutoRetry)
+	memoryLimit, err := core.ParseMemorySize(opts.MemoryLimit)
+	if err != nil {
+		log.Error("错误: %v\n", err)
+		exitCode = 1
+		return
+	}
+	if opts.MemoryLimit == "" {
+		memoryLimit = core.RuntimeMemoryLimit()
+	}
+	if memoryLimit > 0 {
+		qm.SetMemoryGuard(memoryLimit)
+		log.Info("内存守护: 上限 %s\n", core.FormatMemorySize(memoryLimit))
+	}
+
+	// 只写了合约地址的代币在查询开始时查询 symbol()，导出使用查到的符号（查询结束后从 qm.Tokens() 读取）
+	qm.SetTokensCallback(func(resolved []tron.Token) {
+		for _, token := range resolved {
+			log.Info("代币: %s %s\n", token.Label(), token.Contract)
+		}
+	})
+
+	// 预估请求数，剩余额度可能不够时提前警告（不阻止查询）
+	estimate := core.EstimateRun(core.QueryableAddresses(addresses), core.QueryOptions{
+		ContractFilter: contractMode,
+		Tokens:         tokens,
+		JSONRPCBatch:   opts.RPCBatch,
+		RetryPolicy:    &retryPolicy,
+	}, keyManager)
+	if estimate.Verdict == core.EstimateSufficient {
+		log.Info(estimate.Text())
+	} else {
+		log.Warn(estimate.Text())
+	}
+
+	if opts.StreamJSONL {
+		var streamMu sync.Mutex
+		encoder := json.NewEncoder(os.Stdout)
+		qm.SetResultCallback(func(index int, result core.QueryResult) {
+			streamMu.Lock()
+			defer streamMu.Unlock()
+			if err := encoder.Encode(streamRecord{
+				Index:       index,
+				Address:     result.Address,
+				Balance:     result.DisplayBalance(),
+				Status:      string(result.Status),
+				Error:       result.Error,
+				AddressType: result.AddressType,
+
+				TokenBalances: result.TokenBalances,
+			}); err != nil {
+				log.Error("错误: 输出结果失败: %v\n", err)
+			}
+		})
+	}
+
+	// 心跳：长时间没有新结果时输出提示，避免看起来像卡住
+	heartbeatDone := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(core.HeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-heartbeatDone:
+				return
+			case <-ticker.C:
+				if activity := qm.GetActivity(); activity.Stalled() {
+					log.Info(activity.HeartbeatText())
+					if text := activity.RetryText(); text != "" {
+						log.Info(text)
+					}
+					log.Info(qm.RateLimiterStats().String())
+				}
+			}
+		}
+	}()
+
+	// 有余额的地址实时追加写入单独的文件，不必等全部完成
+	if opts.BalanceSink != "" {
+		sinkFile, err := os.OpenFile(opts.BalanceSink, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			log.Error("错误: 打开实时写入文件失败: %v\n", err)
+			exitCode = 1
+			return
+		}
+		defer sinkFile.Close()
+		qm.SetFilteredSink(core.HasBalance, sinkFile)
+		log.Info("有余额的地址将实时写入: %s\n", opts.BalanceSink)
+	}
+
+	// 失败地址的完整错误信息追加写入错误日志（结果中的错误信息会被截断）
+	if opts.ErrorLog != "" {
+		errorLogFile, err := os.OpenFile(opts.ErrorLog, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			log.Error("错误: 打开错误日志失败: %v\n", err)
+			exitCode = 1
+			return
+		}
+		defer errorLogFile.Close()
+		qm.SetErrorLog(errorLogFile)
+		log.Info("完整的错误信息将写入: %s\n", opts.ErrorLog)
+	}
+
+	// 进度里程碑（25%、50%、75%、100%）单独记录一行，便于在日志中查看长任务的进展
+	qm.SetMilestoneCallback(func(percent int) {
+		log.Info("\n里程碑: 已完成 %d%%（%s）\n", percent, qm.Progress().Text())
+	})
+
+	// 总时长上限：到时取消查询，未完成的地址在结果中为待查询或已取消，已完成的结果照常导出
+	if opts.Timeout > 0 {
+		timer := time.AfterFunc(opts.Timeout, func() {
+			log.Warn("\n已达到查询时长上限 %v，停止查询并导出已完成的结果\n", opts.Timeout)
+			qm.CancelWithReason(core.FinishDeadline)
+		})
+		defer timer.Stop()
+	}
 
 	// 查询
-	qm.QueryAddresses(addresses, func(cur, total int) {
-		log.Info("\r进度: %d / %d (%.1f%%)", cur, total, float64(cur)/float64(total)*100)
+	err = qm.QueryAddresses(addresses, func(cur, total int) {
+		progress := qm.Progress()
+		log.Info("\r进度: %s (%.1f%%)", progress.Text(), progress.Fraction()*100)
 	})
+	close(heartbeatDone)
+	if err != nil {
+		log.Error("错误: %v\n", err)
+		exitCode = 1
+		return
+	}
 	log.Info("\n") // 换行
 
 	// 获取结果
 	results := qm.GetResults()
-	total, success, failed := qm.GetStats()
+	summary := qm.GetSummary()
+	tokens = qm.Tokens()
+	tokenSymbols := make([]string, len(tokens))
+	for i, token := range tokens {
+		tokenSymbols[i] = token.Symbol
+	}
 
-	log.Info("查询完成! 总计: %d, 成功: %d, 失败: %d\n", total, success, failed)
+	finished := "查询完成!"
+	reason := qm.FinishReason()
+	exitCode = finishExitCode(reason)
+	if reason != core.FinishCompleted {
+		finished = fmt.Sprintf("查询提前结束（%s，退出码 %d）!", reason, exitCode)
+		log.Warn("只完成了部分地址（%s），未完成的地址在结果中为待查询或已取消\n", qm.Progress().Text())
+	}
+	log.Info("%s 总计: %d, 成功: %d, 失败: %d\n", finished, summary.Total, summary.Success, summary.Failed)
+	if opts.ShowTotal {
+		for i, symbol := range tokenSymbols {
+			var sum string
+			if i == 0 {
+				sum = core.SumBalances(results) // 第一个代币的余额在 Balance 中
+			} else {
+				sum = core.SumTokenBalances(results, symbol)
+			}
+			log.Info("%s 合计: %s\n", symbol, core.FormatBalanceTotal(sum))
+		}
+	}
+	if text := summary.AutoRetryText(); text != "" {
+		log.Info("%s\n", text)
+	}
+	if text := summary.AutoThreadsText(); text != "" {
+		log.Info("%s（可用 -threads %d 固定）\n", text, summary.AutoThreads)
+	}
+	if summary.Failed > 0 {
+		log.Info("%s\n", summary.FailureText())
+	}
+	if qm.KeysExhausted() {
+		log.Warn("所有 API Key 都已达到使用上限，查询已自动暂停，未查询的地址在结果中标记为已取消\n")
+	}
+	if qm.MemoryExceeded() {
+		log.Warn("内存占用达到上限，查询已自动暂停，未查询的地址在结果中标记为已取消；可以拆分输入文件（split 子命令）后分批查询\n")
+	} else if qm.LowMemory() {
+		log.Warn("查询中内存占用接近上限，已切换到低内存模式，导出的原始值列为空\n")
+	}
+	log.Info(summary.BaselineText())
+	log.Info("输入指纹: %s\n", summary.InputHash)
+	log.Info("重试次数: %s\n", summary.RetryText())
+	for _, line := range summary.KeyUsageText() {
+		log.Info("Key 使用 %s\n", line)
+	}
+	log.Info(qm.RateLimiterStats().String())
+	if watchlist != nil {
+		log.Info("关注列表中的地址: %d 行\n", watchlist.Apply(results))
+	}
+	applySavedTags(results)
 
 	// 导出结果
-	if strings.HasSuffix(strings.ToLower(outputFile), ".xlsx") {
-		err = core.ExportToExcel(results, outputFile)
+	exportOpts := core.ExportOptions{Summary: &summary, Language: exportLang, SplitFiles: opts.SplitFiles, RawHex: opts.RawHex, Tokens: tokenSymbols, StatusLabels: statusLabels, AllowFormulas: opts.AllowFormulas}
+	if opts.CompareWith != "" {
+		log.Info("余额变化的地址: %d 个\n", len(core.DiffResults(previousResults, results)))
+		err = core.ExportChanges(previousResults, results, outputFile)
+	} else if exportTemplate != "" {
+		err = core.ExportWithTemplateOptions(results, exportTemplate, outputFile, exportOpts)
+	} else if strings.HasSuffix(strings.ToLower(outputFile), ".xlsx") {
+		err = core.ExportToExcelWithOptions(results, outputFile, exportOpts)
+		if notice := core.ExcelSplitNotice(len(results), exportOpts); notice != "" && err == nil {
+			log.Warn(notice)
+		}
 	} else {
-		err = core.ExportToCSV(results, outputFile)
+		err = core.ExportToCSVWithOptions(results, outputFile, exportOpts)
 	}
 
 	if err != nil {
 		log.Error("错误: 导出失败: %v\n", err)
-		os.Exit(1)
+		exitCode = 1
+		return
 	}
 
 	log.Info("结果已导出到: %s\n", outputFile)
+	// 提前结束时提示如何从停止的地方继续（结果文件需包含本次的全部地址，只导出变化或按模板导出时不提示）
+	if reason != core.FinishCompleted && opts.OnlyNonzero == "" && opts.CompareWith == "" && exportTemplate == "" && !exportOpts.SplitFiles {
+		next := startIndex + core.ResumeIndex(addresses, results, 0)
+		log.Info("可使用 -resume %s -start-index %d 从第 %d 个地址继续，之后用 -merge 合并两次的结果\n", outputFile, startIndex, next+1)
+	}
+
+	if alertRules != nil {
+		alerts := core.CheckAlerts(results, alertRules)
+		for _, alert := range alerts {
+			log.Warn("告警 %s\n", alert.Text())
+		}
+		alertFile := opts.AlertOutput
+		if alertFile == "" {
+			alertFile = strings.TrimSuffix(outputFile, filepath.Ext(outputFile)) + "_alerts.csv"
+		}
+		if err := core.ExportAlerts(alerts, alertFile); err != nil {
+			log.Error("错误: 导出告警失败: %v\n", err)
+			exitCode = 1
+			return
+		}
+		log.Info("余额跨越阈值的地址: %d 个，告警已导出到: %s\n", len(alerts), alertFile)
+	}
+
+	if opts.QRDir != "" {
+		// 只为有余额的地址生成二维码
+		var withBalance []core.QueryResult
+		for _, result := range results {
+			if balance, err := core.ParseBalance(result.Balance); result.Status == core.StatusSuccess && err == nil && balance > 0 {
+				withBalance = append(withBalance, result)
+			}
+		}
+		count, err := core.ExportQRCodes(withBalance, opts.QRDir)
+		if err != nil {
+			log.Error("错误: 生成二维码失败: %v\n", err)
+			exitCode = 1
+			return
+		}
+		log.Info("已为 %d 个有余额的地址生成二维码图片: %s\n", count, opts.QRDir)
+	}
+
+	if opts.Open {
+		// 拆分为多个文件时打开所在目录
+		openPath := outputFile
+		if exportOpts.SplitFiles && opts.CompareWith == "" && strings.HasSuffix(strings.ToLower(outputFile), ".xlsx") && core.ExcelSplitCount(len(results)) > 1 {
+			openPath = filepath.Dir(outputFile)
+		}
+		if err := openFile(openPath); err != nil {
+			log.Warn("%v\n", err)
+		}
+	}
+
+	if googleCreds != nil {
+		if err := core.ExportToGoogleSheets(results, opts.GoogleSheetID, googleCreds); err != nil {
+			log.Error("错误: 导出到 Google 表格失败: %v\n", err)
+			exitCode = 1
+			return
+		}
+		log.Info("结果已导出到 Google 表格: %s\n", opts.GoogleSheetID)
+	}
+}
+
+// runMerge 合并多个结果文件（按地址去重，见 core.MergeResults）并导出到 -output
+func runMerge(opts CLIOptions) {
+	var paths []string
+	for _, path := range strings.Split(opts.MergeFiles, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+
+	exportLang, err := core.ParseExportLanguage(opts.Language)
+	if err != nil {
+		log.Error("错误: %v\n", err)
+		os.Exit(1)
+	}
+	statusLabels, err := core.ParseStatusLabels(opts.StatusLabels)
+	if err != nil {
+		log.Error("错误: %v\n", err)
+		os.Exit(1)
+	}
+
+	results, total, err := core.MergeResultFiles(paths)
+	if err != nil {
+		log.Error("错误: 合并失败: %v\n", err)
+		os.Exit(1)
+	}
+	log.Info("已合并 %d 个文件，共 %d 行，去重后 %d 个地址\n", len(paths), total, len(results))
+	applySavedTags(results)
+
+	exportOpts := core.ExportOptions{Language: exportLang, SplitFiles: opts.SplitFiles, StatusLabels: statusLabels, AllowFormulas: opts.AllowFormulas}
+	if strings.HasSuffix(strings.ToLower(opts.OutputFile), ".xlsx") {
+		err = core.ExportToExcelWithOptions(results, opts.OutputFile, exportOpts)
+	} else {
+		err = core.ExportToCSVWithOptions(results, opts.OutputFile, exportOpts)
+	}
+	if err != nil {
+		log.Error("错误: 导出失败: %v\n", err)
+		os.Exit(1)
+	}
+	log.Info("结果已导出到: %s\n", opts.OutputFile)
+}
+
+// applySavedTags 按地址带上界面中设置的标签（见 core.Tags），导出时增加"标签"列；读取失败时只警告
+func applySavedTags(results []core.QueryResult) {
+	tags, err := core.LoadTags()
+	if err != nil {
+		log.Warn("加载标签失败: %v\n", err)
+		return
+	}
+	if tagged := tags.Apply(results); tagged > 0 {
+		log.Info("有标签的地址: %d 行\n", tagged)
+	}
+}
+
+// dryRunRows -dry-run 预览的数据行数
+const dryRunRows = 10
+
+// runDryRun 打印输入文件前 dryRunRows 行中被当作地址读取的单元格及校验结果，然后统计整个文件
+// 输出到 stdout（不依赖日志设置），出错时以状态码 1 退出
+func runDryRun(inputFile string, loadOpts core.LoadOptions) {
+	cells, err := core.PreviewAddressCells(inputFile, loadOpts, dryRunRows)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("前 %d 行中读取的单元格:\n", dryRunRows)
+	for _, cell := range cells {
+		position := fmt.Sprintf("第 %d 行", cell.Row)
+		if cell.Ref != "" {
+			position = cell.Ref
+		}
+		mark := "有效"
+		if err := tron.ValidateAddressWithError(cell.Value); err != nil {
+			mark = "无效: " + err.Error()
+		}
+		fmt.Printf("  %-8s %s  [%s]\n", position, cell.Value, mark)
+	}
+
+	addresses, err := core.LoadAddressesFromFileWithOptions(inputFile, loadOpts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: 加载地址失败: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("整个文件共识别 %d 个地址（试运行，未发送任何请求）\n", len(addresses))
 }
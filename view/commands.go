@@ -0,0 +1,154 @@
+package view
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"usdt-balance-checker/core"
+	"usdt-balance-checker/tron"
+)
+
+// 子命令的结果输出到 stdout（不依赖日志设置），出错时以状态码 1 退出
+
+// RunConvert 在 Base58 和 hex 格式之间转换地址（convert 子命令），每行输出 "输入,转换结果"
+// 地址来自 inputFile（每行一个）或 args；to 为 hex、base58，空字符串时按输入格式自动选择另一种
+func RunConvert(inputFile string, args []string, to string) {
+	values := args
+	if inputFile != "" {
+		data, err := os.ReadFile(inputFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: 读取文件失败: %v\n", err)
+			os.Exit(1)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimSpace(strings.TrimPrefix(line, "\ufeff")); line != "" {
+				values = append(values, line)
+			}
+		}
+	}
+	if len(values) == 0 {
+		fmt.Fprintln(os.Stderr, "错误: 请指定要转换的地址或 -input 文件")
+		os.Exit(1)
+	}
+	if to != "" && to != "hex" && to != "base58" {
+		fmt.Fprintf(os.Stderr, "错误: 未知的目标格式: %s（可选: hex, base58）\n", to)
+		os.Exit(1)
+	}
+
+	failed := 0
+	for _, value := range values {
+		converted, err := convertAddress(value, to)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", value, err)
+			failed++
+			continue
+		}
+		fmt.Printf("%s,%s\n", value, converted)
+	}
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "%d 个地址转换失败\n", failed)
+		os.Exit(1)
+	}
+}
+
+// convertAddress 转换单个地址，to 为空时 T 开头的地址转为 hex，其他转为 Base58
+func convertAddress(value, to string) (string, error) {
+	if to == "" {
+		to = "base58"
+		if strings.HasPrefix(value, "T") {
+			to = "hex"
+		}
+	}
+	if to == "base58" {
+		return tron.HexToAddress(value)
+	}
+	if err := tron.ValidateAddressWithError(value); err != nil {
+		return "", err
+	}
+	return tron.AddressToHex(value)
+}
+
+// RunValidate 校验输入文件中的每个地址（validate 子命令），列出无效的单元格并统计，有无效地址时以状态码 1 退出
+func RunValidate(inputFile string, loadOpts core.LoadOptions) {
+	if inputFile == "" {
+		fmt.Fprintln(os.Stderr, "错误: 请指定 -input 文件")
+		os.Exit(1)
+	}
+	cells, err := core.PreviewAddressCells(inputFile, loadOpts, 0)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(1)
+	}
+
+	valid, invalid, evm := 0, 0, 0
+	seen := make(map[string]bool)
+	duplicates, sanitized := 0, 0
+	for _, cell := range cells {
+		if cell.Sanitized {
+			sanitized++
+		}
+		err := tron.ValidateAddressWithError(cell.Value)
+		if err == nil {
+			valid++
+			if seen[cell.Value] {
+				duplicates++
+			}
+			seen[cell.Value] = true
+			continue
+		}
+		invalid++
+		if errors.Is(err, tron.ErrEVMAddress) {
+			evm++
+		}
+		position := fmt.Sprintf("第 %d 行", cell.Row)
+		if cell.Ref != "" {
+			position = cell.Ref
+		}
+		fmt.Printf("  %-8s %s  [无效: %v]\n", position, cell.Value, err)
+	}
+
+	fmt.Printf("共 %d 个单元格：有效 %d（其中重复 %d），无效 %d", len(cells), valid, duplicates, invalid)
+	if evm > 0 {
+		fmt.Printf("（其中以太坊地址 %d）", evm)
+	}
+	fmt.Println()
+	if sanitized > 0 {
+		fmt.Printf("已去掉 %d 个单元格中的零宽字符、BOM 等不可见字符（导入时同样会去掉）\n", sanitized)
+	}
+	if invalid > 0 {
+		os.Exit(1)
+	}
+}
+
+// RunSplit 将输入文件中的地址（按 loadOpts 去重、校验）拆分为每份 size 个的 TXT 文件（split 子命令）
+// prefix 为空时使用输入文件去掉扩展名的路径
+func RunSplit(inputFile string, loadOpts core.LoadOptions, size int, prefix string) {
+	if inputFile == "" {
+		fmt.Fprintln(os.Stderr, "错误: 请指定 -input 文件")
+		os.Exit(1)
+	}
+	addresses, report, err := core.LoadAddressesFromFileWithReport(inputFile, loadOpts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: 加载地址失败: %v\n", err)
+		os.Exit(1)
+	}
+	if text := report.Text(); text != "" {
+		fmt.Fprintln(os.Stderr, text)
+	}
+
+	if prefix == "" {
+		prefix = strings.TrimSuffix(inputFile, filepath.Ext(inputFile))
+	}
+	paths, err := core.SplitAddressFiles(addresses, size, prefix)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: 拆分失败: %v\n", err)
+		os.Exit(1)
+	}
+	for _, path := range paths {
+		fmt.Println(path)
+	}
+	fmt.Printf("共 %d 个地址，已拆分为 %d 个文件（每份最多 %d 个）\n", len(addresses), len(paths), size)
+}
@@ -0,0 +1,102 @@
+package view
+
+import (
+	"fmt"
+	"sort"
+	"time"
+	"usdt-balance-checker/core"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// doubleTapInterval 两次点击同一行的最大间隔，视为双击
+const doubleTapInterval = 500 * time.Millisecond
+
+// maskAPIKey 隐藏 Key 的中间部分，只显示前 6 位和后 4 位
+func maskAPIKey(key string) string {
+	if len(key) <= 10 {
+		return key
+	}
+	return key[:6] + "…" + key[len(key)-4:]
+}
+
+// showResultDetail 弹出单条结果的详情（完整地址、余额、状态、错误信息、节点原始值、查询时间和使用的 Key），每项可复制
+// 有效地址在上方显示地址二维码，便于扫码核对
+func showResultDetail(w fyne.Window, result core.QueryResult) {
+	// 余额按状态显示（见 QueryResult.DisplayBalance），未查询成功时显示 "-"
+	displayOrDash := func(balance string) string {
+		if balance == "" {
+			return "-"
+		}
+		return balance
+	}
+	queriedAt := "-"
+	if !result.QueriedAt.IsZero() {
+		queriedAt = result.QueriedAt.Format("2006-01-02 15:04:05")
+	}
+	apiKey := "-"
+	if result.APIKey != "" {
+		apiKey = maskAPIKey(result.APIKey)
+	}
+	status := result.Status.String()
+	if result.Duplicate {
+		status += "（重复地址，复用首次查询结果）"
+	}
+
+	// 每项：标签、只读文本（可选中）、复制按钮（复制完整值，Key 也只复制掩码后的内容）
+	form := widget.NewForm()
+	addRow := func(label, value string) {
+		valueEntry := widget.NewMultiLineEntry()
+		valueEntry.SetText(value)
+		valueEntry.Wrapping = fyne.TextWrapBreak
+		valueEntry.SetMinRowsVisible(1)
+		valueEntry.Disable()
+
+		copyBtn := widget.NewButton("复制", func() {
+			fyne.CurrentApp().Clipboard().SetContent(value)
+		})
+		form.Append(label, container.NewBorder(nil, nil, nil, copyBtn, valueEntry))
+	}
+	addRow("地址:", result.Address)
+	balanceLabel := "余额 (USDT):"
+	if result.TokenBalances != nil {
+		balanceLabel = "余额:" // 多代币查询时第一个代币不一定是 USDT
+	}
+	addRow(balanceLabel, displayOrDash(result.DisplayBalance()))
+	symbols := make([]string, 0, len(result.TokenBalances))
+	for symbol := range result.TokenBalances {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	for _, symbol := range symbols {
+		addRow(fmt.Sprintf("余额 (%s):", symbol), displayOrDash(result.DisplayTokenBalance(symbol)))
+	}
+	addRow("状态:", status)
+	if result.Error != "" {
+		addRow("错误信息:", result.Error)
+	}
+	if result.AddressType != "" {
+		addRow("地址类型:", result.AddressType)
+	}
+	if result.RawHex != "" {
+		addRow("原始值 (hex):", result.RawHex)
+	}
+	addRow("查询时间:", queriedAt)
+	addRow("使用的 Key:", apiKey)
+
+	content := fyne.CanvasObject(form)
+	if png, err := core.AddressQRCode(result.Address, core.QRCodeSize); err == nil {
+		qr := canvas.NewImageFromResource(fyne.NewStaticResource(result.Address+".png", png))
+		qr.FillMode = canvas.ImageFillContain
+		qr.SetMinSize(fyne.NewSize(160, 160))
+		content = container.NewVBox(container.NewCenter(qr), form)
+	}
+
+	d := dialog.NewCustom("结果详情", "关闭", container.NewVScroll(content), w)
+	d.Resize(fyne.NewSize(620, 600))
+	d.Show()
+}
@@ -1,44 +1,31 @@
 package view
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 	"usdt-balance-checker/resource"
 
 	"usdt-balance-checker/core"
+	"usdt-balance-checker/tron"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/widget"
-)
-
-var (
-	queryManager        *core.QueryManager
-	keyManager          *core.APIKeyManager
-	isQuerying          bool
-	isPaused            bool // 是否处于暂停状态
-	queryCancel         func()
-	addressList         []string
-	currentQueryAddrs   []string           // 当前正在查询的完整地址列表
-	resultData          []core.QueryResult // 所有原始数据
-	filteredData        []core.QueryResult // 筛选后的数据
-	displayData         []core.QueryResult // 当前页显示的数据
-	currentPage         int                // 当前页码（从1开始）
-	pageSize            int                // 每页显示数量
-	totalPages          int                // 总页数
-	filterMode          string             // 筛选模式："all", "withBalance", "address"
-	filterText          string             // 筛选文本（地址搜索）
-	pausedAddresses     []string           // 暂停时剩余的地址
-	pausedStartIndex    int                // 暂停时已完成的索引
-	pausedTotalProgress int                // 暂停时的总进度（用于累计显示）
+	"github.com/ethereum/go-ethereum/log"
 )
 
 // ShowMainWindow 显示主窗口
+// 每个标签页是一个独立的批次（地址、结果、筛选和查询管理器），所有批次共享同一个 Key 管理器
 func ShowMainWindow(a fyne.App) {
 	w := a.NewWindow("USDT balance check")
 
@@ -51,42 +38,180 @@ func ShowMainWindow(a fyne.App) {
 	// 设置窗口的图标
 	w.SetIcon(logoResource)
 
-	w.Resize(fyne.NewSize(1200, 700)) // 增大窗口尺寸，提供更好的显示空间
+	// 恢复上次关闭时的窗口布局（首次启动时为默认尺寸 1200x700）
+	layout := loadLayoutPrefs(a.Preferences())
+	w.Resize(fyne.NewSize(layout.width, layout.height))
 	w.CenterOnScreen()
+	w.SetFullScreen(layout.fullScreen)
+
+	// 日志显示在窗口底部的日志面板中（双击启动时看不到终端输出），同时写到 stderr
+	logs := newLogBuffer(os.Stderr)
+	log.SetDefault(log.NewLogger(logs))
 
-	// 初始化 Key Manager
-	keyManager = core.NewAPIKeyManager()
+	// 初始化 Key Manager（所有批次共享，额度统计全局一致）
+	keyManager := core.NewAPIKeyManager()
 	// 尝试加载之前保存的使用记录（如果之前导入过 Key）
 	if err := keyManager.LoadStatsIfExists(); err != nil {
 
 	}
 
+	// 书签（所有批次共享，修改后立即保存）
+	bookmarks, err := core.LoadBookmarks()
+	if err != nil {
+		log.Warn("加载书签失败: %v", err)
+	}
+
+	// 地址标签（所有批次共享，修改后立即保存）
+	tags, err := core.LoadTags()
+	if err != nil {
+		log.Warn("加载标签失败: %v", err)
+	}
+
+	group := &batchGroup{}
+	views := make(map[*container.TabItem]*batchView)
+	batchCount := 0
+
+	tabs := container.NewDocTabs()
+	newBatchTab := func() *container.TabItem {
+		batchCount++
+		vm := NewMainViewModel(keyManager, bookmarks, tags)
+		vm.filterMode = layout.filterMode
+		vm.pageSize = layout.pageSize
+		view := newBatchView(w, vm, group)
+		view.split.SetOffset(layout.splitOffset)
+		item := container.NewTabItem(fmt.Sprintf("批次 %d", batchCount), view.content)
+		views[item] = view
+		group.add(vm)
+		view.refresh()
+		return item
+	}
+	tabs.CreateTab = newBatchTab // 标签栏的"+"按钮新建批次
+	tabs.CloseIntercept = func(item *container.TabItem) {
+		view := views[item]
+		if view != nil && view.vm.QueryActive() {
+			dialog.ShowError(errors.New("该批次正在查询，请先停止查询再关闭"), w)
+			return
+		}
+		if len(tabs.Items) <= 1 {
+			return // 至少保留一个批次
+		}
+		tabs.Remove(item)
+		if view != nil {
+			view.dispose()
+			group.remove(view.vm)
+			delete(views, item)
+		}
+	}
+	tabs.Append(newBatchTab())
+	// 切换批次时刷新共享的 Key 状态（可能在其他批次中导入或删除了 Key）
+	tabs.OnSelected = func(item *container.TabItem) {
+		if view := views[item]; view != nil {
+			view.refresh()
+		}
+	}
+
+	// 允许多个批次同时查询（各批次的请求共用 Key 额度，开启平滑使用额度时按所有批次的请求统一限速）
+	concurrentCheck := widget.NewCheck("允许多个批次同时查询", func(checked bool) {
+		group.setConcurrent(checked)
+	})
+
+	// 查询结束或自动暂停时发送系统通知（长时间查询时通常在做其他事），设置保存在偏好设置中
+	notifyCheck := widget.NewCheck("发送系统通知", func(checked bool) {
+		group.setNotify(checked)
+		a.Preferences().SetBool(prefNotify, checked)
+	})
+	notifyCheck.SetChecked(a.Preferences().BoolWithFallback(prefNotify, true))
+	group.setNotify(notifyCheck.Checked)
+
+	// 查询完成时在状态栏显示余额合计（需要遍历所有结果做大数加法，默认关闭），设置保存在偏好设置中
+	showTotalCheck := widget.NewCheck("显示余额合计", func(checked bool) {
+		group.setShowTotal(checked)
+		a.Preferences().SetBool(prefShowTotal, checked)
+	})
+	showTotalCheck.SetChecked(a.Preferences().BoolWithFallback(prefShowTotal, false))
+	group.setShowTotal(showTotalCheck.Checked)
+
+	// 拖拽的文件交给当前批次处理
+	w.SetOnDropped(func(pos fyne.Position, uris []fyne.URI) {
+		if view := views[tabs.Selected()]; view != nil {
+			view.onDropped(pos, uris)
+		}
+	})
+
+	// 关闭窗口时保存布局（分栏位置和筛选模式取当前批次），下次启动时恢复
+	w.SetOnClosed(func() {
+		layout.fullScreen = w.FullScreen()
+		if !layout.fullScreen {
+			// 全屏时的画布尺寸是屏幕尺寸，保留全屏前的窗口尺寸
+			size := w.Canvas().Size()
+			layout.width, layout.height = size.Width, size.Height
+		}
+		if view := views[tabs.Selected()]; view != nil {
+			layout.splitOffset = view.split.Offset
+			layout.filterMode = view.vm.filterMode
+			layout.pageSize = view.vm.pageSize
+		}
+		layout.save(a.Preferences())
+		if err := keyManager.CloseStats(); err != nil {
+			log.Warn("保存 Key 使用统计失败: %v", err)
+		}
+	})
+
+	w.SetContent(container.NewBorder(container.NewHBox(concurrentCheck, notifyCheck, showTotalCheck), newLogPanel(logs), nil, nil, tabs))
+	w.Show()
+	if owner := keyManager.StatsLockOwner(); owner != 0 {
+		dialog.ShowInformation("统计文件被占用", core.StatsLockedText(owner), w)
+	}
+	// 首次启动时引导添加 Key 并试查询一个地址，关闭后刷新当前批次的 Key 状态
+	showOnboarding(w, a.Preferences(), keyManager, func() {
+		if view := views[tabs.Selected()]; view != nil {
+			view.refresh()
+		}
+	})
+}
+
+// batchView 一个批次（标签页）的界面
+type batchView struct {
+	vm        *MainViewModel
+	content   fyne.CanvasObject
+	split     *container.Split                         // 左右分栏，关闭窗口时保存分栏位置
+	onDropped func(pos fyne.Position, uris []fyne.URI) // 拖拽文件到窗口时调用（当前标签页）
+	refresh   func()                                   // 按共享的 Key 管理器刷新 Key 状态区域
+	dispose   func()                                   // 关闭标签页时停止后台定时器
+}
+
+// newBatchView 构建一个批次的界面：左侧 Key 和网络配置，右侧地址结果
+func newBatchView(w fyne.Window, vm *MainViewModel, group *batchGroup) *batchView {
 	// 使用 sync 保护的状态变量
 	var mu sync.Mutex
 	var lastProgress struct {
 		current, total int
-		stats          struct {
-			total, success, failed int
-		}
-		results []core.QueryResult
-		done    bool
+		skipped        int              // 已完成中未发送请求的数量（无效、重复地址，继续查询前已完成的地址）
+		stats          core.ResultStats // 继续查询时包含之前已完成的结果
+		results        []core.QueryResult
+		done           bool
 	}
 
 	// API Key 管理区域
 	apiKeyStatusLabel := widget.NewLabel("no found API Key")
 	apiKeyStatusLabel.Wrapping = fyne.TextWrapWord
 
+	// 没有 Key 时在表格位置显示的提示
+	keyEmptyHint := newEmptyHint("请导入 API Key")
+
 	// Key 状态表格（先定义，后面会引用）
 	keyStatusTable := widget.NewTable(
 		func() (int, int) {
-			return keyManager.GetKeyCount(), 4
+			count := vm.keyManager.GetKeyCount()
+			setEmptyHint(keyEmptyHint, count == 0)
+			return count, 6
 		},
 		func() fyne.CanvasObject {
 			return widget.NewLabel("")
 		},
 		func(id widget.TableCellID, obj fyne.CanvasObject) {
 			label := obj.(*widget.Label)
-			status := keyManager.GetKeyStatus()
+			status := vm.keyManager.GetKeyStatus()
 			if id.Row >= len(status) {
 				return
 			}
@@ -96,10 +221,18 @@ func ShowMainWindow(a fyne.App) {
 			case 0:
 				label.SetText(keyStatus.DisplayName)
 			case 1:
-				label.SetText(fmt.Sprintf("%d / %d", keyStatus.Used, keyStatus.MaxLimit))
+				// 本次使用：相对当前（或最近一次）查询开始时的使用次数，新的查询开始时重新计算
+				label.SetText("-")
+				if qm := vm.QueryManager(); qm != nil {
+					if usage := qm.KeyUsage(); id.Row < len(usage) && usage[id.Row].Key == keyStatus.Key {
+						label.SetText(fmt.Sprintf("%d", usage[id.Row].RunUsed))
+					}
+				}
 			case 2:
-				label.SetText(fmt.Sprintf("%d", keyStatus.Remaining))
+				label.SetText(fmt.Sprintf("%d / %d", keyStatus.Used, keyStatus.MaxLimit))
 			case 3:
+				label.SetText(fmt.Sprintf("%d", keyStatus.Remaining))
+			case 4:
 				if keyStatus.Enabled && keyStatus.Remaining > 0 {
 					label.SetText("可用")
 					label.Importance = widget.SuccessImportance
@@ -107,20 +240,65 @@ func ShowMainWindow(a fyne.App) {
 					label.SetText("已用完")
 					label.Importance = widget.DangerImportance
 				}
+			case 5:
+				if keyStatus.Pace > 0 {
+					label.SetText(fmt.Sprintf("%.2f/秒", keyStatus.Pace))
+				} else {
+					label.SetText("-")
+				}
 			}
 		})
 
-	keyStatusTable.SetColumnWidth(0, 80)  // Key 名称
-	keyStatusTable.SetColumnWidth(1, 120) // 已用/总额
-	keyStatusTable.SetColumnWidth(2, 100) // 剩余
-	keyStatusTable.SetColumnWidth(3, 80)  // 状态
+	keyStatusTable.SetColumnWidth(0, 120) // Key 名称（备注名）
+	keyStatusTable.SetColumnWidth(1, 80)  // 本次使用
+	keyStatusTable.SetColumnWidth(2, 120) // 累计已用/总额
+	keyStatusTable.SetColumnWidth(3, 100) // 剩余
+	keyStatusTable.SetColumnWidth(4, 80)  // 状态
+	keyStatusTable.SetColumnWidth(5, 80)  // 平滑速率
+
+	// 双击 Key 行编辑备注名（与结果表格相同，按短时间内两次选中同一行判断）
+	var lastKeyTapRow = -1
+	var lastKeyTapTime time.Time
+	keyStatusTable.OnSelected = func(id widget.TableCellID) {
+		keyStatusTable.Unselect(id)
+		now := time.Now()
+		if id.Row != lastKeyTapRow || now.Sub(lastKeyTapTime) > doubleTapInterval {
+			lastKeyTapRow, lastKeyTapTime = id.Row, now
+			return
+		}
+		lastKeyTapRow = -1
+
+		status := vm.keyManager.GetKeyStatus()
+		if id.Row >= len(status) {
+			return
+		}
+		keyStatus := status[id.Row]
+		nameEntry := widget.NewEntry()
+		nameEntry.SetText(keyStatus.Name)
+		nameEntry.SetPlaceHolder("如：免费 Key、付费 Key A（留空清除备注）")
+		dialog.ShowForm("Key 备注名", "保存", "取消", []*widget.FormItem{
+			widget.NewFormItem("Key:", widget.NewLabel(maskAPIKey(keyStatus.Key))),
+			widget.NewFormItem("备注名:", nameEntry),
+		}, func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			if err := vm.keyManager.SetKeyName(keyStatus.Key, nameEntry.Text); err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			keyStatusTable.Refresh()
+		}, w)
+	}
 
 	// Key 状态表头
-	keyStatusHeader := container.NewGridWithColumns(4,
+	keyStatusHeader := container.NewGridWithColumns(6,
 		widget.NewLabelWithStyle("Key", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
-		widget.NewLabelWithStyle("已用/总额", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("本次使用", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("累计/总额", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
 		widget.NewLabelWithStyle("剩余", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
 		widget.NewLabelWithStyle("状态", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("速率", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
 	)
 
 	// 更新 Key 状态表格的辅助函数
@@ -130,9 +308,30 @@ func ShowMainWindow(a fyne.App) {
 			table.Refresh()
 			// 确保表格大小更新
 			table.SetColumnWidth(0, 80)
-			table.SetColumnWidth(1, 120)
-			table.SetColumnWidth(2, 100)
-			table.SetColumnWidth(3, 80)
+			table.SetColumnWidth(1, 80)
+			table.SetColumnWidth(2, 120)
+			table.SetColumnWidth(3, 100)
+			table.SetColumnWidth(4, 80)
+		})
+	}
+
+	// 加载 Key 文件（加密文件会先弹出密码输入框），成功后调用 onLoaded
+	loadKeyFile := func(path string, onLoaded func()) {
+		if !core.IsEncryptedFile(path) {
+			if err := vm.keyManager.LoadKeysFromFile(path); err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			onLoaded()
+			return
+		}
+
+		showPasswordDialog(w, "加密的 Key 文件", func(password string) {
+			if err := vm.keyManager.LoadKeysFromEncryptedFile(path, password); err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			onLoaded()
 		})
 	}
 
@@ -148,32 +347,59 @@ func ShowMainWindow(a fyne.App) {
 			}
 			defer reader.Close()
 
-			if err := keyManager.LoadKeysFromFile(reader.URI().Path()); err != nil {
-				dialog.ShowError(err, w)
-				return
-			}
+			loadKeyFile(reader.URI().Path(), func() {
+				keyCount := vm.keyManager.GetKeyCount()
+				totalUsed := vm.keyManager.GetTotalUsed()
+				apiKeyStatusLabel.SetText(fmt.Sprintf("已加载 %d 个 API Key", keyCount))
 
-			keyCount := keyManager.GetKeyCount()
-			totalUsed := keyManager.GetTotalUsed()
-			apiKeyStatusLabel.SetText(fmt.Sprintf("已加载 %d 个 API Key", keyCount))
+				// 强制刷新表格（在主线程中）
+				fyne.Do(func() {
+					keyStatusTable.Refresh()
+				})
 
-			// 强制刷新表格（在主线程中）
-			fyne.Do(func() {
-				keyStatusTable.Refresh()
+				// 显示统计信息
+				statsMsg := fmt.Sprintf("已加载 %d 个 API Key\n历史总使用次数: %d", keyCount, totalUsed)
+				if totalUsed > 0 {
+					statsMsg += "\n\n已自动加载历史使用记录！"
+				}
+				dialog.ShowInformation("成功", statsMsg, w)
 			})
-
-			// 显示统计信息
-			statsMsg := fmt.Sprintf("已加载 %d 个 API Key\n历史总使用次数: %d", keyCount, totalUsed)
-			if totalUsed > 0 {
-				statsMsg += "\n\n已自动加载历史使用记录！"
-			}
-			dialog.ShowInformation("成功", statsMsg, w)
 		}, w)
 	})
 
+	// 加密保存 Key 按钮
+	saveKeyEncryptedBtn := widget.NewButton("🔒 加密保存", func() {
+		if vm.keyManager.GetKeyCount() == 0 {
+			dialog.ShowError(errors.New("没有可保存的 API Key"), w)
+			return
+		}
+
+		// 先设置密码再选择保存位置：保存对话框会创建文件，取消输入密码时不留下空文件
+		showPasswordDialog(w, "设置加密密码", func(password string) {
+			dialog.ShowFileSave(func(writer fyne.URIWriteCloser, err error) {
+				if err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+				if writer == nil {
+					return
+				}
+				path := writer.URI().Path()
+				writer.Close()
+
+				if err := vm.keyManager.SaveKeysEncrypted(path, password); err != nil {
+					os.Remove(path) // 删除保存对话框创建的空文件
+					dialog.ShowError(err, w)
+					return
+				}
+				dialog.ShowInformation("成功", fmt.Sprintf("已加密保存 %d 个 API Key 到: %s", vm.keyManager.GetKeyCount(), path), w)
+			}, w)
+		})
+	})
+
 	// 删除单个 Key 按钮
 	deleteKeyBtn := widget.NewButton("删除Key", func() {
-		status := keyManager.GetKeyStatus()
+		status := vm.keyManager.GetKeyStatus()
 		if len(status) == 0 {
 			dialog.ShowError(errors.New("没有可删除的 Key"), w)
 			return
@@ -218,12 +444,12 @@ func ShowMainWindow(a fyne.App) {
 			// 确认对话框
 			dialog.ShowConfirm("确认删除", fmt.Sprintf("确定要删除 %s 吗？\nKey: %s...", displayName, keyToDelete[:min(20, len(keyToDelete))]), func(confirmed bool) {
 				if confirmed {
-					if err := keyManager.RemoveKey(keyToDelete); err != nil {
+					if err := vm.keyManager.RemoveKey(keyToDelete); err != nil {
 						dialog.ShowError(err, w)
 						return
 					}
 
-					keyCount := keyManager.GetKeyCount()
+					keyCount := vm.keyManager.GetKeyCount()
 					apiKeyStatusLabel.SetText(fmt.Sprintf("已加载 %d 个 API Key", keyCount))
 
 					fyne.Do(func() {
@@ -256,26 +482,24 @@ func ShowMainWindow(a fyne.App) {
 		thresholdEntry := widget.NewEntry()
 		thresholdEntry.SetText("99998")
 		thresholdEntry.SetPlaceHolder("使用次数阈值（>=此值将被删除）")
+		thresholdRange := intRange{name: "使用次数阈值", min: 1}
+		thresholdError := newInlineError(thresholdEntry, func() intRange { return thresholdRange })
 
 		var batchDeleteDialog *dialog.CustomDialog
 
-		// 创建确认按钮
+		// 创建确认按钮（阈值无效时对话框保持打开，可以直接修改）
 		confirmBatchBtn := widget.NewButton("确定", func() {
+			threshold, err := thresholdRange.parse(thresholdEntry.Text)
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
 			if batchDeleteDialog != nil {
 				batchDeleteDialog.Hide()
 			}
 
-			thresholdStr := strings.TrimSpace(thresholdEntry.Text)
-			threshold := 99998
-			if thresholdStr != "" {
-				if _, err := fmt.Sscanf(thresholdStr, "%d", &threshold); err != nil {
-					dialog.ShowError(errors.New("无效的阈值"), w)
-					return
-				}
-			}
-
 			// 获取将要删除的Key列表（预览）
-			status := keyManager.GetKeyStatus()
+			status := vm.keyManager.GetKeyStatus()
 			matchingKeys := make([]string, 0)
 			for _, keyStatus := range status {
 				if keyStatus.Used >= threshold {
@@ -298,13 +522,13 @@ func ShowMainWindow(a fyne.App) {
 
 			dialog.ShowConfirm("确认批量删除", previewText, func(confirmed bool) {
 				if confirmed {
-					removedCount, err := keyManager.RemoveKeysByUsageThreshold(threshold)
+					removedCount, err := vm.keyManager.RemoveKeysByUsageThreshold(threshold)
 					if err != nil {
 						dialog.ShowError(err, w)
 						return
 					}
 
-					keyCount := keyManager.GetKeyCount()
+					keyCount := vm.keyManager.GetKeyCount()
 					apiKeyStatusLabel.SetText(fmt.Sprintf("已加载 %d 个 API Key", keyCount))
 
 					fyne.Do(func() {
@@ -322,6 +546,7 @@ func ShowMainWindow(a fyne.App) {
 			widget.NewForm(
 				widget.NewFormItem("使用次数阈值:", thresholdEntry),
 			),
+			thresholdError,
 			container.NewHBox(
 				widget.NewButton("取消", func() {
 					if batchDeleteDialog != nil {
@@ -338,19 +563,259 @@ func ShowMainWindow(a fyne.App) {
 		batchDeleteDialog.Show()
 	})
 
+	// 不保存使用统计（只读环境或不希望写入 apikey_stats.json 时勾选，使用次数只在本次运行中有效）
+	noStatsCheck := widget.NewCheck("不保存使用统计", func(checked bool) {
+		vm.keyManager.SetStatsPersistence(!checked)
+	})
+
+	// 平滑使用额度：按剩余额度和距离重置的时间给每个 Key 限速，让额度撑满全天（适合长时间监控）
+	pacingCheck := widget.NewCheck("平滑使用额度", func(checked bool) {
+		vm.keyManager.SetPacing(checked)
+		keyStatusTable.Refresh()
+	})
+
 	// 自定义节点 URL（可选）
 	nodeURLEntry := widget.NewEntry()
-	nodeURLEntry.SetPlaceHolder("自定义 TRON 节点 URL（留空使用 TronGrid）")
+	nodeURLEntry.SetPlaceHolder("节点地址或网关前缀，如 https://gw.example.com/tron（留空使用 TronGrid）")
+	nodeURLEntry.Validator = func(text string) error {
+		_, err := tron.NormalizeNodeURL(text)
+		return err
+	}
+	// 输入完成后自动补全协议，让用户看到实际使用的地址
+	nodeURLEntry.OnSubmitted = func(text string) {
+		if normalized, err := tron.NormalizeNodeURL(text); err == nil && normalized != text {
+			nodeURLEntry.SetText(normalized)
+		}
+	}
 
-	// 限流设置
-	rateLimitEntry := widget.NewEntry()
-	rateLimitEntry.SetText("12")
-	rateLimitEntry.SetPlaceHolder("每秒请求数 (10-15)")
+	// 要查询的代币（留空只查 USDT），多个代币时结果表格和导出按代币增加余额列
+	tokensEntry := widget.NewEntry()
+	tokensEntry.SetPlaceHolder("USDT（多个用逗号分隔，如 USDT,USDC,USDD）")
+	tokensEntry.Validator = func(text string) error {
+		_, err := tron.ParseTokens(text)
+		return err
+	}
+	// 代币输入框下的说明：只写了合约地址的代币停止输入后查询 symbol() 和 name() 显示在这里（不使用 Key 的额度）
+	const tokensHint = "自定义合约可写 合约地址:小数位数，自动查询代币符号"
+	tokensItem := widget.NewFormItem("代币:", tokensEntry)
+	tokensItem.HintText = tokensHint
+	var networkForm *widget.Form
+	tokenLookupSeq := 0
+	tokensEntry.OnChanged = func(text string) {
+		tokenLookupSeq++
+		seq := tokenLookupSeq
+		tokens, err := tron.ParseTokens(text)
+		if err != nil || !tron.NeedsSymbolLookup(tokens) {
+			if networkForm != nil && tokensItem.HintText != tokensHint {
+				tokensItem.HintText = tokensHint
+				networkForm.Refresh()
+			}
+			return
+		}
+		nodeURL := nodeURLEntry.Text
+		go func() {
+			time.Sleep(600 * time.Millisecond)
+			client := tron.NewAPIClientWithOptions(tron.ClientOptions{BaseURL: nodeURL, Timeout: 10 * time.Second})
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			defer cancel()
+			var names []string
+			for i, token := range tron.ResolveTokenSymbols(ctx, client, tokens) {
+				if tokens[i].Symbol == "" {
+					names = append(names, tron.ShortContract(token.Contract)+" = "+token.Label())
+				}
+			}
+			fyne.Do(func() {
+				if seq != tokenLookupSeq || networkForm == nil {
+					return // 已经继续输入，结果过时
+				}
+				tokensItem.HintText = strings.Join(names, "，")
+				networkForm.Refresh()
+			})
+		}()
+	}
+
+	// 限流设置：滑块实时生效，查询中拖动会立即调整速度（遇到 429 时可以手动调低）
+	rateValueLabel := widget.NewLabel("12 次/秒")
+	rateSlider := widget.NewSlider(1, 50)
+	rateSlider.Step = 1
+	rateSlider.SetValue(12)
+	rateSlider.OnChanged = func(value float64) {
+		rateValueLabel.SetText(fmt.Sprintf("%d 次/秒", int(value)))
+		if qm := vm.QueryManager(); qm != nil {
+			qm.SetRateLimit(int(value))
+		}
+	}
+	rateLimitControl := container.NewBorder(nil, nil, nil, rateValueLabel, rateSlider)
 
 	// 线程数设置
 	threadCountEntry := widget.NewEntry()
 	threadCountEntry.SetText("1")
 	threadCountEntry.SetPlaceHolder("并发线程数 (1-20)")
+	threadRange := intRange{name: "线程数", min: 1, max: 20}
+	threadCountError := newInlineError(threadCountEntry, func() intRange { return threadRange })
+
+	// 自动调整线程数：从 2 开始按成功率和 429 调整，线程数为上限（为 1 时上限为 20）
+	autoThreadsCheck := widget.NewCheck("自动", nil)
+
+	// 配置方案：选择后一次性应用线程数、节点、代币和速率（保存在 profiles.json）
+	profileSelect := widget.NewSelect(nil, nil)
+	profileSelect.PlaceHolder = "选择配置方案"
+	reloadProfiles := func() {
+		profiles, err := core.LoadProfiles()
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		names := make([]string, len(profiles))
+		for i, profile := range profiles {
+			names[i] = profile.Name
+		}
+		profileSelect.Options = names
+		profileSelect.Refresh()
+	}
+	profileSelect.OnChanged = func(name string) {
+		profile, err := core.FindProfile(name)
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		if profile.Threads > 0 {
+			threadCountEntry.SetText(fmt.Sprintf("%d", profile.Threads))
+		}
+		if profile.RateLimit > 0 {
+			rateSlider.SetValue(float64(min(profile.RateLimit, int(rateSlider.Max))))
+		}
+		nodeURLEntry.SetText(profile.NodeURL)
+		tokensEntry.SetText(profile.Tokens)
+	}
+	reloadProfiles()
+
+	// 将当前设置保存为配置方案（同名覆盖）
+	saveProfileBtn := widget.NewButton("保存方案", func() {
+		nameEntry := widget.NewEntry()
+		nameEntry.SetText(profileSelect.Selected)
+		nameEntry.SetPlaceHolder("如：快速、谨慎")
+		dialog.ShowForm("保存配置方案", "保存", "取消", []*widget.FormItem{
+			widget.NewFormItem("方案名称:", nameEntry),
+		}, func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			threads, err := threadRange.parse(threadCountEntry.Text)
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			profile := core.Profile{
+				Name:      nameEntry.Text,
+				RateLimit: int(rateSlider.Value),
+				Threads:   threads,
+				NodeURL:   strings.TrimSpace(nodeURLEntry.Text),
+				Tokens:    strings.TrimSpace(tokensEntry.Text),
+			}
+			if err := core.SaveProfile(profile); err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			reloadProfiles()
+			profileSelect.SetSelected(strings.TrimSpace(nameEntry.Text))
+		}, w)
+	})
+
+	// 合约地址检查（可选，每个地址额外一次请求）
+	contractFilterSelect := widget.NewSelect([]string{"不检查", "标记合约", "排除合约"}, nil)
+	contractFilterSelect.SetSelected("不检查")
+
+	// 打乱查询顺序（规避按规律排列的地址触发节点异常检测）
+	shuffleCheck := widget.NewCheck("打乱查询顺序", nil)
+
+	// 查询结束后自动重新查询失败的地址（失败多为临时的限流或网络问题）
+	autoRetryCheck := widget.NewCheck(fmt.Sprintf("失败自动重试 %d 轮", guiAutoRetryPasses), nil)
+
+	// 导入时保留重复地址（每个输入行对应一个结果，查询时同一地址只查一次）
+	keepDuplicatesCheck := widget.NewCheck("保留重复地址", nil)
+
+	// 导入时保留无效地址（结果中标记为无效，便于审计时输入输出一一对照）
+	keepInvalidCheck := widget.NewCheck("保留无效地址", nil)
+
+	// 导入时逐行自动识别 API Key 和地址（同一个文件中两者混合）
+	autoDetectCheck := widget.NewCheck("自动识别", nil)
+
+	// currentLoadOptions 根据界面选项构建地址加载选项
+	currentLoadOptions := func() core.LoadOptions {
+		return core.LoadOptions{
+			KeepDuplicates: keepDuplicatesCheck.Checked,
+			KeepInvalid:    keepInvalidCheck.Checked,
+			MaxAddresses:   core.DefaultMaxAddresses,
+		}
+	}
+
+	// loadAddressFile 加载地址文件：文件过大时先确认，地址数超过上限时确认是否只导入前面的部分
+	// 在后台加载并显示进度，成功后调用 onLoaded，出错时显示错误
+	loadAddressFile := func(path string, onLoaded func(addresses []string)) {
+		// showReport 导入后提示需要注意的情况（如混入了以太坊地址）
+		showReport := func(report core.LoadReport) {
+			if text := report.Text(); text != "" {
+				dialog.ShowInformation("导入提示", text, w)
+			}
+		}
+		// loadInBackground 在后台加载地址（大文件需要较长时间），加载中显示进度条，完成后在界面线程中调用 done
+		loadInBackground := func(opts core.LoadOptions, done func(addresses []string, report core.LoadReport, err error)) {
+			progress := widget.NewProgressBar()
+			progressDialog := dialog.NewCustomWithoutButtons("导入地址", container.NewVBox(widget.NewLabel(filepath.Base(path)), progress), w)
+			progressDialog.Show()
+			opts.Progress = func(fraction float64) {
+				fyne.Do(func() {
+					progress.SetValue(fraction)
+				})
+			}
+			go func() {
+				addresses, report, err := core.LoadAddressesFromFileWithReport(path, opts)
+				fyne.Do(func() {
+					progressDialog.Hide()
+					done(addresses, report, err)
+				})
+			}()
+		}
+		load := func() {
+			opts := currentLoadOptions()
+			loadInBackground(opts, func(addresses []string, report core.LoadReport, err error) {
+				if errors.Is(err, core.ErrTooManyAddresses) {
+					dialog.ShowConfirm("地址数量过多", fmt.Sprintf("%v\n\n只导入前 %d 个地址吗？", err, opts.MaxAddresses), func(confirmed bool) {
+						if !confirmed {
+							return
+						}
+						opts.TruncateAddresses = true
+						loadInBackground(opts, func(addresses []string, report core.LoadReport, err error) {
+							if err != nil {
+								dialog.ShowError(err, w)
+								return
+							}
+							onLoaded(addresses)
+							showReport(report)
+						})
+					}, w)
+					return
+				}
+				if err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+				onLoaded(addresses)
+				showReport(report)
+			})
+		}
+
+		if err := core.CheckInputFileSize(path); err != nil {
+			dialog.ShowConfirm("文件较大", err.Error()+"\n\n仍要导入吗？", func(confirmed bool) {
+				if confirmed {
+					load()
+				}
+			}, w)
+			return
+		}
+		load()
+	}
 
 	// 线程数说明
 	threadHelpLabel := widget.NewLabel("💡 多线程并发不能太高")
@@ -359,9 +824,66 @@ func ShowMainWindow(a fyne.App) {
 
 	// 地址输入区域
 	addressInput := widget.NewMultiLineEntry()
-	addressInput.SetPlaceHolder("输入或者导入TXT/CSV")
+	addressInput.SetPlaceHolder("输入或者导入TXT/CSV/XLSX/JSON")
 	addressInput.Wrapping = fyne.TextWrapOff // 关闭自动换行，确保地址正确显示（每行一个地址）
 
+	// showAddresses 将导入的地址设置为待查询地址并显示在输入框中
+	// 地址很多时输入框只显示前面的部分（见 addressPreviewText），查询使用 vm.addressList 中的完整列表
+	showAddresses := func(addresses []string) {
+		vm.addressList = addresses
+		addressText := addressPreviewText(addresses)
+		// 确保所有地址都被设置（使用fyne.Do确保在主线程更新）
+		fyne.Do(func() {
+			addressInput.SetText(addressText)
+			addressInput.Refresh() // 强制刷新
+			// 滚动到顶部，确保能看到第一个地址
+			addressInput.CursorRow = 0
+			addressInput.CursorColumn = 0
+			// 再次刷新，确保滚动位置正确
+			addressInput.Refresh()
+		})
+	}
+
+	// importMixed 导入逐行识别的 Key 和地址（见 core.ParseMixedFile），在一个对话框中说明识别结果
+	importMixed := func(mixed core.MixedImport) {
+		message := mixed.Text()
+		if len(mixed.Keys) > 0 {
+			if err := vm.keyManager.LoadKeysFromLines(mixed.Keys); err != nil {
+				message += fmt.Sprintf("\nKey 导入失败: %v", err)
+			} else {
+				message += fmt.Sprintf("\n已导入 %d 个 API Key", vm.keyManager.GetKeyCount())
+			}
+			apiKeyStatusLabel.SetText(fmt.Sprintf("已加载 %d 个 API Key", vm.keyManager.GetKeyCount()))
+			keyStatusTable.Refresh()
+		}
+		if len(mixed.Addresses) > 0 {
+			showAddresses(mixed.Addresses)
+			message += fmt.Sprintf("\n已加载 %d 个地址", len(mixed.Addresses))
+		}
+		dialog.ShowInformation("导入完成", message, w)
+	}
+
+	// importMixedFile 自动识别模式下导入文件：文件过大时先确认
+	importMixedFile := func(path string) {
+		load := func() {
+			mixed, err := core.ParseMixedFile(path, currentLoadOptions())
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			importMixed(mixed)
+		}
+		if err := core.CheckInputFileSize(path); err != nil {
+			dialog.ShowConfirm("文件较大", err.Error()+"\n\n仍要导入吗？", func(confirmed bool) {
+				if confirmed {
+					load()
+				}
+			}, w)
+			return
+		}
+		load()
+	}
+
 	// 导入文件按钮（清空按钮会在后面定义，因为这些控件需要先创建）
 	importFileBtn := widget.NewButton("📁 导入地址", func() {
 		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
@@ -372,28 +894,16 @@ func ShowMainWindow(a fyne.App) {
 			if reader == nil {
 				return
 			}
-			defer reader.Close()
+			reader.Close()
 
-			addresses, err := core.LoadAddressesFromFile(reader.URI().Path())
-			if err != nil {
-				dialog.ShowError(err, w)
+			if autoDetectCheck.Checked {
+				importMixedFile(reader.URI().Path())
 				return
 			}
-
-			addressList = addresses
-			// 构建所有地址的文本（每行一个地址）
-			addressText := strings.Join(addresses, "\n")
-			// 确保所有地址都被设置（使用fyne.Do确保在主线程更新）
-			fyne.Do(func() {
-				addressInput.SetText(addressText)
-				addressInput.Refresh() // 强制刷新
-				// 滚动到顶部，确保能看到第一个地址
-				addressInput.CursorRow = 0
-				addressInput.CursorColumn = 0
-				// 再次刷新，确保滚动位置正确
-				addressInput.Refresh()
+			loadAddressFile(reader.URI().Path(), func(addresses []string) {
+				showAddresses(addresses)
+				dialog.ShowInformation("成功", fmt.Sprintf("已加载 %d 个地址", len(addresses)), w)
 			})
-			dialog.ShowInformation("成功", fmt.Sprintf("已加载 %d 个地址", len(addresses)), w)
 		}, w)
 	})
 
@@ -407,94 +917,36 @@ func ShowMainWindow(a fyne.App) {
 	// 进度条
 	progressBar := widget.NewProgressBar()
 	progressLabel := widget.NewLabel("等待开始...")
+	retryLabel := widget.NewLabel("") // 重试状态（正在退避重试的请求数），没有重试时为空
+	retryLabel.Importance = widget.WarningImportance
 
 	// 状态栏
 	statusLabel := widget.NewLabel("就绪")
 
-	// 初始化分页和筛选变量
-	currentPage = 1
-	pageSize = 10000 // 每页10000条
-	filterMode = "all"
-	filterText = ""
-
-	// 筛选和分页函数
-	applyFilter := func() {
-		if resultData == nil || len(resultData) == 0 {
-			filteredData = make([]core.QueryResult, 0)
-			displayData = make([]core.QueryResult, 0)
-			totalPages = 1
-			currentPage = 1
-			return
-		}
-
-		// 应用筛选
-		filteredData = make([]core.QueryResult, 0)
-		for _, result := range resultData {
-			match := true
-
-			// 按筛选模式筛选
-			if filterMode == "withBalance" {
-				// 只显示有余额的（余额>0）
-				balanceStr := result.Balance
-				if balanceStr == "" {
-					balanceStr = "0"
-				}
-				// 解析余额字符串（去除逗号等）
-				balanceStr = strings.ReplaceAll(balanceStr, ",", "")
-				if len(balanceStr) > 0 {
-					// 检查是否为0
-					var balance float64
-					fmt.Sscanf(balanceStr, "%f", &balance)
-					if balance <= 0 {
-						match = false
-					}
-				} else {
-					match = false
-				}
-			}
-
-			// 按地址文本筛选
-			if match && filterText != "" {
-				if !strings.Contains(strings.ToLower(result.Address), strings.ToLower(filterText)) {
-					match = false
-				}
-			}
-
-			if match {
-				filteredData = append(filteredData, result)
-			}
+	// 统计图表（可选，随查询实时更新）
+	chart := newStatsChart()
+	chartContainer := container.NewVBox(chart.Content())
+	chartContainer.Hide()
+	showChartCheck := widget.NewCheck("显示统计图表", func(checked bool) {
+		if checked {
+			chartContainer.Show()
+		} else {
+			chartContainer.Hide()
 		}
+	})
 
-		// 计算分页
-		totalPages = (len(filteredData) + pageSize - 1) / pageSize
-		if totalPages == 0 {
-			totalPages = 1
-		}
-		if currentPage > totalPages {
-			currentPage = totalPages
-		}
+	// 结果表格中其他代币的余额列（第 6 列起，第 5 列为标签），开始查询时按选择的代币设置
+	var tableTokens []string
 
-		// 获取当前页数据
-		start := (currentPage - 1) * pageSize
-		end := start + pageSize
-		if end > len(filteredData) {
-			end = len(filteredData)
-		}
-		if start < len(filteredData) {
-			displayData = filteredData[start:end]
-		} else {
-			displayData = nil
-		}
-	}
+	// 还没有结果时在表格位置显示的提示
+	resultEmptyHint := newEmptyHint("请导入地址开始查询")
 
 	// 结果表格（改进样式 - 显示当前页数据）
 	// 使用局部变量快照避免并发访问问题
 	resultTable := widget.NewTable(
 		func() (int, int) {
-			if displayData == nil {
-				return 0, 4
-			}
-			return len(displayData), 4
+			setEmptyHint(resultEmptyHint, len(vm.resultData) == 0)
+			return len(vm.displayIndices), 5 + len(tableTokens)
 		},
 		func() fyne.CanvasObject {
 			label := widget.NewLabel("")
@@ -503,38 +955,64 @@ func ShowMainWindow(a fyne.App) {
 		},
 		func(id widget.TableCellID, obj fyne.CanvasObject) {
 			label := obj.(*widget.Label)
-			// 使用局部快照避免滚动时数据变化
-			dataSnapshot := displayData
-			if dataSnapshot == nil || id.Row >= len(dataSnapshot) {
+			// 使用局部快照避免滚动时数据变化；只能访问当前页的索引
+			indexSnapshot := vm.displayIndices
+			dataSnapshot := vm.resultData
+			if id.Row >= len(indexSnapshot) || indexSnapshot[id.Row] >= len(dataSnapshot) {
 				label.SetText("")
 				return
 			}
 
-			result := dataSnapshot[id.Row]
+			result := dataSnapshot[indexSnapshot[id.Row]]
 			switch id.Col {
 			case 0: // 地址列 - 左对齐，不换行
-				label.SetText(result.Address)
+				text := result.Address
+				if result.Bookmarked {
+					text = "★ " + text
+				}
+				label.Importance = widget.MediumImportance
+				if result.Flagged {
+					// 关注列表中的地址醒目显示，有标签时附在地址后
+					text = "⚑ " + text
+					if result.WatchTag != "" {
+						text += "  [" + result.WatchTag + "]"
+					}
+					label.Importance = widget.DangerImportance
+				}
+				if result.Duplicate {
+					text += "  (重复)"
+				} else if n := vm.duplicateCounts[result.Address]; n > 0 {
+					text += fmt.Sprintf("  (×%d)", n+1)
+				}
+				label.SetText(text)
 				label.Alignment = fyne.TextAlignLeading
 				label.Wrapping = fyne.TextWrapOff // 地址不换行，避免对齐问题
 			case 1: // 余额列 - 右对齐
-				if result.Balance == "" {
-					label.SetText("0.000000")
-				} else {
-					label.SetText(result.Balance)
-				}
+				label.SetText(result.DisplayBalance())
 				label.Alignment = fyne.TextAlignTrailing
 			case 2: // 状态列 - 居中对齐
 				switch result.Status {
-				case "success":
-					label.SetText("成功")
-					label.Importance = widget.SuccessImportance
-				case "error":
+				case core.StatusSuccess:
+					if result.AddressType == "contract" {
+						label.SetText("成功(合约)")
+						label.Importance = widget.WarningImportance
+					} else {
+						label.SetText("成功")
+						label.Importance = widget.SuccessImportance
+					}
+				case core.StatusSkipped:
+					label.SetText("已跳过")
+					label.Importance = widget.MediumImportance
+				case core.StatusInvalid:
+					label.SetText("无效地址")
+					label.Importance = widget.WarningImportance
+				case core.StatusError:
 					label.SetText("失败")
 					label.Importance = widget.DangerImportance
-				case "cancelled":
+				case core.StatusCancelled:
 					label.SetText("已取消")
 					label.Importance = widget.MediumImportance
-				case "pending":
+				case core.StatusPending:
 					label.SetText("待查询")
 					label.Importance = widget.MediumImportance
 				default:
@@ -546,6 +1024,16 @@ func ShowMainWindow(a fyne.App) {
 				label.SetText(result.Error)
 				label.Alignment = fyne.TextAlignLeading
 				label.Wrapping = fyne.TextWrapWord // 错误信息可以换行
+			case 4: // 标签列 - 左对齐
+				label.SetText(result.Tag)
+				label.Importance = widget.MediumImportance
+				label.Alignment = fyne.TextAlignLeading
+				label.Wrapping = fyne.TextWrapOff
+			default: // 其他代币余额列 - 右对齐
+				if i := id.Col - 5; i < len(tableTokens) {
+					label.SetText(result.DisplayTokenBalance(tableTokens[i]))
+				}
+				label.Alignment = fyne.TextAlignTrailing
 			}
 		})
 
@@ -553,6 +1041,50 @@ func ShowMainWindow(a fyne.App) {
 	resultTable.SetColumnWidth(1, 120) // 余额列
 	resultTable.SetColumnWidth(2, 80)  // 状态列
 	resultTable.SetColumnWidth(3, 250) // 错误信息列
+	resultTable.SetColumnWidth(4, 120) // 标签列
+
+	// 书签和标签按钮：标记最近点击的结果行（同一地址的所有行一起标记）
+	selectedIndex := -1
+	bookmarkBtn := widget.NewButton("☆ 书签", nil)
+	bookmarkBtn.Disable()
+	tagBtn := widget.NewButton("🏷 标签", nil)
+	tagBtn.Disable()
+	updateRowActions := func() {
+		if selectedIndex < 0 || selectedIndex >= len(vm.resultData) {
+			bookmarkBtn.SetText("☆ 书签")
+			bookmarkBtn.Disable()
+			tagBtn.Disable()
+			return
+		}
+		if vm.resultData[selectedIndex].Bookmarked {
+			bookmarkBtn.SetText("★ 取消书签")
+		} else {
+			bookmarkBtn.SetText("☆ 加入书签")
+		}
+		bookmarkBtn.Enable()
+		tagBtn.Enable()
+	}
+
+	// 双击结果行弹出详情（Table 没有双击事件，按短时间内两次选中同一条结果判断）
+	var lastTapIndex = -1
+	var lastTapTime time.Time
+	resultTable.OnSelected = func(id widget.TableCellID) {
+		if id.Row >= len(vm.displayIndices) || vm.displayIndices[id.Row] >= len(vm.resultData) {
+			return
+		}
+		index := vm.displayIndices[id.Row]
+		selectedIndex = index
+		updateRowActions()
+		now := time.Now()
+		if index == lastTapIndex && now.Sub(lastTapTime) <= doubleTapInterval {
+			lastTapIndex = -1
+			showResultDetail(w, vm.resultData[index])
+			return
+		}
+		lastTapIndex, lastTapTime = index, now
+		// 取消选中，保证再次点击同一单元格时仍会触发 OnSelected
+		resultTable.Unselect(id)
+	}
 
 	// 分页控件（先定义，因为筛选控件会用到）
 	pageInfoLabel := widget.NewLabel("第 1 页 / 共 1 页 (共 0 条)")
@@ -560,52 +1092,121 @@ func ShowMainWindow(a fyne.App) {
 	// 更新分页信息的辅助函数
 	updatePageInfo := func() {
 		pageInfoLabel.SetText(fmt.Sprintf("第 %d 页 / 共 %d 页 (共 %d 条，显示 %d-%d 条)",
-			currentPage, totalPages, len(filteredData),
+			vm.currentPage, vm.totalPages, len(vm.filteredIndices),
 			func() int {
-				if len(filteredData) == 0 {
+				if len(vm.filteredIndices) == 0 {
 					return 0
 				}
-				return (currentPage-1)*pageSize + 1
+				return (vm.currentPage-1)*vm.pageSize + 1
 			}(),
-			min(currentPage*pageSize, len(filteredData))))
+			min(vm.currentPage*vm.pageSize, len(vm.filteredIndices))))
 	}
 
 	// 筛选控件
-	filterModeSelect := widget.NewSelect([]string{"全部", "有余额", "按地址搜索"}, func(selected string) {
+	filterModeSelect := widget.NewSelect([]string{"全部", "有余额", "只看书签", "按地址搜索"}, func(selected string) {
 		switch selected {
 		case "全部":
-			filterMode = "all"
+			vm.filterMode = "all"
 		case "有余额":
-			filterMode = "withBalance"
+			vm.filterMode = "withBalance"
+		case "只看书签":
+			vm.filterMode = "bookmarked"
 		case "按地址搜索":
-			filterMode = "address"
+			vm.filterMode = "address"
 		}
-		applyFilter()
+		vm.ApplyFilter()
+		resultTable.Refresh()
+		updatePageInfo()
+	})
+	filterModeSelect.SetSelected(filterModeLabel(vm.filterMode)) // 恢复上次关闭时的筛选模式
+
+	// 结果视图：按输入行（重复地址每行一条）或按唯一地址（合并重复行）
+	viewModeSelect := widget.NewSelect([]string{"按输入行", "按唯一地址"}, func(selected string) {
+		vm.uniqueView = selected == "按唯一地址"
+		vm.currentPage = 1
+		vm.ApplyFilter()
 		resultTable.Refresh()
 		updatePageInfo()
 	})
-	filterModeSelect.SetSelected("全部")
+	viewModeSelect.SetSelected("按输入行")
+
+	bookmarkBtn.OnTapped = func() {
+		marked, err := vm.ToggleBookmark(selectedIndex)
+		if err != nil {
+			dialog.ShowError(err, w)
+		}
+		if marked {
+			statusLabel.SetText(fmt.Sprintf("已加入书签（共 %d 个）: %s", vm.bookmarks.Count(), vm.resultData[selectedIndex].Address))
+		} else if err == nil {
+			statusLabel.SetText(fmt.Sprintf("已取消书签（共 %d 个）: %s", vm.bookmarks.Count(), vm.resultData[selectedIndex].Address))
+		}
+		// 只看书签时取消标记的行会从列表中消失
+		vm.ApplyFilter()
+		resultTable.Refresh()
+		updatePageInfo()
+		updateRowActions()
+	}
+
+	// 设置标签：标签按地址保存，之后查询到同一地址时自动带上，导出时增加"标签"列
+	tagBtn.OnTapped = func() {
+		if selectedIndex < 0 || selectedIndex >= len(vm.resultData) {
+			return
+		}
+		index := selectedIndex
+		address := vm.resultData[index].Address
+		tagEntry := widget.NewEntry()
+		tagEntry.SetText(vm.resultData[index].Tag)
+		tagEntry.SetPlaceHolder("如：交易所、可疑（留空删除标签）")
+		dialog.ShowForm("地址标签", "保存", "取消", []*widget.FormItem{
+			widget.NewFormItem("地址:", widget.NewLabel(address)),
+			widget.NewFormItem("标签:", tagEntry),
+		}, func(confirmed bool) {
+			if !confirmed {
+				return
+			}
+			if err := vm.SetTag(index, tagEntry.Text); err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			if tag := vm.tags.Get(address); tag != "" {
+				statusLabel.SetText(fmt.Sprintf("已设置标签（共 %d 个地址有标签）: %s [%s]", vm.tags.Count(), address, tag))
+			} else {
+				statusLabel.SetText(fmt.Sprintf("已删除标签: %s", address))
+			}
+			resultTable.Refresh()
+		}, w)
+	}
 
 	addressSearchEntry := widget.NewEntry()
-	addressSearchEntry.SetPlaceHolder("输入地址关键词搜索...")
+	addressSearchEntry.SetPlaceHolder("输入地址开头或关键词搜索...")
 	addressSearchEntry.OnChanged = func(text string) {
-		filterText = text
-		applyFilter()
+		vm.filterText = text
+		vm.ApplyFilter()
 		resultTable.Refresh()
 		updatePageInfo()
 	}
+	// 翻页只移动当前页，不重新筛选（查询中追加的行保持在原来的位置）
 	prevPageBtn := widget.NewButton("上一页", func() {
-		if currentPage > 1 {
-			currentPage--
-			applyFilter()
+		if vm.currentPage > 1 {
+			vm.currentPage--
+			vm.Paginate()
 			resultTable.Refresh()
 			updatePageInfo()
 		}
 	})
 	nextPageBtn := widget.NewButton("下一页", func() {
-		if currentPage < totalPages {
-			currentPage++
-			applyFilter()
+		if vm.currentPage < vm.totalPages {
+			vm.currentPage++
+			vm.Paginate()
+			resultTable.Refresh()
+			updatePageInfo()
+		}
+	})
+
+	// 暂停刷新：查询继续进行，但结果表格不再更新，便于查看正在看的行；取消勾选后立即刷新
+	freezeCheck := widget.NewCheck("暂停刷新", func(checked bool) {
+		if !checked {
+			vm.RefreshFilter()
 			resultTable.Refresh()
 			updatePageInfo()
 		}
@@ -614,20 +1215,46 @@ func ShowMainWindow(a fyne.App) {
 	jumpPageEntry := widget.NewEntry()
 	jumpPageEntry.SetPlaceHolder("页码")
 	jumpPageEntry.Resize(fyne.NewSize(60, 0)) // 设置跳转输入框的宽度
+	pageRange := func() intRange { return intRange{name: "页码", min: 1, max: vm.totalPages} }
+	jumpPageError := newInlineError(jumpPageEntry, pageRange)
 	jumpPageBtn := widget.NewButton("跳转", func() {
-		pageStr := strings.TrimSpace(jumpPageEntry.Text)
-		if pageStr != "" {
-			var page int
-			if _, err := fmt.Sscanf(pageStr, "%d", &page); err == nil && page >= 1 && page <= totalPages {
-				currentPage = page
-				applyFilter()
-				resultTable.Refresh()
-				updatePageInfo()
-				jumpPageEntry.SetText("")
-			} else {
-				dialog.ShowError(errors.New("无效的页码，请输入 1-%d 之间的数字"), w)
-			}
+		if strings.TrimSpace(jumpPageEntry.Text) == "" {
+			return
+		}
+		page, err := pageRange().parse(jumpPageEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
 		}
+		vm.currentPage = page
+		vm.Paginate()
+		resultTable.Refresh()
+		updatePageInfo()
+		jumpPageEntry.SetText("")
+	})
+
+	// 导入关注列表：匹配的地址在表格中醒目显示，导出时增加"关注"列
+	watchlistBtn := widget.NewButton("⚑ 关注列表", func() {
+		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			if reader == nil {
+				return
+			}
+			reader.Close()
+
+			watchlist, err := core.LoadWatchlist(reader.URI().Path())
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			flagged := vm.SetWatchlist(watchlist)
+			vm.ApplyFilter()
+			resultTable.Refresh()
+			statusLabel.SetText(fmt.Sprintf("已导入关注列表: %d 个地址，当前结果中匹配 %d 行", watchlist.Count(), flagged))
+		}, w)
 	})
 
 	// 删除地址按钮
@@ -641,29 +1268,87 @@ func ShowMainWindow(a fyne.App) {
 		container.NewHBox(
 			widget.NewLabel("筛选:"),
 			filterModeSelect,
+			viewModeSelect,
+			bookmarkBtn,
+			tagBtn,
+			watchlistBtn,
 		),
 		nil,
 		addressSearchEntry, // 搜索框占据中间的主要空间，自动扩展
 	)
 
 	// 表头（放在筛选下面）- 使用GridWithColumns自动对齐表格列
-	headerContainer := container.NewGridWithColumns(4,
+	headerContainer := container.NewGridWithColumns(5,
 		widget.NewLabelWithStyle("地址", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
 		widget.NewLabelWithStyle("余额 (USDT)", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
 		widget.NewLabelWithStyle("状态", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
 		widget.NewLabelWithStyle("错误信息", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("标签", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
 	)
 
+	// setTableTokens 按查询的代币设置余额列：第一个代币显示在余额列，其余各占一列
+	setTableTokens := func(symbols []string) {
+		tableTokens = append([]string(nil), symbols[1:]...)
+		headers := []string{"地址", fmt.Sprintf("余额 (%s)", symbols[0]), "状态", "错误信息", "标签"}
+		for i, symbol := range tableTokens {
+			headers = append(headers, fmt.Sprintf("余额 (%s)", symbol))
+			resultTable.SetColumnWidth(5+i, 120)
+		}
+		objects := make([]fyne.CanvasObject, len(headers))
+		for i, header := range headers {
+			objects[i] = widget.NewLabelWithStyle(header, fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
+		}
+		headerContainer.Objects = objects
+		headerContainer.Layout = layout.NewGridLayoutWithColumns(len(headers))
+		headerContainer.Refresh()
+		resultTable.Refresh()
+	}
+
 	// 导出按钮
 	exportCSVBtn := widget.NewButton("📄 导出 CSV", nil)
 	exportExcelBtn := widget.NewButton("📊 导出 Excel", nil)
+	exportQRBtn := widget.NewButton("🔳 导出二维码", nil)
 	exportCSVBtn.Disable()
 	exportExcelBtn.Disable()
+	exportQRBtn.Disable()
+
+	// 导出时在末尾追加合计行（地址数、余额合计）
+	totalRowCheck := widget.NewCheck("包含汇总行", func(checked bool) {
+		vm.includeTotalRow = checked
+	})
+
+	// 导出时增加节点原始 hex 列（审计用）
+	rawHexCheck := widget.NewCheck("包含原始值", func(checked bool) {
+		vm.includeRawHex = checked
+	})
+
+	// 导出成功后自动用系统默认程序打开文件
+	openAfterExportCheck := widget.NewCheck("导出后打开", func(checked bool) {
+		vm.openAfterExport = checked
+	})
+
+	// showExported 显示导出成功提示，勾选了"导出后打开"时同时打开文件
+	showExported := func(path, message string) {
+		dialog.ShowInformation("成功", message, w)
+		if vm.openAfterExport {
+			if err := openFile(path); err != nil {
+				dialog.ShowError(err, w)
+			}
+		}
+	}
+
+	// done 在关闭标签页时关闭，后台的定时器 goroutine 随之退出（Ticker.Stop 不会关闭 C）
+	done := make(chan struct{})
 
 	// 使用 channel 将更新请求发送到主线程
 	updateChan := make(chan struct{}, 1)
 	go func() {
-		for range updateChan {
+		for {
+			select {
+			case <-done:
+				return
+			case <-updateChan:
+			}
 			mu.Lock()
 			progress := lastProgress
 			mu.Unlock()
@@ -672,67 +1357,49 @@ func ShowMainWindow(a fyne.App) {
 				fyne.Do(func() {
 					// 计算剩余数量
 					remaining := progress.total - progress.current
+					queryProgress := core.Progress{Total: progress.total, Completed: progress.current, Skipped: progress.skipped}
 
-					progressBar.SetValue(float64(progress.current) / float64(progress.total))
-					// 显示进度：已完成/总数，剩余X个
-					progressLabel.SetText(fmt.Sprintf("已完成: %d / %d | 剩余: %d 个", progress.current, progress.total, remaining))
-
-					if progress.stats.total > 0 {
-						// 计算有余额和没有余额的数量
-						withBalance := 0
-						withoutBalance := 0
-						for _, result := range progress.results {
-							if result.Status == "success" {
-								balanceStr := result.Balance
-								if balanceStr == "" {
-									balanceStr = "0"
-								}
-								balanceStr = strings.ReplaceAll(balanceStr, ",", "")
-								var balance float64
-								if _, err := fmt.Sscanf(balanceStr, "%f", &balance); err == nil {
-									if balance > 0 {
-										withBalance++
-									} else {
-										withoutBalance++
-									}
-								} else {
-									withoutBalance++
-								}
-							}
-						}
+					progressBar.SetValue(queryProgress.Fraction())
+					// 显示进度：完成 X（含跳过 Y）/ 总数，剩余X个
+					progressLabel.SetText(fmt.Sprintf("%s | 剩余: %d 个", queryProgress.Text(), remaining))
+
+					if stats := progress.stats; stats.Total > 0 {
 						statusText := fmt.Sprintf("总计: %d | 成功: %d | 失败: %d | 有余额: %d | 无余额: %d",
-							progress.stats.total, progress.stats.success, progress.stats.failed, withBalance, withoutBalance)
+							stats.Total, stats.Success, stats.Failed, stats.WithBalance, stats.WithoutBalance)
 						statusLabel.SetText(statusText)
+						chart.Update(stats.Success, stats.Failed, stats.WithBalance, stats.WithoutBalance)
 					}
 
 					// 更新结果表格（确保显示所有结果，包括空结果）
-					// 创建结果数据的副本，避免引用问题
+					// progress.results 已是查询结果的副本，直接使用，不再复制
 					if len(progress.results) > 0 {
-						resultData = make([]core.QueryResult, len(progress.results))
-						copy(resultData, progress.results)
+						vm.resultData = progress.results
 					} else if progress.total > 0 {
 						// 如果结果为空但总数大于0，确保至少显示与地址数量对应的空行
-						if resultData == nil || len(resultData) != progress.total {
-							resultData = make([]core.QueryResult, progress.total)
+						if vm.resultData == nil || len(vm.resultData) != progress.total {
+							vm.resultData = make([]core.QueryResult, progress.total)
 						}
 					}
-					// 应用筛选和分页
-					applyFilter()
-					// 更新分页信息
-					updatePageInfo()
-					// 强制刷新表格，确保所有行都显示
-					resultTable.Refresh()
+					// 查询结束时取消暂停刷新（取消勾选时会刷新表格）
+					if progress.done {
+						freezeCheck.SetChecked(false)
+					}
+					// 新的结果追加到筛选结果末尾，已显示的行和当前页不动（见 RefreshFilter）；暂停刷新时表格保持不变
+					if !freezeCheck.Checked {
+						vm.RefreshFilter()
+						// 更新分页信息
+						updatePageInfo()
+						// 强制刷新表格，确保所有行都显示
+						resultTable.Refresh()
+					}
 
 					// 更新 Key 状态
-					updateKeyStatusTable(keyStatusTable, keyManager)
+					updateKeyStatusTable(keyStatusTable, vm.keyManager)
 
 					if progress.done {
-						isQuerying = false
-						isPaused = false
-						pausedAddresses = nil
-						pausedStartIndex = 0
-						pausedTotalProgress = 0
-						// 不清空 currentQueryAddrs，以便用户可以重新查询
+						vm.ClearPaused()
+						vm.prepareSearchIndex()
+						// 不清空 vm.currentQueryAddrs，以便用户可以重新查询
 						queryBtn.Enable()
 						queryBtn.SetText("▶ 开始查询")
 						pauseBtn.Disable()
@@ -740,34 +1407,22 @@ func ShowMainWindow(a fyne.App) {
 						importFileBtn.Enable()
 						exportCSVBtn.Enable()
 						exportExcelBtn.Enable()
-
-						// 计算有余额和没有余额的数量
-						withBalance := 0
-						withoutBalance := 0
-						for _, result := range progress.results {
-							if result.Status == "success" {
-								balanceStr := result.Balance
-								if balanceStr == "" {
-									balanceStr = "0"
-								}
-								balanceStr = strings.ReplaceAll(balanceStr, ",", "")
-								var balance float64
-								if _, err := fmt.Sscanf(balanceStr, "%f", &balance); err == nil {
-									if balance > 0 {
-										withBalance++
-									} else {
-										withoutBalance++
-									}
-								} else {
-									withoutBalance++
-								}
-							}
-						}
+						exportQRBtn.Enable()
 
 						finalStatus := fmt.Sprintf("完成！总计: %d | 成功: %d | 失败: %d | 有余额: %d | 无余额: %d",
-							progress.total, progress.stats.success, progress.stats.failed, withBalance, withoutBalance)
+							progress.total, progress.stats.Success, progress.stats.Failed, progress.stats.WithBalance, progress.stats.WithoutBalance)
+						qm := vm.QueryManager()
+						if qm != nil && group.showTotalEnabled() {
+							symbol := tokenSymbols(qm.Tokens())[0]
+							finalStatus += fmt.Sprintf(" | 合计: %s %s", core.FormatBalanceTotal(core.SumBalances(progress.results)), symbol)
+						}
+						if qm != nil {
+							summary := qm.GetSummary()
+							finalStatus += " | 重试: " + summary.RetryText() + " | " + summary.BaselineText()
+						}
 						statusLabel.SetText(finalStatus)
-						progressLabel.SetText(fmt.Sprintf("完成：%d / %d（剩余: 0 个）", progress.total, progress.total))
+						queryProgress := core.Progress{Total: progress.total, Completed: progress.total, Skipped: progress.skipped}
+						progressLabel.SetText(fmt.Sprintf("%s（剩余: 0 个）", queryProgress.Text()))
 					}
 				})
 			}
@@ -777,7 +1432,12 @@ func ShowMainWindow(a fyne.App) {
 	// 使用定时器触发更新检查
 	updateTicker := time.NewTicker(200 * time.Millisecond)
 	go func() {
-		for range updateTicker.C {
+		for {
+			select {
+			case <-done:
+				return
+			case <-updateTicker.C:
+			}
 			select {
 			case updateChan <- struct{}{}:
 			default:
@@ -785,26 +1445,90 @@ func ShowMainWindow(a fyne.App) {
 		}
 	}()
 
-	// 查询按钮点击事件
-	queryBtn.OnTapped = func() {
+	// contractFilterMode 当前选择的合约地址检查模式
+	contractFilterMode := func() core.ContractFilterMode {
+		switch contractFilterSelect.Selected {
+		case "标记合约":
+			return core.ContractFilterFlag
+		case "排除合约":
+			return core.ContractFilterExclude
+		}
+		return core.ContractFilterOff
+	}
+
+	// estimateQuery 按当前的地址和设置预估请求数，地址或代币无法解析时返回 false（由 startQuery 报错）
+	estimateQuery := func() (core.Estimate, bool) {
+		addresses := vm.addressList
+		if vm.isPaused && len(vm.pausedAddresses) > 0 {
+			addresses = vm.pausedAddresses
+		} else if len(addresses) == 0 {
+			var err error
+			addresses, err = core.LoadAddressesFromTextWithOptions(strings.TrimSpace(addressInput.Text), currentLoadOptions())
+			if err != nil {
+				return core.Estimate{}, false
+			}
+		}
+		tokens, err := tron.ParseTokens(tokensEntry.Text)
+		if err != nil {
+			return core.Estimate{}, false
+		}
+		opts := core.QueryOptions{Tokens: tokens, ContractFilter: contractFilterMode()}
+		return core.EstimateRun(core.QueryableAddresses(addresses), opts, vm.keyManager), true
+	}
+
+	// 开始（或继续）查询，confirmed 为 true 时不再确认额度
+	var startQuery func(confirmed bool)
+	var enterPaused func()
+	startQuery = func(confirmed bool) {
+		// 已有查询在进行时忽略（如快速连续点击开始）
+		if vm.QueryActive() {
+			return
+		}
+		// 未开启同时查询时，其他批次查询中不能开始
+		if err := group.canStart(vm); err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+
 		// 检查是否有 API Key
-		if keyManager.GetKeyCount() == 0 {
+		if vm.keyManager.GetKeyCount() == 0 {
 			dialog.ShowError(errors.New("请先导入 API Key 文件"), w)
 			return
 		}
 
+		// 线程数无效时不开始（不替换为默认值）
+		threadCount, threadErr := threadRange.parse(threadCountEntry.Text)
+		if threadErr != nil {
+			dialog.ShowError(threadErr, w)
+			return
+		}
+
+		// 剩余额度可能不够时先确认，确认后重新进入
+		if !confirmed {
+			if estimate, ok := estimateQuery(); ok && estimate.Verdict != core.EstimateSufficient {
+				dialog.ShowConfirm(estimate.Verdict.String(), estimate.Text()+"\n\n仍要开始查询吗？", func(ok bool) {
+					if ok {
+						startQuery(true)
+					}
+				}, w)
+				return
+			}
+		}
+
 		var addresses []string
-		var startOffset int = 0 // 本次查询的起始偏移量（用于合并结果）
+		var startOffset int = 0 // 本次查询之前已完成的数量（用于累计进度）
+		var indices []int       // 继续查询时，每个地址在完整列表中的索引（用于合并结果）
 		var isContinue bool = false
 
 		// 如果是继续之前暂停的查询
-		if isPaused && pausedAddresses != nil && len(pausedAddresses) > 0 {
-			addresses = pausedAddresses
-			startOffset = pausedTotalProgress
+		if vm.isPaused && vm.pausedAddresses != nil && len(vm.pausedAddresses) > 0 {
+			addresses = vm.pausedAddresses
+			indices = vm.pausedIndices
+			startOffset = vm.pausedTotalProgress
 			isContinue = true
-			isPaused = false
+			vm.isPaused = false
 			queryBtn.SetText("▶ 开始查询")
-			statusLabel.SetText(fmt.Sprintf("继续查询，从第 %d 个开始，剩余 %d 个地址...", startOffset+1, len(addresses)))
+			statusLabel.SetText(fmt.Sprintf("继续查询，已完成 %d 个，剩余 %d 个地址...", startOffset, len(addresses)))
 		} else {
 			// 新查询
 			text := strings.TrimSpace(addressInput.Text)
@@ -815,14 +1539,18 @@ func ShowMainWindow(a fyne.App) {
 
 			// 加载地址
 			var err error
-			if addressList != nil && len(addressList) > 0 {
-				addresses = addressList
+			if vm.addressList != nil && len(vm.addressList) > 0 {
+				addresses = vm.addressList
 			} else {
-				addresses, err = core.LoadAddressesFromText(text)
+				var report core.LoadReport
+				addresses, report, err = core.LoadAddressesFromTextWithReport(text, currentLoadOptions())
 				if err != nil {
-					dialog.ShowError(errors.New("地址解析失败: %v\n\n提示：\n- 每行一个地址\n- 或用逗号/空格分隔：地址1,地址2 地址3\n- 或使用导入文件功能"), w)
+					dialog.ShowError(fmt.Errorf("地址解析失败: %v\n\n提示：\n- 每行一个地址\n- 或用逗号/空格分隔：地址1,地址2 地址3\n- 或使用导入文件功能", err), w)
 					return
 				}
+				if text := report.Text(); text != "" {
+					dialog.ShowInformation("导入提示", text, w)
+				}
 			}
 
 			if len(addresses) == 0 {
@@ -836,39 +1564,79 @@ func ShowMainWindow(a fyne.App) {
 			}
 
 			// 如果之前有查询，先取消它（避免状态混乱）
-			if queryManager != nil && isQuerying {
-				queryManager.Cancel()
+			if vm.QueryActive() {
+				vm.QueryManager().Cancel()
 			}
 
-			// 初始化结果（新查询）
-			currentQueryAddrs = addresses
-			resultData = make([]core.QueryResult, len(addresses))
+			// 初始化结果（新查询），之前选中的行已不存在
+			selectedIndex = -1
+			updateRowActions()
+			vm.currentQueryAddrs = addresses
+			vm.resultData = make([]core.QueryResult, len(addresses))
 			resultTable.Refresh()
-			pausedTotalProgress = 0
+			vm.pausedTotalProgress = 0
 			startOffset = 0
 		}
 
 		// 创建查询管理器
-		nodeURL := strings.TrimSpace(nodeURLEntry.Text)
-		queryManager = core.NewQueryManager(keyManager, nodeURL)
-
-		// 设置线程数
-		threadCountText := strings.TrimSpace(threadCountEntry.Text)
-		if threadCountText == "" {
-			threadCountText = "1"
+		nodeURL, err := tron.NormalizeNodeURL(nodeURLEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		if nodeURL != strings.TrimSpace(nodeURLEntry.Text) {
+			nodeURLEntry.SetText(nodeURL)
+		}
+		tokens, err := tron.ParseTokens(tokensEntry.Text)
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
 		}
-		var threadCount int
-		_, err := fmt.Sscanf(threadCountText, "%d", &threadCount)
-		if err != nil || threadCount < 1 {
-			threadCount = 1
+		// 先配置好新的查询管理器再替换，查询 goroutine 只使用这一个，不读取 vm.queryManager
+		qm := core.NewQueryManager(vm.keyManager, nodeURL)
+		qm.SetTokens(tokens)
+		setTableTokens(tokenSymbols(tokens))
+		// 只写了合约地址的代币在查询开始时查询符号，查到后更新表头
+		qm.SetTokensCallback(func(resolved []tron.Token) {
+			symbols := tokenSymbols(resolved)
+			fyne.Do(func() {
+				setTableTokens(symbols)
+			})
+		})
+
+		// 设置线程数（开始时已校验）
+		if autoThreadsCheck.Checked && threadCount == 1 {
+			threadCount = core.DefaultAutoThreadsMax
 		}
-		if threadCount > 20 {
-			threadCount = 20
+		qm.SetMaxConcurrent(threadCount)
+		qm.SetAutoThreads(autoThreadsCheck.Checked)
+		qm.SetRateLimit(int(rateSlider.Value))
+
+		qm.SetShuffle(shuffleCheck.Checked)
+		qm.SetMemoryGuard(core.RuntimeMemoryLimit())
+		if autoRetryCheck.Checked {
+			qm.SetAutoRetry(guiAutoRetryPasses)
+		} else {
+			qm.SetAutoRetry(0)
 		}
-		queryManager.SetMaxConcurrent(threadCount)
+		qm.SetWarningCallback(func(message string) {
+			fyne.Do(func() {
+				statusLabel.SetText("⚠ " + message)
+			})
+		})
+		// 进度里程碑（25%、50%、75%、100%）：记录日志并发送系统通知（100% 由查询结束的通知代替）
+		qm.SetMilestoneCallback(func(percent int) {
+			log.Info("查询进度: 已完成 %d%%\n", percent)
+			if percent < 100 && group.notifyEnabled() {
+				fyne.CurrentApp().SendNotification(fyne.NewNotification("USDT balance check", fmt.Sprintf("查询进度: 已完成 %d%%", percent)))
+			}
+		})
+
+		// 设置合约地址检查模式
+		qm.SetContractFilter(contractFilterMode())
+		vm.SetQueryManager(qm)
 
 		// 开始查询
-		isQuerying = true
 		queryBtn.Disable()
 		pauseBtn.Enable() // 确保暂停按钮可用
 		stopBtn.Enable()  // 启用停止按钮
@@ -876,45 +1644,55 @@ func ShowMainWindow(a fyne.App) {
 		importKeyBtn.Disable()
 		exportCSVBtn.Disable()
 		exportExcelBtn.Disable()
+		exportQRBtn.Disable()
 		if !isContinue {
 			progressBar.SetValue(0)
-			progressLabel.SetText(fmt.Sprintf("0 / %d", len(currentQueryAddrs)))
+			progressLabel.SetText(fmt.Sprintf("0 / %d", len(vm.currentQueryAddrs)))
+			// 新的查询重新筛选，行数可能变化，不能保持暂停刷新时的表格
+			freezeCheck.SetChecked(false)
+			vm.filterMember = nil
+			vm.searchIndex = nil
+		}
+
+		// 继续查询时在界面线程复制之前的结果，查询线程只在这份副本上合并，不读写 vm.resultData
+		var base []core.QueryResult
+		if isContinue {
+			base = slices.Clone(vm.resultData)
 		}
 
-		// 在新 goroutine 中查询（使用闭包捕获 startOffset 和 isContinue）
-		go func(offset int, isCont bool) {
-			queryCancel = queryManager.Cancel
+		// 在新 goroutine 中查询（传入 startOffset、indices、isContinue 和完整地址数，不读取 vm 的字段）
+		go func(offset int, indices []int, isCont bool, base []core.QueryResult, fullTotal int) {
+			// 继续查询时之前已完成的结果只统计一次，进度更新时加上本次查询的增量统计
+			var baseStats core.ResultStats
+			var baseFailures []core.FailureCount
+			if isCont {
+				baseStats = settledStats(base, indices)
+				baseFailures = settledFailures(base, indices)
+			}
 
-			queryManager.QueryAddresses(addresses, func(current, total int) {
+			err := qm.QueryAddresses(addresses, func(current, total int) {
 				mu.Lock()
-				// 如果是继续查询，需要累加之前的进度
+				// 如果是继续查询，需要累加之前的进度（之前完成的部分计为跳过）
+				lastProgress.skipped = qm.Progress().Skipped
 				if isCont {
 					lastProgress.current = offset + current
-					lastProgress.total = len(currentQueryAddrs)
+					lastProgress.total = fullTotal
+					lastProgress.skipped += offset
 				} else {
 					lastProgress.current = current
 					lastProgress.total = total
 				}
-				lastProgress.stats.total, lastProgress.stats.success, lastProgress.stats.failed = queryManager.GetStats()
+				lastProgress.stats = baseStats.Plus(qm.GetStats())
 
 				// 获取当前批次的结果
-				currentResults := queryManager.GetResults()
+				currentResults := qm.GetResults()
 
-				// 如果是继续查询，需要合并到之前的结果中
+				// 如果是继续查询，需要合并到之前的结果中（新的切片，由界面线程替换 vm.resultData）
 				if isCont {
-					// 将新结果合并到 resultData 的对应位置
-					for i, result := range currentResults {
-						if offset+i < len(resultData) {
-							resultData[offset+i] = result
-						}
-					}
-					// lastProgress.results 保持为完整的 resultData
-					lastProgress.results = make([]core.QueryResult, len(resultData))
-					copy(lastProgress.results, resultData)
+					lastProgress.results = mergedResults(base, currentResults, indices)
 				} else {
-					// 新查询，直接使用结果
-					lastProgress.results = make([]core.QueryResult, len(currentResults))
-					copy(lastProgress.results, currentResults)
+					// 新查询，GetResults 返回的已是副本，直接使用
+					lastProgress.results = currentResults
 				}
 				mu.Unlock()
 				// 触发更新
@@ -923,133 +1701,248 @@ func ShowMainWindow(a fyne.App) {
 				default:
 				}
 			})
+			if err != nil {
+				log.Error("查询未开始: %v", err)
+			}
 
 			// 查询完成或被取消
 			mu.Lock()
 			// 检查是否被取消
-			wasCancelled := (queryManager.Ctx().Err() != nil)
+			wasCancelled := (qm.Ctx().Err() != nil)
 			if !wasCancelled {
 				lastProgress.done = true
 			}
 
 			if isCont {
 				// 合并最终结果
-				currentResults := queryManager.GetResults()
-				for i, result := range currentResults {
-					if offset+i < len(resultData) {
-						resultData[offset+i] = result
-					}
-				}
-				lastProgress.results = make([]core.QueryResult, len(resultData))
-				copy(lastProgress.results, resultData)
+				lastProgress.results = mergedResults(base, qm.GetResults(), indices)
 				if !wasCancelled {
-					lastProgress.current = len(currentQueryAddrs)
-					lastProgress.total = len(currentQueryAddrs)
+					lastProgress.current = fullTotal
+					lastProgress.total = fullTotal
 				}
 			} else {
-				results := queryManager.GetResults()
-				lastProgress.results = make([]core.QueryResult, len(results))
-				copy(lastProgress.results, results)
+				lastProgress.results = qm.GetResults()
 				if !wasCancelled {
 					lastProgress.current = len(addresses)
 					lastProgress.total = len(addresses)
 				}
 			}
-			lastProgress.stats.total, lastProgress.stats.success, lastProgress.stats.failed = queryManager.GetStats()
+			lastProgress.stats = baseStats.Plus(qm.GetStats())
+			finalResults := lastProgress.results
 			mu.Unlock()
 			// 触发最终更新
 			select {
 			case updateChan <- struct{}{}:
 			default:
 			}
-		}(startOffset, isContinue)
+
+			// 查询完成且有失败或自动重试过时，提示每轮恢复的数量和按错误类别的失败分类（继续查询时按完整结果统计）
+			if !wasCancelled {
+				runSummary := qm.GetSummary()
+				summary := core.RunSummary{
+					FailedByKind: core.CombineFailures(baseFailures, runSummary.FailedByKind),
+					AutoRetries:  runSummary.AutoRetries,
+				}
+				var lines []string
+				if text := runSummary.AutoThreadsText(); text != "" && runSummary.AutoThreadsConverged {
+					// 收敛的线程数填入线程数输入框，取消勾选"自动"即固定使用
+					lines = append(lines, text+"，已填入并发线程；取消勾选\"自动\"即固定使用")
+					fyne.Do(func() {
+						threadCountEntry.SetText(fmt.Sprintf("%d", runSummary.AutoThreads))
+					})
+				}
+				if text := summary.AutoRetryText(); text != "" {
+					lines = append(lines, text)
+				}
+				for _, failure := range summary.FailedByKind {
+					summary.Failed += failure.Count
+				}
+				if summary.Failed > 0 {
+					lines = append(lines, summary.FailureText())
+				}
+				if len(lines) > 0 {
+					fyne.Do(func() {
+						dialog.ShowInformation("查询完成", strings.Join(lines, "\n"), w)
+					})
+				}
+			}
+
+			// 内存占用达到上限或所有 Key 额度用完时查询已自动取消，按暂停处理，未查询的地址保留，之后可以继续
+			reason := qm.FinishReason()
+			hint := ""
+			switch reason {
+			case core.FinishMemoryLimit:
+				hint = "为避免程序崩溃，查询已自动暂停，未查询的地址已保留。\n\n建议先导出已完成的结果，再拆分地址文件分批查询"
+			case core.FinishKeysExhausted:
+				hint = "所有 API Key 都已达到使用上限，未查询的地址已保留。\n\n请导入新的 Key 或等待额度重置后点击\"继续查询\""
+			}
+			if hint != "" {
+				fyne.Do(func() {
+					enterPaused()
+					dialog.ShowInformation("已自动暂停", reason.String()+"。\n"+hint, w)
+				})
+			}
+
+			// 查询结束或自动暂停时发送系统通知（手动暂停、停止时不发送）
+			if group.notifyEnabled() {
+				title := ""
+				switch reason {
+				case core.FinishKeysExhausted, core.FinishMemoryLimit:
+					title = "查询已自动暂停：" + reason.String()
+				case core.FinishCompleted:
+					title = "查询完成"
+				}
+				if title != "" {
+					fyne.CurrentApp().SendNotification(finishNotification(title, finalResults))
+				}
+			}
+		}(startOffset, indices, isContinue, base, len(vm.currentQueryAddrs))
+	}
+
+	// 心跳提示：长时间没有新结果时（通常是限流或重试退避），提示用户查询仍在进行
+	heartbeatTicker := time.NewTicker(core.HeartbeatInterval)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-heartbeatTicker.C:
+			}
+			qm := vm.QueryManager()
+			if qm == nil || qm.State() != core.StateRunning {
+				continue
+			}
+			activity := qm.GetActivity()
+			if !activity.Stalled() {
+				continue
+			}
+			heartbeat := activity.HeartbeatText()
+			log.Info(heartbeat)
+			log.Info(qm.RateLimiterStats().String()) // 判断是否卡在本地限流
+			fyne.Do(func() {
+				if vm.QueryActive() {
+					statusLabel.SetText(heartbeat)
+				}
+			})
+		}
+	}()
+
+	// 重试状态：每秒刷新正在退避重试的请求数，让暂时的变慢有明确原因
+	retryTicker := time.NewTicker(time.Second)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-retryTicker.C:
+			}
+			text := ""
+			if qm := vm.QueryManager(); qm != nil && qm.State() == core.StateRunning {
+				activity := qm.GetActivity()
+				text = activity.RetryText()
+				if pass := activity.AutoRetryText(); pass != "" {
+					text = strings.TrimSpace(pass + "  " + text)
+				}
+				if threads, _ := qm.AutoThreads(); threads > 0 {
+					text = strings.TrimSpace(fmt.Sprintf("自动线程 %d  %s", threads, text))
+				}
+			}
+			fyne.Do(func() {
+				if retryLabel.Text != text {
+					retryLabel.SetText(text)
+				}
+			})
+		}
+	}()
+
+	// 查询按钮点击事件：开始前检查磁盘空间（统计自动保存和导出都需要写盘）
+	queryBtn.OnTapped = func() {
+		if err := core.CheckDiskSpace(vm.keyManager.GetStatsFilePath()); err != nil {
+			dialog.ShowConfirm("磁盘空间不足", err.Error()+"\n\n自动保存和导出可能失败，仍要开始查询吗？", func(confirmed bool) {
+				if confirmed {
+					startQuery(false)
+				}
+			}, w)
+			return
+		}
+		startQuery(false)
 	}
 
 	// 暂停按钮（保留未完成的地址，可以继续）
 	pauseBtn.OnTapped = func() {
-		if queryManager != nil && isQuerying {
+		if vm.QueryActive() {
 			// 取消当前查询
-			queryManager.Cancel()
+			vm.QueryManager().Cancel()
 
 			// 等待一小段时间确保查询已停止
 			time.Sleep(200 * time.Millisecond)
 
-			// 获取当前进度
-			mu.Lock()
-			currentProgress := lastProgress.current
-			totalAddresses := lastProgress.total
-			mu.Unlock()
+			enterPaused()
+		}
+	}
 
-			// 保存剩余未查询的地址（从 currentQueryAddrs 获取）
-			if currentProgress < totalAddresses && currentQueryAddrs != nil && len(currentQueryAddrs) > 0 {
-				if currentProgress < len(currentQueryAddrs) {
-					pausedAddresses = currentQueryAddrs[currentProgress:]
-					pausedTotalProgress = currentProgress
-				}
-			}
+	// enterPaused 查询取消后进入暂停状态：保存剩余地址，按钮切换为"继续查询"
+	enterPaused = func() {
+		// 保存剩余未查询的地址（按结果状态判断，乱序或并发查询时已完成的不一定是前缀）
+		mu.Lock()
+		vm.SavePaused(lastProgress.results)
+		mu.Unlock()
 
-			isQuerying = false
-			isPaused = true
+		vm.isPaused = true
 
-			// 重要：使用 fyne.Do 确保 UI 更新在主线程
-			fyne.Do(func() {
-				queryBtn.Enable()
-				queryBtn.SetText("▶ 继续查询")
-				pauseBtn.Disable()
-				stopBtn.Disable()
-				importFileBtn.Enable()
-				importKeyBtn.Enable()
-				deleteKeyBtn.Enable()
-				batchDeleteBtn.Enable()
-			})
+		// 重要：使用 fyne.Do 确保 UI 更新在主线程
+		fyne.Do(func() {
+			queryBtn.Enable()
+			queryBtn.SetText("▶ 继续查询")
+			pauseBtn.Disable()
+			stopBtn.Disable()
+			importFileBtn.Enable()
+			importKeyBtn.Enable()
+			deleteKeyBtn.Enable()
+			batchDeleteBtn.Enable()
+		})
 
-			finalTotal, finalSuccess, finalFailed := queryManager.GetStats()
-			// 计算有余额和无余额数量
-			withBalance := 0
-			withoutBalance := 0
-			for _, result := range resultData {
-				if result.Status == "success" {
-					balanceStr := result.Balance
-					if balanceStr == "" {
-						balanceStr = "0"
-					}
-					balanceStr = strings.ReplaceAll(balanceStr, ",", "")
-					var balance float64
-					if _, err := fmt.Sscanf(balanceStr, "%f", &balance); err == nil {
-						if balance > 0 {
-							withBalance++
-						} else {
-							withoutBalance++
-						}
-					} else {
-						withoutBalance++
-					}
-				}
-			}
-			remainingCount := 0
-			if totalAddresses > currentProgress {
-				remainingCount = totalAddresses - currentProgress
+		mu.Lock()
+		stats := lastProgress.stats
+		mu.Unlock()
+		remainingCount := len(vm.pausedAddresses)
+		statusText := fmt.Sprintf("已暂停 | 总计: %d | 成功: %d | 失败: %d | 有余额: %d | 无余额: %d | 剩余: %d",
+			stats.Total, stats.Success, stats.Failed, stats.WithBalance, stats.WithoutBalance, remainingCount)
+		statusLabel.SetText(statusText)
+	}
+
+	// 查询过程中定期检查导出目录（见 DiskCheckPath）的剩余空间，不足时自动暂停，避免长时间任务的结果无法保存
+	diskCheckTicker := time.NewTicker(30 * time.Second)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-diskCheckTicker.C:
 			}
-			statusText := fmt.Sprintf("已暂停 | 总计: %d | 成功: %d | 失败: %d | 有余额: %d | 无余额: %d | 剩余: %d",
-				finalTotal, finalSuccess, finalFailed, withBalance, withoutBalance, remainingCount)
-			statusLabel.SetText(statusText)
+			// 在界面线程读取查询状态和导出路径
+			fyne.Do(func() {
+				if !vm.QueryActive() {
+					return
+				}
+				if err := core.CheckDiskSpace(vm.DiskCheckPath()); err != nil {
+					pauseBtn.OnTapped()
+					dialog.ShowError(errors.New("已自动暂停查询\n"+err.Error()+"\n\n请清理磁盘空间后点击\"继续查询\""), w)
+				}
+			})
 		}
-	}
+	}()
 
 	// 停止按钮（清空所有状态，不能继续）
 	stopBtn.OnTapped = func() {
-		if queryManager != nil && isQuerying {
-			queryManager.Cancel()
+		if vm.QueryActive() {
+			vm.QueryManager().Cancel()
 
 			// 等待查询停止
 			time.Sleep(200 * time.Millisecond)
 
-			isQuerying = false
-			isPaused = false
-			pausedAddresses = nil
-			pausedStartIndex = 0
-			pausedTotalProgress = 0
-			currentQueryAddrs = nil
+			vm.ClearPaused()
+			vm.currentQueryAddrs = nil
 
 			// 使用 fyne.Do 确保 UI 更新在主线程
 			fyne.Do(func() {
@@ -1063,38 +1956,18 @@ func ShowMainWindow(a fyne.App) {
 				batchDeleteBtn.Enable()
 			})
 
-			finalTotal, finalSuccess, finalFailed := queryManager.GetStats()
-			// 计算有余额和无余额数量
-			withBalance := 0
-			withoutBalance := 0
-			for _, result := range resultData {
-				if result.Status == "success" {
-					balanceStr := result.Balance
-					if balanceStr == "" {
-						balanceStr = "0"
-					}
-					balanceStr = strings.ReplaceAll(balanceStr, ",", "")
-					var balance float64
-					if _, err := fmt.Sscanf(balanceStr, "%f", &balance); err == nil {
-						if balance > 0 {
-							withBalance++
-						} else {
-							withoutBalance++
-						}
-					} else {
-						withoutBalance++
-					}
-				}
-			}
+			mu.Lock()
+			stats := lastProgress.stats
+			mu.Unlock()
 			statusText := fmt.Sprintf("已停止 | 总计: %d | 成功: %d | 失败: %d | 有余额: %d | 无余额: %d",
-				finalTotal, finalSuccess, finalFailed, withBalance, withoutBalance)
+				stats.Total, stats.Success, stats.Failed, stats.WithBalance, stats.WithoutBalance)
 			statusLabel.SetText(statusText)
 		}
 	}
 
 	// 导出 CSV
 	exportCSVBtn.OnTapped = func() {
-		if resultData == nil || len(resultData) == 0 {
+		if vm.resultData == nil || len(vm.resultData) == 0 {
 			dialog.ShowError(errors.New("没有可导出的数据"), w)
 			return
 		}
@@ -1113,19 +1986,72 @@ func ShowMainWindow(a fyne.App) {
 			if !strings.HasSuffix(strings.ToLower(filepath), ".csv") {
 				filepath += ".csv"
 			}
+			vm.exportPath = filepath
+
+			if err := core.CheckDiskSpace(filepath); err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
 
-			if err := core.ExportToCSV(resultData, filepath); err != nil {
+			if err := core.ExportToCSVWithOptions(vm.ExportResults(), filepath, vm.ExportOptions()); err != nil {
 				dialog.ShowError(err, w)
 				return
 			}
 
-			dialog.ShowInformation("成功", fmt.Sprintf("已导出到: %s", filepath), w)
+			showExported(filepath, fmt.Sprintf("已导出到: %s", filepath))
 		}, w)
 	}
 
+	// exportExcel 在后台导出 Excel，显示进度（导出中 N/总数），取消时删除未完成的文件
+	exportExcel := func(results []core.QueryResult, path string) {
+		var cancelled atomic.Bool
+		progress := widget.NewProgressBar()
+		progressLabel := widget.NewLabel(fmt.Sprintf("导出中 0/%d", len(results)))
+		progressDialog := dialog.NewCustom("导出 Excel", "取消", container.NewVBox(progressLabel, progress), w)
+		progressDialog.SetOnClosed(func() {
+			cancelled.Store(true)
+		})
+		progressDialog.Show()
+
+		opts := vm.ExportOptions()
+		opts.Progress = func(written, total int) bool {
+			fyne.Do(func() {
+				progressLabel.SetText(fmt.Sprintf("导出中 %d/%d", written, total))
+				if total > 0 {
+					progress.SetValue(float64(written) / float64(total))
+				}
+			})
+			return !cancelled.Load()
+		}
+
+		go func() {
+			err := core.ExportToExcelWithOptions(results, path, opts)
+			fyne.Do(func() {
+				if cancelled.Load() {
+					if errors.Is(err, core.ErrExportCancelled) {
+						os.Remove(path)
+					}
+					return
+				}
+				progressDialog.SetOnClosed(nil)
+				progressDialog.Hide()
+				if err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+
+				message := fmt.Sprintf("已导出到: %s", path)
+				if notice := core.ExcelSplitNotice(len(results), opts); notice != "" {
+					message += "\n\n" + notice
+				}
+				showExported(path, message)
+			})
+		}()
+	}
+
 	// 导出 Excel
 	exportExcelBtn.OnTapped = func() {
-		if resultData == nil || len(resultData) == 0 {
+		if vm.resultData == nil || len(vm.resultData) == 0 {
 			dialog.ShowError(errors.New("没有可导出的数据"), w)
 			return
 		}
@@ -1144,13 +2070,59 @@ func ShowMainWindow(a fyne.App) {
 			if !strings.HasSuffix(strings.ToLower(filepath), ".xlsx") {
 				filepath += ".xlsx"
 			}
+			vm.exportPath = filepath
+
+			if err := core.CheckDiskSpace(filepath); err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+
+			results := vm.ExportResults()
+			if len(results) < largeExportRows {
+				exportExcel(results, filepath)
+				return
+			}
+			dialog.ShowConfirm("导出数据较多", fmt.Sprintf("将导出 %d 行结果，可能需要较长时间，导出过程中可以取消。\n\n继续导出吗？", len(results)), func(confirmed bool) {
+				if confirmed {
+					exportExcel(results, filepath)
+				}
+			}, w)
+		}, w)
+	}
 
-			if err := core.ExportToExcel(resultData, filepath); err != nil {
+	// 导出二维码：为当前筛选出的地址（如"有余额"、"只看书签"）生成地址二维码图片，便于扫码核对
+	exportQRCodes := func(results []core.QueryResult) {
+		dialog.ShowFolderOpen(func(dir fyne.ListableURI, err error) {
+			if err != nil {
 				dialog.ShowError(err, w)
 				return
 			}
+			if dir == nil {
+				return
+			}
 
-			dialog.ShowInformation("成功", fmt.Sprintf("已导出到: %s", filepath), w)
+			count, err := core.ExportQRCodes(results, dir.Path())
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			dialog.ShowInformation("成功", fmt.Sprintf("已为 %d 个地址生成二维码图片: %s", count, dir.Path()), w)
+		}, w)
+	}
+	exportQRBtn.OnTapped = func() {
+		results := vm.FilteredResults()
+		if len(results) == 0 {
+			dialog.ShowError(errors.New("没有可导出的数据"), w)
+			return
+		}
+		if len(results) < qrConfirmCount {
+			exportQRCodes(results)
+			return
+		}
+		dialog.ShowConfirm("地址较多", fmt.Sprintf("将为当前筛选出的 %d 个地址各生成一张二维码图片，可以先筛选\"有余额\"或\"只看书签\"。\n\n继续吗？", len(results)), func(confirmed bool) {
+			if confirmed {
+				exportQRCodes(results)
+			}
 		}, w)
 	}
 
@@ -1159,18 +2131,18 @@ func ShowMainWindow(a fyne.App) {
 		fyne.Do(func() {
 			// 清空输入框
 			addressInput.SetText("")
-			addressList = nil
+			vm.addressList = nil
 
 			// 清空所有结果数据
-			resultData = nil
-			filteredData = nil
-			displayData = nil
+			vm.resultData = nil
+			vm.filteredIndices = nil
+			vm.displayIndices = nil
 
 			// 重置分页和筛选
-			currentPage = 1
-			totalPages = 1
-			filterMode = "all"
-			filterText = ""
+			vm.currentPage = 1
+			vm.totalPages = 1
+			vm.filterMode = "all"
+			vm.filterText = ""
 			if filterModeSelect != nil {
 				filterModeSelect.SetSelected("全部")
 			}
@@ -1178,8 +2150,8 @@ func ShowMainWindow(a fyne.App) {
 				addressSearchEntry.SetText("")
 			}
 
-			// 应用筛选（会更新 filteredData 和 displayData）
-			applyFilter()
+			// 应用筛选（会更新 vm.filteredIndices 和 vm.displayIndices）
+			vm.ApplyFilter()
 
 			// 强制刷新表格和分页信息
 			if resultTable != nil {
@@ -1196,6 +2168,9 @@ func ShowMainWindow(a fyne.App) {
 			if exportExcelBtn != nil {
 				exportExcelBtn.Disable()
 			}
+			if exportQRBtn != nil {
+				exportQRBtn.Disable()
+			}
 
 			// 重置进度
 			if progressBar != nil {
@@ -1207,6 +2182,7 @@ func ShowMainWindow(a fyne.App) {
 			if statusLabel != nil {
 				statusLabel.SetText("就绪")
 			}
+			chart.Update(0, 0, 0, 0)
 		})
 	})
 
@@ -1218,23 +2194,30 @@ func ShowMainWindow(a fyne.App) {
 	apiKeyContainer := widget.NewCard("API Key 管理", "",
 		container.NewVBox(
 			apiKeyStatusLabel,
-			importKeyBtn,
+			container.NewHBox(importKeyBtn, saveKeyEncryptedBtn),
 			container.NewHBox(deleteKeyBtn, batchDeleteBtn),
+			container.NewHBox(noStatsCheck, pacingCheck),
 			keyStatusHeader,
-			keyTableScroll,
+			container.NewStack(keyTableScroll, container.NewCenter(keyEmptyHint)),
 		),
 	)
 
 	// 左侧配置区域布局
+	networkForm = widget.NewForm(
+		widget.NewFormItem("配置方案:", container.NewBorder(nil, nil, nil, saveProfileBtn, profileSelect)),
+		widget.NewFormItem("并发线程:", container.NewVBox(container.NewBorder(nil, nil, nil, autoThreadsCheck, threadCountEntry), threadCountError)),
+		widget.NewFormItem("节点URL:", nodeURLEntry),
+		tokensItem,
+		widget.NewFormItem("请求数/秒:", rateLimitControl),
+		widget.NewFormItem("合约地址:", contractFilterSelect),
+	)
 	configContainer := container.NewVBox(
 		apiKeyContainer,
 		widget.NewCard("网络配置", "",
 			container.NewVBox(
-				widget.NewForm(
-					widget.NewFormItem("并发线程:", threadCountEntry),
-					widget.NewFormItem("节点URL:", nodeURLEntry),
-					widget.NewFormItem("请求数/秒:", rateLimitEntry),
-				),
+				networkForm,
+				shuffleCheck,
+				autoRetryCheck,
 				threadHelpLabel,
 			),
 		),
@@ -1245,7 +2228,7 @@ func ShowMainWindow(a fyne.App) {
 					nil, nil, nil, nil,
 					addressInput,
 				),
-				container.NewHBox(importFileBtn, clearAddressBtn),
+				container.NewHBox(importFileBtn, clearAddressBtn, keepDuplicatesCheck, keepInvalidCheck, autoDetectCheck),
 			),
 		),
 		widget.NewSeparator(), // 添加分隔线，使布局更清晰
@@ -1254,7 +2237,10 @@ func ShowMainWindow(a fyne.App) {
 				container.NewHBox(queryBtn, pauseBtn, stopBtn),
 				progressBar,
 				progressLabel,
+				retryLabel,
 				statusLabel,
+				showChartCheck,
+				chartContainer,
 			),
 		),
 	)
@@ -1262,8 +2248,9 @@ func ShowMainWindow(a fyne.App) {
 	// 底部控件（分页和导出）- 优化布局，使分页信息更清晰
 	paginationControls := container.NewBorder(
 		nil, nil,
-		container.NewHBox(prevPageBtn, nextPageBtn),
+		container.NewHBox(prevPageBtn, nextPageBtn, freezeCheck),
 		container.NewHBox(
+			jumpPageError,
 			widget.NewLabel("跳转:"),
 			jumpPageEntry,
 			jumpPageBtn,
@@ -1276,26 +2263,31 @@ func ShowMainWindow(a fyne.App) {
 		container.NewHBox(
 			exportCSVBtn,
 			exportExcelBtn,
+			exportQRBtn,
+			totalRowCheck,
+			rawHexCheck,
+			openAfterExportCheck,
 			deleteAddressBtn,
 		),
 	)
 
+	// 无结果时在表格上叠加提示
+	resultTableArea := container.NewStack(container.NewScroll(resultTable), container.NewCenter(resultEmptyHint))
+
 	// 使用Border布局，将分页和导出固定在底部
 	resultContainer := container.NewBorder(
 		container.NewVBox(filterContainer, headerContainer), // Top: 筛选和表头
-		bottomControls,                   // Bottom: 分页和导出（固定在最底部）
-		nil,                              // Left: 无
-		nil,                              // Right: 无
-		container.NewScroll(resultTable), // Center: 表格（可扩展）
+		bottomControls,  // Bottom: 分页和导出（固定在最底部）
+		nil,             // Left: 无
+		nil,             // Right: 无
+		resultTableArea, // Center: 表格（可扩展）
 	)
 
 	split := container.NewHSplit(configContainer, resultContainer)
-	split.SetOffset(0.32) // 调整左右分栏比例，左侧更紧凑，右侧表格有更多空间
-
-	w.SetContent(split)
+	split.SetOffset(defaultSplitOffset)
 
 	// 设置拖拽功能
-	w.SetOnDropped(func(pos fyne.Position, uris []fyne.URI) {
+	onDropped := func(pos fyne.Position, uris []fyne.URI) {
 		if len(uris) == 0 {
 			return
 		}
@@ -1304,47 +2296,55 @@ func ShowMainWindow(a fyne.App) {
 			filePath := uri.Path()
 			ext := strings.ToLower(filepath.Ext(filePath))
 
-			// 只支持 TXT 和 CSV 文件
-			if ext != ".txt" && ext != ".csv" {
-				dialog.ShowError(errors.New("不支持的文件类型: %s\n请拖入 TXT 或 CSV 文件"), w)
+			// 只支持 TXT、CSV、XLSX 和 JSON 文件
+			if ext != ".txt" && ext != ".csv" && ext != ".xlsx" && ext != ".json" {
+				dialog.ShowError(fmt.Errorf("不支持的文件类型: %s\n请拖入 TXT、CSV、XLSX 或 JSON 文件", ext), w)
+				continue
+			}
+
+			// 超大文件或地址数超过上限时不直接读取，改用导入按钮（可以确认或只导入前面的部分）
+			if err := core.CheckInputFileSize(filePath); err != nil {
+				dialog.ShowError(fmt.Errorf("%v\n请使用\"导入地址\"按钮导入", err), w)
 				continue
 			}
 
+			// 同时包含 Key 和地址的文件逐行识别，两者一起导入
+			if ext != ".xlsx" && ext != ".json" && !core.IsEncryptedFile(filePath) {
+				if mixed, err := core.ParseMixedFile(filePath, currentLoadOptions()); err == nil && len(mixed.Keys) > 0 && len(mixed.Addresses) > 0 {
+					importMixed(mixed)
+					continue
+				}
+			}
+
 			// 尝试读取文件内容，判断是 Key 文件还是地址文件
-			addresses, addrErr := core.LoadAddressesFromFile(filePath)
+			addresses, addrErr := core.LoadAddressesFromFileWithOptions(filePath, currentLoadOptions())
+			if errors.Is(addrErr, core.ErrTooManyAddresses) {
+				dialog.ShowError(fmt.Errorf("%v\n请使用\"导入地址\"按钮导入", addrErr), w)
+				continue
+			}
 
 			// 判断是否为地址文件：如果成功加载了地址，则认为是地址文件
 			if addrErr == nil && len(addresses) > 0 {
 				// 这是地址文件
-				addressList = addresses
-				// 构建所有地址的文本（每行一个地址）
-				addressText := strings.Join(addresses, "\n")
-				// 确保所有地址都被设置（使用fyne.Do确保在主线程更新）
-				fyne.Do(func() {
-					addressInput.SetText(addressText)
-					addressInput.Refresh() // 强制刷新，确保文本正确显示
-					// 滚动到顶部，确保能看到第一个地址
-					addressInput.CursorRow = 0
-					addressInput.CursorColumn = 0
-				})
+				showAddresses(addresses)
 
 				// 在结果表格中显示这些地址（初始状态：待查询）
-				resultData = make([]core.QueryResult, len(addresses))
+				vm.resultData = make([]core.QueryResult, len(addresses))
 				for i, addr := range addresses {
-					resultData[i] = core.QueryResult{
+					vm.resultData[i] = core.QueryResult{
 						Address: addr,
-						Status:  "pending",
+						Status:  core.StatusPending,
 						Balance: "",
 						Error:   "",
 					}
 				}
 				// 重置到第一页并应用筛选
-				currentPage = 1
-				filterMode = "all"
-				filterText = ""
+				vm.currentPage = 1
+				vm.filterMode = "all"
+				vm.filterText = ""
 				filterModeSelect.SetSelected("全部")
 				addressSearchEntry.SetText("")
-				applyFilter()
+				vm.ApplyFilter()
 				fyne.Do(func() {
 					updatePageInfo()
 					resultTable.Refresh()
@@ -1352,15 +2352,29 @@ func ShowMainWindow(a fyne.App) {
 
 				statusLabel.SetText(fmt.Sprintf("已导入 %d 个地址（拖拽）", len(addresses)))
 				dialog.ShowInformation("成功", fmt.Sprintf("已导入 %d 个地址\n地址已显示在右侧表格中", len(addresses)), w)
+			} else if ext == ".xlsx" || ext == ".json" {
+				// Excel 和 JSON 只作为地址文件导入
+				dialog.ShowError(addrErr, w)
 			} else {
+				// 加密文件直接按 Key 文件处理（需要输入密码）
+				if core.IsEncryptedFile(filePath) {
+					loadKeyFile(filePath, func() {
+						keyCount := vm.keyManager.GetKeyCount()
+						apiKeyStatusLabel.SetText(fmt.Sprintf("已加载 %d 个 API Key", keyCount))
+						keyStatusTable.Refresh()
+						dialog.ShowInformation("成功", fmt.Sprintf("已导入 %d 个 API Key（拖拽）", keyCount), w)
+					})
+					continue
+				}
+
 				// 尝试作为 API Key 文件导入
-				if err := keyManager.LoadKeysFromFile(filePath); err != nil {
-					dialog.ShowError(errors.New("无法识别文件类型\n既不是有效的地址文件，也不是有效的 Key 文件\n地址错误: %v\nKey错误: %v"), w)
+				if err := vm.keyManager.LoadKeysFromFile(filePath); err != nil {
+					dialog.ShowError(fmt.Errorf("无法识别文件类型\n既不是有效的地址文件，也不是有效的 Key 文件\n地址错误: %v\nKey错误: %v", addrErr, err), w)
 					continue
 				}
 
 				// Key 导入成功
-				keyCount := keyManager.GetKeyCount()
+				keyCount := vm.keyManager.GetKeyCount()
 				apiKeyStatusLabel.SetText(fmt.Sprintf("已加载 %d 个 API Key", keyCount))
 
 				fyne.Do(func() {
@@ -1370,7 +2384,101 @@ func ShowMainWindow(a fyne.App) {
 				dialog.ShowInformation("成功", fmt.Sprintf("已导入 %d 个 API Key（拖拽）", keyCount), w)
 			}
 		}
-	})
+	}
 
-	w.Show()
+	return &batchView{
+		vm:        vm,
+		content:   split,
+		split:     split,
+		onDropped: onDropped,
+		refresh: func() {
+			if keyCount := vm.keyManager.GetKeyCount(); keyCount > 0 {
+				apiKeyStatusLabel.SetText(fmt.Sprintf("已加载 %d 个 API Key", keyCount))
+			} else {
+				apiKeyStatusLabel.SetText("no found API Key")
+			}
+			noStatsCheck.SetChecked(!vm.keyManager.StatsPersistenceEnabled())
+			pacingCheck.SetChecked(vm.keyManager.PacingEnabled())
+			keyStatusTable.Refresh()
+		},
+		dispose: func() {
+			updateTicker.Stop()
+			heartbeatTicker.Stop()
+			diskCheckTicker.Stop()
+			retryTicker.Stop()
+			close(done)
+		},
+	}
+}
+
+// newEmptyHint 创建表格为空时显示的提示文字
+func newEmptyHint(message string) *widget.Label {
+	hint := widget.NewLabelWithStyle(message, fyne.TextAlignCenter, fyne.TextStyle{Italic: true})
+	hint.Importance = widget.LowImportance
+	return hint
+}
+
+// setEmptyHint 按数据是否为空显示或隐藏提示（只在变化时切换，可以在表格的长度回调中调用）
+func setEmptyHint(hint *widget.Label, empty bool) {
+	if empty && !hint.Visible() {
+		hint.Show()
+	} else if !empty && hint.Visible() {
+		hint.Hide()
+	}
+}
+
+// largeExportRows 导出 Excel 的结果达到该行数时先确认（导出可能需要较长时间）
+const largeExportRows = 100000
+
+// qrConfirmCount 导出二维码的地址达到该数量时先确认（每个地址一个图片文件）
+const qrConfirmCount = 1000
+
+// finishNotification 查询结束时的系统通知，内容为按完整结果统计的数量
+func finishNotification(title string, results []core.QueryResult) *fyne.Notification {
+	success, failed := 0, 0
+	for _, result := range results {
+		switch result.Status {
+		case core.StatusSuccess:
+			success++
+		case core.StatusError:
+			failed++
+		}
+	}
+	withBalance, _ := countBalances(results)
+	return fyne.NewNotification(title, fmt.Sprintf("总计 %d，成功 %d，失败 %d，有余额 %d", len(results), success, failed, withBalance))
+}
+
+// guiAutoRetryPasses 界面勾选"失败自动重试"时的轮数
+const guiAutoRetryPasses = 2
+
+// addressPreviewLines 导入的地址在输入框中最多显示的行数
+// 十万行以上的多行输入框会让界面明显卡顿，超出部分只保留在地址列表中
+const addressPreviewLines = 1000
+
+// addressPreviewText 返回输入框中显示的地址文本（每行一个），超过 addressPreviewLines 时只显示前面的部分并注明剩余数量
+func addressPreviewText(addresses []string) string {
+	if len(addresses) <= addressPreviewLines {
+		return strings.Join(addresses, "\n")
+	}
+	return strings.Join(addresses[:addressPreviewLines], "\n") +
+		fmt.Sprintf("\n… 还有 %d 个（共 %d 个地址已全部加载，查询时使用完整列表）", len(addresses)-addressPreviewLines, len(addresses))
+}
+
+// showPasswordDialog 弹出密码输入框，确认后调用 onConfirm
+func showPasswordDialog(w fyne.Window, title string, onConfirm func(password string)) {
+	passwordEntry := widget.NewPasswordEntry()
+	passwordEntry.SetPlaceHolder("请输入密码")
+
+	dialog.ShowForm(title, "确定", "取消", []*widget.FormItem{
+		widget.NewFormItem("密码:", passwordEntry),
+	}, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+		if passwordEntry.Text == "" {
+			dialog.ShowError(errors.New("密码不能为空"), w)
+			return
+		}
+		onConfirm(passwordEntry.Text)
+	}, w)
 }
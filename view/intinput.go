@@ -0,0 +1,48 @@
+package view
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2/widget"
+)
+
+// intRange 输入框中整数的名称和有效范围：无效输入给出明确的错误，不再静默替换为默认值
+type intRange struct {
+	name     string
+	min, max int // max 为 0 时不限上限
+}
+
+// parse 解析 text（忽略首尾空白），不是范围内的整数时返回错误，如"线程数需为 1-20 的整数"
+func (r intRange) parse(text string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil || n < r.min || r.max > 0 && n > r.max {
+		if r.max > 0 {
+			return 0, fmt.Errorf("%s需为 %d-%d 的整数", r.name, r.min, r.max)
+		}
+		return 0, fmt.Errorf("%s需为不小于 %d 的整数", r.name, r.min)
+	}
+	return n, nil
+}
+
+// newInlineError 返回显示在输入框下方的错误提示：输入变化时按 rangeOf() 校验，无效时显示错误，有效或为空时隐藏
+// （范围可能变化，如跳转页码的上限为当前总页数，所以每次校验时重新获取）
+func newInlineError(entry *widget.Entry, rangeOf func() intRange) *widget.Label {
+	label := widget.NewLabel("")
+	label.Importance = widget.DangerImportance
+	label.Hide()
+	entry.OnChanged = func(text string) {
+		if strings.TrimSpace(text) == "" {
+			label.Hide()
+			return
+		}
+		if _, err := rangeOf().parse(text); err != nil {
+			label.SetText(err.Error())
+			label.Show()
+		} else {
+			label.Hide()
+		}
+	}
+	return label
+}
@@ -0,0 +1,95 @@
+package view
+
+import "fyne.io/fyne/v2"
+
+// 窗口布局在偏好设置中的键
+const (
+	prefWindowWidth      = "layout.window.width"
+	prefWindowHeight     = "layout.window.height"
+	prefWindowFullScreen = "layout.window.fullscreen"
+	prefSplitOffset      = "layout.split.offset"
+	prefFilterMode       = "layout.filter.mode"
+	prefPageSize         = "layout.page.size"
+)
+
+// 布局的默认值和恢复时的范围
+// Fyne 不提供屏幕尺寸和最大化状态，窗口尺寸按固定上限限制（超出屏幕时由系统窗口管理器收缩），
+// 最大化用全屏状态代替
+const (
+	defaultWindowWidth  = 1200
+	defaultWindowHeight = 700
+	minWindowWidth      = 800
+	minWindowHeight     = 500
+	maxWindowWidth      = 3840
+	maxWindowHeight     = 2160
+	defaultSplitOffset  = 0.32 // 左侧更紧凑，右侧表格有更多空间
+	minSplitOffset      = 0.15
+	maxSplitOffset      = 0.85
+	defaultPageSize     = 10000
+	minPageSize         = 100
+	maxPageSize         = 100000
+)
+
+// layoutPrefs 上次关闭时的窗口布局（窗口尺寸、分栏位置、筛选模式和每页数量）
+type layoutPrefs struct {
+	width, height float32
+	fullScreen    bool
+	splitOffset   float64
+	filterMode    string
+	pageSize      int
+}
+
+// loadLayoutPrefs 从偏好设置读取窗口布局，没有保存过或值超出范围时使用默认值或限制到范围内
+func loadLayoutPrefs(p fyne.Preferences) layoutPrefs {
+	layout := layoutPrefs{
+		width:       float32(clampFloat(p.FloatWithFallback(prefWindowWidth, defaultWindowWidth), minWindowWidth, maxWindowWidth)),
+		height:      float32(clampFloat(p.FloatWithFallback(prefWindowHeight, defaultWindowHeight), minWindowHeight, maxWindowHeight)),
+		fullScreen:  p.Bool(prefWindowFullScreen),
+		splitOffset: clampFloat(p.FloatWithFallback(prefSplitOffset, defaultSplitOffset), minSplitOffset, maxSplitOffset),
+		filterMode:  p.StringWithFallback(prefFilterMode, "all"),
+		pageSize:    p.IntWithFallback(prefPageSize, defaultPageSize),
+	}
+	if filterModeLabel(layout.filterMode) == "" {
+		layout.filterMode = "all"
+	}
+	if layout.pageSize < minPageSize || layout.pageSize > maxPageSize {
+		layout.pageSize = defaultPageSize
+	}
+	return layout
+}
+
+// save 将窗口布局写入偏好设置
+func (layout layoutPrefs) save(p fyne.Preferences) {
+	p.SetFloat(prefWindowWidth, float64(layout.width))
+	p.SetFloat(prefWindowHeight, float64(layout.height))
+	p.SetBool(prefWindowFullScreen, layout.fullScreen)
+	p.SetFloat(prefSplitOffset, layout.splitOffset)
+	p.SetString(prefFilterMode, layout.filterMode)
+	p.SetInt(prefPageSize, layout.pageSize)
+}
+
+// filterModeLabel 返回筛选模式在下拉框中的显示文字，未知模式返回空字符串
+func filterModeLabel(mode string) string {
+	switch mode {
+	case "all":
+		return "全部"
+	case "withBalance":
+		return "有余额"
+	case "bookmarked":
+		return "只看书签"
+	case "address":
+		return "按地址搜索"
+	}
+	return ""
+}
+
+// clampFloat 将 v 限制在 [lo, hi] 范围内
+func clampFloat(v, lo, hi float64) float64 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
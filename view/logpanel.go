@@ -0,0 +1,156 @@
+package view
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// maxLogLines 日志面板最多保留的日志条数，超过后丢弃最早的四分之一
+const maxLogLines = 2000
+
+// logBuffer 界面模式下的日志处理器：保存最近的日志供日志面板显示和复制，同时写入 out（从终端启动时可见）
+// 程序中的日志都是 printf 风格（如 log.Info("已加载 %d 个地址\n", n)），这里按格式串还原成一行文本
+type logBuffer struct {
+	mu      sync.Mutex
+	lines   []string
+	version uint64 // 每次追加或清空时加 1，日志面板据此判断是否需要刷新
+	out     io.Writer
+}
+
+func newLogBuffer(out io.Writer) *logBuffer {
+	return &logBuffer{out: out}
+}
+
+// Enabled 记录 Debug 及以上级别的日志
+func (b *logBuffer) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= slog.LevelDebug
+}
+
+// Handle 格式化一条日志并保存
+func (b *logBuffer) Handle(_ context.Context, r slog.Record) error {
+	line := fmt.Sprintf("%s %-5s %s", r.Time.Format("15:04:05"), r.Level, logRecordText(r))
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.lines) >= maxLogLines {
+		b.lines = append(b.lines[:0], b.lines[maxLogLines/4:]...)
+	}
+	b.lines = append(b.lines, line)
+	b.version++
+	if b.out != nil {
+		fmt.Fprintln(b.out, line)
+	}
+	return nil
+}
+
+// WithAttrs 程序中不使用带固定属性的子 Logger，忽略属性
+func (b *logBuffer) WithAttrs([]slog.Attr) slog.Handler {
+	return b
+}
+
+// WithGroup 同 WithAttrs
+func (b *logBuffer) WithGroup(string) slog.Handler {
+	return b
+}
+
+// Text 返回保存的所有日志（每条一行）和当前版本
+func (b *logBuffer) Text() (string, uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strings.Join(b.lines, "\n"), b.version
+}
+
+// Version 返回当前版本
+func (b *logBuffer) Version() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.version
+}
+
+// Clear 清空保存的日志
+func (b *logBuffer) Clear() {
+	b.mu.Lock()
+	b.lines = nil
+	b.version++
+	b.mu.Unlock()
+}
+
+// logRecordText 将 printf 风格的日志还原为文本：日志库把格式参数当作键值对保存，这里按顺序取回参数再格式化
+func logRecordText(r slog.Record) string {
+	var args []any
+	r.Attrs(func(attr slog.Attr) bool {
+		switch attr.Key {
+		case "LOG_ERROR":
+			// 参数个数为奇数时日志库补了一个 nil 和这条说明，去掉
+			if n := len(args); n > 0 && args[n-1] == nil {
+				args = args[:n-1]
+			}
+			return false
+		case "!BADKEY":
+			args = append(args, attr.Value.Any())
+		default:
+			args = append(args, attr.Key, attr.Value.Any())
+		}
+		return true
+	})
+
+	text := r.Message
+	if len(args) > 0 {
+		if strings.Contains(text, "%") {
+			text = fmt.Sprintf(text, args...)
+		} else {
+			text = strings.TrimRight(text, "\n") + " " + strings.TrimRight(fmt.Sprintln(args...), "\n")
+		}
+	}
+	return strings.TrimSpace(text)
+}
+
+// newLogPanel 构建窗口底部可折叠的日志面板，展开后显示最近的日志，可清空和复制（用于反馈问题）
+func newLogPanel(logs *logBuffer) fyne.CanvasObject {
+	logText := widget.NewLabel("")
+	logText.Selectable = true
+	scroll := container.NewScroll(logText)
+	scroll.SetMinSize(fyne.NewSize(0, 160))
+
+	clearBtn := widget.NewButton("清空日志", func() {
+		logs.Clear()
+	})
+	copyBtn := widget.NewButton("复制日志", func() {
+		text, _ := logs.Text()
+		fyne.CurrentApp().Clipboard().SetContent(text)
+	})
+
+	item := widget.NewAccordionItem("日志", container.NewBorder(container.NewHBox(clearBtn, copyBtn), nil, nil, nil, scroll))
+	panel := widget.NewAccordion(item)
+
+	// 定时刷新（日志可能很频繁，不在每条日志时刷新界面）；原来在最底部时跟随新日志滚动
+	go func() {
+		ticker := time.NewTicker(500 * time.Millisecond)
+		defer ticker.Stop()
+		var shown uint64
+		for range ticker.C {
+			if logs.Version() == shown {
+				continue
+			}
+			text, version := logs.Text()
+			shown = version
+			fyne.Do(func() {
+				atBottom := scroll.Offset.Y+scroll.Size().Height >= logText.MinSize().Height-1
+				logText.SetText(text)
+				if atBottom {
+					scroll.ScrollToBottom()
+				}
+			})
+		}
+	}()
+	return panel
+}
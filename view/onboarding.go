@@ -0,0 +1,234 @@
+package view
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"usdt-balance-checker/core"
+	"usdt-balance-checker/tron"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+)
+
+// prefOnboardingDone 首次启动引导是否已完成（或跳过）在偏好设置中的键，之后不再显示
+const prefOnboardingDone = "onboarding.done"
+
+// showOnboarding 首次启动时引导新用户完成第一次查询：添加 API Key、填写一个地址、试查询、说明结果和导出的位置
+// 试查询使用正常的 QueryManager 流程，同时检查网络和 Key 是否可用。完成或跳过后记录在偏好设置中，不再显示；
+// 关闭引导后调用 onClosed（刷新界面上的 Key 状态）
+func showOnboarding(w fyne.Window, prefs fyne.Preferences, keyManager *core.APIKeyManager, onClosed func()) {
+	if prefs.Bool(prefOnboardingDone) {
+		return
+	}
+
+	wrapped := func(text string) *widget.Label {
+		label := widget.NewLabel(text)
+		label.Wrapping = fyne.TextWrapWord
+		return label
+	}
+
+	// 第 1 步：粘贴或导入 API Key
+	keyEntry := widget.NewMultiLineEntry()
+	keyEntry.SetPlaceHolder("每行一个 API Key，可在 Key 后加逗号和备注名")
+	keyEntry.SetMinRowsVisible(4)
+	keyStatus := widget.NewLabel("")
+	updateKeyStatus := func() {
+		if keyCount := keyManager.GetKeyCount(); keyCount > 0 {
+			keyStatus.SetText(fmt.Sprintf("已加载 %d 个 API Key", keyCount))
+		} else {
+			keyStatus.SetText("还没有 API Key")
+		}
+	}
+	updateKeyStatus()
+	importKeyBtn := widget.NewButton("📁 从文件导入", func() {
+		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			if reader == nil {
+				return
+			}
+			path := reader.URI().Path()
+			reader.Close()
+
+			if core.IsEncryptedFile(path) {
+				showPasswordDialog(w, "加密的 Key 文件", func(password string) {
+					if err := keyManager.LoadKeysFromEncryptedFile(path, password); err != nil {
+						dialog.ShowError(err, w)
+					}
+					updateKeyStatus()
+				})
+				return
+			}
+			if err := keyManager.LoadKeysFromFile(path); err != nil {
+				dialog.ShowError(err, w)
+			}
+			updateKeyStatus()
+		}, w)
+	})
+	keyStep := container.NewVBox(
+		wrapped(fmt.Sprintf("查询余额需要 TronGrid 的 API Key：在 https://www.trongrid.io 注册后免费申请。"+
+			"每个 Key 最多使用 %d 次，添加多个 Key 时轮流使用。可以直接粘贴，也可以从 TXT 文件导入（每行一个）。", core.MaxQueriesPerKey)),
+		keyEntry,
+		container.NewHBox(importKeyBtn),
+		keyStatus,
+	)
+
+	// 第 2 步：填写一个地址
+	addressEntry := widget.NewEntry()
+	addressEntry.SetPlaceHolder("T 开头的 TRON 地址")
+	addressStep := container.NewVBox(
+		wrapped("粘贴一个要查询的 TRON 地址（例如自己的钱包地址），下一步会用它试查询一次，检查网络和 API Key 是否可用。"),
+		addressEntry,
+	)
+
+	// 第 3 步：试查询
+	testStatus := wrapped("")
+	testStep := container.NewVBox(testStatus)
+
+	// 第 4 步：结果和导出的位置
+	doneStep := container.NewVBox(wrapped(
+		"1. 在地址输入框中粘贴地址，或点击\"📁 导入地址\"导入 TXT/CSV/XLSX/JSON 文件，然后点击\"▶ 开始查询\"\n" +
+			"2. 结果显示在右侧表格中，双击一行查看详情；查询中可以暂停，之后继续查询\n" +
+			"3. 查询完成后点击\"📄 导出 CSV\"或\"📊 导出 Excel\"，选择结果文件的保存位置\n" +
+			"4. Key 的使用次数自动保存在: " + keyManager.GetStatsFilePath()))
+
+	steps := []struct {
+		title   string
+		content fyne.CanvasObject
+	}{
+		{"第 1 步 / 共 4 步：添加 API Key", keyStep},
+		{"第 2 步 / 共 4 步：填写一个地址", addressStep},
+		{"第 3 步 / 共 4 步：试查询", testStep},
+		{"第 4 步 / 共 4 步：开始使用", doneStep},
+	}
+
+	stepLabel := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	body := container.NewStack()
+	backBtn := widget.NewButton("上一步", nil)
+	nextBtn := widget.NewButton("下一步", nil)
+	nextBtn.Importance = widget.HighImportance
+	skipBtn := widget.NewButton("跳过引导", nil)
+
+	var d *dialog.CustomDialog
+	finish := func() {
+		prefs.SetBool(prefOnboardingDone, true)
+		d.Hide()
+		onClosed()
+	}
+
+	current := 0
+	var showStep func(step int)
+	showStep = func(step int) {
+		current = step
+		stepLabel.SetText(steps[step].title)
+		body.Objects = []fyne.CanvasObject{steps[step].content}
+		body.Refresh()
+		if step == 0 {
+			backBtn.Disable()
+		} else {
+			backBtn.Enable()
+		}
+		nextBtn.Enable()
+		if step == len(steps)-1 {
+			nextBtn.SetText("完成")
+			skipBtn.Hide()
+		} else {
+			nextBtn.SetText("下一步")
+			skipBtn.Show()
+		}
+	}
+
+	// runTest 用正常的查询流程查询一个地址，查询中不能切换步骤
+	runTest := func(address string) {
+		testStatus.SetText(fmt.Sprintf("正在查询 %s …", address))
+		backBtn.Disable()
+		nextBtn.Disable()
+		go func() {
+			qm := core.NewQueryManager(keyManager, "")
+			err := qm.QueryAddresses([]string{address}, nil)
+			results := qm.GetResults()
+			fyne.Do(func() {
+				if current != 2 {
+					return
+				}
+				testStatus.SetText(onboardingTestText(results, err))
+				backBtn.Enable()
+				nextBtn.Enable()
+			})
+		}()
+	}
+
+	backBtn.OnTapped = func() {
+		showStep(current - 1)
+	}
+	nextBtn.OnTapped = func() {
+		switch current {
+		case 0:
+			if text := strings.TrimSpace(keyEntry.Text); text != "" {
+				if err := keyManager.LoadKeysFromLines(strings.Split(text, "\n")); err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+				keyEntry.SetText("")
+				updateKeyStatus()
+			}
+			if keyManager.GetKeyCount() == 0 {
+				dialog.ShowError(errors.New("请先粘贴或导入至少一个 API Key"), w)
+				return
+			}
+		case 1:
+			address := strings.TrimSpace(addressEntry.Text)
+			if err := tron.ValidateAddressWithError(address); err != nil {
+				dialog.ShowError(fmt.Errorf("地址无效: %v", err), w)
+				return
+			}
+			showStep(2)
+			runTest(address)
+			return
+		case len(steps) - 1:
+			finish()
+			return
+		}
+		showStep(current + 1)
+	}
+	skipBtn.OnTapped = finish
+
+	content := container.NewBorder(stepLabel, container.NewHBox(skipBtn, layout.NewSpacer(), backBtn, nextBtn), nil, nil, body)
+	d = dialog.NewCustomWithoutButtons("欢迎使用 USDT 余额查询", content, w)
+	d.Resize(fyne.NewSize(560, 380))
+	showStep(0)
+	d.Show()
+}
+
+// onboardingTestText 返回试查询结果的说明，失败时按错误类别给出排查建议
+func onboardingTestText(results []core.QueryResult, err error) string {
+	if err != nil {
+		return fmt.Sprintf("❌ 查询未开始: %v", err)
+	}
+	if len(results) == 0 {
+		return "❌ 没有查询结果"
+	}
+
+	result := results[0]
+	if result.Status == core.StatusSuccess {
+		return fmt.Sprintf("✅ 查询成功，余额: %s USDT\n网络和 API Key 都可以正常使用。", result.DisplayBalance())
+	}
+
+	hint := "请检查 API Key 是否正确，或稍后再试。"
+	switch result.ErrorKind {
+	case tron.ErrorKindRateLimited:
+		hint = "请求被限流（429）：Key 的额度可能已用完，或短时间内请求过多，请稍后再试或添加更多 Key。"
+	case tron.ErrorKindNetwork, tron.ErrorKindTimeout:
+		hint = "无法连接节点：请检查网络连接或代理设置。"
+	case tron.ErrorKindServer:
+		hint = "节点暂时不可用（5xx）：请稍后再试，或换一个节点。"
+	}
+	return fmt.Sprintf("❌ 查询失败（%s）: %s\n%s", result.ErrorKind, result.Error, hint)
+}
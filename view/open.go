@@ -0,0 +1,26 @@
+package view
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// openFile 用系统默认程序打开文件（xlsx 用 Excel、csv 用默认表格程序等），不等待程序退出
+func openFile(path string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", path)
+	case "darwin":
+		cmd = exec.Command("open", path)
+	default:
+		cmd = exec.Command("xdg-open", path)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("打开文件失败: %v", err)
+	}
+	// 回收子进程，避免残留僵尸进程
+	go cmd.Wait()
+	return nil
+}
@@ -0,0 +1,122 @@
+package view
+
+import (
+	"slices"
+
+	"usdt-balance-checker/core"
+
+	"fyne.io/fyne/v2"
+)
+
+// addressIndex 结果地址的前缀索引：按地址排序（不区分大小写）的行号，按地址开头搜索时二分查找，
+// 不必逐行比较（几百万条结果时逐行扫描明显卡顿）
+type addressIndex struct {
+	entries []addressIndexEntry
+}
+
+type addressIndexEntry struct {
+	address string
+	row     int32
+}
+
+// buildAddressIndex 为 results 的地址建立索引（只读取地址，可在后台线程中调用）
+func buildAddressIndex(results []core.QueryResult) *addressIndex {
+	entries := make([]addressIndexEntry, len(results))
+	for i := range results {
+		entries[i] = addressIndexEntry{address: results[i].Address, row: int32(i)}
+	}
+	slices.SortFunc(entries, func(a, b addressIndexEntry) int {
+		return compareFold(a.address, b.address)
+	})
+	return &addressIndex{entries: entries}
+}
+
+// validFor 索引是否对应 results 当前的地址（查询进度刷新时结果会替换为新的副本，地址不变时索引仍可使用）
+func (idx *addressIndex) validFor(results []core.QueryResult) bool {
+	if idx == nil || len(idx.entries) != len(results) {
+		return false
+	}
+	for _, entry := range idx.entries {
+		// 副本与原结果共用地址字符串，比较时先比较指针，很快
+		if results[entry.row].Address != entry.address {
+			return false
+		}
+	}
+	return true
+}
+
+// lookupPrefix 返回地址以 prefix 开头（不区分大小写）的行号，按行号排序
+func (idx *addressIndex) lookupPrefix(prefix string) []int {
+	start, _ := slices.BinarySearchFunc(idx.entries, prefix, func(entry addressIndexEntry, target string) int {
+		return compareFold(entry.address, target)
+	})
+	var rows []int
+	for _, entry := range idx.entries[start:] {
+		if !hasPrefixFold(entry.address, prefix) {
+			break
+		}
+		rows = append(rows, int(entry.row))
+	}
+	slices.Sort(rows)
+	return rows
+}
+
+// compareFold 按 ASCII 不区分大小写比较（地址只包含 ASCII 字符），不分配内存
+func compareFold(a, b string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if ca, cb := lowerASCII(a[i]), lowerASCII(b[i]); ca != cb {
+			if ca < cb {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(a) - len(b)
+}
+
+// hasPrefixFold 同 strings.HasPrefix，按 ASCII 不区分大小写
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && compareFold(s[:len(prefix)], prefix) == 0
+}
+
+func lowerASCII(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+// searchRows 按搜索文本设置 searchPrefix，并返回地址以搜索文本开头的行号（按行号排序）。
+// 有地址以搜索文本开头时按开头匹配，否则按包含匹配（如按地址结尾搜索）；ok 为 false 时需要逐行比较：
+// 没有搜索文本、按包含匹配，或匹配的行太多（逐行比较不比排序慢）。索引不存在或已过期（结果的地址变化）时先重新建立
+func (vm *MainViewModel) searchRows() (rows []int, ok bool) {
+	vm.searchPrefix = false
+	if vm.filterText == "" || len(vm.resultData) == 0 {
+		return nil, false
+	}
+	if !vm.searchIndex.validFor(vm.resultData) {
+		vm.searchIndex = buildAddressIndex(vm.resultData)
+	}
+	rows = vm.searchIndex.lookupPrefix(vm.filterText)
+	vm.searchPrefix = len(rows) > 0
+	if !vm.searchPrefix || len(rows) > len(vm.resultData)/8 {
+		return nil, false
+	}
+	return rows, true
+}
+
+// prepareSearchIndex 查询结束后在后台为结果建立索引，之后第一次搜索不必等待建立索引
+func (vm *MainViewModel) prepareSearchIndex() {
+	results := vm.resultData
+	if len(results) == 0 || vm.searchIndex.validFor(results) {
+		return
+	}
+	go func() {
+		idx := buildAddressIndex(results)
+		fyne.Do(func() {
+			if idx.validFor(vm.resultData) {
+				vm.searchIndex = idx
+			}
+		})
+	}()
+}
@@ -0,0 +1,389 @@
+package view
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"usdt-balance-checker/core"
+	"usdt-balance-checker/tron"
+)
+
+// MainViewModel 一个批次（标签页）的状态，每个批次一份，由 ShowMainWindow 创建
+// 包含查询管理器、结果数据、筛选分页和暂停信息；控件只通过它读写状态，
+// 筛选、分页、进度合并等逻辑不依赖 Fyne，可以脱离界面单独使用
+type MainViewModel struct {
+	queryManager        *core.QueryManager // 只在界面线程替换（SetQueryManager），读取都通过 QueryManager
+	queryManagerMu      sync.Mutex         // 保护 queryManager 的替换和后台读取
+	keyManager          *core.APIKeyManager
+	isPaused            bool // 是否处于暂停状态
+	addressList         []string
+	currentQueryAddrs   []string           // 当前正在查询的完整地址列表
+	resultData          []core.QueryResult // 所有原始数据（唯一一份结果数据，只在界面线程读写；查询进度整体替换，不原地修改）
+	filteredIndices     []int              // 筛选后的结果在 resultData 中的索引
+	filterMember        []bool             // resultData 中每一行是否已在 filteredIndices 中，见 RefreshFilter
+	displayIndices      []int              // 当前页显示的索引（每次分页新建，重新筛选复用 filteredIndices 时不受影响）
+	currentPage         int                // 当前页码（从1开始）
+	pageSize            int                // 每页显示数量
+	totalPages          int                // 总页数
+	filterMode          string             // 筛选模式："all", "withBalance", "address", "bookmarked"
+	filterText          string             // 筛选文本（地址搜索）
+	searchIndex         *addressIndex      // 地址前缀索引（按地址搜索时使用），见 searchRows
+	searchPrefix        bool               // 按地址开头匹配筛选文本（有地址以其开头时），否则按包含匹配
+	uniqueView          bool               // 按唯一地址显示（隐藏重复行），否则按输入行显示
+	duplicateCounts     map[string]int     // 唯一地址视图下每个地址的重复行数（不含首行）
+	pausedAddresses     []string           // 暂停时剩余的地址
+	pausedIndices       []int              // 暂停时剩余地址在完整列表中的索引
+	pausedTotalProgress int                // 暂停时的总进度（用于累计显示）
+	includeTotalRow     bool               // 导出时在末尾追加合计行
+	includeRawHex       bool               // 导出时增加节点原始 hex 列
+	openAfterExport     bool               // 导出成功后用系统默认程序打开文件
+	exportPath          string             // 最近一次导出选择的文件路径，见 DiskCheckPath
+	bookmarks           *core.Bookmarks    // 书签（所有批次共享）
+	tags                *core.Tags         // 地址标签（所有批次共享）
+	watchlist           *core.Watchlist    // 关注列表（本批次导入），匹配的结果标记为 Flagged
+}
+
+// NewMainViewModel 创建批次状态（第 1 页、每页 10000 条、不筛选），keyManager、bookmarks 和 tags 由所有批次共享
+func NewMainViewModel(keyManager *core.APIKeyManager, bookmarks *core.Bookmarks, tags *core.Tags) *MainViewModel {
+	return &MainViewModel{
+		keyManager:  keyManager,
+		bookmarks:   bookmarks,
+		tags:        tags,
+		currentPage: 1,
+		pageSize:    10000,
+		totalPages:  1,
+		filterMode:  "all",
+	}
+}
+
+// ApplyFilter 按筛选条件重新筛选（按结果顺序排列），并按当前页更新 displayIndices 和总页数
+// 筛选条件、视图或数据来源变化时调用；查询进度刷新时用 RefreshFilter
+func (vm *MainViewModel) ApplyFilter() {
+	if len(vm.resultData) == 0 {
+		vm.filteredIndices = nil
+		vm.filterMember = nil
+		vm.displayIndices = nil
+		vm.totalPages = 1
+		vm.currentPage = 1
+		return
+	}
+
+	// 应用筛选（只记录索引，不复制结果数据；复用上次的索引切片，避免重复分配）
+	vm.filteredIndices = vm.filteredIndices[:0]
+	vm.filterMember = make([]bool, len(vm.resultData))
+	if rows, ok := vm.searchRows(); ok {
+		// 按地址开头搜索：只检查索引找到的行
+		for _, i := range rows {
+			if vm.matchFilter(i) {
+				vm.filteredIndices = append(vm.filteredIndices, i)
+				vm.filterMember[i] = true
+			}
+		}
+	} else {
+		for i := range vm.resultData {
+			if vm.matchFilter(i) {
+				vm.filteredIndices = append(vm.filteredIndices, i)
+				vm.filterMember[i] = true
+			}
+		}
+	}
+
+	// 唯一地址视图：重复行只计数，不显示
+	vm.duplicateCounts = nil
+	if vm.uniqueView {
+		vm.duplicateCounts = make(map[string]int)
+		for i := range vm.resultData {
+			if vm.resultData[i].Duplicate {
+				vm.duplicateCounts[vm.resultData[i].Address]++
+			}
+		}
+	}
+	vm.Paginate()
+}
+
+// RefreshFilter 查询进度更新结果后调用：已筛选出的行保留原来的位置，新符合条件的行追加到末尾，当前页不变，
+// 用户正在看的行不会因为前面插入了新结果而移走（已筛选出的行之后不再符合条件时也保留，直到重新筛选）。
+// 行数变化（新的查询）时等同于 ApplyFilter
+func (vm *MainViewModel) RefreshFilter() {
+	if len(vm.filterMember) != len(vm.resultData) {
+		vm.ApplyFilter()
+		return
+	}
+	for i, member := range vm.filterMember {
+		if member {
+			// 进度刷新后的结果是新的副本，已筛选出的行也要重新同步标记
+			vm.syncMarks(i)
+		} else if vm.matchFilter(i) {
+			vm.filteredIndices = append(vm.filteredIndices, i)
+			vm.filterMember[i] = true
+		}
+	}
+	vm.Paginate()
+}
+
+// syncMarks 将书签、标签和关注标记同步到第 i 行（这些按地址保存，查询进度刷新后的结果中没有）
+func (vm *MainViewModel) syncMarks(i int) {
+	result := &vm.resultData[i]
+	result.Bookmarked = vm.bookmarks != nil && vm.bookmarks.Has(result.Address)
+	result.Tag = vm.tags.Get(result.Address)
+	result.WatchTag, result.Flagged = vm.watchlist.Lookup(result.Address)
+}
+
+// syncAllMarks 对所有行调用 syncMarks（按地址搜索时 ApplyFilter 只同步找到的行）
+func (vm *MainViewModel) syncAllMarks() {
+	for i := range vm.resultData {
+		vm.syncMarks(i)
+	}
+}
+
+// SetWatchlist 设置关注列表并标记所有结果行，返回标记的行数
+func (vm *MainViewModel) SetWatchlist(watchlist *core.Watchlist) int {
+	vm.watchlist = watchlist
+	return watchlist.Apply(vm.resultData)
+}
+
+// matchFilter 同步第 i 行的标记（见 syncMarks），并判断是否符合当前筛选条件（唯一地址视图下重复行不显示）
+func (vm *MainViewModel) matchFilter(i int) bool {
+	vm.syncMarks(i)
+	result := &vm.resultData[i]
+
+	if vm.uniqueView && result.Duplicate {
+		return false
+	}
+
+	// 按筛选模式筛选
+	switch vm.filterMode {
+	case "withBalance":
+		// 只显示有余额的（余额>0，无法解析的视为无余额）
+		if balance, err := core.ParseBalance(result.Balance); err != nil || balance <= 0 {
+			return false
+		}
+	case "bookmarked":
+		if !result.Bookmarked {
+			return false
+		}
+	}
+
+	// 按地址文本筛选
+	if vm.filterText == "" {
+		return true
+	}
+	if vm.searchPrefix {
+		return hasPrefixFold(result.Address, vm.filterText)
+	}
+	return strings.Contains(strings.ToLower(result.Address), strings.ToLower(vm.filterText))
+}
+
+// Paginate 按当前页从 filteredIndices 中取出 displayIndices 并计算总页数（不重新筛选，翻页时调用）
+func (vm *MainViewModel) Paginate() {
+	vm.totalPages = (len(vm.filteredIndices) + vm.pageSize - 1) / vm.pageSize
+	if vm.totalPages == 0 {
+		vm.totalPages = 1
+	}
+	if vm.currentPage > vm.totalPages {
+		vm.currentPage = vm.totalPages
+	}
+
+	start := (vm.currentPage - 1) * vm.pageSize
+	end := start + vm.pageSize
+	if end > len(vm.filteredIndices) {
+		end = len(vm.filteredIndices)
+	}
+	// 复制当前页的索引（最多 pageSize 个），重新筛选时 filteredIndices 被复用，不能共用底层数组
+	if start < len(vm.filteredIndices) {
+		vm.displayIndices = slices.Clone(vm.filteredIndices[start:end])
+	} else {
+		vm.displayIndices = nil
+	}
+}
+
+// ToggleBookmark 加入或取消 resultData[index] 地址的书签（同一地址的所有行一起变化），返回修改后是否在书签中
+func (vm *MainViewModel) ToggleBookmark(index int) (bool, error) {
+	if vm.bookmarks == nil || index < 0 || index >= len(vm.resultData) {
+		return false, errors.New("没有可标记的结果")
+	}
+	address := vm.resultData[index].Address
+	marked, err := vm.bookmarks.Toggle(address)
+	for i := range vm.resultData {
+		if vm.resultData[i].Address == address {
+			vm.resultData[i].Bookmarked = marked
+		}
+	}
+	return marked, err
+}
+
+// SetTag 设置 resultData[index] 地址的标签并保存（同一地址的所有行一起变化），tag 为空时删除标签
+func (vm *MainViewModel) SetTag(index int, tag string) error {
+	if vm.tags == nil || index < 0 || index >= len(vm.resultData) {
+		return errors.New("没有可设置标签的结果")
+	}
+	address := vm.resultData[index].Address
+	if err := vm.tags.Set(address, tag); err != nil {
+		return err
+	}
+	tag = vm.tags.Get(address)
+	for i := range vm.resultData {
+		if vm.resultData[i].Address == address {
+			vm.resultData[i].Tag = tag
+		}
+	}
+	return nil
+}
+
+// SetQueryManager 替换当前查询管理器（新的查询或继续查询开始时，在界面线程调用）
+func (vm *MainViewModel) SetQueryManager(qm *core.QueryManager) {
+	vm.queryManagerMu.Lock()
+	defer vm.queryManagerMu.Unlock()
+	vm.queryManager = qm
+}
+
+// QueryManager 返回当前查询管理器，可能为 nil；界面线程以外（如定时检查的 goroutine）通过它读取
+func (vm *MainViewModel) QueryManager() *core.QueryManager {
+	vm.queryManagerMu.Lock()
+	defer vm.queryManagerMu.Unlock()
+	return vm.queryManager
+}
+
+// QueryActive 当前查询管理器是否在查询（或已创建即将开始），按钮和后台检查以此为准
+// 暂停、停止后 context 已取消，即使 worker 还在收尾也视为不在查询
+func (vm *MainViewModel) QueryActive() bool {
+	qm := vm.QueryManager()
+	if qm == nil || qm.Ctx().Err() != nil {
+		return false
+	}
+	state := qm.State()
+	return state == core.StateIdle || state == core.StateRunning
+}
+
+// DiskCheckPath 查询中检查剩余空间的目录：最近一次导出的目录（结果要保存到这里），还没导出过时为统计文件所在目录
+func (vm *MainViewModel) DiskCheckPath() string {
+	if vm.exportPath != "" {
+		return filepath.Dir(vm.exportPath)
+	}
+	return filepath.Dir(vm.keyManager.GetStatsFilePath())
+}
+
+// SavePaused 暂停时根据当前结果记录剩余未完成的地址，继续查询时只查这些地址
+func (vm *MainViewModel) SavePaused(results []core.QueryResult) {
+	vm.pausedAddresses, vm.pausedIndices = remainingAddresses(vm.currentQueryAddrs, results)
+	vm.pausedTotalProgress = len(vm.currentQueryAddrs) - len(vm.pausedAddresses)
+}
+
+// ClearPaused 清空暂停信息（查询完成或停止后不能再继续）
+func (vm *MainViewModel) ClearPaused() {
+	vm.isPaused = false
+	vm.pausedAddresses = nil
+	vm.pausedIndices = nil
+	vm.pausedTotalProgress = 0
+}
+
+// ExportResults 返回要导出的结果（唯一地址视图下合并重复行）
+func (vm *MainViewModel) ExportResults() []core.QueryResult {
+	vm.syncAllMarks()
+	if vm.uniqueView {
+		return core.UniqueResults(vm.resultData)
+	}
+	return vm.resultData
+}
+
+// FilteredResults 返回当前筛选条件下的结果（所有页），如导出二维码时只导出筛选出的地址
+func (vm *MainViewModel) FilteredResults() []core.QueryResult {
+	results := make([]core.QueryResult, len(vm.filteredIndices))
+	for i, index := range vm.filteredIndices {
+		results[i] = vm.resultData[index]
+	}
+	return results
+}
+
+// ExportOptions 根据最近一次查询构建导出选项（包含数据基准等元数据）
+func (vm *MainViewModel) ExportOptions() core.ExportOptions {
+	opts := core.ExportOptions{TotalRow: vm.includeTotalRow, RawHex: vm.includeRawHex}
+	if qm := vm.QueryManager(); qm != nil {
+		summary := qm.GetSummary()
+		// 暂停后继续时最后一次查询只包含剩余地址，指纹按完整列表计算
+		if len(vm.currentQueryAddrs) > 0 {
+			summary.InputHash = core.InputHash(vm.currentQueryAddrs)
+		}
+		opts.Summary = &summary
+		opts.Tokens = tokenSymbols(qm.Tokens())
+	}
+	return opts
+}
+
+// tokenSymbols 返回代币符号列表（表头和导出用），符号尚未查询的自定义合约显示缩短的合约地址
+func tokenSymbols(tokens []tron.Token) []string {
+	symbols := make([]string, len(tokens))
+	for i, token := range tokens {
+		symbols[i] = token.Symbol
+		if symbols[i] == "" {
+			symbols[i] = tron.ShortContract(token.Contract)
+		}
+	}
+	return symbols
+}
+
+// countBalances 统计查询成功的结果中有余额（>0）和无余额的数量，无法解析的余额视为无余额
+func countBalances(results []core.QueryResult) (withBalance, withoutBalance int) {
+	stats := core.CountResultStats(results)
+	return stats.WithBalance, stats.WithoutBalance
+}
+
+// settledStats 统计继续查询前已完成的结果（索引不在 indices 中），继续查询过程中这部分结果不再变化
+func settledStats(results []core.QueryResult, indices []int) core.ResultStats {
+	pending := make(map[int]bool, len(indices))
+	for _, i := range indices {
+		pending[i] = true
+	}
+	var stats core.ResultStats
+	for i, result := range results {
+		if !pending[i] {
+			stats.Add(result)
+		}
+	}
+	return stats
+}
+
+// settledFailures 统计 results 中不在 indices 内（继续查询前已完成）的失败分类
+func settledFailures(results []core.QueryResult, indices []int) []core.FailureCount {
+	pending := make(map[int]bool, len(indices))
+	for _, i := range indices {
+		pending[i] = true
+	}
+	settled := make([]core.QueryResult, 0, len(results))
+	for i, result := range results {
+		if !pending[i] {
+			settled = append(settled, result)
+		}
+	}
+	return core.FailureBreakdown(settled)
+}
+
+// remainingAddresses 根据结果状态找出尚未完成的地址及其在完整列表中的索引
+// 结果与地址列表不对应时（例如还没有任何进度），视为全部未完成
+func remainingAddresses(addresses []string, results []core.QueryResult) ([]string, []int) {
+	remaining := make([]string, 0)
+	indices := make([]int, 0)
+	for i, addr := range addresses {
+		if i < len(results) && results[i].Address == addr && results[i].Status.IsFinal() {
+			continue
+		}
+		remaining = append(remaining, addr)
+		indices = append(indices, i)
+	}
+	return remaining, indices
+}
+
+// mergedResults 返回将继续查询的结果按索引合并到 base 后的新切片，不修改 base
+// （查询线程合并后交给界面线程整体替换 resultData，界面线程读取的结果不会被查询线程修改）
+func mergedResults(base []core.QueryResult, partial []core.QueryResult, indices []int) []core.QueryResult {
+	all := slices.Clone(base)
+	for i, result := range partial {
+		if i < len(indices) && indices[i] < len(all) {
+			all[indices[i]] = result
+		}
+	}
+	return all
+}
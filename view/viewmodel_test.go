@@ -0,0 +1,291 @@
+package view
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+	"unsafe"
+
+	"usdt-balance-checker/core"
+	"usdt-balance-checker/tron"
+)
+
+// benchResults 生成 n 条查询成功的结果，每 3 条中有 1 条有余额
+func benchResults(n int) []core.QueryResult {
+	results := make([]core.QueryResult, n)
+	for i := range results {
+		balance := "0"
+		if i%3 == 0 {
+			balance = fmt.Sprintf("%d.5", i)
+		}
+		results[i] = core.QueryResult{Address: fmt.Sprintf("T%033d", i), Balance: balance, Status: core.StatusSuccess}
+	}
+	return results
+}
+
+// BenchmarkResultViewMemory 比较筛选"有余额"后保留的内存：复制符合条件的结果（之前 filteredData 的做法）
+// 和只记录索引（ApplyFilter）。retained-B/row 为筛选结果平均每行（按全部结果行数计）额外占用的字节数
+func BenchmarkResultViewMemory(b *testing.B) {
+	const rows = 200000
+	results := benchResults(rows)
+	resultSize := int(unsafe.Sizeof(core.QueryResult{}))
+
+	b.Run("copies", func(b *testing.B) {
+		b.ReportAllocs()
+		var filtered []core.QueryResult
+		for b.Loop() {
+			filtered = make([]core.QueryResult, 0)
+			for _, result := range results {
+				if balance, err := core.ParseBalance(result.Balance); err == nil && balance > 0 {
+					filtered = append(filtered, result)
+				}
+			}
+		}
+		b.ReportMetric(float64(cap(filtered)*resultSize)/rows, "retained-B/row")
+	})
+
+	b.Run("indices", func(b *testing.B) {
+		vm := NewMainViewModel(nil, nil, nil)
+		vm.resultData = results
+		vm.filterMode = "withBalance"
+		b.ReportAllocs()
+		for b.Loop() {
+			vm.ApplyFilter()
+		}
+		retained := cap(vm.filteredIndices)*int(unsafe.Sizeof(0)) + len(vm.filterMember) + cap(vm.displayIndices)*int(unsafe.Sizeof(0))
+		b.ReportMetric(float64(retained)/rows, "retained-B/row")
+	})
+}
+
+// 重新筛选复用 filteredIndices 时，之前取出的当前页索引不能被改写
+func TestDisplayIndicesNotShared(t *testing.T) {
+	vm := NewMainViewModel(nil, nil, nil)
+	vm.resultData = benchResults(9)
+	vm.ApplyFilter()
+	shown := vm.displayIndices
+
+	vm.filterMode = "withBalance"
+	vm.ApplyFilter()
+
+	for i, index := range shown {
+		if index != i {
+			t.Fatalf("重新筛选后之前的当前页索引被改写: %v", shown)
+		}
+	}
+	if want := []int{0, 3, 6}; !slices.Equal(vm.displayIndices, want) {
+		t.Fatalf("displayIndices = %v, want %v", vm.displayIndices, want)
+	}
+}
+
+func TestMergedResultsKeepsBase(t *testing.T) {
+	base := benchResults(4)
+	partial := []core.QueryResult{{Address: "TNew", Status: core.StatusError}}
+	merged := mergedResults(base, partial, []int{2})
+
+	if merged[2].Address != "TNew" || base[2].Address == "TNew" {
+		t.Fatalf("merged[2] = %q, base[2] = %q", merged[2].Address, base[2].Address)
+	}
+}
+
+// 查询中检查剩余空间的目录：导出过时为导出目录，否则为统计文件所在目录
+func TestDiskCheckPath(t *testing.T) {
+	vm := NewMainViewModel(core.NewAPIKeyManager(), nil, nil)
+	if got, want := vm.DiskCheckPath(), filepath.Dir(vm.keyManager.GetStatsFilePath()); got != want {
+		t.Fatalf("未导出时 DiskCheckPath = %q, want %q", got, want)
+	}
+	export := filepath.Join(t.TempDir(), "out", "results.xlsx")
+	vm.exportPath = export
+	if got := vm.DiskCheckPath(); got != filepath.Dir(export) {
+		t.Fatalf("DiskCheckPath = %q, want %q", got, filepath.Dir(export))
+	}
+}
+
+func TestApplyFilter(t *testing.T) {
+	cases := []struct {
+		name   string
+		setup  func(vm *MainViewModel)
+		want   []int
+		hidden int // 唯一地址视图下 results[4] 地址的重复行数
+	}{
+		{"全部", func(vm *MainViewModel) {}, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}, 0},
+		{"有余额", func(vm *MainViewModel) { vm.filterMode = "withBalance" }, []int{0, 3, 6, 9}, 0},
+		{"按地址包含匹配", func(vm *MainViewModel) { vm.filterText = "11" }, []int{11}, 0},
+		{"按地址开头匹配（不区分大小写）", func(vm *MainViewModel) { vm.filterText = "t0" }, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}, 0},
+		{"唯一地址视图", func(vm *MainViewModel) { vm.uniqueView = true }, []int{0, 1, 2, 3, 4, 6, 7, 8, 9, 10, 11}, 1},
+		{"有余额且唯一地址", func(vm *MainViewModel) { vm.filterMode = "withBalance"; vm.uniqueView = true }, []int{0, 3, 6, 9}, 1},
+	}
+	for _, c := range cases {
+		vm := NewMainViewModel(nil, nil, nil)
+		vm.resultData = benchResults(12)
+		vm.resultData[5].Address, vm.resultData[5].Duplicate = vm.resultData[4].Address, true
+		c.setup(vm)
+		vm.ApplyFilter()
+
+		if !slices.Equal(vm.filteredIndices, c.want) || !slices.Equal(vm.displayIndices, c.want) {
+			t.Errorf("%s: filteredIndices = %v, displayIndices = %v, want %v", c.name, vm.filteredIndices, vm.displayIndices, c.want)
+		}
+		if got := vm.duplicateCounts[vm.resultData[4].Address]; got != c.hidden {
+			t.Errorf("%s: 重复行数 = %d, want %d", c.name, got, c.hidden)
+		}
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	vm := NewMainViewModel(nil, nil, nil)
+	vm.resultData = benchResults(12)
+	vm.pageSize = 5
+	vm.currentPage = 3
+	vm.ApplyFilter()
+	if vm.totalPages != 3 || !slices.Equal(vm.displayIndices, []int{10, 11}) {
+		t.Fatalf("第 3 页: totalPages = %d, displayIndices = %v", vm.totalPages, vm.displayIndices)
+	}
+
+	// 筛选后页数变少，当前页调整为最后一页
+	vm.filterMode = "withBalance"
+	vm.ApplyFilter()
+	if vm.totalPages != 1 || vm.currentPage != 1 || !slices.Equal(vm.displayIndices, []int{0, 3, 6, 9}) {
+		t.Fatalf("筛选后: totalPages = %d, currentPage = %d, displayIndices = %v", vm.totalPages, vm.currentPage, vm.displayIndices)
+	}
+
+	// 没有结果时为 1 页
+	vm.resultData = nil
+	vm.ApplyFilter()
+	if vm.totalPages != 1 || vm.currentPage != 1 || vm.displayIndices != nil {
+		t.Fatalf("没有结果: totalPages = %d, currentPage = %d, displayIndices = %v", vm.totalPages, vm.currentPage, vm.displayIndices)
+	}
+}
+
+// 查询进度刷新时已筛选出的行保持原来的位置，新符合条件的行追加到末尾
+func TestRefreshFilterKeepsRows(t *testing.T) {
+	vm := NewMainViewModel(nil, nil, nil)
+	vm.filterMode = "withBalance"
+	vm.resultData = benchResults(6)
+	vm.ApplyFilter()
+	if !slices.Equal(vm.filteredIndices, []int{0, 3}) {
+		t.Fatalf("filteredIndices = %v, want [0 3]", vm.filteredIndices)
+	}
+
+	refreshed := benchResults(6)
+	refreshed[1].Balance = "2"
+	refreshed[3].Balance = "0"
+	vm.resultData = refreshed
+	vm.RefreshFilter()
+	if want := []int{0, 3, 1}; !slices.Equal(vm.filteredIndices, want) {
+		t.Fatalf("刷新后 filteredIndices = %v, want %v", vm.filteredIndices, want)
+	}
+
+	// 行数变化（新的查询）时重新筛选
+	vm.resultData = benchResults(4)
+	vm.RefreshFilter()
+	if want := []int{0, 3}; !slices.Equal(vm.filteredIndices, want) {
+		t.Fatalf("新的查询后 filteredIndices = %v, want %v", vm.filteredIndices, want)
+	}
+}
+
+func TestMergedResults(t *testing.T) {
+	base := make([]core.QueryResult, 4)
+	for i := range base {
+		base[i] = core.QueryResult{Address: fmt.Sprintf("T%d", i), Status: core.StatusSuccess}
+	}
+	base[1].Status, base[3].Status = core.StatusCancelled, core.StatusPending
+	partial := []core.QueryResult{
+		{Address: "T1", Status: core.StatusSuccess, Balance: "1"},
+		{Address: "T3", Status: core.StatusError},
+		{Address: "TExtra", Status: core.StatusSuccess}, // 超出 indices 的结果忽略
+	}
+
+	merged := mergedResults(base, partial, []int{1, 3})
+	want := []core.ResultStatus{core.StatusSuccess, core.StatusSuccess, core.StatusSuccess, core.StatusError}
+	for i, result := range merged {
+		if result.Status != want[i] {
+			t.Errorf("merged[%d].Status = %s, want %s", i, result.Status, want[i])
+		}
+	}
+	if len(merged) != len(base) || merged[1].Balance != "1" {
+		t.Fatalf("merged = %+v", merged)
+	}
+	if base[1].Status != core.StatusCancelled || base[3].Status != core.StatusPending {
+		t.Fatalf("mergedResults 修改了 base: %+v", base)
+	}
+
+	// 索引超出 base 的结果忽略
+	if merged := mergedResults(base, partial[:1], []int{9}); len(merged) != len(base) || merged[1].Status != core.StatusCancelled {
+		t.Fatalf("索引超出范围时 merged = %+v", merged)
+	}
+}
+
+// 暂停时按结果状态记录剩余地址（不一定是前缀），继续查询的结果按记录的索引合并回完整结果
+func TestPauseAndResumeIndices(t *testing.T) {
+	vm := NewMainViewModel(nil, nil, nil)
+	vm.currentQueryAddrs = []string{"T0", "T1", "T2", "T3", "T4", "T5"}
+	results := []core.QueryResult{
+		{Address: "T0", Status: core.StatusSuccess},
+		{Address: "T1", Status: core.StatusPending},
+		{Address: "T2", Status: core.StatusError, ErrorKind: tron.ErrorKindNetwork},
+		{Address: "T3", Status: core.StatusCancelled},
+		{Address: "T4", Status: core.StatusSkipped},
+		{Address: "TOther", Status: core.StatusSuccess}, // 与地址列表不对应，视为未完成
+	}
+
+	vm.SavePaused(results)
+	if want := []string{"T1", "T3", "T5"}; !slices.Equal(vm.pausedAddresses, want) {
+		t.Fatalf("pausedAddresses = %v, want %v", vm.pausedAddresses, want)
+	}
+	if want := []int{1, 3, 5}; !slices.Equal(vm.pausedIndices, want) {
+		t.Fatalf("pausedIndices = %v, want %v", vm.pausedIndices, want)
+	}
+	if vm.pausedTotalProgress != 3 {
+		t.Fatalf("pausedTotalProgress = %d, want 3", vm.pausedTotalProgress)
+	}
+
+	// 继续查询只查剩余地址，合并后全部完成
+	partial := make([]core.QueryResult, len(vm.pausedAddresses))
+	for i, addr := range vm.pausedAddresses {
+		partial[i] = core.QueryResult{Address: addr, Status: core.StatusSuccess}
+	}
+	merged := mergedResults(results, partial, vm.pausedIndices)
+	if stats := settledStats(results, vm.pausedIndices); stats.Total != 3 {
+		t.Fatalf("继续查询前已完成 %d 个, want 3", stats.Total)
+	}
+	want := []core.FailureCount{{Kind: tron.ErrorKindNetwork, Count: 1}}
+	if failures := settledFailures(results, vm.pausedIndices); !slices.Equal(failures, want) {
+		t.Fatalf("继续查询前的失败分类 = %v, want %v", failures, want)
+	}
+	vm.SavePaused(merged)
+	if len(vm.pausedAddresses) != 0 || vm.pausedTotalProgress != len(vm.currentQueryAddrs) {
+		t.Fatalf("继续查询后仍剩余 %v (进度 %d)", vm.pausedAddresses, vm.pausedTotalProgress)
+	}
+
+	// 还没有任何进度时全部未完成
+	vm.SavePaused(nil)
+	if len(vm.pausedAddresses) != len(vm.currentQueryAddrs) || vm.pausedTotalProgress != 0 {
+		t.Fatalf("没有进度时 pausedAddresses = %v", vm.pausedAddresses)
+	}
+
+	vm.isPaused = true
+	vm.ClearPaused()
+	if vm.isPaused || vm.pausedAddresses != nil || vm.pausedIndices != nil || vm.pausedTotalProgress != 0 {
+		t.Fatalf("ClearPaused 后仍有暂停信息: %+v", vm)
+	}
+}
+
+// 后台 goroutine 读取查询管理器时，界面线程可以同时替换（用 -race 运行）
+func TestQueryManagerConcurrentAccess(t *testing.T) {
+	vm := NewMainViewModel(core.NewAPIKeyManager(), nil, nil)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			if qm := vm.QueryManager(); qm != nil {
+				qm.State()
+			}
+			vm.QueryActive()
+			vm.ExportOptions()
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		vm.SetQueryManager(core.NewQueryManager(vm.keyManager, "http://127.0.0.1:1"))
+	}
+	<-done
+}